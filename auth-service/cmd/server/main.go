@@ -2,14 +2,20 @@ package main
 
 import (
 	"context"
+	"strings"
+	"time"
 
+	"github.com/HatefBarari/microblog-auth/internal/domain"
 	"github.com/HatefBarari/microblog-auth/internal/infrastructure"
 	"github.com/HatefBarari/microblog-auth/internal/presenter"
 	"github.com/HatefBarari/microblog-auth/internal/repository"
 	"github.com/HatefBarari/microblog-auth/internal/usecase"
+	"github.com/HatefBarari/microblog-shared/pkg/auth"
 	"github.com/HatefBarari/microblog-shared/pkg/email"
 	"github.com/HatefBarari/microblog-shared/pkg/logger"
 	"github.com/HatefBarari/microblog-shared/pkg/mongo"
+	"github.com/HatefBarari/microblog-shared/pkg/rbac"
+	"github.com/HatefBarari/microblog-shared/pkg/userdata"
 	"go.mongodb.org/mongo-driver/bson"
 	mongoOptions "go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
@@ -36,36 +42,297 @@ func main() {
 	_, _ = idx.CreateOne(context.Background(), mongoOptions.IndexModel{
 		Keys: bson.M{"email": 1}, Options: (&options.IndexOptions{}).SetUnique(true),
 	})
-	emailSender := email.NewSender(email.Config{
-		Host: cfg.Email.SMTP.Host,
-		Port: cfg.Email.SMTP.Port,
-		User: cfg.Email.SMTP.User,
-		Pass: cfg.Email.SMTP.Pass,
+	// profile handles are unique among users that set one
+	_, _ = idx.CreateOne(context.Background(), mongoOptions.IndexModel{
+		Keys: bson.M{"profile.handle": 1},
+		Options: (&options.IndexOptions{}).SetUnique(true).
+			SetPartialFilterExpression(bson.M{"profile.handle": bson.M{"$exists": true}}),
+	})
+	// accounts waiting for deletion, polled by the purge loop
+	_, _ = idx.CreateOne(context.Background(), mongoOptions.IndexModel{
+		Keys: bson.M{"deletion.scheduled_at": 1},
+		Options: (&options.IndexOptions{}).
+			SetPartialFilterExpression(bson.M{"deletion": bson.M{"$exists": true}}),
+	})
+	// sessions: listed per user, dropped by mongo once the refresh token expires
+	sessionIdx := mongo.DB().Collection("sessions").Indexes()
+	_, _ = sessionIdx.CreateOne(context.Background(), mongoOptions.IndexModel{
+		Keys: bson.M{"user_id": 1},
+	})
+	_, _ = sessionIdx.CreateOne(context.Background(), mongoOptions.IndexModel{
+		Keys: bson.M{"expires_at": 1}, Options: (&options.IndexOptions{}).SetExpireAfterSeconds(0),
+	})
+	// revoked tokens and users, read by every service's auth middleware
+	_, _ = mongo.DB().Collection("revocations").Indexes().CreateOne(context.Background(), mongoOptions.IndexModel{
+		Keys: bson.M{"expires_at": 1}, Options: (&options.IndexOptions{}).SetExpireAfterSeconds(0),
+	})
+	// attempt counters for login/reset throttling
+	_, _ = mongo.DB().Collection("login_attempts").Indexes().CreateOne(context.Background(), mongoOptions.IndexModel{
+		Keys: bson.M{"expires_at": 1}, Options: (&options.IndexOptions{}).SetExpireAfterSeconds(0),
+	})
+	// personal access tokens, resolved by hash in every service
+	apiKeyIdx := mongo.DB().Collection("api_keys").Indexes()
+	_, _ = apiKeyIdx.CreateOne(context.Background(), mongoOptions.IndexModel{
+		Keys: bson.M{"hash": 1}, Options: (&options.IndexOptions{}).SetUnique(true),
+	})
+	_, _ = apiKeyIdx.CreateOne(context.Background(), mongoOptions.IndexModel{
+		Keys: bson.M{"user_id": 1},
+	})
+	// admin audit log, browsed per target user or per admin
+	actionIdx := mongo.DB().Collection("admin_actions").Indexes()
+	_, _ = actionIdx.CreateOne(context.Background(), mongoOptions.IndexModel{
+		Keys: bson.D{{Key: "target_id", Value: 1}, {Key: "created_at", Value: -1}},
+	})
+	_, _ = actionIdx.CreateOne(context.Background(), mongoOptions.IndexModel{
+		Keys: bson.D{{Key: "actor_id", Value: 1}, {Key: "created_at", Value: -1}},
+	})
+	// email tokens: lookup by hash, expired ones are purged by mongo
+	tokenIdx := mongo.DB().Collection("auth_tokens").Indexes()
+	_, _ = tokenIdx.CreateOne(context.Background(), mongoOptions.IndexModel{
+		Keys: bson.M{"token_hash": 1}, Options: (&options.IndexOptions{}).SetUnique(true),
+	})
+	_, _ = tokenIdx.CreateOne(context.Background(), mongoOptions.IndexModel{
+		Keys: bson.M{"expires_at": 1}, Options: (&options.IndexOptions{}).SetExpireAfterSeconds(0),
+	})
+	// authorization codes: lookup by hash, expired ones are purged by mongo
+	codeIdx := mongo.DB().Collection("oauth_codes").Indexes()
+	_, _ = codeIdx.CreateOne(context.Background(), mongoOptions.IndexModel{
+		Keys: bson.M{"code_hash": 1}, Options: (&options.IndexOptions{}).SetUnique(true),
+	})
+	_, _ = codeIdx.CreateOne(context.Background(), mongoOptions.IndexModel{
+		Keys: bson.M{"expires_at": 1}, Options: (&options.IndexOptions{}).SetExpireAfterSeconds(0),
+	})
+	// invitations: lookup by hash, listed per invitee
+	invitationIdx := mongo.DB().Collection("invitations").Indexes()
+	_, _ = invitationIdx.CreateOne(context.Background(), mongoOptions.IndexModel{
+		Keys: bson.M{"code_hash": 1}, Options: (&options.IndexOptions{}).SetUnique(true),
 	})
+	_, _ = invitationIdx.CreateOne(context.Background(), mongoOptions.IndexModel{
+		Keys: bson.M{"email": 1},
+	})
+	// security audit log: per user and for admin queries, purged by mongo
+	// after the retention period
+	eventIdx := mongo.DB().Collection("auth_events").Indexes()
+	_, _ = eventIdx.CreateOne(context.Background(), mongoOptions.IndexModel{
+		Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: -1}},
+	})
+	_, _ = eventIdx.CreateOne(context.Background(), mongoOptions.IndexModel{
+		Keys: bson.D{{Key: "type", Value: 1}, {Key: "created_at", Value: -1}},
+	})
+	_, _ = eventIdx.CreateOne(context.Background(), mongoOptions.IndexModel{
+		Keys:    bson.M{"created_at": 1},
+		Options: (&options.IndexOptions{}).SetExpireAfterSeconds(int32(cfg.Auth.Events.RetentionDays * 24 * 3600)),
+	})
+	// queued emails, polled by the dispatcher; sent ones are purged by mongo
+	outboxIdx := mongo.DB().Collection("email_outbox").Indexes()
+	_, _ = outboxIdx.CreateOne(context.Background(), mongoOptions.IndexModel{
+		Keys: bson.D{{Key: "status", Value: 1}, {Key: "next_attempt_at", Value: 1}},
+	})
+	_, _ = outboxIdx.CreateOne(context.Background(), mongoOptions.IndexModel{
+		Keys:    bson.M{"sent_at": 1},
+		Options: (&options.IndexOptions{}).SetExpireAfterSeconds(int32(cfg.Email.Outbox.RetentionDays * 24 * 3600)),
+	})
+	emailSender, err := email.NewSender(email.Config{
+		Transport: cfg.Email.Transport,
+		Host:      cfg.Email.SMTP.Host,
+		Port:      cfg.Email.SMTP.Port,
+		User:      cfg.Email.SMTP.User,
+		Pass:      cfg.Email.SMTP.Pass,
+		TLS:       cfg.Email.SMTP.TLS,
+		PoolSize:  cfg.Email.SMTP.PoolSize,
+		Timeout:   time.Duration(cfg.Email.SMTP.TimeoutSec) * time.Second,
+		Dir:       cfg.Email.FileDir,
+		From:      cfg.Email.From,
+	}, log)
+	if err != nil {
+		log.Fatal("email transport", zap.Error(err))
+	}
+	defer emailSender.Close()
+	templates, err := email.LoadTemplates(cfg.Email.TemplatesDir)
+	if err != nil {
+		log.Fatal("load email templates", zap.Error(err))
+	}
+	oc := cfg.Email.Outbox
+	outbox := usecase.NewOutboxUseCase(repository.NewMongoOutboxRepo(), emailSender, usecase.OutboxConfig{
+		MaxAttempts: oc.MaxAttempts,
+		BaseDelay:   time.Duration(oc.BaseDelaySec) * time.Second,
+		MaxDelay:    time.Duration(oc.MaxDelayMin) * time.Minute,
+	}, log)
+	go outbox.Run(context.Background(), time.Duration(oc.PollIntervalSec)*time.Second)
 
 	// wiring
 	repo := repository.NewMongoUserRepo()
+	tokenRepo := repository.NewMongoAuthTokenRepo()
+	sessionRepo := repository.NewMongoSessionRepo()
+	revocations := auth.NewMongoRevocationStore(mongo.DB().Collection("revocations"))
+	tc := cfg.Auth.Throttle
+	throttle := usecase.NewThrottler(repository.NewMongoAttemptRepo(), usecase.ThrottleConfig{
+		Window:                 time.Duration(tc.WindowMin) * time.Minute,
+		BaseDelay:              time.Duration(tc.BaseDelaySec) * time.Second,
+		MaxDelay:               time.Duration(tc.MaxDelaySec) * time.Second,
+		Lockout:                time.Duration(tc.LockoutMin) * time.Minute,
+		MaxAccountFailures:     tc.MaxAccountFailures,
+		MaxIPFailures:          tc.MaxIPFailures,
+		MaxResetRequests:       tc.MaxResetRequests,
+		MaxResetIPRequests:     tc.MaxResetIPRequests,
+		MaxMagicLinkRequests:   tc.MaxMagicLinkRequests,
+		MaxMagicLinkIPRequests: tc.MaxMagicLinkIPRequests,
+	}, log)
+	keys, err := infrastructure.LoadKeySet(cfg.Auth.Signing, log)
+	if err != nil {
+		log.Fatal("load signing keys", zap.Error(err))
+	}
+	pc := cfg.Auth.Password
+	hasher, err := auth.NewHasher(auth.HasherConfig{
+		Algorithm: pc.Algorithm,
+		Argon2: auth.Argon2Params{
+			Memory:      pc.Argon2.MemoryKiB,
+			Iterations:  pc.Argon2.Iterations,
+			Parallelism: pc.Argon2.Parallelism,
+		},
+		BcryptCost: pc.BcryptCost,
+	})
+	if err != nil {
+		log.Fatal("password hasher", zap.Error(err))
+	}
+	passwords, err := usecase.NewPasswordPolicy(usecase.PasswordPolicyConfig{
+		MinLength:        pc.MinLength,
+		MaxLength:        pc.MaxLength,
+		BreachedListFile: pc.BreachedList,
+		HistorySize:      pc.History,
+	})
+	if err != nil {
+		log.Fatal("password policy", zap.Error(err))
+	}
+	eventsUC := usecase.NewAuthEventUseCase(repository.NewMongoAuthEventRepo(), log)
+
+	// email usecase
+	emailCfg := &usecase.EmailConfig{
+		FromEmail:     cfg.Email.From,
+		BaseURL:       cfg.Server.BaseURL,
+		TokenSecret:   cfg.Email.TokenSecret,
+		TokenTTLHours: cfg.Email.TokenTTLHours,
+		UndoTTLHours:  cfg.Email.UndoTTLHours,
+		Hasher:        hasher,
+		Passwords:     passwords,
+		Templates:     templates,
+		Events:        eventsUC,
+	}
+	emailUC := usecase.NewEmailUseCase(repo, tokenRepo, throttle, outbox, emailCfg, log)
+
 	ucCfg := &usecase.Config{
-		AccessSecret:   cfg.Auth.AccessSecret,
+		AccessSigner:   keys,
 		RefreshSecret:  cfg.Auth.RefreshSecret,
 		AccessTTLMin:   cfg.Auth.AccessTTLMin,
 		RefreshTTLHour: cfg.Auth.RefreshTTLHour,
 		EmailFrom:      cfg.Email.From,
+		TwoFactor: usecase.TwoFactorConfig{
+			Issuer:          cfg.Auth.TwoFactor.Issuer,
+			Secret:          cfg.Auth.TwoFactor.Secret,
+			ChallengeTTLMin: cfg.Auth.TwoFactor.ChallengeTTLMin,
+		},
+		Hasher:              hasher,
+		Passwords:           passwords,
+		Templates:           templates,
+		ImpersonationTTLMin: cfg.Auth.ImpersonationTTLMin,
+		Events:              eventsUC,
+		Verification:        emailUC,
 	}
-	uc := usecase.NewUserUseCase(repo, emailSender, ucCfg, log)
-	
-	// email usecase
-	emailCfg := &usecase.EmailConfig{
-		FromEmail:     cfg.Email.From,
-		BaseURL:       cfg.Server.BaseURL,
-		TokenSecret:   cfg.Auth.AccessSecret,
-		TokenTTLHours: cfg.Auth.RefreshTTLHour,
+	switch mode := usecase.RegistrationMode(cfg.Auth.Registration.Mode); mode {
+	case usecase.RegistrationInviteOnly, usecase.RegistrationClosed:
+		ucCfg.Registration = mode
+	case "", "open":
+	default:
+		log.Fatal("unknown registration mode", zap.String("mode", string(mode)))
+	}
+	for _, r := range cfg.Auth.TwoFactor.RequiredRoles {
+		ucCfg.TwoFactor.RequiredRoles = append(ucCfg.TwoFactor.RequiredRoles, domain.Role(r))
 	}
-	emailUC := usecase.NewEmailUseCase(repo, emailSender, emailCfg, log)
+	invitations := repository.NewMongoInvitationRepo()
+	uc := usecase.NewUserUseCase(repo, sessionRepo, invitations, revocations, throttle, outbox, ucCfg, log)
 	
-	handler := presenter.NewHTTPHandler(uc, emailUC)
+	sessionUC := usecase.NewSessionUseCase(sessionRepo, revocations, time.Duration(cfg.Auth.AccessTTLMin)*time.Minute, log)
+
+	apiKeys := auth.NewMongoAPIKeyStore(mongo.DB().Collection("api_keys"))
+	apiKeyUC := usecase.NewAPIKeyUseCase(
+		apiKeys,
+		repo,
+		usecase.APIKeyConfig{
+			DefaultTTLDays: cfg.Auth.APIKeys.DefaultTTLDays,
+			MaxTTLDays:     cfg.Auth.APIKeys.MaxTTLDays,
+		},
+		log,
+	)
+
+	adminUC := usecase.NewAdminUseCase(
+		repo, sessionRepo, revocations, apiKeys,
+		repository.NewMongoAdminActionRepo(),
+		uc, emailUC,
+		time.Duration(cfg.Auth.AccessTTLMin)*time.Minute,
+		log,
+	)
+
+	oauthClients := repository.NewMongoOAuthClientRepo()
+	oauthUC := usecase.NewOAuthUseCase(
+		oauthClients,
+		keys, revocations, apiKeys,
+		usecase.OAuthConfig{
+			ClientTokenTTL: time.Duration(cfg.Auth.OAuth.ClientTokenTTLMin) * time.Minute,
+		},
+		log,
+	)
+
+	issuer := cfg.Auth.OAuth.Issuer
+	if issuer == "" {
+		issuer = cfg.Server.BaseURL
+	}
+	oidcUC := usecase.NewOIDCUseCase(
+		oauthClients, repository.NewMongoOAuthCodeRepo(), repo, uc, keys,
+		usecase.OIDCConfig{
+			Issuer:   strings.TrimSuffix(issuer, "/"),
+			CodeTTL:  time.Duration(cfg.Auth.OAuth.CodeTTLSec) * time.Second,
+			TokenTTL: time.Duration(cfg.Auth.AccessTTLMin) * time.Minute,
+		},
+		log,
+	)
+
+	policy, err := rbac.LoadPolicy(cfg.Auth.RBACPolicy)
+	if err != nil {
+		log.Fatal("load rbac policy", zap.Error(err))
+	}
+
+	profileUC := usecase.NewProfileUseCase(repo, log)
+
+	magicUC := usecase.NewMagicLinkUseCase(repo, tokenRepo, throttle, outbox, uc, usecase.MagicLinkConfig{
+		BaseURL:     cfg.Server.BaseURL,
+		TokenSecret: cfg.Email.TokenSecret,
+		TTL:         time.Duration(cfg.Auth.MagicLink.TTLMin) * time.Minute,
+		Templates:   templates,
+	}, log)
+
+	invitationUC := usecase.NewInvitationUseCase(invitations, repo, outbox, usecase.InvitationConfig{
+		BaseURL:        cfg.Server.BaseURL,
+		DefaultTTLDays: cfg.Auth.Registration.InvitationTTLDays,
+		MaxTTLDays:     cfg.Auth.Registration.MaxInvitationTTLDays,
+		Registration:   ucCfg.Registration,
+		Templates:      templates,
+	}, log)
+
+	changeUC := usecase.NewEmailChangeUseCase(repo, emailUC, sessionUC, uc, log)
+
+	var services []usecase.UserDataService
+	for _, s := range cfg.Account.Services {
+		services = append(services, userdata.NewClient(s.Name, s.URL, cfg.Account.InternalSecret))
+	}
+	accountUC := usecase.NewAccountUseCase(repo, tokenRepo, sessionRepo, sessionUC, apiKeys, outbox, services, usecase.AccountConfig{
+		GracePeriod: time.Duration(cfg.Account.DeletionGraceDays) * 24 * time.Hour,
+		Templates:   templates,
+	}, log)
+	go accountUC.Run(context.Background(), time.Duration(cfg.Account.PurgeIntervalMin)*time.Minute)
+
+	handler := presenter.NewHTTPHandler(uc, emailUC, sessionUC, apiKeyUC, adminUC, profileUC, magicUC, changeUC, accountUC, outbox, oauthUC, oidcUC, invitationUC, eventsUC)
 
-	if err := infrastructure.StartEcho(cfg, log, handler); err != nil {
+	if err := infrastructure.StartEcho(cfg, log, handler, keys, revocations, policy); err != nil {
 		log.Fatal("start server", zap.Error(err))
 	}
 }
\ No newline at end of file
@@ -0,0 +1,33 @@
+package domain
+
+import "time"
+
+// AdminAction is an audit record of one change an admin made to an account.
+type AdminAction struct {
+	ID        string            `bson:"_id,omitempty"`
+	ActorID   string            `bson:"actor_id"`
+	Action    string            `bson:"action"`
+	TargetID  string            `bson:"target_id"`
+	Details   map[string]string `bson:"details,omitempty"`
+	IP        string            `bson:"ip,omitempty"`
+	CreatedAt time.Time         `bson:"created_at"`
+}
+
+const (
+	ActionRoleChanged       = "user.role_changed"
+	ActionSuspended         = "user.suspended"
+	ActionBanned            = "user.banned"
+	ActionReactivated       = "user.reactivated"
+	ActionVerified          = "user.verified"
+	ActionPasswordResetSent = "user.password_reset_sent"
+	ActionUnlocked          = "user.unlocked"
+	ActionImpersonated      = "user.impersonated"
+)
+
+type AdminActionFilter struct {
+	ActorID  string
+	TargetID string
+	Action   string
+	Page     int
+	PageSize int
+}
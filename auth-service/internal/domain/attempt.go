@@ -0,0 +1,17 @@
+package domain
+
+import "time"
+
+// Attempt counts recent events, such as failed logins, for one key like
+// an account or an IP address.
+type Attempt struct {
+	Key         string     `bson:"_id"`
+	Count       int        `bson:"count"`
+	Last        time.Time  `bson:"last"`
+	LockedUntil *time.Time `bson:"locked_until,omitempty"`
+	ExpiresAt   time.Time  `bson:"expires_at"`
+}
+
+func (a *Attempt) Locked(now time.Time) bool {
+	return a.LockedUntil != nil && now.Before(*a.LockedUntil)
+}
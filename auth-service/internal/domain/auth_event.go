@@ -0,0 +1,54 @@
+package domain
+
+import "time"
+
+type AuthEventType string
+
+const (
+	EventRegister               AuthEventType = "register"
+	EventLogin                  AuthEventType = "login"
+	EventLoginFailed            AuthEventType = "login_failed"
+	EventTokenRefreshed         AuthEventType = "token_refreshed"
+	EventPasswordResetRequested AuthEventType = "password_reset_requested"
+	EventPasswordReset          AuthEventType = "password_reset"
+	EventEmailVerified          AuthEventType = "email_verified"
+	EventRoleChanged            AuthEventType = "role_changed"
+)
+
+type AuthOutcome string
+
+const (
+	OutcomeSuccess AuthOutcome = "success"
+	OutcomeFailure AuthOutcome = "failure"
+)
+
+// AuthEvent is one entry of the security audit log. Entries are only ever
+// appended; they leave the log when its retention period ends.
+type AuthEvent struct {
+	ID      string        `bson:"_id,omitempty"`
+	Type    AuthEventType `bson:"type"`
+	Outcome AuthOutcome   `bson:"outcome"`
+	UserID  string        `bson:"user_id,omitempty"`
+	// Email is the address given, for failed logins of unknown accounts.
+	Email string `bson:"email,omitempty"`
+	// ActorID is the admin who made the change, if it wasn't the user.
+	ActorID   string            `bson:"actor_id,omitempty"`
+	Reason    string            `bson:"reason,omitempty"`
+	IP        string            `bson:"ip,omitempty"`
+	UserAgent string            `bson:"user_agent,omitempty"`
+	Details   map[string]string `bson:"details,omitempty"`
+	CreatedAt time.Time         `bson:"created_at"`
+}
+
+type AuthEventFilter struct {
+	UserID  string
+	Email   string
+	Type    AuthEventType
+	Outcome AuthOutcome
+	IP      string
+	// From and To bound CreatedAt, inclusive and exclusive.
+	From     *time.Time
+	To       *time.Time
+	Page     int
+	PageSize int
+}
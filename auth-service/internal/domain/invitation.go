@@ -0,0 +1,28 @@
+package domain
+
+import "time"
+
+// Invitation lets one email address register with a preset role. It is
+// emailed as a link; only the SHA-256 of the code is stored.
+type Invitation struct {
+	ID         string     `bson:"_id,omitempty"`
+	CodeHash   string     `bson:"code_hash"`
+	Email      string     `bson:"email"`
+	Role       Role       `bson:"role"`
+	InvitedBy  string     `bson:"invited_by"`
+	CreatedAt  time.Time  `bson:"created_at"`
+	ExpiresAt  time.Time  `bson:"expires_at"`
+	AcceptedAt *time.Time `bson:"accepted_at,omitempty"`
+	// AcceptedBy is the ID of the account created with the invitation.
+	AcceptedBy string     `bson:"accepted_by,omitempty"`
+	RevokedAt  *time.Time `bson:"revoked_at,omitempty"`
+}
+
+type InvitationFilter struct {
+	Email     string
+	InvitedBy string
+	// Pending limits the list to invitations that can still be used.
+	Pending  bool
+	Page     int
+	PageSize int
+}
@@ -0,0 +1,20 @@
+package domain
+
+import "time"
+
+// OAuthClient is a registered application. Services get access tokens with
+// the client_credentials grant for their Scopes; apps with RedirectURIs sign
+// users in through OpenID Connect. Only the SHA-256 of the secret is stored.
+type OAuthClient struct {
+	ID         string `bson:"_id"`
+	Name       string `bson:"name"`
+	SecretHash string `bson:"secret_hash,omitempty"`
+	// Public clients, such as single-page apps, cannot keep a secret and
+	// have none; they rely on PKCE alone.
+	Public       bool       `bson:"public,omitempty"`
+	Scopes       []string   `bson:"scopes"`
+	RedirectURIs []string   `bson:"redirect_uris,omitempty"`
+	CreatedAt    time.Time  `bson:"created_at"`
+	LastUsedAt   *time.Time `bson:"last_used_at,omitempty"`
+	RevokedAt    *time.Time `bson:"revoked_at,omitempty"`
+}
@@ -0,0 +1,22 @@
+package domain
+
+import "time"
+
+// AuthorizationCode is issued to an OpenID Connect client once the user
+// has signed in and consented, and is exchanged once for tokens. Only the
+// SHA-256 of the code is stored.
+type AuthorizationCode struct {
+	ID          string   `bson:"_id,omitempty"`
+	CodeHash    string   `bson:"code_hash"`
+	ClientID    string   `bson:"client_id"`
+	UserID      string   `bson:"user_id"`
+	SessionID   string   `bson:"session_id"`
+	RedirectURI string   `bson:"redirect_uri"`
+	Scopes      []string `bson:"scopes"`
+	Nonce       string   `bson:"nonce,omitempty"`
+	// CodeChallenge is the S256 PKCE challenge the code_verifier must match.
+	CodeChallenge string     `bson:"code_challenge"`
+	AuthTime      time.Time  `bson:"auth_time"`
+	ExpiresAt     time.Time  `bson:"expires_at"`
+	ConsumedAt    *time.Time `bson:"consumed_at"`
+}
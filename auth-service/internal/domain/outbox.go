@@ -0,0 +1,36 @@
+package domain
+
+import "time"
+
+type OutboxStatus string
+
+const (
+	OutboxPending OutboxStatus = "pending"
+	OutboxSent    OutboxStatus = "sent"
+	// OutboxDead messages ran out of attempts and stay until an admin
+	// resends them.
+	OutboxDead OutboxStatus = "dead"
+)
+
+// OutboxEmail is an email queued for delivery. The bodies are dropped once
+// it is sent, since they may hold sign-in or reset links.
+type OutboxEmail struct {
+	ID            string       `bson:"_id,omitempty"`
+	To            string       `bson:"to"`
+	Subject       string       `bson:"subject"`
+	Text          string       `bson:"text,omitempty"`
+	HTML          string       `bson:"html,omitempty"`
+	Status        OutboxStatus `bson:"status"`
+	Attempts      int          `bson:"attempts"`
+	NextAttemptAt time.Time    `bson:"next_attempt_at"`
+	LastError     string       `bson:"last_error,omitempty"`
+	CreatedAt     time.Time    `bson:"created_at"`
+	SentAt        *time.Time   `bson:"sent_at,omitempty"`
+}
+
+type OutboxFilter struct {
+	Status   OutboxStatus
+	To       string
+	Page     int
+	PageSize int
+}
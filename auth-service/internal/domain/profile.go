@@ -0,0 +1,21 @@
+package domain
+
+import "errors"
+
+var ErrHandleTaken = errors.New("handle already taken")
+
+// Profile is the public face of an account. Handle is stored lowercase and
+// is unique among users that have one.
+type Profile struct {
+	DisplayName   string        `bson:"display_name"`
+	Handle        string        `bson:"handle,omitempty"`
+	Bio           string        `bson:"bio,omitempty"`
+	AvatarMediaID string        `bson:"avatar_media_id,omitempty"`
+	Links         []ProfileLink `bson:"links,omitempty"`
+	Locale        string        `bson:"locale,omitempty"`
+}
+
+type ProfileLink struct {
+	Label string `bson:"label"`
+	URL   string `bson:"url"`
+}
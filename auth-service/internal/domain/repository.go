@@ -1,9 +1,153 @@
 package domain
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 type UserRepository interface {
 	Create(ctx context.Context, u *User) error
+	// GetByID and GetByEmail return nil, nil when the user does not exist.
+	GetByID(ctx context.Context, id string) (*User, error)
 	GetByEmail(ctx context.Context, email string) (*User, error)
+	// GetByHandle looks up a profile handle, which must already be lowercase.
+	GetByHandle(ctx context.Context, handle string) (*User, error)
+	// GetByIDs returns the users that exist among ids, in no particular order.
+	GetByIDs(ctx context.Context, ids []string) ([]*User, error)
+	List(ctx context.Context, filter UserFilter) ([]*User, int, error)
+	Update(ctx context.Context, u *User) error
 	UpdateVerified(ctx context.Context, userID string, verified bool) error
-}
\ No newline at end of file
+	// UpdatePasswordHash replaces the password hash only while it is still
+	// oldHash, so a rehash never undoes a concurrent password change.
+	UpdatePasswordHash(ctx context.Context, userID, oldHash, newHash string) error
+	// UpdateEmail moves the user to a confirmed address. It returns
+	// ErrEmailTaken when another user holds it.
+	UpdateEmail(ctx context.Context, userID, email string) error
+	UpdateRole(ctx context.Context, userID string, role Role) error
+	// SetStatus changes the account status; a nil until clears any
+	// suspension end date.
+	SetStatus(ctx context.Context, userID string, status UserStatus, reason string, until *time.Time) error
+	// SetProfile replaces the user's profile. It returns ErrHandleTaken when
+	// another user holds the handle.
+	SetProfile(ctx context.Context, userID string, p *Profile) error
+	// SetDeletion schedules the account for deletion; nil cancels it.
+	SetDeletion(ctx context.Context, userID string, d *DeletionRequest) error
+	// ListDueDeletions returns up to limit users whose deletion is due.
+	ListDueDeletions(ctx context.Context, now time.Time, limit int) ([]*User, error)
+	Delete(ctx context.Context, id string) error
+	// SetTwoFactor replaces the user's 2FA settings; nil removes them.
+	SetTwoFactor(ctx context.Context, userID string, tf *TwoFactor) error
+	// ConsumeRecoveryCode atomically removes the hashed code and reports
+	// whether it was present.
+	ConsumeRecoveryCode(ctx context.Context, userID, codeHash string) (bool, error)
+	// AdvanceTOTPStep records step as the last accepted TOTP time step. It
+	// reports false if that step or a later one was already used.
+	AdvanceTOTPStep(ctx context.Context, userID string, step int64) (bool, error)
+}
+
+type AuthTokenRepository interface {
+	Create(ctx context.Context, t *AuthToken) error
+	// Consume atomically marks an unexpired, unused token as consumed and
+	// returns it. It returns nil, nil when no such token exists.
+	Consume(ctx context.Context, tokenHash string, purpose TokenPurpose, now time.Time) (*AuthToken, error)
+	// DeleteByUser removes every outstanding token of the given purpose.
+	DeleteByUser(ctx context.Context, userID string, purpose TokenPurpose) error
+	// PurgeUser removes every token of the user, used or not.
+	PurgeUser(ctx context.Context, userID string) error
+}
+
+type SessionRepository interface {
+	Create(ctx context.Context, s *Session) error
+	// GetByID returns nil, nil when the session does not exist.
+	GetByID(ctx context.Context, id string) (*Session, error)
+	// Rotate replaces the refresh jti only while oldJTI is still current and
+	// the session is not revoked. It reports whether the swap happened.
+	Rotate(ctx context.Context, id, oldJTI, newJTI string, expiresAt time.Time, ip, userAgent string, now time.Time) (bool, error)
+	Revoke(ctx context.Context, id, reason string, now time.Time) error
+	// ListActiveByUser returns unrevoked, unexpired sessions, newest first.
+	ListActiveByUser(ctx context.Context, userID string, now time.Time) ([]*Session, error)
+	// RevokeAllByUser revokes every live session of the user and returns how
+	// many were revoked.
+	RevokeAllByUser(ctx context.Context, userID, reason string, now time.Time) (int, error)
+	// DeleteByUser removes the user's sessions, revoked or not.
+	DeleteByUser(ctx context.Context, userID string) error
+}
+
+// AttemptRepository stores attempt counters. A counter is forgotten once it
+// passes its ExpiresAt, which every new event pushes out again.
+type AttemptRepository interface {
+	// Get returns nil, nil when the key has no live counter.
+	Get(ctx context.Context, key string, now time.Time) (*Attempt, error)
+	Increment(ctx context.Context, key string, now time.Time, window time.Duration) (*Attempt, error)
+	Lock(ctx context.Context, key string, until time.Time) error
+	Reset(ctx context.Context, key string) error
+}
+
+// AdminActionRepository is append-only.
+type AdminActionRepository interface {
+	Create(ctx context.Context, a *AdminAction) error
+	List(ctx context.Context, filter AdminActionFilter) ([]*AdminAction, int, error)
+}
+
+// EmailOutboxRepository queues emails for the dispatcher.
+type EmailOutboxRepository interface {
+	Create(ctx context.Context, m *OutboxEmail) error
+	// GetByID returns nil, nil when the message does not exist.
+	GetByID(ctx context.Context, id string) (*OutboxEmail, error)
+	// ClaimDue takes the oldest pending message whose attempt is due,
+	// counts the attempt and hides it from other dispatchers until
+	// leaseUntil. It returns nil, nil when nothing is due.
+	ClaimDue(ctx context.Context, now, leaseUntil time.Time) (*OutboxEmail, error)
+	MarkSent(ctx context.Context, id string, now time.Time) error
+	// MarkFailed records a failed attempt: status is OutboxPending with the
+	// time of the next attempt, or OutboxDead.
+	MarkFailed(ctx context.Context, id string, status OutboxStatus, next time.Time, lastErr string) error
+	// Requeue makes a dead message pending again with no attempts counted.
+	// It reports false when no dead message has that id.
+	Requeue(ctx context.Context, id string, now time.Time) (bool, error)
+	List(ctx context.Context, filter OutboxFilter) ([]*OutboxEmail, int, error)
+}
+
+type OAuthClientRepository interface {
+	Create(ctx context.Context, c *OAuthClient) error
+	// GetByID returns nil, nil when the client does not exist.
+	GetByID(ctx context.Context, id string) (*OAuthClient, error)
+	List(ctx context.Context) ([]*OAuthClient, error)
+	TouchLastUsed(ctx context.Context, id string, now time.Time) error
+	// Revoke reports false when no active client has that id.
+	Revoke(ctx context.Context, id string, now time.Time) (bool, error)
+}
+
+type AuthorizationCodeRepository interface {
+	Create(ctx context.Context, c *AuthorizationCode) error
+	// Consume atomically marks an unexpired, unused code as consumed and
+	// returns it. It returns nil, nil when no such code exists.
+	Consume(ctx context.Context, codeHash string, now time.Time) (*AuthorizationCode, error)
+}
+
+type InvitationRepository interface {
+	Create(ctx context.Context, inv *Invitation) error
+	// GetByID returns nil, nil when the invitation does not exist.
+	GetByID(ctx context.Context, id string) (*Invitation, error)
+	// Accept atomically marks the unexpired, unused and unrevoked
+	// invitation for email as accepted. It returns nil, nil when there is
+	// no such invitation.
+	Accept(ctx context.Context, codeHash, email string, now time.Time) (*Invitation, error)
+	// SetAcceptedBy records the account the invitation created.
+	SetAcceptedBy(ctx context.Context, id, userID string) error
+	// Release undoes Accept when the account could not be created.
+	Release(ctx context.Context, id string) error
+	// Revoke reports false when no pending invitation has that id.
+	Revoke(ctx context.Context, id string, now time.Time) (bool, error)
+	List(ctx context.Context, filter InvitationFilter, now time.Time) ([]*Invitation, int, error)
+}
+
+// AuthEventRepository is append-only: events are never updated or deleted.
+type AuthEventRepository interface {
+	Create(ctx context.Context, e *AuthEvent) error
+	// List returns a page of matching events, newest first.
+	List(ctx context.Context, filter AuthEventFilter) ([]*AuthEvent, int, error)
+	// Each calls fn for every matching event, oldest first, ignoring the
+	// filter's paging. It stops at the first error fn returns.
+	Each(ctx context.Context, filter AuthEventFilter, fn func(*AuthEvent) error) error
+}
@@ -7,4 +7,20 @@ const (
 	RoleUser    Role = "user"
 	RoleManager Role = "manager"
 	RoleAdmin   Role = "admin"
-)
\ No newline at end of file
+)
+
+func (r Role) Valid() bool {
+	switch r {
+	case RoleGuest, RoleUser, RoleManager, RoleAdmin:
+		return true
+	}
+	return false
+}
+
+var roleRank = map[Role]int{RoleGuest: 0, RoleUser: 1, RoleManager: 2, RoleAdmin: 3}
+
+// AtLeast reports whether r has every privilege of other, e.g. so nobody
+// can hand out a role above their own.
+func (r Role) AtLeast(other Role) bool {
+	return roleRank[r] >= roleRank[other]
+}
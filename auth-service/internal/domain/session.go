@@ -0,0 +1,36 @@
+package domain
+
+import "time"
+
+// Session is one sign-in of a user on one device. A session owns exactly one
+// live refresh token at a time, identified by RefreshJTI; every refresh
+// replaces it. Presenting an older token of the session means it was stolen
+// or replayed, and the whole session is revoked.
+type Session struct {
+	ID           string     `bson:"_id"`
+	UserID       string     `bson:"user_id"`
+	RefreshJTI   string     `bson:"refresh_jti"`
+	Device       string     `bson:"device"`
+	IP           string     `bson:"ip"`
+	UserAgent    string     `bson:"user_agent"`
+	CreatedAt    time.Time  `bson:"created_at"`
+	LastUsedAt   time.Time  `bson:"last_used_at"`
+	ExpiresAt    time.Time  `bson:"expires_at"`
+	RevokedAt    *time.Time `bson:"revoked_at"`
+	RevokeReason string     `bson:"revoke_reason,omitempty"`
+}
+
+const (
+	RevokeReasonReuse     = "refresh_token_reuse"
+	RevokeReasonLogout    = "logout"
+	RevokeReasonLogoutAll = "logout_all"
+	RevokeReasonByUser    = "revoked_by_user"
+	RevokeReasonAdmin     = "revoked_by_admin"
+	RevokeReasonEmail     = "email_changed"
+	RevokeReasonDeleted   = "account_deleted"
+)
+
+// Active reports whether the session can still be refreshed.
+func (s *Session) Active(now time.Time) bool {
+	return s.RevokedAt == nil && now.Before(s.ExpiresAt)
+}
@@ -0,0 +1,29 @@
+package domain
+
+import "time"
+
+type TokenPurpose string
+
+const (
+	PurposeVerifyEmail   TokenPurpose = "verify_email"
+	PurposeResetPassword TokenPurpose = "reset_password"
+	// sign-in link, hashed together with the requesting browser's nonce
+	PurposeMagicLogin TokenPurpose = "magic_login"
+	// email change: confirmation sent to the new address, undo link to the old
+	PurposeChangeEmail     TokenPurpose = "change_email"
+	PurposeUndoEmailChange TokenPurpose = "undo_email_change"
+)
+
+// AuthToken is a single-use token sent to the user by email. Only the HMAC
+// of the raw token is persisted.
+type AuthToken struct {
+	ID         string       `bson:"_id,omitempty"`
+	UserID     string       `bson:"user_id"`
+	Purpose    TokenPurpose `bson:"purpose"`
+	TokenHash  string       `bson:"token_hash"`
+	ExpiresAt  time.Time    `bson:"expires_at"`
+	ConsumedAt *time.Time   `bson:"consumed_at"`
+	CreatedAt  time.Time    `bson:"created_at"`
+	// Email is the address an email-change token moves the account to.
+	Email string `bson:"email,omitempty"`
+}
@@ -0,0 +1,15 @@
+package domain
+
+import "time"
+
+// TwoFactor holds a user's TOTP settings. It is stored with Enabled false
+// between enrollment and confirmation.
+type TwoFactor struct {
+	Enabled bool `bson:"enabled"`
+	// Secret is the TOTP seed, encrypted at rest.
+	Secret string `bson:"secret"`
+	// RecoveryCodes are SHA-256 hashes of the unused one-time codes.
+	RecoveryCodes []string   `bson:"recovery_codes,omitempty"`
+	LastStep      int64      `bson:"last_step"`
+	EnabledAt     *time.Time `bson:"enabled_at,omitempty"`
+}
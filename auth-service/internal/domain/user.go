@@ -1,12 +1,75 @@
 package domain
 
-import "time"
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrUserNotFound = errors.New("user not found")
+	ErrEmailTaken   = errors.New("email already in use")
+)
 
 type User struct {
-	ID           string
-	Email        string
-	PasswordHash string
-	Role         Role
-	Verified     bool
-	CreatedAt    time.Time
+	ID           string     `bson:"_id,omitempty"`
+	Email        string     `bson:"email"`
+	PasswordHash string     `bson:"password_hash"`
+	Role         Role       `bson:"role"`
+	Verified     bool       `bson:"verified"`
+	TwoFactor    *TwoFactor `bson:"two_factor,omitempty"`
+	Profile      *Profile   `bson:"profile,omitempty"`
+	// PasswordHistory holds the hashes of earlier passwords, newest first.
+	PasswordHistory []string `bson:"password_history,omitempty"`
+	// Status is empty for accounts created before statuses existed,
+	// which counts as active.
+	Status         UserStatus `bson:"status,omitempty"`
+	StatusReason   string     `bson:"status_reason,omitempty"`
+	SuspendedUntil *time.Time `bson:"suspended_until,omitempty"`
+	// Deletion is set while the account waits to be deleted.
+	Deletion  *DeletionRequest `bson:"deletion,omitempty"`
+	CreatedAt time.Time        `bson:"created_at"`
+	UpdatedAt time.Time        `bson:"updated_at"`
+}
+
+// DeletionRequest schedules the account for deletion. Until ScheduledAt the
+// owner can still sign in and cancel it.
+type DeletionRequest struct {
+	// Mode is what happens to authored content: "anonymize" or "remove".
+	Mode        string    `bson:"mode"`
+	RequestedAt time.Time `bson:"requested_at"`
+	ScheduledAt time.Time `bson:"scheduled_at"`
+}
+
+type UserStatus string
+
+const (
+	StatusActive    UserStatus = "active"
+	StatusSuspended UserStatus = "suspended"
+	StatusBanned    UserStatus = "banned"
+)
+
+// CanSignIn reports whether the account may start new sessions. A
+// suspension without an end date lasts until it is lifted.
+func (u *User) CanSignIn(now time.Time) bool {
+	switch u.Status {
+	case StatusBanned:
+		return false
+	case StatusSuspended:
+		return u.SuspendedUntil != nil && !now.Before(*u.SuspendedUntil)
+	}
+	return true
+}
+
+func (u *User) TwoFactorEnabled() bool {
+	return u.TwoFactor != nil && u.TwoFactor.Enabled
+}
+
+// UserFilter narrows List results. Nil fields are not filtered on.
+type UserFilter struct {
+	Email    string // case-insensitive substring match
+	Role     *Role
+	Verified *bool
+	Status   *UserStatus
+	Page     int
+	PageSize int
 }
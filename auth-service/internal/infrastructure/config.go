@@ -11,29 +11,131 @@ type Config struct {
 		Port    int    `yaml:"port"`
 		Mode    string `yaml:"mode"`
 		BaseURL string `yaml:"base_url"`
+		// proxies (IPs or CIDRs) whose X-Forwarded-For is believed; with
+		// none the peer address is the client IP
+		TrustedProxies []string `yaml:"trusted_proxies"`
 	} `yaml:"server"`
 	Mongo struct {
 		URI    string `yaml:"uri"`
 		DBName string `yaml:"dbname"`
 	} `yaml:"mongo"`
 	Auth struct {
-		AccessSecret   string `yaml:"access_secret"`
-		RefreshSecret  string `yaml:"refresh_secret"`
-		AccessTTLMin   int    `yaml:"access_ttl_min"`
-		RefreshTTLHour int    `yaml:"refresh_ttl_hour"`
+		RefreshSecret  string        `yaml:"refresh_secret"`
+		AccessTTLMin   int           `yaml:"access_ttl_min"`
+		RefreshTTLHour int           `yaml:"refresh_ttl_hour"`
+		// lifetime of tokens from POST /admin/impersonate/:userID
+		ImpersonationTTLMin int `yaml:"impersonation_ttl_min"`
+		Signing        SigningConfig `yaml:"signing"`
+		// role -> permission policy file; empty uses rbac.DefaultPolicy
+		RBACPolicy string `yaml:"rbac_policy"`
+		TwoFactor      struct {
+			Issuer          string   `yaml:"issuer"`
+			Secret          string   `yaml:"secret"`
+			ChallengeTTLMin int      `yaml:"challenge_ttl_min"`
+			RequiredRoles   []string `yaml:"required_roles"`
+		} `yaml:"two_factor"`
+		APIKeys struct {
+			DefaultTTLDays int `yaml:"default_ttl_days"`
+			MaxTTLDays     int `yaml:"max_ttl_days"`
+		} `yaml:"api_keys"`
+		OAuth struct {
+			ClientTokenTTLMin int `yaml:"client_token_ttl_min"`
+			// Issuer defaults to server.base_url
+			Issuer     string `yaml:"issuer"`
+			CodeTTLSec int    `yaml:"code_ttl_sec"`
+		} `yaml:"oauth"`
+		Registration struct {
+			// open, invite_only or closed
+			Mode                 string `yaml:"mode"`
+			InvitationTTLDays    int    `yaml:"invitation_ttl_days"`
+			MaxInvitationTTLDays int    `yaml:"max_invitation_ttl_days"`
+		} `yaml:"registration"`
+		// security audit log; events are purged after retention_days
+		Events struct {
+			RetentionDays int `yaml:"retention_days"`
+		} `yaml:"events"`
+		MagicLink struct {
+			TTLMin int `yaml:"ttl_min"`
+		} `yaml:"magic_link"`
+		Password struct {
+			Algorithm string `yaml:"algorithm"`
+			Argon2    struct {
+				MemoryKiB   uint32 `yaml:"memory_kib"`
+				Iterations  uint32 `yaml:"iterations"`
+				Parallelism uint8  `yaml:"parallelism"`
+			} `yaml:"argon2"`
+			BcryptCost   int    `yaml:"bcrypt_cost"`
+			MinLength    int    `yaml:"min_length"`
+			MaxLength    int    `yaml:"max_length"`
+			History      int    `yaml:"history"`
+			BreachedList string `yaml:"breached_list"`
+		} `yaml:"password"`
+		Throttle struct {
+			WindowMin              int `yaml:"window_min"`
+			BaseDelaySec           int `yaml:"base_delay_sec"`
+			MaxDelaySec            int `yaml:"max_delay_sec"`
+			LockoutMin             int `yaml:"lockout_min"`
+			MaxAccountFailures     int `yaml:"max_account_failures"`
+			MaxIPFailures          int `yaml:"max_ip_failures"`
+			MaxResetRequests       int `yaml:"max_reset_requests"`
+			MaxResetIPRequests     int `yaml:"max_reset_ip_requests"`
+			MaxMagicLinkRequests   int `yaml:"max_magic_link_requests"`
+			MaxMagicLinkIPRequests int `yaml:"max_magic_link_ip_requests"`
+		} `yaml:"throttle"`
 	} `yaml:"auth"`
 	Email struct {
-		From string `yaml:"from"`
-		SMTP struct {
-			Host string `yaml:"host"`
-			Port string `yaml:"port"`
-			User string `yaml:"user"`
-			Pass string `yaml:"pass"`
+		From          string `yaml:"from"`
+		TokenSecret   string `yaml:"token_secret"`
+		TokenTTLHours int    `yaml:"token_ttl_hours"`
+		UndoTTLHours  int    `yaml:"undo_ttl_hours"`
+		// TemplatesDir overrides built-in email templates per <locale>/<file>
+		TemplatesDir string `yaml:"templates_dir"`
+		// smtp, file (a maildir under file_dir) or log
+		Transport string `yaml:"transport"`
+		FileDir   string `yaml:"file_dir"`
+		SMTP      struct {
+			Host       string `yaml:"host"`
+			Port       string `yaml:"port"`
+			User       string `yaml:"user"`
+			Pass       string `yaml:"pass"`
+			TLS        string `yaml:"tls"`
+			PoolSize   int    `yaml:"pool_size"`
+			TimeoutSec int    `yaml:"timeout_sec"`
 		} `yaml:"smtp"`
+		Outbox struct {
+			MaxAttempts     int `yaml:"max_attempts"`
+			BaseDelaySec    int `yaml:"base_delay_sec"`
+			MaxDelayMin     int `yaml:"max_delay_min"`
+			PollIntervalSec int `yaml:"poll_interval_sec"`
+			// sent emails are removed after this many days
+			RetentionDays int `yaml:"retention_days"`
+		} `yaml:"outbox"`
 	} `yaml:"email"`
 	Log struct {
 		Level string `yaml:"level"`
 	} `yaml:"log"`
+	Account struct {
+		DeletionGraceDays int `yaml:"deletion_grace_days"`
+		PurgeIntervalMin  int `yaml:"purge_interval_min"`
+		// services holding user data, reached through the userdata protocol
+		InternalSecret string `yaml:"internal_secret"`
+		Services       []struct {
+			Name string `yaml:"name"`
+			URL  string `yaml:"url"`
+		} `yaml:"services"`
+	} `yaml:"account"`
+}
+
+// SigningConfig lists the access-token signing keys. All keys are published
+// in the JWKS; only ActiveKID signs. With no keys an ephemeral one of
+// Algorithm is generated, which is only fit for development.
+type SigningConfig struct {
+	Algorithm string `yaml:"algorithm"`
+	ActiveKID string `yaml:"active_kid"`
+	Keys      []struct {
+		KID            string `yaml:"kid"`
+		PrivateKeyFile string `yaml:"private_key_file"`
+	} `yaml:"keys"`
 }
 
 func Load(path string) (*Config, error) {
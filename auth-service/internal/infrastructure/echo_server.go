@@ -1,52 +1,178 @@
 package infrastructure
 
 import (
+	"fmt"
+	"net"
 	"strconv"
+	"strings"
 
 	"github.com/HatefBarari/microblog-auth/internal/presenter"
 	"github.com/HatefBarari/microblog-shared/pkg/auth"
+	"github.com/HatefBarari/microblog-shared/pkg/rbac"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
 	"go.uber.org/zap"
 )
 
-func StartEcho(cfg *Config, log *zap.Logger, handler *presenter.HTTPHandler) error {
+func StartEcho(cfg *Config, log *zap.Logger, handler *presenter.HTTPHandler, keys *auth.KeySet, revocations auth.RevocationChecker, policy *rbac.Policy) error {
 	e := echo.New()
 	e.HideBanner = true
+	ipExtractor, err := IPExtractor(cfg.Server.TrustedProxies)
+	if err != nil {
+		return err
+	}
+	e.IPExtractor = ipExtractor
 	e.Use(middleware.Recover())
 	e.Use(middleware.RequestLoggerWithConfig(middleware.RequestLoggerConfig{
 		LogURI:    true,
 		LogStatus: true,
 		LogValuesFunc: func(c echo.Context, v middleware.RequestLoggerValues) error {
-			log.Info("request",
+			fields := []zap.Field{
 				zap.String("method", c.Request().Method),
 				zap.String("uri", v.URI),
 				zap.Int("status", v.Status),
-			)
+			}
+			if uid, ok := c.Get("userID").(string); ok && uid != "" {
+				fields = append(fields, zap.String("user_id", uid))
+			}
+			// the admin behind an impersonated token
+			if actor := auth.ActorID(c); actor != "" {
+				fields = append(fields, zap.String("actor_id", actor))
+			}
+			log.Info("request", fields...)
 			return nil
 		},
 	}))
 
 	// JWT middleware
-	jwtMid := auth.Middleware(cfg.Auth.AccessSecret)
+	jwtMid := auth.Middleware(keys, auth.WithRevocationChecker(revocations), auth.WithPolicy(policy))
+
+	// public keys for verifying access tokens
+	e.GET("/.well-known/jwks.json", func(c echo.Context) error {
+		c.Response().Header().Set("Cache-Control", "public, max-age=300")
+		return c.JSON(200, keys.JWKS())
+	})
 
 	// public routes
 	e.POST("/register", handler.Register)
 	e.POST("/login", handler.Login)
+	e.POST("/login/2fa", handler.LoginTwoFactor)
+	e.POST("/login/2fa/setup", handler.SetupTwoFactor)
+	e.POST("/login/magic", handler.RequestMagicLink)
+	e.GET("/login/magic/verify", handler.VerifyMagicLink)
 	e.POST("/auth/refresh", handler.Refresh)
+
+	// OAuth2 and OpenID Connect for service clients and apps
+	e.GET("/.well-known/openid-configuration", handler.OpenIDConfiguration)
+	e.POST("/oauth/token", handler.OAuthToken)
+	e.POST("/oauth/introspect", handler.OAuthIntrospect)
+	e.GET("/oauth/authorize", handler.Authorize)
+	e.POST("/oauth/authorize", handler.ApproveAuthorize)
+	// only app access tokens are accepted here, and only here
+	userInfoMid := auth.Middleware(keys, auth.WithRevocationChecker(revocations), auth.WithAudience(handler.UserInfoAudience()))
+	e.GET("/userinfo", handler.UserInfo, userInfoMid)
+	e.POST("/userinfo", handler.UserInfo, userInfoMid)
 	
 	// email verification routes
 	e.GET("/verify", handler.VerifyEmail)
 	e.POST("/forgot-password", handler.SendPasswordResetEmail)
 	e.POST("/reset-password", handler.ResetPassword)
+	e.GET("/email/confirm", handler.ConfirmEmailChange)
+	e.GET("/email/undo", handler.UndoEmailChange)
+
+	// public profiles
+	e.GET("/users/:handle", handler.GetPublicProfile)
+	e.GET("/authors", handler.ListAuthors)
 
 	// protected routes
 	protected := e.Group("/api/v1", jwtMid)
 	protected.POST("/resend-verification", handler.ResendVerificationEmail)
+	protected.POST("/logout", handler.Logout)
+	protected.POST("/logout-all", handler.LogoutAll)
+	protected.GET("/sessions", handler.ListSessions)
+	protected.DELETE("/sessions/:id", handler.RevokeSession)
+	// impersonated tokens can't change credentials or outlive themselves
+	// through an API key
+	noImpersonation := auth.DenyImpersonation()
+	protected.POST("/tokens", handler.CreateAPIKey, noImpersonation)
+	protected.GET("/tokens", handler.ListAPIKeys)
+	protected.DELETE("/tokens/:id", handler.RevokeAPIKey)
+	protected.POST("/2fa/enroll", handler.EnrollTwoFactor, noImpersonation)
+	protected.POST("/2fa/confirm", handler.ConfirmTwoFactor, noImpersonation)
+	protected.POST("/2fa/disable", handler.DisableTwoFactor, noImpersonation)
+	protected.POST("/2fa/recovery-codes", handler.RegenerateRecoveryCodes, noImpersonation)
+	protected.GET("/me/profile", handler.GetMyProfile)
+	protected.PUT("/me/profile", handler.UpdateMyProfile)
+	protected.POST("/me/email", handler.ChangeEmail, noImpersonation)
+	protected.DELETE("/me", handler.DeleteAccount, noImpersonation)
+	protected.POST("/me/deletion/cancel", handler.CancelAccountDeletion)
+	protected.GET("/me/export", handler.ExportAccount)
+	protected.GET("/me/security-events", handler.ListMySecurityEvents)
 	protected.GET("/me", func(c echo.Context) error {
-		return c.JSON(200, map[string]string{"userID": c.Get("userID").(string)})
+		return c.JSON(200, map[string]interface{}{
+			"userID":      c.Get("userID").(string),
+			"role":        c.Get("role").(string),
+			"permissions": auth.Permissions(c),
+		})
 	})
 
+	// invitations, by managers and admins
+	invites := protected.Group("/invitations", auth.Require(rbac.UserInvite))
+	invites.POST("", handler.CreateInvitation)
+	invites.GET("", handler.ListInvitations)
+	invites.DELETE("/:id", handler.RevokeInvitation)
+
+	// admin routes
+	admin := protected.Group("/admin", auth.Require(rbac.UserManage))
+	admin.GET("/users", handler.ListUsers)
+	admin.GET("/users/:id", handler.GetUser)
+	admin.PATCH("/users/:id/role", handler.ChangeUserRole)
+	admin.POST("/users/:id/suspend", handler.SuspendUser)
+	admin.POST("/users/:id/ban", handler.BanUser)
+	admin.POST("/users/:id/reactivate", handler.ReactivateUser)
+	admin.POST("/users/:id/verify", handler.VerifyUser)
+	admin.POST("/users/:id/password-reset", handler.SendUserPasswordReset)
+	admin.POST("/users/:id/unlock", handler.UnlockUser)
+	admin.POST("/impersonate/:userID", handler.ImpersonateUser)
+	admin.GET("/actions", handler.ListAdminActions)
+	admin.GET("/auth-events", handler.ListAuthEvents)
+	admin.GET("/auth-events/export", handler.ExportAuthEvents)
+	admin.GET("/emails", handler.ListOutboxEmails)
+	admin.POST("/emails/:id/resend", handler.ResendOutboxEmail)
+	admin.POST("/oauth-clients", handler.CreateOAuthClient)
+	admin.GET("/oauth-clients", handler.ListOAuthClients)
+	admin.DELETE("/oauth-clients/:id", handler.RevokeOAuthClient)
+
 	log.Info("starting auth server", zap.Int("port", cfg.Server.Port))
 	return e.Start(":" + strconv.Itoa(cfg.Server.Port))
-}
\ No newline at end of file
+}
+
+// IPExtractor decides where c.RealIP() comes from, and with it the client IP
+// used by the per-IP throttles, sessions and the audit log. Forwarding
+// headers are client-controlled, so they are read only when the request
+// arrives from one of trustedProxies; otherwise the peer address is used.
+func IPExtractor(trustedProxies []string) (echo.IPExtractor, error) {
+	if len(trustedProxies) == 0 {
+		return echo.ExtractIPDirect(), nil
+	}
+	opts := []echo.TrustOption{
+		echo.TrustLoopback(false),
+		echo.TrustLinkLocal(false),
+		echo.TrustPrivateNet(false),
+	}
+	for _, p := range trustedProxies {
+		if !strings.Contains(p, "/") {
+			if ip := net.ParseIP(p); ip != nil && ip.To4() != nil {
+				p += "/32"
+			} else {
+				p += "/128"
+			}
+		}
+		_, ipNet, err := net.ParseCIDR(p)
+		if err != nil {
+			return nil, fmt.Errorf("server.trusted_proxies: %w", err)
+		}
+		opts = append(opts, echo.TrustIPRange(ipNet))
+	}
+	return echo.ExtractIPFromXFFHeader(opts...), nil
+}
@@ -0,0 +1,40 @@
+package infrastructure
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/HatefBarari/microblog-shared/pkg/auth"
+	"go.uber.org/zap"
+)
+
+// LoadKeySet reads the configured signing keys from their PEM files.
+func LoadKeySet(cfg SigningConfig, log *zap.Logger) (*auth.KeySet, error) {
+	if len(cfg.Keys) == 0 {
+		kid := "dev-" + auth.NewTokenID()[:8]
+		key, err := auth.GenerateSigningKey(kid, cfg.Algorithm)
+		if err != nil {
+			return nil, err
+		}
+		log.Warn("no signing keys configured, using an ephemeral key; tokens will not survive a restart",
+			zap.String("kid", kid))
+		return auth.NewKeySet(kid, key)
+	}
+	keys := make([]auth.SigningKey, 0, len(cfg.Keys))
+	for _, k := range cfg.Keys {
+		data, err := os.ReadFile(k.PrivateKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("signing key %s: %w", k.KID, err)
+		}
+		key, err := auth.ParseSigningKeyPEM(k.KID, data)
+		if err != nil {
+			return nil, fmt.Errorf("signing key %s: %w", k.KID, err)
+		}
+		keys = append(keys, key)
+	}
+	active := cfg.ActiveKID
+	if active == "" {
+		active = cfg.Keys[len(cfg.Keys)-1].KID
+	}
+	return auth.NewKeySet(active, keys...)
+}
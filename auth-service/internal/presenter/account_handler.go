@@ -0,0 +1,70 @@
+package presenter
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/HatefBarari/microblog-auth/internal/domain"
+	"github.com/HatefBarari/microblog-auth/internal/usecase"
+	"github.com/HatefBarari/microblog-shared/pkg/httputil"
+	"github.com/labstack/echo/v4"
+)
+
+// DeleteAccount schedules the caller's account for deletion after the grace
+// period
+func (h *HTTPHandler) DeleteAccount(c echo.Context) error {
+	var req usecase.DeleteAccountRequest
+	if err := httputil.BindAndValidate(c, &req); err != nil {
+		return c.JSON(http.StatusBadRequest, httputil.NewError(400, err.Error()))
+	}
+	userID := c.Get("userID").(string)
+	resp, err := h.accountUC.ScheduleDeletion(requestContext(c), userID, req)
+	if err != nil {
+		return accountError(c, err)
+	}
+	return c.JSON(http.StatusAccepted, httputil.OK(resp))
+}
+
+// CancelAccountDeletion keeps an account whose deletion is still pending
+func (h *HTTPHandler) CancelAccountDeletion(c echo.Context) error {
+	userID := c.Get("userID").(string)
+	if err := h.accountUC.CancelDeletion(requestContext(c), userID); err != nil {
+		return accountError(c, err)
+	}
+	return c.JSON(http.StatusOK, httputil.OK(map[string]string{
+		"message": "account deletion cancelled",
+	}))
+}
+
+// ExportAccount streams a ZIP of everything the services hold about the
+// caller
+func (h *HTTPHandler) ExportAccount(c echo.Context) error {
+	userID := c.Get("userID").(string)
+	ctx := requestContext(c)
+	export, err := h.accountUC.Export(ctx, userID)
+	if err != nil {
+		return accountError(c, err)
+	}
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentType, "application/zip")
+	res.Header().Set(echo.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="microblog-%s.zip"`, userID))
+	res.WriteHeader(http.StatusOK)
+	// the status is already sent; a failure here leaves a truncated archive
+	return export.WriteZip(ctx, res)
+}
+
+func accountError(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, usecase.ErrInvalidDeletionMode):
+		return c.JSON(http.StatusBadRequest, httputil.NewError(400, err.Error()))
+	case errors.Is(err, usecase.ErrInvalidCredentials):
+		return c.JSON(http.StatusUnauthorized, httputil.NewError(401, err.Error()))
+	case errors.Is(err, domain.ErrUserNotFound):
+		return c.JSON(http.StatusNotFound, httputil.NewError(404, err.Error()))
+	case errors.Is(err, usecase.ErrDeletionPending),
+		errors.Is(err, usecase.ErrNoDeletionPending):
+		return c.JSON(http.StatusConflict, httputil.NewError(409, err.Error()))
+	}
+	return c.JSON(http.StatusInternalServerError, httputil.NewError(500, err.Error()))
+}
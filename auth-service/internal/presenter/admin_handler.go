@@ -0,0 +1,145 @@
+package presenter
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/HatefBarari/microblog-auth/internal/domain"
+	"github.com/HatefBarari/microblog-auth/internal/usecase"
+	"github.com/HatefBarari/microblog-shared/pkg/httputil"
+	"github.com/labstack/echo/v4"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ListUsers searches accounts by email, role, verified and status (admin only)
+func (h *HTTPHandler) ListUsers(c echo.Context) error {
+	var req usecase.ListUsersRequest
+	if err := httputil.BindAndValidate(c, &req); err != nil {
+		return c.JSON(http.StatusBadRequest, httputil.NewError(400, err.Error()))
+	}
+	resp, err := h.adminUC.ListUsers(c.Request().Context(), req)
+	if err != nil {
+		return adminError(c, err)
+	}
+	return c.JSON(http.StatusOK, httputil.OK(resp))
+}
+
+func (h *HTTPHandler) GetUser(c echo.Context) error {
+	resp, err := h.adminUC.GetUser(c.Request().Context(), c.Param("id"))
+	if err != nil {
+		return adminError(c, err)
+	}
+	return c.JSON(http.StatusOK, httputil.OK(resp))
+}
+
+func (h *HTTPHandler) ChangeUserRole(c echo.Context) error {
+	var req usecase.ChangeRoleRequest
+	if err := httputil.BindAndValidate(c, &req); err != nil {
+		return c.JSON(http.StatusBadRequest, httputil.NewError(400, err.Error()))
+	}
+	actorID := c.Get("userID").(string)
+	resp, err := h.adminUC.ChangeRole(requestContext(c), actorID, c.Param("id"), domain.Role(req.Role))
+	if err != nil {
+		return adminError(c, err)
+	}
+	return c.JSON(http.StatusOK, httputil.OK(resp))
+}
+
+func (h *HTTPHandler) SuspendUser(c echo.Context) error {
+	var req usecase.AccountStatusRequest
+	if err := httputil.BindAndValidate(c, &req); err != nil {
+		return c.JSON(http.StatusBadRequest, httputil.NewError(400, err.Error()))
+	}
+	actorID := c.Get("userID").(string)
+	resp, err := h.adminUC.Suspend(requestContext(c), actorID, c.Param("id"), req)
+	if err != nil {
+		return adminError(c, err)
+	}
+	return c.JSON(http.StatusOK, httputil.OK(resp))
+}
+
+func (h *HTTPHandler) BanUser(c echo.Context) error {
+	var req usecase.AccountStatusRequest
+	if err := httputil.BindAndValidate(c, &req); err != nil {
+		return c.JSON(http.StatusBadRequest, httputil.NewError(400, err.Error()))
+	}
+	actorID := c.Get("userID").(string)
+	resp, err := h.adminUC.Ban(requestContext(c), actorID, c.Param("id"), req.Reason)
+	if err != nil {
+		return adminError(c, err)
+	}
+	return c.JSON(http.StatusOK, httputil.OK(resp))
+}
+
+func (h *HTTPHandler) ReactivateUser(c echo.Context) error {
+	actorID := c.Get("userID").(string)
+	resp, err := h.adminUC.Reactivate(requestContext(c), actorID, c.Param("id"))
+	if err != nil {
+		return adminError(c, err)
+	}
+	return c.JSON(http.StatusOK, httputil.OK(resp))
+}
+
+func (h *HTTPHandler) VerifyUser(c echo.Context) error {
+	actorID := c.Get("userID").(string)
+	resp, err := h.adminUC.ForceVerify(requestContext(c), actorID, c.Param("id"))
+	if err != nil {
+		return adminError(c, err)
+	}
+	return c.JSON(http.StatusOK, httputil.OK(resp))
+}
+
+func (h *HTTPHandler) SendUserPasswordReset(c echo.Context) error {
+	actorID := c.Get("userID").(string)
+	if err := h.adminUC.SendPasswordReset(requestContext(c), actorID, c.Param("id")); err != nil {
+		return adminError(c, err)
+	}
+	return c.JSON(http.StatusOK, httputil.OK(map[string]string{
+		"message": "Password reset email sent",
+	}))
+}
+
+// UnlockUser clears a user's login lockout (admin only)
+func (h *HTTPHandler) UnlockUser(c echo.Context) error {
+	actorID := c.Get("userID").(string)
+	if err := h.adminUC.Unlock(requestContext(c), actorID, c.Param("id")); err != nil {
+		return adminError(c, err)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// ImpersonateUser issues a short-lived token to act as a user (admin only)
+func (h *HTTPHandler) ImpersonateUser(c echo.Context) error {
+	actorID := c.Get("userID").(string)
+	resp, err := h.adminUC.Impersonate(requestContext(c), actorID, c.Param("userID"))
+	if err != nil {
+		return adminError(c, err)
+	}
+	return c.JSON(http.StatusCreated, httputil.OK(resp))
+}
+
+func (h *HTTPHandler) ListAdminActions(c echo.Context) error {
+	var req usecase.ListAdminActionsRequest
+	if err := httputil.BindAndValidate(c, &req); err != nil {
+		return c.JSON(http.StatusBadRequest, httputil.NewError(400, err.Error()))
+	}
+	resp, err := h.adminUC.ListActions(c.Request().Context(), req)
+	if err != nil {
+		return adminError(c, err)
+	}
+	return c.JSON(http.StatusOK, httputil.OK(resp))
+}
+
+func adminError(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, domain.ErrUserNotFound), errors.Is(err, primitive.ErrInvalidHex):
+		return c.JSON(http.StatusNotFound, httputil.NewError(404, domain.ErrUserNotFound.Error()))
+	case errors.Is(err, usecase.ErrSelfAction), errors.Is(err, usecase.ErrImpersonateAdmin), errors.Is(err, usecase.ErrAccountSuspended):
+		return c.JSON(http.StatusForbidden, httputil.NewError(403, err.Error()))
+	case errors.Is(err, usecase.ErrAccountActive):
+		return c.JSON(http.StatusConflict, httputil.NewError(409, err.Error()))
+	case errors.Is(err, usecase.ErrInvalidUntil):
+		return c.JSON(http.StatusBadRequest, httputil.NewError(400, err.Error()))
+	}
+	return c.JSON(http.StatusInternalServerError, httputil.NewError(500, err.Error()))
+}
@@ -0,0 +1,51 @@
+package presenter
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/HatefBarari/microblog-auth/internal/usecase"
+	"github.com/HatefBarari/microblog-shared/pkg/httputil"
+	"github.com/labstack/echo/v4"
+)
+
+// ListMySecurityEvents shows the caller's sign-ins and other security events
+func (h *HTTPHandler) ListMySecurityEvents(c echo.Context) error {
+	var req usecase.ListSecurityEventsRequest
+	if err := httputil.BindAndValidate(c, &req); err != nil {
+		return c.JSON(http.StatusBadRequest, httputil.NewError(400, err.Error()))
+	}
+	resp, err := h.eventsUC.ListMine(c.Request().Context(), c.Get("userID").(string), req)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, httputil.NewError(500, err.Error()))
+	}
+	return c.JSON(http.StatusOK, httputil.OK(resp))
+}
+
+// ListAuthEvents queries the security audit log (admin only)
+func (h *HTTPHandler) ListAuthEvents(c echo.Context) error {
+	var req usecase.ListAuthEventsRequest
+	if err := httputil.BindAndValidate(c, &req); err != nil {
+		return c.JSON(http.StatusBadRequest, httputil.NewError(400, err.Error()))
+	}
+	resp, err := h.eventsUC.List(c.Request().Context(), req)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, httputil.NewError(500, err.Error()))
+	}
+	return c.JSON(http.StatusOK, httputil.OK(resp))
+}
+
+// ExportAuthEvents streams the matching audit log as NDJSON for a SIEM
+// (admin only)
+func (h *HTTPHandler) ExportAuthEvents(c echo.Context) error {
+	var req usecase.ListAuthEventsRequest
+	if err := httputil.BindAndValidate(c, &req); err != nil {
+		return c.JSON(http.StatusBadRequest, httputil.NewError(400, err.Error()))
+	}
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentType, "application/x-ndjson")
+	res.Header().Set(echo.HeaderContentDisposition, `attachment; filename="auth-events-`+time.Now().UTC().Format("20060102T150405Z")+`.ndjson"`)
+	res.WriteHeader(http.StatusOK)
+	// the status is already sent; a failure here leaves a truncated export
+	return h.eventsUC.Export(c.Request().Context(), req, res)
+}
@@ -0,0 +1,72 @@
+package presenter
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/HatefBarari/microblog-auth/internal/domain"
+	"github.com/HatefBarari/microblog-auth/internal/usecase"
+	"github.com/HatefBarari/microblog-shared/pkg/httputil"
+	"github.com/labstack/echo/v4"
+)
+
+// ChangeEmail sends a confirmation link to the new address and a notice
+// with an undo link to the current one
+func (h *HTTPHandler) ChangeEmail(c echo.Context) error {
+	var req usecase.ChangeEmailRequest
+	if err := httputil.BindAndValidate(c, &req); err != nil {
+		return c.JSON(http.StatusBadRequest, httputil.NewError(400, err.Error()))
+	}
+	userID := c.Get("userID").(string)
+	if err := h.changeUC.Request(requestContext(c), userID, req); err != nil {
+		return emailChangeError(c, err)
+	}
+	return c.JSON(http.StatusAccepted, httputil.OK(map[string]string{
+		"message": "confirmation email sent to the new address",
+	}))
+}
+
+// ConfirmEmailChange applies the change and issues a new session
+func (h *HTTPHandler) ConfirmEmailChange(c echo.Context) error {
+	token := c.QueryParam("token")
+	if token == "" {
+		return c.JSON(http.StatusBadRequest, httputil.NewError(400, "confirmation token is required"))
+	}
+	resp, err := h.changeUC.Confirm(requestContext(c), token)
+	if err != nil {
+		return emailChangeError(c, err)
+	}
+	return c.JSON(http.StatusOK, httputil.OK(resp))
+}
+
+// UndoEmailChange restores the previous address and signs out everywhere
+func (h *HTTPHandler) UndoEmailChange(c echo.Context) error {
+	token := c.QueryParam("token")
+	if token == "" {
+		return c.JSON(http.StatusBadRequest, httputil.NewError(400, "undo token is required"))
+	}
+	if err := h.changeUC.Undo(requestContext(c), token); err != nil {
+		return emailChangeError(c, err)
+	}
+	return c.JSON(http.StatusOK, httputil.OK(map[string]string{
+		"message": "email change undone, all sessions ended",
+	}))
+}
+
+func emailChangeError(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, usecase.ErrInvalidToken),
+		errors.Is(err, usecase.ErrSameEmail):
+		return c.JSON(http.StatusBadRequest, httputil.NewError(400, err.Error()))
+	case errors.Is(err, usecase.ErrInvalidCredentials):
+		return c.JSON(http.StatusUnauthorized, httputil.NewError(401, err.Error()))
+	case errors.Is(err, usecase.ErrAccountSuspended),
+		errors.Is(err, usecase.ErrAccountNotVerified):
+		return c.JSON(http.StatusForbidden, httputil.NewError(403, err.Error()))
+	case errors.Is(err, domain.ErrUserNotFound):
+		return c.JSON(http.StatusNotFound, httputil.NewError(404, err.Error()))
+	case errors.Is(err, domain.ErrEmailTaken):
+		return c.JSON(http.StatusConflict, httputil.NewError(409, err.Error()))
+	}
+	return c.JSON(http.StatusInternalServerError, httputil.NewError(500, err.Error()))
+}
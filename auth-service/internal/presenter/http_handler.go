@@ -1,31 +1,73 @@
 package presenter
 
 import (
+	"context"
+	"errors"
+	"math"
 	"net/http"
+	"strconv"
 
+	"github.com/HatefBarari/microblog-auth/internal/domain"
 	"github.com/HatefBarari/microblog-auth/internal/usecase"
 	"github.com/HatefBarari/microblog-shared/pkg/httputil"
 	"github.com/labstack/echo/v4"
 )
 
 type HTTPHandler struct {
-	uc      *usecase.UserUseCase
-	emailUC *usecase.EmailUseCase
+	uc        *usecase.UserUseCase
+	emailUC   *usecase.EmailUseCase
+	sessionUC *usecase.SessionUseCase
+	apiKeyUC  *usecase.APIKeyUseCase
+	adminUC   *usecase.AdminUseCase
+	profileUC *usecase.ProfileUseCase
+	magicUC   *usecase.MagicLinkUseCase
+	changeUC  *usecase.EmailChangeUseCase
+	accountUC *usecase.AccountUseCase
+	outboxUC  *usecase.OutboxUseCase
+	oauthUC   *usecase.OAuthUseCase
+	oidcUC    *usecase.OIDCUseCase
+	inviteUC  *usecase.InvitationUseCase
+	eventsUC  *usecase.AuthEventUseCase
 }
 
-func NewHTTPHandler(uc *usecase.UserUseCase, emailUC *usecase.EmailUseCase) *HTTPHandler {
+func NewHTTPHandler(uc *usecase.UserUseCase, emailUC *usecase.EmailUseCase, sessionUC *usecase.SessionUseCase, apiKeyUC *usecase.APIKeyUseCase, adminUC *usecase.AdminUseCase, profileUC *usecase.ProfileUseCase, magicUC *usecase.MagicLinkUseCase, changeUC *usecase.EmailChangeUseCase, accountUC *usecase.AccountUseCase, outboxUC *usecase.OutboxUseCase, oauthUC *usecase.OAuthUseCase, oidcUC *usecase.OIDCUseCase, inviteUC *usecase.InvitationUseCase, eventsUC *usecase.AuthEventUseCase) *HTTPHandler {
 	return &HTTPHandler{
-		uc:      uc,
-		emailUC: emailUC,
+		uc:        uc,
+		emailUC:   emailUC,
+		sessionUC: sessionUC,
+		apiKeyUC:  apiKeyUC,
+		adminUC:   adminUC,
+		profileUC: profileUC,
+		magicUC:   magicUC,
+		changeUC:  changeUC,
+		accountUC: accountUC,
+		outboxUC:  outboxUC,
+		oauthUC:   oauthUC,
+		oidcUC:    oidcUC,
+		inviteUC:  inviteUC,
+		eventsUC:  eventsUC,
 	}
 }
 
+// requestContext returns the request context annotated with the caller's
+// address, user agent and optional X-Device-Name header.
+func requestContext(c echo.Context) context.Context {
+	return usecase.WithClientInfo(c.Request().Context(), usecase.ClientInfo{
+		IP:        c.RealIP(),
+		UserAgent: c.Request().UserAgent(),
+		Device:    c.Request().Header.Get("X-Device-Name"),
+	})
+}
+
 func (h *HTTPHandler) Register(c echo.Context) error {
 	var req usecase.RegisterRequest
 	if err := httputil.BindAndValidate(c, &req); err != nil {
 		return c.JSON(http.StatusBadRequest, httputil.NewError(400, err.Error()))
 	}
-	resp, err := h.uc.Register(c.Request().Context(), req)
+	resp, err := h.uc.Register(requestContext(c), req)
+	if errors.Is(err, usecase.ErrRegistrationClosed) || errors.Is(err, usecase.ErrInvitationRequired) {
+		return c.JSON(http.StatusForbidden, httputil.NewError(403, err.Error()))
+	}
 	if err != nil {
 		return c.JSON(http.StatusBadRequest, httputil.NewError(400, err.Error()))
 	}
@@ -37,7 +79,13 @@ func (h *HTTPHandler) Login(c echo.Context) error {
 	if err := httputil.BindAndValidate(c, &req); err != nil {
 		return c.JSON(http.StatusBadRequest, httputil.NewError(400, err.Error()))
 	}
-	resp, err := h.uc.Login(c.Request().Context(), req)
+	resp, err := h.uc.Login(requestContext(c), req)
+	if errors.Is(err, usecase.ErrTooManyAttempts) {
+		return tooManyAttempts(c, err)
+	}
+	if errors.Is(err, usecase.ErrAccountSuspended) {
+		return c.JSON(http.StatusForbidden, httputil.NewError(403, err.Error()))
+	}
 	if err != nil {
 		return c.JSON(http.StatusUnauthorized, httputil.NewError(401, err.Error()))
 	}
@@ -49,7 +97,7 @@ func (h *HTTPHandler) Refresh(c echo.Context) error {
 	if err := httputil.BindAndValidate(c, &req); err != nil {
 		return c.JSON(http.StatusBadRequest, httputil.NewError(400, err.Error()))
 	}
-	resp, err := h.uc.Refresh(c.Request().Context(), req.RefreshToken)
+	resp, err := h.uc.Refresh(requestContext(c), req.RefreshToken)
 	if err != nil {
 		return c.JSON(http.StatusUnauthorized, httputil.NewError(401, err.Error()))
 	}
@@ -76,10 +124,12 @@ func (h *HTTPHandler) VerifyEmail(c echo.Context) error {
 // ResendVerificationEmail resends verification email
 func (h *HTTPHandler) ResendVerificationEmail(c echo.Context) error {
 	userID := c.Get("userID").(string)
-	email := c.Get("email").(string)
 
-	err := h.emailUC.SendVerificationEmail(c.Request().Context(), userID, email)
+	err := h.emailUC.ResendVerificationEmail(c.Request().Context(), userID)
 	if err != nil {
+		if errors.Is(err, domain.ErrUserNotFound) {
+			return c.JSON(http.StatusNotFound, httputil.NewError(404, err.Error()))
+		}
 		return c.JSON(http.StatusInternalServerError, httputil.NewError(500, err.Error()))
 	}
 
@@ -97,7 +147,10 @@ func (h *HTTPHandler) SendPasswordResetEmail(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, httputil.NewError(400, err.Error()))
 	}
 
-	err := h.emailUC.SendPasswordResetEmail(c.Request().Context(), req.Email)
+	err := h.emailUC.SendPasswordResetEmail(requestContext(c), req.Email)
+	if errors.Is(err, usecase.ErrTooManyAttempts) {
+		return tooManyAttempts(c, err)
+	}
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, httputil.NewError(500, err.Error()))
 	}
@@ -125,4 +178,200 @@ func (h *HTTPHandler) ResetPassword(c echo.Context) error {
 	return c.JSON(http.StatusOK, httputil.OK(map[string]string{
 		"message": "password reset successfully",
 	}))
-}
\ No newline at end of file
+}
+
+// Logout ends the current session
+func (h *HTTPHandler) Logout(c echo.Context) error {
+	userID := c.Get("userID").(string)
+	sessionID, _ := c.Get("sessionID").(string)
+	if err := h.sessionUC.Logout(c.Request().Context(), userID, sessionID); err != nil {
+		return sessionError(c, err)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// LogoutAll ends every session of the current user
+func (h *HTTPHandler) LogoutAll(c echo.Context) error {
+	userID := c.Get("userID").(string)
+	n, err := h.sessionUC.LogoutAll(c.Request().Context(), userID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, httputil.NewError(500, err.Error()))
+	}
+	return c.JSON(http.StatusOK, httputil.OK(map[string]int{"revoked": n}))
+}
+
+// ListSessions lists the current user's active sessions
+func (h *HTTPHandler) ListSessions(c echo.Context) error {
+	userID := c.Get("userID").(string)
+	sessionID, _ := c.Get("sessionID").(string)
+	list, err := h.sessionUC.List(c.Request().Context(), userID, sessionID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, httputil.NewError(500, err.Error()))
+	}
+	return c.JSON(http.StatusOK, httputil.OK(list))
+}
+
+// RevokeSession ends one of the current user's sessions
+func (h *HTTPHandler) RevokeSession(c echo.Context) error {
+	userID := c.Get("userID").(string)
+	if err := h.sessionUC.Revoke(c.Request().Context(), userID, c.Param("id")); err != nil {
+		return sessionError(c, err)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// CreateAPIKey issues a personal access token; the token is shown only once
+func (h *HTTPHandler) CreateAPIKey(c echo.Context) error {
+	var req usecase.CreateAPIKeyRequest
+	if err := httputil.BindAndValidate(c, &req); err != nil {
+		return c.JSON(http.StatusBadRequest, httputil.NewError(400, err.Error()))
+	}
+	userID := c.Get("userID").(string)
+	resp, err := h.apiKeyUC.Create(c.Request().Context(), userID, req)
+	if err != nil {
+		if errors.Is(err, domain.ErrUserNotFound) {
+			return c.JSON(http.StatusNotFound, httputil.NewError(404, err.Error()))
+		}
+		return c.JSON(http.StatusBadRequest, httputil.NewError(400, err.Error()))
+	}
+	return c.JSON(http.StatusCreated, httputil.OK(resp))
+}
+
+// ListAPIKeys lists the current user's personal access tokens
+func (h *HTTPHandler) ListAPIKeys(c echo.Context) error {
+	userID := c.Get("userID").(string)
+	list, err := h.apiKeyUC.List(c.Request().Context(), userID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, httputil.NewError(500, err.Error()))
+	}
+	return c.JSON(http.StatusOK, httputil.OK(list))
+}
+
+// RevokeAPIKey revokes one of the current user's personal access tokens
+func (h *HTTPHandler) RevokeAPIKey(c echo.Context) error {
+	userID := c.Get("userID").(string)
+	if err := h.apiKeyUC.Revoke(c.Request().Context(), userID, c.Param("id")); err != nil {
+		if errors.Is(err, usecase.ErrAPIKeyNotFound) {
+			return c.JSON(http.StatusNotFound, httputil.NewError(404, err.Error()))
+		}
+		return c.JSON(http.StatusInternalServerError, httputil.NewError(500, err.Error()))
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// LoginTwoFactor completes a login with a TOTP or recovery code
+func (h *HTTPHandler) LoginTwoFactor(c echo.Context) error {
+	var req usecase.LoginTwoFactorRequest
+	if err := httputil.BindAndValidate(c, &req); err != nil {
+		return c.JSON(http.StatusBadRequest, httputil.NewError(400, err.Error()))
+	}
+	resp, err := h.uc.LoginTwoFactor(requestContext(c), req.ChallengeToken, req.Code)
+	if err != nil {
+		return twoFactorError(c, err)
+	}
+	return c.JSON(http.StatusOK, httputil.OK(resp))
+}
+
+// SetupTwoFactor starts the enrollment a role requires before login
+func (h *HTTPHandler) SetupTwoFactor(c echo.Context) error {
+	var req usecase.TwoFactorSetupRequest
+	if err := httputil.BindAndValidate(c, &req); err != nil {
+		return c.JSON(http.StatusBadRequest, httputil.NewError(400, err.Error()))
+	}
+	resp, err := h.uc.SetupTwoFactor(c.Request().Context(), req.ChallengeToken)
+	if err != nil {
+		return twoFactorError(c, err)
+	}
+	return c.JSON(http.StatusOK, httputil.OK(resp))
+}
+
+// EnrollTwoFactor returns a new TOTP secret with its otpauth URI and QR code
+func (h *HTTPHandler) EnrollTwoFactor(c echo.Context) error {
+	userID := c.Get("userID").(string)
+	resp, err := h.uc.EnrollTwoFactor(c.Request().Context(), userID)
+	if err != nil {
+		return twoFactorError(c, err)
+	}
+	return c.JSON(http.StatusOK, httputil.OK(resp))
+}
+
+// ConfirmTwoFactor enables 2FA and returns the recovery codes
+func (h *HTTPHandler) ConfirmTwoFactor(c echo.Context) error {
+	var req usecase.TwoFactorCodeRequest
+	if err := httputil.BindAndValidate(c, &req); err != nil {
+		return c.JSON(http.StatusBadRequest, httputil.NewError(400, err.Error()))
+	}
+	userID := c.Get("userID").(string)
+	resp, err := h.uc.ConfirmTwoFactor(c.Request().Context(), userID, req.Code)
+	if err != nil {
+		return twoFactorError(c, err)
+	}
+	return c.JSON(http.StatusOK, httputil.OK(resp))
+}
+
+// DisableTwoFactor turns 2FA off
+func (h *HTTPHandler) DisableTwoFactor(c echo.Context) error {
+	var req usecase.TwoFactorDisableRequest
+	if err := httputil.BindAndValidate(c, &req); err != nil {
+		return c.JSON(http.StatusBadRequest, httputil.NewError(400, err.Error()))
+	}
+	userID := c.Get("userID").(string)
+	if err := h.uc.DisableTwoFactor(c.Request().Context(), userID, req.Password, req.Code); err != nil {
+		return twoFactorError(c, err)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// RegenerateRecoveryCodes replaces the user's recovery codes
+func (h *HTTPHandler) RegenerateRecoveryCodes(c echo.Context) error {
+	var req usecase.TwoFactorCodeRequest
+	if err := httputil.BindAndValidate(c, &req); err != nil {
+		return c.JSON(http.StatusBadRequest, httputil.NewError(400, err.Error()))
+	}
+	userID := c.Get("userID").(string)
+	resp, err := h.uc.RegenerateRecoveryCodes(c.Request().Context(), userID, req.Code)
+	if err != nil {
+		return twoFactorError(c, err)
+	}
+	return c.JSON(http.StatusOK, httputil.OK(resp))
+}
+
+// tooManyAttempts answers a throttled request with 429 and Retry-After.
+func tooManyAttempts(c echo.Context, err error) error {
+	var re *usecase.RetryError
+	if errors.As(err, &re) {
+		c.Response().Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(re.RetryAfter.Seconds()))))
+	}
+	return c.JSON(http.StatusTooManyRequests, httputil.NewError(429, err.Error()))
+}
+
+func twoFactorError(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, usecase.ErrTooManyAttempts):
+		return tooManyAttempts(c, err)
+	case errors.Is(err, usecase.ErrInvalidChallenge),
+		errors.Is(err, usecase.ErrInvalidTwoFactorCode),
+		errors.Is(err, usecase.ErrInvalidCredentials):
+		return c.JSON(http.StatusUnauthorized, httputil.NewError(401, err.Error()))
+	case errors.Is(err, usecase.ErrTwoFactorEnabled),
+		errors.Is(err, usecase.ErrTwoFactorNotEnabled),
+		errors.Is(err, usecase.ErrTwoFactorNotEnrolled),
+		errors.Is(err, usecase.ErrTwoFactorRequired):
+		return c.JSON(http.StatusConflict, httputil.NewError(409, err.Error()))
+	case errors.Is(err, usecase.ErrAccountSuspended):
+		return c.JSON(http.StatusForbidden, httputil.NewError(403, err.Error()))
+	case errors.Is(err, domain.ErrUserNotFound):
+		return c.JSON(http.StatusNotFound, httputil.NewError(404, err.Error()))
+	}
+	return c.JSON(http.StatusInternalServerError, httputil.NewError(500, err.Error()))
+}
+
+func sessionError(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, usecase.ErrSessionNotFound):
+		return c.JSON(http.StatusNotFound, httputil.NewError(404, err.Error()))
+	case errors.Is(err, usecase.ErrNoSession):
+		return c.JSON(http.StatusBadRequest, httputil.NewError(400, err.Error()))
+	}
+	return c.JSON(http.StatusInternalServerError, httputil.NewError(500, err.Error()))
+}
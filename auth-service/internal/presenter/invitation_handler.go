@@ -0,0 +1,56 @@
+package presenter
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/HatefBarari/microblog-auth/internal/domain"
+	"github.com/HatefBarari/microblog-auth/internal/usecase"
+	"github.com/HatefBarari/microblog-shared/pkg/httputil"
+	"github.com/labstack/echo/v4"
+)
+
+// CreateInvitation emails an invitation with a preset role
+func (h *HTTPHandler) CreateInvitation(c echo.Context) error {
+	var req usecase.CreateInvitationRequest
+	if err := httputil.BindAndValidate(c, &req); err != nil {
+		return c.JSON(http.StatusBadRequest, httputil.NewError(400, err.Error()))
+	}
+	resp, err := h.inviteUC.Create(c.Request().Context(), c.Get("userID").(string), req)
+	if err != nil {
+		return invitationError(c, err)
+	}
+	return c.JSON(http.StatusCreated, httputil.OK(resp))
+}
+
+// ListInvitations lists the caller's invitations, or everyone's for admins
+func (h *HTTPHandler) ListInvitations(c echo.Context) error {
+	var req usecase.ListInvitationsRequest
+	if err := httputil.BindAndValidate(c, &req); err != nil {
+		return c.JSON(http.StatusBadRequest, httputil.NewError(400, err.Error()))
+	}
+	resp, err := h.inviteUC.List(c.Request().Context(), c.Get("userID").(string), domain.Role(c.Get("role").(string)), req)
+	if err != nil {
+		return invitationError(c, err)
+	}
+	return c.JSON(http.StatusOK, httputil.OK(resp))
+}
+
+// RevokeInvitation cancels a pending invitation
+func (h *HTTPHandler) RevokeInvitation(c echo.Context) error {
+	err := h.inviteUC.Revoke(c.Request().Context(), c.Get("userID").(string), domain.Role(c.Get("role").(string)), c.Param("id"))
+	if err != nil {
+		return invitationError(c, err)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+func invitationError(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, usecase.ErrInvitationNotFound), errors.Is(err, domain.ErrUserNotFound):
+		return c.JSON(http.StatusNotFound, httputil.NewError(404, err.Error()))
+	case errors.Is(err, usecase.ErrRoleTooHigh), errors.Is(err, usecase.ErrRegistrationClosed):
+		return c.JSON(http.StatusForbidden, httputil.NewError(403, err.Error()))
+	}
+	return c.JSON(http.StatusBadRequest, httputil.NewError(400, err.Error()))
+}
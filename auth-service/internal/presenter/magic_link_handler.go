@@ -0,0 +1,78 @@
+package presenter
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/HatefBarari/microblog-auth/internal/usecase"
+	"github.com/HatefBarari/microblog-shared/pkg/httputil"
+	"github.com/labstack/echo/v4"
+)
+
+// magicNonceCookie carries the nonce a sign-in link is bound to. It is only
+// sent back to the magic-link routes.
+const (
+	magicNonceCookie = "magic_nonce"
+	magicNoncePath   = "/login/magic"
+)
+
+// RequestMagicLink emails a sign-in link and binds it to this browser
+func (h *HTTPHandler) RequestMagicLink(c echo.Context) error {
+	var req usecase.MagicLinkRequest
+	if err := httputil.BindAndValidate(c, &req); err != nil {
+		return c.JSON(http.StatusBadRequest, httputil.NewError(400, err.Error()))
+	}
+	nonce, err := h.magicUC.Request(requestContext(c), req.Email)
+	if errors.Is(err, usecase.ErrTooManyAttempts) {
+		return tooManyAttempts(c, err)
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, httputil.NewError(500, err.Error()))
+	}
+	c.SetCookie(&http.Cookie{
+		Name:     magicNonceCookie,
+		Value:    nonce.Value,
+		Path:     magicNoncePath,
+		Expires:  nonce.ExpiresAt,
+		MaxAge:   int(time.Until(nonce.ExpiresAt).Seconds()),
+		HttpOnly: true,
+		Secure:   c.Scheme() == "https",
+		// Lax so the cookie comes along when the link is opened from a mail client
+		SameSite: http.SameSiteLaxMode,
+	})
+	return c.JSON(http.StatusOK, httputil.OK(map[string]string{
+		"message": "if the address belongs to an account, a sign-in link has been sent",
+	}))
+}
+
+// VerifyMagicLink signs in with a link from RequestMagicLink
+func (h *HTTPHandler) VerifyMagicLink(c echo.Context) error {
+	token := c.QueryParam("token")
+	if token == "" {
+		return c.JSON(http.StatusBadRequest, httputil.NewError(400, "sign-in token is required"))
+	}
+	nonce := ""
+	if cookie, err := c.Cookie(magicNonceCookie); err == nil {
+		nonce = cookie.Value
+	}
+	resp, err := h.magicUC.Complete(requestContext(c), token, nonce)
+	switch {
+	case errors.Is(err, usecase.ErrInvalidToken):
+		return c.JSON(http.StatusUnauthorized, httputil.NewError(401, err.Error()))
+	case errors.Is(err, usecase.ErrAccountNotVerified),
+		errors.Is(err, usecase.ErrAccountSuspended):
+		return c.JSON(http.StatusForbidden, httputil.NewError(403, err.Error()))
+	case err != nil:
+		return c.JSON(http.StatusInternalServerError, httputil.NewError(500, err.Error()))
+	}
+	c.SetCookie(&http.Cookie{
+		Name:     magicNonceCookie,
+		Path:     magicNoncePath,
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   c.Scheme() == "https",
+		SameSite: http.SameSiteLaxMode,
+	})
+	return c.JSON(http.StatusOK, httputil.OK(resp))
+}
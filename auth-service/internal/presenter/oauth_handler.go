@@ -0,0 +1,124 @@
+package presenter
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+
+	"github.com/HatefBarari/microblog-auth/internal/usecase"
+	"github.com/HatefBarari/microblog-shared/pkg/httputil"
+	"github.com/labstack/echo/v4"
+)
+
+// OAuthToken is the OAuth2 token endpoint for the client_credentials and
+// authorization_code grants. Clients authenticate with HTTP Basic or with
+// client_id and client_secret form fields; public clients send client_id
+// alone. Responses follow RFC 6749 rather than the usual envelope, so
+// standard OAuth2 clients can use it.
+func (h *HTTPHandler) OAuthToken(c echo.Context) error {
+	c.Response().Header().Set("Cache-Control", "no-store")
+	clientID, secret, basic, ok := clientCredentials(c)
+	if !ok {
+		return c.JSON(http.StatusBadRequest, &usecase.OAuthError{Code: "invalid_request", Description: "malformed client credentials"})
+	}
+
+	var resp *usecase.OAuthTokenResponse
+	var err error
+	ctx := c.Request().Context()
+	switch c.FormValue("grant_type") {
+	case "client_credentials":
+		resp, err = h.oauthUC.ClientCredentials(ctx, clientID, secret, c.FormValue("scope"))
+	case "authorization_code":
+		resp, err = h.oidcUC.ExchangeCode(ctx, clientID, secret, c.FormValue("code"), c.FormValue("redirect_uri"), c.FormValue("code_verifier"))
+	default:
+		err = usecase.ErrUnsupportedGrantType
+	}
+	if err != nil {
+		return oauthError(c, err, basic)
+	}
+	return c.JSON(http.StatusOK, resp)
+}
+
+// OAuthIntrospect is the token introspection endpoint (RFC 7662) for
+// consumers that can't verify tokens themselves. Confidential clients
+// authenticate as at the token endpoint and post the token to check.
+func (h *HTTPHandler) OAuthIntrospect(c echo.Context) error {
+	c.Response().Header().Set("Cache-Control", "no-store")
+	clientID, secret, basic, ok := clientCredentials(c)
+	if !ok {
+		return c.JSON(http.StatusBadRequest, &usecase.OAuthError{Code: "invalid_request", Description: "malformed client credentials"})
+	}
+	token := c.FormValue("token")
+	if token == "" {
+		return c.JSON(http.StatusBadRequest, &usecase.OAuthError{Code: "invalid_request", Description: "token is required"})
+	}
+	resp, err := h.oauthUC.Introspect(c.Request().Context(), clientID, secret, token)
+	if err != nil {
+		return oauthError(c, err, basic)
+	}
+	return c.JSON(http.StatusOK, resp)
+}
+
+// clientCredentials reads the client from HTTP Basic or from the
+// client_id and client_secret form fields. ok is false when the Basic
+// credentials can't be decoded.
+func clientCredentials(c echo.Context) (clientID, secret string, basic, ok bool) {
+	clientID, secret, basic = c.Request().BasicAuth()
+	if !basic {
+		return c.FormValue("client_id"), c.FormValue("client_secret"), false, true
+	}
+	// RFC 6749 2.3.1 form-encodes both before Basic encoding
+	var err1, err2 error
+	clientID, err1 = url.QueryUnescape(clientID)
+	secret, err2 = url.QueryUnescape(secret)
+	return clientID, secret, true, err1 == nil && err2 == nil
+}
+
+// oauthError writes err as an RFC 6749 error response.
+func oauthError(c echo.Context, err error, basic bool) error {
+	var oauthErr *usecase.OAuthError
+	if !errors.As(err, &oauthErr) {
+		return c.JSON(http.StatusInternalServerError, &usecase.OAuthError{Code: "server_error"})
+	}
+	if oauthErr == usecase.ErrInvalidClient {
+		if basic {
+			c.Response().Header().Set("WWW-Authenticate", `Basic realm="microblog"`)
+		}
+		return c.JSON(http.StatusUnauthorized, oauthErr)
+	}
+	return c.JSON(http.StatusBadRequest, oauthErr)
+}
+
+// CreateOAuthClient registers a service client; the secret is shown only
+// once (admin only)
+func (h *HTTPHandler) CreateOAuthClient(c echo.Context) error {
+	var req usecase.CreateOAuthClientRequest
+	if err := httputil.BindAndValidate(c, &req); err != nil {
+		return c.JSON(http.StatusBadRequest, httputil.NewError(400, err.Error()))
+	}
+	resp, err := h.oauthUC.CreateClient(c.Request().Context(), req)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, httputil.NewError(400, err.Error()))
+	}
+	return c.JSON(http.StatusCreated, httputil.OK(resp))
+}
+
+// ListOAuthClients lists registered service clients (admin only)
+func (h *HTTPHandler) ListOAuthClients(c echo.Context) error {
+	list, err := h.oauthUC.ListClients(c.Request().Context())
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, httputil.NewError(500, err.Error()))
+	}
+	return c.JSON(http.StatusOK, httputil.OK(list))
+}
+
+// RevokeOAuthClient revokes a client and its outstanding tokens (admin only)
+func (h *HTTPHandler) RevokeOAuthClient(c echo.Context) error {
+	if err := h.oauthUC.RevokeClient(c.Request().Context(), c.Param("id")); err != nil {
+		if errors.Is(err, usecase.ErrOAuthClientNotFound) {
+			return c.JSON(http.StatusNotFound, httputil.NewError(404, err.Error()))
+		}
+		return c.JSON(http.StatusInternalServerError, httputil.NewError(500, err.Error()))
+	}
+	return c.NoContent(http.StatusNoContent)
+}
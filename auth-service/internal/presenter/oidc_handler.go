@@ -0,0 +1,203 @@
+package presenter
+
+import (
+	"errors"
+	"html/template"
+	"net/http"
+	"slices"
+
+	"github.com/HatefBarari/microblog-auth/internal/domain"
+	"github.com/HatefBarari/microblog-auth/internal/usecase"
+	"github.com/HatefBarari/microblog-shared/pkg/auth"
+	"github.com/HatefBarari/microblog-shared/pkg/httputil"
+	"github.com/labstack/echo/v4"
+)
+
+// UserInfoAudience is the audience /userinfo must accept.
+func (h *HTTPHandler) UserInfoAudience() string {
+	return h.oidcUC.UserInfoAudience()
+}
+
+// OpenIDConfiguration serves the OpenID Connect discovery document
+func (h *HTTPHandler) OpenIDConfiguration(c echo.Context) error {
+	c.Response().Header().Set("Cache-Control", "public, max-age=300")
+	return c.JSON(http.StatusOK, h.oidcUC.Discovery())
+}
+
+// Authorize shows the sign-in and consent screen of an app
+func (h *HTTPHandler) Authorize(c echo.Context) error {
+	var req usecase.AuthorizeRequest
+	if err := c.Bind(&req); err != nil {
+		return renderAuthorizeError(c, usecase.ErrInvalidAuthorizeRequest)
+	}
+	res, err := h.oidcUC.Authorize(c.Request().Context(), req)
+	if err != nil {
+		return renderAuthorizeError(c, err)
+	}
+	return renderAuthorize(c, res)
+}
+
+// ApproveAuthorize handles the consent form: the user's credentials and
+// whether they allow the app in
+func (h *HTTPHandler) ApproveAuthorize(c echo.Context) error {
+	var req usecase.AuthorizeRequest
+	if err := c.Bind(&req); err != nil {
+		return renderAuthorizeError(c, usecase.ErrInvalidAuthorizeRequest)
+	}
+	res, err := h.oidcUC.Approve(requestContext(c), req, usecase.ConsentForm{
+		Approve:        c.FormValue("decision") == "approve",
+		Email:          c.FormValue("email"),
+		Password:       c.FormValue("password"),
+		ChallengeToken: c.FormValue("challenge_token"),
+		Code:           c.FormValue("code"),
+	})
+	if err != nil {
+		return renderAuthorizeError(c, err)
+	}
+	return renderAuthorize(c, res)
+}
+
+// UserInfo returns the claims about the user that the app's access token
+// allows
+func (h *HTTPHandler) UserInfo(c echo.Context) error {
+	scopes := auth.Scopes(c)
+	if !slices.Contains(scopes, usecase.ScopeOpenID) {
+		return c.JSON(http.StatusForbidden, httputil.NewError(403, "token lacks scope openid"))
+	}
+	info, err := h.oidcUC.UserInfo(c.Request().Context(), c.Get("userID").(string), scopes)
+	if err != nil {
+		if errors.Is(err, domain.ErrUserNotFound) {
+			return c.JSON(http.StatusUnauthorized, httputil.NewError(401, err.Error()))
+		}
+		return c.JSON(http.StatusInternalServerError, httputil.NewError(500, err.Error()))
+	}
+	return c.JSON(http.StatusOK, info)
+}
+
+func renderAuthorize(c echo.Context, res *usecase.AuthorizeResult) error {
+	if res.RedirectURL != "" {
+		// 303 so the browser follows a POST with a GET
+		return c.Redirect(http.StatusSeeOther, res.RedirectURL)
+	}
+	screen := res.Consent
+	data := map[string]interface{}{
+		"Client":    screen.ClientName,
+		"Request":   screen.Request,
+		"Challenge": screen.ChallengeToken,
+	}
+	var scopes []string
+	for _, s := range screen.Scopes {
+		scopes = append(scopes, scopeDescriptions[s])
+	}
+	data["Scopes"] = scopes
+	status := http.StatusOK
+	if screen.Err != nil {
+		data["Error"] = signInMessage(screen.Err)
+		status = http.StatusUnauthorized
+	}
+	return renderPage(c, status, data)
+}
+
+func renderAuthorizeError(c echo.Context, err error) error {
+	msg := "خطای داخلی سرور. لطفا دوباره تلاش کنید."
+	status := http.StatusInternalServerError
+	if errors.Is(err, usecase.ErrInvalidAuthorizeRequest) {
+		msg = "درخواست ورود نامعتبر است: برنامه یا نشانی بازگشت آن ثبت نشده است."
+		status = http.StatusBadRequest
+	}
+	return renderPage(c, status, map[string]interface{}{"Fatal": msg})
+}
+
+func renderPage(c echo.Context, status int, data map[string]interface{}) error {
+	h := c.Response().Header()
+	h.Set("Cache-Control", "no-store")
+	// the consent screen must not be framed by another site
+	h.Set("X-Frame-Options", "DENY")
+	h.Set("Content-Security-Policy", "default-src 'none'; style-src 'unsafe-inline'; frame-ancestors 'none'")
+	h.Set(echo.HeaderContentType, echo.MIMETextHTMLCharsetUTF8)
+	c.Response().WriteHeader(status)
+	return authorizePage.Execute(c.Response(), data)
+}
+
+var scopeDescriptions = map[string]string{
+	usecase.ScopeOpenID:  "شناسه‌ی حساب کاربری شما",
+	usecase.ScopeProfile: "نام نمایشی، نام کاربری و زبان",
+	usecase.ScopeEmail:   "نشانی ایمیل",
+}
+
+func signInMessage(err error) string {
+	switch {
+	case errors.Is(err, usecase.ErrInvalidCredentials):
+		return "ایمیل یا رمز عبور اشتباه است."
+	case errors.Is(err, usecase.ErrInvalidTwoFactorCode):
+		return "کد تایید دو مرحله‌ای اشتباه است."
+	case errors.Is(err, usecase.ErrInvalidChallenge):
+		return "مهلت ورود تمام شده است. دوباره وارد شوید."
+	case errors.Is(err, usecase.ErrAccountNotVerified):
+		return "ایمیل حساب هنوز تایید نشده است."
+	case errors.Is(err, usecase.ErrAccountSuspended):
+		return "حساب شما معلق یا مسدود شده است."
+	case errors.Is(err, usecase.ErrTooManyAttempts):
+		return "تلاش‌های ناموفق زیادی انجام شده است. کمی بعد دوباره تلاش کنید."
+	case errors.Is(err, usecase.ErrTwoFactorRequired):
+		return "برای حساب شما ورود دو مرحله‌ای الزامی است. ابتدا آن را در میکروبلاگ فعال کنید."
+	}
+	return err.Error()
+}
+
+var authorizePage = template.Must(template.New("authorize").Parse(`<!DOCTYPE html>
+<html lang="fa" dir="rtl">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>ورود با حساب میکروبلاگ</title>
+<style>
+body { font-family: Tahoma, sans-serif; background: #f4f5f7; margin: 0; }
+main { max-width: 380px; margin: 48px auto; background: #fff; padding: 24px; border-radius: 8px; }
+label, input { display: block; width: 100%; box-sizing: border-box; }
+input { margin: 4px 0 12px; padding: 8px; }
+.error { color: #b00020; }
+.actions { display: flex; gap: 8px; }
+button { flex: 1; padding: 10px; }
+</style>
+</head>
+<body>
+<main>
+{{if .Fatal}}
+<p class="error">{{.Fatal}}</p>
+{{else}}
+<h1>ورود به {{.Client}}</h1>
+<p><strong>{{.Client}}</strong> می‌خواهد به این اطلاعات حساب میکروبلاگ شما دسترسی داشته باشد:</p>
+<ul>{{range .Scopes}}<li>{{.}}</li>{{end}}</ul>
+{{if .Error}}<p class="error">{{.Error}}</p>{{end}}
+<form method="post" action="/oauth/authorize">
+{{with .Request}}
+<input type="hidden" name="response_type" value="{{.ResponseType}}">
+<input type="hidden" name="client_id" value="{{.ClientID}}">
+<input type="hidden" name="redirect_uri" value="{{.RedirectURI}}">
+<input type="hidden" name="scope" value="{{.Scope}}">
+<input type="hidden" name="state" value="{{.State}}">
+<input type="hidden" name="nonce" value="{{.Nonce}}">
+<input type="hidden" name="code_challenge" value="{{.CodeChallenge}}">
+<input type="hidden" name="code_challenge_method" value="{{.CodeChallengeMethod}}">
+{{end}}
+{{if .Challenge}}
+<input type="hidden" name="challenge_token" value="{{.Challenge}}">
+<label for="code">کد تایید دو مرحله‌ای</label>
+<input id="code" name="code" autocomplete="one-time-code" inputmode="numeric" required autofocus>
+{{else}}
+<label for="email">ایمیل</label>
+<input id="email" name="email" type="email" autocomplete="username" required autofocus>
+<label for="password">رمز عبور</label>
+<input id="password" name="password" type="password" autocomplete="current-password" required>
+{{end}}
+<div class="actions">
+<button type="submit" name="decision" value="approve">ورود و اجازه دادن</button>
+<button type="submit" name="decision" value="deny" formnovalidate>رد کردن</button>
+</div>
+</form>
+{{end}}
+</main>
+</body>
+</html>
+`))
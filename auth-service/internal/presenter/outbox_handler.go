@@ -0,0 +1,43 @@
+package presenter
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/HatefBarari/microblog-auth/internal/usecase"
+	"github.com/HatefBarari/microblog-shared/pkg/httputil"
+	"github.com/labstack/echo/v4"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ListOutboxEmails shows queued, sent and dead emails (admin only)
+func (h *HTTPHandler) ListOutboxEmails(c echo.Context) error {
+	var req usecase.ListOutboxRequest
+	if err := httputil.BindAndValidate(c, &req); err != nil {
+		return c.JSON(http.StatusBadRequest, httputil.NewError(400, err.Error()))
+	}
+	resp, err := h.outboxUC.List(c.Request().Context(), req)
+	if err != nil {
+		return outboxError(c, err)
+	}
+	return c.JSON(http.StatusOK, httputil.OK(resp))
+}
+
+// ResendOutboxEmail retries a dead email (admin only)
+func (h *HTTPHandler) ResendOutboxEmail(c echo.Context) error {
+	resp, err := h.outboxUC.Resend(c.Request().Context(), c.Param("id"))
+	if err != nil {
+		return outboxError(c, err)
+	}
+	return c.JSON(http.StatusOK, httputil.OK(resp))
+}
+
+func outboxError(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, usecase.ErrOutboxNotFound), errors.Is(err, primitive.ErrInvalidHex):
+		return c.JSON(http.StatusNotFound, httputil.NewError(404, usecase.ErrOutboxNotFound.Error()))
+	case errors.Is(err, usecase.ErrOutboxNotFailed):
+		return c.JSON(http.StatusConflict, httputil.NewError(409, err.Error()))
+	}
+	return c.JSON(http.StatusInternalServerError, httputil.NewError(500, err.Error()))
+}
@@ -0,0 +1,72 @@
+package presenter
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/HatefBarari/microblog-auth/internal/domain"
+	"github.com/HatefBarari/microblog-auth/internal/usecase"
+	"github.com/HatefBarari/microblog-shared/pkg/httputil"
+	"github.com/labstack/echo/v4"
+)
+
+func (h *HTTPHandler) GetMyProfile(c echo.Context) error {
+	userID := c.Get("userID").(string)
+	resp, err := h.profileUC.Get(c.Request().Context(), userID)
+	if err != nil {
+		return profileError(c, err)
+	}
+	return c.JSON(http.StatusOK, httputil.OK(resp))
+}
+
+func (h *HTTPHandler) UpdateMyProfile(c echo.Context) error {
+	var req usecase.UpdateProfileRequest
+	if err := httputil.BindAndValidate(c, &req); err != nil {
+		return c.JSON(http.StatusBadRequest, httputil.NewError(400, err.Error()))
+	}
+	userID := c.Get("userID").(string)
+	resp, err := h.profileUC.Update(c.Request().Context(), userID, req)
+	if err != nil {
+		return profileError(c, err)
+	}
+	return c.JSON(http.StatusOK, httputil.OK(resp))
+}
+
+// GetPublicProfile serves GET /users/:handle
+func (h *HTTPHandler) GetPublicProfile(c echo.Context) error {
+	resp, err := h.profileUC.GetByHandle(c.Request().Context(), c.Param("handle"))
+	if err != nil {
+		return profileError(c, err)
+	}
+	return c.JSON(http.StatusOK, httputil.OK(resp))
+}
+
+// ListAuthors serves GET /authors?ids=a,b,c for listings in other services
+func (h *HTTPHandler) ListAuthors(c echo.Context) error {
+	var ids []string
+	for _, id := range strings.Split(c.QueryParam("ids"), ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			ids = append(ids, id)
+		}
+	}
+	resp, err := h.profileUC.Authors(c.Request().Context(), ids)
+	if err != nil {
+		return profileError(c, err)
+	}
+	return c.JSON(http.StatusOK, httputil.OK(resp))
+}
+
+func profileError(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, usecase.ErrProfileNotFound), errors.Is(err, domain.ErrUserNotFound):
+		return c.JSON(http.StatusNotFound, httputil.NewError(404, err.Error()))
+	case errors.Is(err, domain.ErrHandleTaken):
+		return c.JSON(http.StatusConflict, httputil.NewError(409, err.Error()))
+	case errors.Is(err, usecase.ErrInvalidHandle),
+		errors.Is(err, usecase.ErrInvalidLink),
+		errors.Is(err, usecase.ErrTooManyAuthors):
+		return c.JSON(http.StatusBadRequest, httputil.NewError(400, err.Error()))
+	}
+	return c.JSON(http.StatusInternalServerError, httputil.NewError(500, err.Error()))
+}
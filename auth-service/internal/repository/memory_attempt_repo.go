@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/HatefBarari/microblog-auth/internal/domain"
+)
+
+type memoryAttemptRepo struct {
+	mu       sync.Mutex
+	attempts map[string]domain.Attempt
+}
+
+// NewMemoryAttemptRepo keeps counters in process. It suits tests and a
+// single instance; counters are lost on restart.
+func NewMemoryAttemptRepo() domain.AttemptRepository {
+	return &memoryAttemptRepo{attempts: map[string]domain.Attempt{}}
+}
+
+func (r *memoryAttemptRepo) Get(ctx context.Context, key string, now time.Time) (*domain.Attempt, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	a, ok := r.live(key, now)
+	if !ok {
+		return nil, nil
+	}
+	return &a, nil
+}
+
+func (r *memoryAttemptRepo) Increment(ctx context.Context, key string, now time.Time, window time.Duration) (*domain.Attempt, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	a, ok := r.live(key, now)
+	if !ok {
+		a = domain.Attempt{Key: key}
+	}
+	a.Count++
+	a.Last = now
+	a.ExpiresAt = now.Add(window)
+	if a.LockedUntil != nil && a.LockedUntil.After(a.ExpiresAt) {
+		a.ExpiresAt = *a.LockedUntil
+	}
+	r.attempts[key] = a
+	return &a, nil
+}
+
+func (r *memoryAttemptRepo) Lock(ctx context.Context, key string, until time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	a, ok := r.attempts[key]
+	if !ok {
+		a = domain.Attempt{Key: key}
+	}
+	a.LockedUntil = &until
+	if until.After(a.ExpiresAt) {
+		a.ExpiresAt = until
+	}
+	r.attempts[key] = a
+	return nil
+}
+
+func (r *memoryAttemptRepo) Reset(ctx context.Context, key string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.attempts, key)
+	return nil
+}
+
+func (r *memoryAttemptRepo) live(key string, now time.Time) (domain.Attempt, bool) {
+	a, ok := r.attempts[key]
+	if !ok || !now.Before(a.ExpiresAt) {
+		delete(r.attempts, key)
+		return domain.Attempt{}, false
+	}
+	return a, true
+}
@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/HatefBarari/microblog-auth/internal/domain"
+	"github.com/HatefBarari/microblog-shared/pkg/mongo"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const adminActionsCollection = "admin_actions"
+
+type mongoAdminActionRepo struct{}
+
+func NewMongoAdminActionRepo() domain.AdminActionRepository {
+	return &mongoAdminActionRepo{}
+}
+
+func (r *mongoAdminActionRepo) Create(ctx context.Context, a *domain.AdminAction) error {
+	res, err := mongo.DB().Collection(adminActionsCollection).InsertOne(ctx, a)
+	if err != nil {
+		return err
+	}
+	a.ID = res.InsertedID.(primitive.ObjectID).Hex()
+	return nil
+}
+
+func (r *mongoAdminActionRepo) List(ctx context.Context, filter domain.AdminActionFilter) ([]*domain.AdminAction, int, error) {
+	q := bson.M{}
+	if filter.ActorID != "" {
+		q["actor_id"] = filter.ActorID
+	}
+	if filter.TargetID != "" {
+		q["target_id"] = filter.TargetID
+	}
+	if filter.Action != "" {
+		q["action"] = filter.Action
+	}
+	if filter.Page <= 0 {
+		filter.Page = 1
+	}
+	if filter.PageSize <= 0 {
+		filter.PageSize = 20
+	}
+
+	coll := mongo.DB().Collection(adminActionsCollection)
+	total, err := coll.CountDocuments(ctx, q)
+	if err != nil {
+		return nil, 0, err
+	}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}}).
+		SetSkip(int64((filter.Page - 1) * filter.PageSize)).
+		SetLimit(int64(filter.PageSize))
+	cursor, err := coll.Find(ctx, q, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+	list := []*domain.AdminAction{}
+	if err := cursor.All(ctx, &list); err != nil {
+		return nil, 0, err
+	}
+	return list, int(total), nil
+}
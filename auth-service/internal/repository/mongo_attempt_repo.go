@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/HatefBarari/microblog-auth/internal/domain"
+	"github.com/HatefBarari/microblog-shared/pkg/mongo"
+	"go.mongodb.org/mongo-driver/bson"
+	driver "go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const attemptsCollection = "login_attempts"
+
+type mongoAttemptRepo struct{}
+
+// NewMongoAttemptRepo stores counters in login_attempts, which carries a
+// TTL index on expires_at.
+func NewMongoAttemptRepo() domain.AttemptRepository {
+	return &mongoAttemptRepo{}
+}
+
+func (r *mongoAttemptRepo) Get(ctx context.Context, key string, now time.Time) (*domain.Attempt, error) {
+	var a domain.Attempt
+	err := mongo.DB().Collection(attemptsCollection).
+		FindOne(ctx, bson.M{"_id": key, "expires_at": bson.M{"$gt": now}}).Decode(&a)
+	if err != nil {
+		if errors.Is(err, driver.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &a, nil
+}
+
+func (r *mongoAttemptRepo) Increment(ctx context.Context, key string, now time.Time, window time.Duration) (*domain.Attempt, error) {
+	// the TTL monitor only runs once a minute, so an expired counter may
+	// still be present and has to restart from one
+	live := bson.M{"$gt": bson.A{"$expires_at", now}}
+	update := driver.Pipeline{{{Key: "$set", Value: bson.M{
+		"count":        bson.M{"$cond": bson.A{live, bson.M{"$add": bson.A{"$count", 1}}, 1}},
+		"locked_until": bson.M{"$cond": bson.A{live, "$locked_until", "$$REMOVE"}},
+		"last":         now,
+		"expires_at":   bson.M{"$max": bson.A{bson.M{"$ifNull": bson.A{"$locked_until", now}}, now.Add(window)}},
+	}}}}
+	var a domain.Attempt
+	err := mongo.DB().Collection(attemptsCollection).FindOneAndUpdate(ctx, bson.M{"_id": key}, update,
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+	).Decode(&a)
+	if err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+func (r *mongoAttemptRepo) Lock(ctx context.Context, key string, until time.Time) error {
+	_, err := mongo.DB().Collection(attemptsCollection).UpdateOne(ctx,
+		bson.M{"_id": key},
+		bson.M{"$set": bson.M{"locked_until": until}, "$max": bson.M{"expires_at": until}},
+		options.Update().SetUpsert(true),
+	)
+	return err
+}
+
+func (r *mongoAttemptRepo) Reset(ctx context.Context, key string) error {
+	_, err := mongo.DB().Collection(attemptsCollection).DeleteOne(ctx, bson.M{"_id": key})
+	return err
+}
@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/HatefBarari/microblog-auth/internal/domain"
+	"github.com/HatefBarari/microblog-shared/pkg/mongo"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const authEventsCollection = "auth_events"
+
+type mongoAuthEventRepo struct{}
+
+func NewMongoAuthEventRepo() domain.AuthEventRepository {
+	return &mongoAuthEventRepo{}
+}
+
+func (r *mongoAuthEventRepo) Create(ctx context.Context, e *domain.AuthEvent) error {
+	res, err := mongo.DB().Collection(authEventsCollection).InsertOne(ctx, e)
+	if err != nil {
+		return err
+	}
+	e.ID = res.InsertedID.(primitive.ObjectID).Hex()
+	return nil
+}
+
+func (r *mongoAuthEventRepo) List(ctx context.Context, filter domain.AuthEventFilter) ([]*domain.AuthEvent, int, error) {
+	q := authEventQuery(filter)
+	if filter.Page <= 0 {
+		filter.Page = 1
+	}
+	if filter.PageSize <= 0 {
+		filter.PageSize = 20
+	}
+
+	coll := mongo.DB().Collection(authEventsCollection)
+	total, err := coll.CountDocuments(ctx, q)
+	if err != nil {
+		return nil, 0, err
+	}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}}).
+		SetSkip(int64((filter.Page - 1) * filter.PageSize)).
+		SetLimit(int64(filter.PageSize))
+	cursor, err := coll.Find(ctx, q, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+	list := []*domain.AuthEvent{}
+	if err := cursor.All(ctx, &list); err != nil {
+		return nil, 0, err
+	}
+	return list, int(total), nil
+}
+
+func (r *mongoAuthEventRepo) Each(ctx context.Context, filter domain.AuthEventFilter, fn func(*domain.AuthEvent) error) error {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}})
+	cursor, err := mongo.DB().Collection(authEventsCollection).Find(ctx, authEventQuery(filter), opts)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+	for cursor.Next(ctx) {
+		var e domain.AuthEvent
+		if err := cursor.Decode(&e); err != nil {
+			return err
+		}
+		if err := fn(&e); err != nil {
+			return err
+		}
+	}
+	return cursor.Err()
+}
+
+func authEventQuery(filter domain.AuthEventFilter) bson.M {
+	q := bson.M{}
+	if filter.UserID != "" {
+		q["user_id"] = filter.UserID
+	}
+	if filter.Email != "" {
+		q["email"] = filter.Email
+	}
+	if filter.Type != "" {
+		q["type"] = filter.Type
+	}
+	if filter.Outcome != "" {
+		q["outcome"] = filter.Outcome
+	}
+	if filter.IP != "" {
+		q["ip"] = filter.IP
+	}
+	created := bson.M{}
+	if filter.From != nil {
+		created["$gte"] = *filter.From
+	}
+	if filter.To != nil {
+		created["$lt"] = *filter.To
+	}
+	if len(created) > 0 {
+		q["created_at"] = created
+	}
+	return q
+}
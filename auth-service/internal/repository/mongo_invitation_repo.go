@@ -0,0 +1,150 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/HatefBarari/microblog-auth/internal/domain"
+	"github.com/HatefBarari/microblog-shared/pkg/mongo"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	driver "go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const invitationsCollection = "invitations"
+
+type mongoInvitationRepo struct{}
+
+func NewMongoInvitationRepo() domain.InvitationRepository {
+	return &mongoInvitationRepo{}
+}
+
+func (r *mongoInvitationRepo) Create(ctx context.Context, inv *domain.Invitation) error {
+	res, err := mongo.DB().Collection(invitationsCollection).InsertOne(ctx, inv)
+	if err != nil {
+		return err
+	}
+	inv.ID = res.InsertedID.(primitive.ObjectID).Hex()
+	return nil
+}
+
+func (r *mongoInvitationRepo) GetByID(ctx context.Context, id string) (*domain.Invitation, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+	var inv domain.Invitation
+	err = mongo.DB().Collection(invitationsCollection).FindOne(ctx, bson.M{"_id": oid}).Decode(&inv)
+	if err != nil {
+		if errors.Is(err, driver.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &inv, nil
+}
+
+func (r *mongoInvitationRepo) Accept(ctx context.Context, codeHash, email string, now time.Time) (*domain.Invitation, error) {
+	filter := pendingInvitations(now)
+	filter["code_hash"] = codeHash
+	filter["email"] = email
+	update := bson.M{"$set": bson.M{"accepted_at": now}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	var inv domain.Invitation
+	err := mongo.DB().Collection(invitationsCollection).FindOneAndUpdate(ctx, filter, update, opts).Decode(&inv)
+	if err != nil {
+		if errors.Is(err, driver.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &inv, nil
+}
+
+func (r *mongoInvitationRepo) SetAcceptedBy(ctx context.Context, id, userID string) error {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+	_, err = mongo.DB().Collection(invitationsCollection).UpdateOne(ctx, bson.M{"_id": oid}, bson.M{
+		"$set": bson.M{"accepted_by": userID},
+	})
+	return err
+}
+
+func (r *mongoInvitationRepo) Release(ctx context.Context, id string) error {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+	_, err = mongo.DB().Collection(invitationsCollection).UpdateOne(ctx, bson.M{"_id": oid}, bson.M{
+		"$unset": bson.M{"accepted_at": "", "accepted_by": ""},
+	})
+	return err
+}
+
+func (r *mongoInvitationRepo) Revoke(ctx context.Context, id string, now time.Time) (bool, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return false, err
+	}
+	filter := pendingInvitations(now)
+	filter["_id"] = oid
+	res, err := mongo.DB().Collection(invitationsCollection).UpdateOne(ctx, filter,
+		bson.M{"$set": bson.M{"revoked_at": now}},
+	)
+	if err != nil {
+		return false, err
+	}
+	return res.ModifiedCount > 0, nil
+}
+
+func (r *mongoInvitationRepo) List(ctx context.Context, filter domain.InvitationFilter, now time.Time) ([]*domain.Invitation, int, error) {
+	q := bson.M{}
+	if filter.Pending {
+		q = pendingInvitations(now)
+	}
+	if filter.Email != "" {
+		q["email"] = filter.Email
+	}
+	if filter.InvitedBy != "" {
+		q["invited_by"] = filter.InvitedBy
+	}
+	if filter.Page <= 0 {
+		filter.Page = 1
+	}
+	if filter.PageSize <= 0 {
+		filter.PageSize = 20
+	}
+
+	coll := mongo.DB().Collection(invitationsCollection)
+	total, err := coll.CountDocuments(ctx, q)
+	if err != nil {
+		return nil, 0, err
+	}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}}).
+		SetSkip(int64((filter.Page - 1) * filter.PageSize)).
+		SetLimit(int64(filter.PageSize))
+	cursor, err := coll.Find(ctx, q, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+	list := []*domain.Invitation{}
+	if err := cursor.All(ctx, &list); err != nil {
+		return nil, 0, err
+	}
+	return list, int(total), nil
+}
+
+// pendingInvitations matches invitations that can still be accepted.
+func pendingInvitations(now time.Time) bson.M {
+	return bson.M{
+		"accepted_at": bson.M{"$exists": false},
+		"revoked_at":  bson.M{"$exists": false},
+		"expires_at":  bson.M{"$gt": now},
+	}
+}
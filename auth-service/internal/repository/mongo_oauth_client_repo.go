@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/HatefBarari/microblog-auth/internal/domain"
+	"github.com/HatefBarari/microblog-shared/pkg/mongo"
+	"go.mongodb.org/mongo-driver/bson"
+	driver "go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const oauthClientCollection = "oauth_clients"
+
+type mongoOAuthClientRepo struct{}
+
+func NewMongoOAuthClientRepo() domain.OAuthClientRepository {
+	return &mongoOAuthClientRepo{}
+}
+
+func (r *mongoOAuthClientRepo) Create(ctx context.Context, c *domain.OAuthClient) error {
+	_, err := mongo.DB().Collection(oauthClientCollection).InsertOne(ctx, c)
+	return err
+}
+
+func (r *mongoOAuthClientRepo) GetByID(ctx context.Context, id string) (*domain.OAuthClient, error) {
+	var c domain.OAuthClient
+	err := mongo.DB().Collection(oauthClientCollection).FindOne(ctx, bson.M{"_id": id}).Decode(&c)
+	if err != nil {
+		if errors.Is(err, driver.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &c, nil
+}
+
+func (r *mongoOAuthClientRepo) List(ctx context.Context) ([]*domain.OAuthClient, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+	cursor, err := mongo.DB().Collection(oauthClientCollection).Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+	list := []*domain.OAuthClient{}
+	if err := cursor.All(ctx, &list); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (r *mongoOAuthClientRepo) TouchLastUsed(ctx context.Context, id string, now time.Time) error {
+	_, err := mongo.DB().Collection(oauthClientCollection).UpdateOne(ctx,
+		bson.M{"_id": id},
+		bson.M{"$set": bson.M{"last_used_at": now}},
+	)
+	return err
+}
+
+func (r *mongoOAuthClientRepo) Revoke(ctx context.Context, id string, now time.Time) (bool, error) {
+	res, err := mongo.DB().Collection(oauthClientCollection).UpdateOne(ctx,
+		bson.M{"_id": id, "revoked_at": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"revoked_at": now}},
+	)
+	if err != nil {
+		return false, err
+	}
+	return res.ModifiedCount > 0, nil
+}
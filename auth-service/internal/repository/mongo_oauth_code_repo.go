@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/HatefBarari/microblog-auth/internal/domain"
+	"github.com/HatefBarari/microblog-shared/pkg/mongo"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	driver "go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const oauthCodeCollection = "oauth_codes"
+
+type mongoOAuthCodeRepo struct{}
+
+func NewMongoOAuthCodeRepo() domain.AuthorizationCodeRepository {
+	return &mongoOAuthCodeRepo{}
+}
+
+func (r *mongoOAuthCodeRepo) Create(ctx context.Context, c *domain.AuthorizationCode) error {
+	res, err := mongo.DB().Collection(oauthCodeCollection).InsertOne(ctx, c)
+	if err != nil {
+		return err
+	}
+	c.ID = res.InsertedID.(primitive.ObjectID).Hex()
+	return nil
+}
+
+func (r *mongoOAuthCodeRepo) Consume(ctx context.Context, codeHash string, now time.Time) (*domain.AuthorizationCode, error) {
+	filter := bson.M{
+		"code_hash":   codeHash,
+		"consumed_at": nil,
+		"expires_at":  bson.M{"$gt": now},
+	}
+	update := bson.M{"$set": bson.M{"consumed_at": now}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	var c domain.AuthorizationCode
+	err := mongo.DB().Collection(oauthCodeCollection).FindOneAndUpdate(ctx, filter, update, opts).Decode(&c)
+	if err != nil {
+		if errors.Is(err, driver.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &c, nil
+}
@@ -0,0 +1,144 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/HatefBarari/microblog-auth/internal/domain"
+	"github.com/HatefBarari/microblog-shared/pkg/mongo"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	driver "go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const emailOutboxCollection = "email_outbox"
+
+type mongoOutboxRepo struct{}
+
+func NewMongoOutboxRepo() domain.EmailOutboxRepository {
+	return &mongoOutboxRepo{}
+}
+
+func (r *mongoOutboxRepo) Create(ctx context.Context, m *domain.OutboxEmail) error {
+	res, err := mongo.DB().Collection(emailOutboxCollection).InsertOne(ctx, m)
+	if err != nil {
+		return err
+	}
+	m.ID = res.InsertedID.(primitive.ObjectID).Hex()
+	return nil
+}
+
+func (r *mongoOutboxRepo) GetByID(ctx context.Context, id string) (*domain.OutboxEmail, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+	var m domain.OutboxEmail
+	err = mongo.DB().Collection(emailOutboxCollection).FindOne(ctx, bson.M{"_id": oid}).Decode(&m)
+	if err != nil {
+		if errors.Is(err, driver.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &m, nil
+}
+
+func (r *mongoOutboxRepo) ClaimDue(ctx context.Context, now, leaseUntil time.Time) (*domain.OutboxEmail, error) {
+	filter := bson.M{
+		"status":          domain.OutboxPending,
+		"next_attempt_at": bson.M{"$lte": now},
+	}
+	update := bson.M{
+		"$set": bson.M{"next_attempt_at": leaseUntil},
+		"$inc": bson.M{"attempts": 1},
+	}
+	opts := options.FindOneAndUpdate().
+		SetSort(bson.M{"next_attempt_at": 1}).
+		SetReturnDocument(options.After)
+	var m domain.OutboxEmail
+	err := mongo.DB().Collection(emailOutboxCollection).FindOneAndUpdate(ctx, filter, update, opts).Decode(&m)
+	if err != nil {
+		if errors.Is(err, driver.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &m, nil
+}
+
+func (r *mongoOutboxRepo) MarkSent(ctx context.Context, id string, now time.Time) error {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+	_, err = mongo.DB().Collection(emailOutboxCollection).UpdateOne(ctx, bson.M{"_id": oid}, bson.M{
+		"$set":   bson.M{"status": domain.OutboxSent, "sent_at": now},
+		"$unset": bson.M{"text": "", "html": "", "last_error": ""},
+	})
+	return err
+}
+
+func (r *mongoOutboxRepo) MarkFailed(ctx context.Context, id string, status domain.OutboxStatus, next time.Time, lastErr string) error {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+	_, err = mongo.DB().Collection(emailOutboxCollection).UpdateOne(ctx, bson.M{"_id": oid}, bson.M{
+		"$set": bson.M{"status": status, "next_attempt_at": next, "last_error": lastErr},
+	})
+	return err
+}
+
+func (r *mongoOutboxRepo) Requeue(ctx context.Context, id string, now time.Time) (bool, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return false, err
+	}
+	res, err := mongo.DB().Collection(emailOutboxCollection).UpdateOne(ctx,
+		bson.M{"_id": oid, "status": domain.OutboxDead},
+		bson.M{"$set": bson.M{"status": domain.OutboxPending, "attempts": 0, "next_attempt_at": now}},
+	)
+	if err != nil {
+		return false, err
+	}
+	return res.ModifiedCount > 0, nil
+}
+
+func (r *mongoOutboxRepo) List(ctx context.Context, filter domain.OutboxFilter) ([]*domain.OutboxEmail, int, error) {
+	q := bson.M{}
+	if filter.Status != "" {
+		q["status"] = filter.Status
+	}
+	if filter.To != "" {
+		q["to"] = filter.To
+	}
+	if filter.Page <= 0 {
+		filter.Page = 1
+	}
+	if filter.PageSize <= 0 {
+		filter.PageSize = 20
+	}
+
+	coll := mongo.DB().Collection(emailOutboxCollection)
+	total, err := coll.CountDocuments(ctx, q)
+	if err != nil {
+		return nil, 0, err
+	}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}}).
+		SetSkip(int64((filter.Page - 1) * filter.PageSize)).
+		SetLimit(int64(filter.PageSize))
+	cursor, err := coll.Find(ctx, q, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+	list := []*domain.OutboxEmail{}
+	if err := cursor.All(ctx, &list); err != nil {
+		return nil, 0, err
+	}
+	return list, int(total), nil
+}
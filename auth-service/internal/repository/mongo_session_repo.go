@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/HatefBarari/microblog-auth/internal/domain"
+	"github.com/HatefBarari/microblog-shared/pkg/mongo"
+	"go.mongodb.org/mongo-driver/bson"
+	driver "go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const sessionsCollection = "sessions"
+
+type mongoSessionRepo struct{}
+
+func NewMongoSessionRepo() domain.SessionRepository {
+	return &mongoSessionRepo{}
+}
+
+func (r *mongoSessionRepo) Create(ctx context.Context, s *domain.Session) error {
+	_, err := mongo.DB().Collection(sessionsCollection).InsertOne(ctx, s)
+	return err
+}
+
+func (r *mongoSessionRepo) GetByID(ctx context.Context, id string) (*domain.Session, error) {
+	var s domain.Session
+	err := mongo.DB().Collection(sessionsCollection).FindOne(ctx, bson.M{"_id": id}).Decode(&s)
+	if err != nil {
+		if errors.Is(err, driver.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (r *mongoSessionRepo) Rotate(ctx context.Context, id, oldJTI, newJTI string, expiresAt time.Time, ip, userAgent string, now time.Time) (bool, error) {
+	filter := bson.M{
+		"_id":         id,
+		"refresh_jti": oldJTI,
+		"revoked_at":  nil,
+	}
+	update := bson.M{"$set": bson.M{
+		"refresh_jti":  newJTI,
+		"expires_at":   expiresAt,
+		"ip":           ip,
+		"user_agent":   userAgent,
+		"last_used_at": now,
+	}}
+	res, err := mongo.DB().Collection(sessionsCollection).UpdateOne(ctx, filter, update)
+	if err != nil {
+		return false, err
+	}
+	return res.ModifiedCount == 1, nil
+}
+
+func (r *mongoSessionRepo) Revoke(ctx context.Context, id, reason string, now time.Time) error {
+	_, err := mongo.DB().Collection(sessionsCollection).UpdateOne(ctx,
+		bson.M{"_id": id, "revoked_at": nil},
+		bson.M{"$set": bson.M{"revoked_at": now, "revoke_reason": reason}},
+	)
+	return err
+}
+
+func (r *mongoSessionRepo) ListActiveByUser(ctx context.Context, userID string, now time.Time) ([]*domain.Session, error) {
+	filter := bson.M{
+		"user_id":    userID,
+		"revoked_at": nil,
+		"expires_at": bson.M{"$gt": now},
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "last_used_at", Value: -1}})
+	cursor, err := mongo.DB().Collection(sessionsCollection).Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+	list := []*domain.Session{}
+	if err := cursor.All(ctx, &list); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (r *mongoSessionRepo) RevokeAllByUser(ctx context.Context, userID, reason string, now time.Time) (int, error) {
+	res, err := mongo.DB().Collection(sessionsCollection).UpdateMany(ctx,
+		bson.M{"user_id": userID, "revoked_at": nil},
+		bson.M{"$set": bson.M{"revoked_at": now, "revoke_reason": reason}},
+	)
+	if err != nil {
+		return 0, err
+	}
+	return int(res.ModifiedCount), nil
+}
+
+func (r *mongoSessionRepo) DeleteByUser(ctx context.Context, userID string) error {
+	_, err := mongo.DB().Collection(sessionsCollection).DeleteMany(ctx, bson.M{"user_id": userID})
+	return err
+}
@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/HatefBarari/microblog-auth/internal/domain"
+	"github.com/HatefBarari/microblog-shared/pkg/mongo"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	driver "go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const authTokensCollection = "auth_tokens"
+
+type mongoAuthTokenRepo struct{}
+
+func NewMongoAuthTokenRepo() domain.AuthTokenRepository {
+	return &mongoAuthTokenRepo{}
+}
+
+func (r *mongoAuthTokenRepo) Create(ctx context.Context, t *domain.AuthToken) error {
+	res, err := mongo.DB().Collection(authTokensCollection).InsertOne(ctx, t)
+	if err != nil {
+		return err
+	}
+	t.ID = res.InsertedID.(primitive.ObjectID).Hex()
+	return nil
+}
+
+func (r *mongoAuthTokenRepo) Consume(ctx context.Context, tokenHash string, purpose domain.TokenPurpose, now time.Time) (*domain.AuthToken, error) {
+	filter := bson.M{
+		"token_hash":  tokenHash,
+		"purpose":     purpose,
+		"consumed_at": nil,
+		"expires_at":  bson.M{"$gt": now},
+	}
+	update := bson.M{"$set": bson.M{"consumed_at": now}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	var t domain.AuthToken
+	err := mongo.DB().Collection(authTokensCollection).FindOneAndUpdate(ctx, filter, update, opts).Decode(&t)
+	if err != nil {
+		if errors.Is(err, driver.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (r *mongoAuthTokenRepo) DeleteByUser(ctx context.Context, userID string, purpose domain.TokenPurpose) error {
+	_, err := mongo.DB().Collection(authTokensCollection).DeleteMany(ctx, bson.M{
+		"user_id":     userID,
+		"purpose":     purpose,
+		"consumed_at": nil,
+	})
+	return err
+}
+
+func (r *mongoAuthTokenRepo) PurgeUser(ctx context.Context, userID string) error {
+	_, err := mongo.DB().Collection(authTokensCollection).DeleteMany(ctx, bson.M{"user_id": userID})
+	return err
+}
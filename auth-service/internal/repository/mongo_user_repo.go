@@ -3,12 +3,15 @@ package repository
 import (
 	"context"
 	"errors"
+	"regexp"
+	"time"
 
 	"github.com/HatefBarari/microblog-auth/internal/domain"
 	"github.com/HatefBarari/microblog-shared/pkg/mongo" // ← پکیج داخلی ما
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	driver "go.mongodb.org/mongo-driver/mongo" // ← alias برای ErrNoDocuments
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type mongoUserRepo struct{}
@@ -26,8 +29,309 @@ func (r *mongoUserRepo) Create(ctx context.Context, u *domain.User) error {
 	return nil
 }
 
+func (r *mongoUserRepo) GetByID(ctx context.Context, id string) (*domain.User, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+	return r.findOne(ctx, bson.M{"_id": oid})
+}
+
 func (r *mongoUserRepo) GetByEmail(ctx context.Context, email string) (*domain.User, error) {
-	res := mongo.UsersColl().FindOne(ctx, bson.M{"email": email})
+	return r.findOne(ctx, bson.M{"email": email})
+}
+
+func (r *mongoUserRepo) GetByHandle(ctx context.Context, handle string) (*domain.User, error) {
+	return r.findOne(ctx, bson.M{"profile.handle": handle})
+}
+
+func (r *mongoUserRepo) GetByIDs(ctx context.Context, ids []string) ([]*domain.User, error) {
+	oids := make([]primitive.ObjectID, 0, len(ids))
+	for _, id := range ids {
+		if oid, err := primitive.ObjectIDFromHex(id); err == nil {
+			oids = append(oids, oid)
+		}
+	}
+	list := []*domain.User{}
+	if len(oids) == 0 {
+		return list, nil
+	}
+	cursor, err := mongo.UsersColl().Find(ctx, bson.M{"_id": bson.M{"$in": oids}})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+	if err := cursor.All(ctx, &list); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (r *mongoUserRepo) List(ctx context.Context, filter domain.UserFilter) ([]*domain.User, int, error) {
+	q := bson.M{}
+	if filter.Email != "" {
+		q["email"] = bson.M{"$regex": regexp.QuoteMeta(filter.Email), "$options": "i"}
+	}
+	if filter.Role != nil {
+		q["role"] = *filter.Role
+	}
+	if filter.Verified != nil {
+		q["verified"] = *filter.Verified
+	}
+	if filter.Status != nil {
+		if *filter.Status == domain.StatusActive {
+			q["status"] = bson.M{"$in": bson.A{nil, domain.StatusActive}}
+		} else {
+			q["status"] = *filter.Status
+		}
+	}
+	if filter.Page <= 0 {
+		filter.Page = 1
+	}
+	if filter.PageSize <= 0 {
+		filter.PageSize = 20
+	}
+
+	total, err := mongo.UsersColl().CountDocuments(ctx, q)
+	if err != nil {
+		return nil, 0, err
+	}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}}).
+		SetSkip(int64((filter.Page - 1) * filter.PageSize)).
+		SetLimit(int64(filter.PageSize))
+	cursor, err := mongo.UsersColl().Find(ctx, q, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+	list := []*domain.User{}
+	if err := cursor.All(ctx, &list); err != nil {
+		return nil, 0, err
+	}
+	return list, int(total), nil
+}
+
+// Update overwrites every stored field of u except its ID.
+func (r *mongoUserRepo) Update(ctx context.Context, u *domain.User) error {
+	oid, err := primitive.ObjectIDFromHex(u.ID)
+	if err != nil {
+		return err
+	}
+	u.UpdatedAt = time.Now()
+	doc, err := toSetDoc(u)
+	if err != nil {
+		return err
+	}
+	res, err := mongo.UsersColl().UpdateOne(ctx, bson.M{"_id": oid}, bson.M{"$set": doc})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return domain.ErrUserNotFound
+	}
+	return nil
+}
+
+func (r *mongoUserRepo) UpdateVerified(ctx context.Context, userID string, verified bool) error {
+	oid, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return err
+	}
+	_, err = mongo.UsersColl().UpdateOne(ctx, bson.M{"_id": oid}, bson.M{"$set": bson.M{"verified": verified, "updated_at": time.Now()}})
+	return err
+}
+
+func (r *mongoUserRepo) UpdatePasswordHash(ctx context.Context, userID, oldHash, newHash string) error {
+	oid, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return err
+	}
+	_, err = mongo.UsersColl().UpdateOne(ctx,
+		bson.M{"_id": oid, "password_hash": oldHash},
+		bson.M{"$set": bson.M{"password_hash": newHash, "updated_at": time.Now()}})
+	return err
+}
+
+func (r *mongoUserRepo) UpdateEmail(ctx context.Context, userID, email string) error {
+	oid, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return err
+	}
+	res, err := mongo.UsersColl().UpdateOne(ctx, bson.M{"_id": oid},
+		bson.M{"$set": bson.M{"email": email, "verified": true, "updated_at": time.Now()}})
+	if driver.IsDuplicateKeyError(err) {
+		return domain.ErrEmailTaken
+	}
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return domain.ErrUserNotFound
+	}
+	return nil
+}
+
+func (r *mongoUserRepo) UpdateRole(ctx context.Context, userID string, role domain.Role) error {
+	oid, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return err
+	}
+	res, err := mongo.UsersColl().UpdateOne(ctx, bson.M{"_id": oid}, bson.M{"$set": bson.M{"role": role, "updated_at": time.Now()}})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return domain.ErrUserNotFound
+	}
+	return nil
+}
+
+func (r *mongoUserRepo) SetStatus(ctx context.Context, userID string, status domain.UserStatus, reason string, until *time.Time) error {
+	oid, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return err
+	}
+	set := bson.M{"status": status, "status_reason": reason, "updated_at": time.Now()}
+	update := bson.M{"$set": set}
+	if until != nil {
+		set["suspended_until"] = *until
+	} else {
+		update["$unset"] = bson.M{"suspended_until": ""}
+	}
+	res, err := mongo.UsersColl().UpdateOne(ctx, bson.M{"_id": oid}, update)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return domain.ErrUserNotFound
+	}
+	return nil
+}
+
+func (r *mongoUserRepo) SetProfile(ctx context.Context, userID string, p *domain.Profile) error {
+	oid, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return err
+	}
+	res, err := mongo.UsersColl().UpdateOne(ctx, bson.M{"_id": oid},
+		bson.M{"$set": bson.M{"profile": p, "updated_at": time.Now()}})
+	if driver.IsDuplicateKeyError(err) {
+		return domain.ErrHandleTaken
+	}
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return domain.ErrUserNotFound
+	}
+	return nil
+}
+
+func (r *mongoUserRepo) SetDeletion(ctx context.Context, userID string, d *domain.DeletionRequest) error {
+	oid, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return err
+	}
+	update := bson.M{"$set": bson.M{"deletion": d, "updated_at": time.Now()}}
+	if d == nil {
+		update = bson.M{
+			"$set":   bson.M{"updated_at": time.Now()},
+			"$unset": bson.M{"deletion": ""},
+		}
+	}
+	res, err := mongo.UsersColl().UpdateOne(ctx, bson.M{"_id": oid}, update)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return domain.ErrUserNotFound
+	}
+	return nil
+}
+
+func (r *mongoUserRepo) ListDueDeletions(ctx context.Context, now time.Time, limit int) ([]*domain.User, error) {
+	cursor, err := mongo.UsersColl().Find(ctx,
+		bson.M{"deletion.scheduled_at": bson.M{"$lte": now}},
+		options.Find().SetSort(bson.M{"deletion.scheduled_at": 1}).SetLimit(int64(limit)),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+	list := []*domain.User{}
+	if err := cursor.All(ctx, &list); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (r *mongoUserRepo) Delete(ctx context.Context, id string) error {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+	res, err := mongo.UsersColl().DeleteOne(ctx, bson.M{"_id": oid})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return domain.ErrUserNotFound
+	}
+	return nil
+}
+
+func (r *mongoUserRepo) SetTwoFactor(ctx context.Context, userID string, tf *domain.TwoFactor) error {
+	oid, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return err
+	}
+	update := bson.M{"$set": bson.M{"two_factor": tf, "updated_at": time.Now()}}
+	if tf == nil {
+		update = bson.M{"$unset": bson.M{"two_factor": ""}, "$set": bson.M{"updated_at": time.Now()}}
+	}
+	res, err := mongo.UsersColl().UpdateOne(ctx, bson.M{"_id": oid}, update)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return domain.ErrUserNotFound
+	}
+	return nil
+}
+
+func (r *mongoUserRepo) ConsumeRecoveryCode(ctx context.Context, userID, codeHash string) (bool, error) {
+	oid, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return false, err
+	}
+	res, err := mongo.UsersColl().UpdateOne(ctx,
+		bson.M{"_id": oid, "two_factor.recovery_codes": codeHash},
+		bson.M{"$pull": bson.M{"two_factor.recovery_codes": codeHash}},
+	)
+	if err != nil {
+		return false, err
+	}
+	return res.ModifiedCount == 1, nil
+}
+
+func (r *mongoUserRepo) AdvanceTOTPStep(ctx context.Context, userID string, step int64) (bool, error) {
+	oid, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return false, err
+	}
+	res, err := mongo.UsersColl().UpdateOne(ctx,
+		bson.M{"_id": oid, "two_factor.last_step": bson.M{"$lt": step}},
+		bson.M{"$set": bson.M{"two_factor.last_step": step}},
+	)
+	if err != nil {
+		return false, err
+	}
+	return res.ModifiedCount == 1, nil
+}
+
+func (r *mongoUserRepo) findOne(ctx context.Context, filter bson.M) (*domain.User, error) {
+	res := mongo.UsersColl().FindOne(ctx, filter)
 	if err := res.Err(); err != nil {
 		if errors.Is(err, driver.ErrNoDocuments) {
 			return nil, nil
@@ -41,11 +345,17 @@ func (r *mongoUserRepo) GetByEmail(ctx context.Context, email string) (*domain.U
 	return &u, nil
 }
 
-func (r *mongoUserRepo) UpdateVerified(ctx context.Context, userID string, verified bool) error {
-	oid, err := primitive.ObjectIDFromHex(userID)
+// toSetDoc marshals v for use in a $set update, dropping _id which mongo
+// refuses to modify.
+func toSetDoc(v interface{}) (bson.M, error) {
+	raw, err := bson.Marshal(v)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	_, err = mongo.UsersColl().UpdateOne(ctx, bson.M{"_id": oid}, bson.M{"$set": bson.M{"verified": verified}})
-	return err
-}
\ No newline at end of file
+	var doc bson.M
+	if err := bson.Unmarshal(raw, &doc); err != nil {
+		return nil, err
+	}
+	delete(doc, "_id")
+	return doc, nil
+}
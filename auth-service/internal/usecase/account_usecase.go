@@ -0,0 +1,341 @@
+package usecase
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/HatefBarari/microblog-auth/internal/domain"
+	"github.com/HatefBarari/microblog-shared/pkg/auth"
+	"github.com/HatefBarari/microblog-shared/pkg/email"
+	"github.com/HatefBarari/microblog-shared/pkg/userdata"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrDeletionPending     = errors.New("account deletion is already scheduled")
+	ErrNoDeletionPending   = errors.New("account deletion is not scheduled")
+	ErrInvalidDeletionMode = errors.New("deletion mode must be anonymize or remove")
+)
+
+// purgeBatchSize caps how many accounts one PurgeDue run deletes.
+const purgeBatchSize = 50
+
+// UserDataService is another service holding user data, reached through the
+// internal userdata protocol. *userdata.Client implements it.
+type UserDataService interface {
+	Name() string
+	Export(ctx context.Context, userID string) (*userdata.Export, error)
+	Fetch(ctx context.Context, path string) (io.ReadCloser, error)
+	Delete(ctx context.Context, userID string, mode userdata.Mode) error
+}
+
+type AccountConfig struct {
+	// GracePeriod is how long a deletion can still be cancelled.
+	GracePeriod time.Duration
+	// Templates renders the notices; nil uses the built-in ones.
+	Templates *email.Templates
+}
+
+// AccountUseCase exports an account's data and deletes accounts, together
+// with what blog and media hold for them, once the grace period is over.
+type AccountUseCase struct {
+	users     domain.UserRepository
+	tokens    domain.AuthTokenRepository
+	sessions  domain.SessionRepository
+	sessionUC *SessionUseCase
+	apiKeys   auth.APIKeyStore
+	email     EmailSender
+	services  []UserDataService
+	cfg       AccountConfig
+	log       *zap.Logger
+}
+
+func NewAccountUseCase(users domain.UserRepository, tokens domain.AuthTokenRepository, sessions domain.SessionRepository, sessionUC *SessionUseCase, apiKeys auth.APIKeyStore, emailSender EmailSender, services []UserDataService, cfg AccountConfig, log *zap.Logger) *AccountUseCase {
+	return &AccountUseCase{
+		users:     users,
+		tokens:    tokens,
+		sessions:  sessions,
+		sessionUC: sessionUC,
+		apiKeys:   apiKeys,
+		email:     emailSender,
+		services:  services,
+		cfg:       cfg,
+		log:       log,
+	}
+}
+
+// ScheduleDeletion marks the account for deletion after the grace period,
+// once the password is confirmed.
+func (uc *AccountUseCase) ScheduleDeletion(ctx context.Context, userID string, req DeleteAccountRequest) (*DeletionResponse, error) {
+	mode := userdata.Mode(req.Mode)
+	if !mode.Valid() {
+		return nil, ErrInvalidDeletionMode
+	}
+	u, err := uc.getUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !auth.CheckPassword(u.PasswordHash, req.Password) {
+		return nil, ErrInvalidCredentials
+	}
+	if u.Deletion != nil {
+		return nil, ErrDeletionPending
+	}
+	now := time.Now()
+	d := &domain.DeletionRequest{
+		Mode:        string(mode),
+		RequestedAt: now,
+		ScheduledAt: now.Add(uc.cfg.GracePeriod),
+	}
+	if err := uc.users.SetDeletion(ctx, u.ID, d); err != nil {
+		return nil, err
+	}
+
+	if err := sendTemplate(uc.email, uc.cfg.Templates, u.Email, userLocale(u), "account_deletion_scheduled", map[string]interface{}{
+		"ScheduledAt": d.ScheduledAt.Format("2006-01-02 15:04 MST"),
+	}); err != nil {
+		// the request stands; the notice is a courtesy
+		uc.log.Error("failed to send deletion notice",
+			zap.String("user_id", u.ID),
+			zap.Error(err))
+	}
+	uc.log.Info("account deletion scheduled",
+		zap.String("user_id", u.ID),
+		zap.String("mode", d.Mode),
+		zap.Time("scheduled_at", d.ScheduledAt))
+	return toDeletionResponse(d), nil
+}
+
+// CancelDeletion keeps an account whose deletion is still pending.
+func (uc *AccountUseCase) CancelDeletion(ctx context.Context, userID string) error {
+	u, err := uc.getUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if u.Deletion == nil {
+		return ErrNoDeletionPending
+	}
+	if err := uc.users.SetDeletion(ctx, u.ID, nil); err != nil {
+		return err
+	}
+	uc.log.Info("account deletion cancelled", zap.String("user_id", u.ID))
+	return nil
+}
+
+// AccountExport is everything gathered for a data export. Service documents
+// are already in memory; files are fetched while the archive is written.
+type AccountExport struct {
+	profile  *AccountExportProfile
+	services []serviceExport
+}
+
+type serviceExport struct {
+	service UserDataService
+	export  *userdata.Export
+}
+
+// Export collects the account's data from this and every other service.
+// It fails before anything is written if any service cannot answer.
+func (uc *AccountUseCase) Export(ctx context.Context, userID string) (*AccountExport, error) {
+	u, err := uc.getUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	sessions, err := uc.sessionUC.List(ctx, u.ID, "")
+	if err != nil {
+		return nil, err
+	}
+	status := u.Status
+	if status == "" {
+		status = domain.StatusActive
+	}
+	result := &AccountExport{profile: &AccountExportProfile{
+		ID:               u.ID,
+		Email:            u.Email,
+		Role:             string(u.Role),
+		Verified:         u.Verified,
+		Status:           string(status),
+		TwoFactorEnabled: u.TwoFactorEnabled(),
+		Profile:          toProfileResponse(u),
+		Sessions:         sessions,
+		CreatedAt:        u.CreatedAt,
+		UpdatedAt:        u.UpdatedAt,
+	}}
+	for _, s := range uc.services {
+		e, err := s.Export(ctx, u.ID)
+		if err != nil {
+			return nil, fmt.Errorf("export from %s: %w", s.Name(), err)
+		}
+		result.services = append(result.services, serviceExport{service: s, export: e})
+	}
+	uc.log.Info("account exported", zap.String("user_id", u.ID))
+	return result, nil
+}
+
+// WriteZip writes the export as a ZIP archive: profile.json, then
+// <service>/<document>.json and <service>/<file> for each service.
+func (e *AccountExport) WriteZip(ctx context.Context, w io.Writer) error {
+	zw := zip.NewWriter(w)
+	if err := writeJSONEntry(zw, "profile.json", e.profile); err != nil {
+		return err
+	}
+	for _, s := range e.services {
+		name := s.service.Name()
+		docs := make([]string, 0, len(s.export.Documents))
+		for doc := range s.export.Documents {
+			docs = append(docs, doc)
+		}
+		sort.Strings(docs)
+		for _, doc := range docs {
+			entry, err := exportEntryName(name, doc+".json")
+			if err != nil {
+				return err
+			}
+			f, err := zw.Create(entry)
+			if err != nil {
+				return err
+			}
+			if _, err := f.Write(s.export.Documents[doc]); err != nil {
+				return err
+			}
+		}
+		for _, file := range s.export.Files {
+			entry, err := exportEntryName(name, file.Name)
+			if err != nil {
+				return err
+			}
+			if err := copyEntry(ctx, zw, entry, s.service, file.Path); err != nil {
+				return err
+			}
+		}
+	}
+	return zw.Close()
+}
+
+// PurgeDue deletes the accounts whose grace period is over and returns how
+// many were deleted. An account any service failed to clean up stays
+// scheduled and is retried on the next run.
+func (uc *AccountUseCase) PurgeDue(ctx context.Context, now time.Time) (int, error) {
+	due, err := uc.users.ListDueDeletions(ctx, now, purgeBatchSize)
+	if err != nil {
+		return 0, err
+	}
+	deleted := 0
+	for _, u := range due {
+		if err := uc.purge(ctx, u); err != nil {
+			uc.log.Error("account deletion failed",
+				zap.String("user_id", u.ID),
+				zap.Error(err))
+			continue
+		}
+		deleted++
+	}
+	return deleted, nil
+}
+
+// Run calls PurgeDue every interval until ctx is done.
+func (uc *AccountUseCase) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if _, err := uc.PurgeDue(ctx, time.Now()); err != nil {
+			uc.log.Error("purge due deletions", zap.Error(err))
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// purge removes the account everywhere. Other services go first so a
+// failure leaves the account, and its schedule, in place for a retry. The
+// admin action log keeps its entries about the account.
+func (uc *AccountUseCase) purge(ctx context.Context, u *domain.User) error {
+	mode := userdata.Mode(u.Deletion.Mode)
+	for _, s := range uc.services {
+		if err := s.Delete(ctx, u.ID, mode); err != nil {
+			return fmt.Errorf("delete from %s: %w", s.Name(), err)
+		}
+	}
+	if _, err := uc.sessionUC.RevokeAll(ctx, u.ID, domain.RevokeReasonDeleted); err != nil {
+		return err
+	}
+	if _, err := uc.apiKeys.RevokeUserAPIKeys(ctx, u.ID, time.Now()); err != nil {
+		return err
+	}
+	if err := uc.tokens.PurgeUser(ctx, u.ID); err != nil {
+		return err
+	}
+	if err := uc.sessions.DeleteByUser(ctx, u.ID); err != nil {
+		return err
+	}
+	if err := uc.users.Delete(ctx, u.ID); err != nil && !errors.Is(err, domain.ErrUserNotFound) {
+		return err
+	}
+
+	if err := sendTemplate(uc.email, uc.cfg.Templates, u.Email, userLocale(u), "account_deleted", nil); err != nil {
+		uc.log.Error("failed to send deletion confirmation",
+			zap.String("user_id", u.ID),
+			zap.Error(err))
+	}
+	uc.log.Info("account deleted", zap.String("user_id", u.ID), zap.String("mode", string(mode)))
+	return nil
+}
+
+func (uc *AccountUseCase) getUser(ctx context.Context, id string) (*domain.User, error) {
+	u, err := uc.users.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if u == nil {
+		return nil, domain.ErrUserNotFound
+	}
+	return u, nil
+}
+
+func toDeletionResponse(d *domain.DeletionRequest) *DeletionResponse {
+	return &DeletionResponse{Mode: d.Mode, RequestedAt: d.RequestedAt, ScheduledAt: d.ScheduledAt}
+}
+
+// exportEntryName keeps a service's entries inside its own directory.
+func exportEntryName(service, name string) (string, error) {
+	clean := path.Clean("/" + name)
+	if clean == "/" || strings.Contains(name, "..") {
+		return "", fmt.Errorf("%s: invalid export entry %q", service, name)
+	}
+	return service + clean, nil
+}
+
+func writeJSONEntry(zw *zip.Writer, name string, v interface{}) error {
+	f, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func copyEntry(ctx context.Context, zw *zip.Writer, entry string, s UserDataService, filePath string) error {
+	body, err := s.Fetch(ctx, filePath)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+	f, err := zw.Create(entry)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(f, body)
+	return err
+}
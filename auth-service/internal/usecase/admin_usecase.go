@@ -0,0 +1,389 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/HatefBarari/microblog-auth/internal/domain"
+	"github.com/HatefBarari/microblog-shared/pkg/auth"
+	"github.com/golang-jwt/jwt/v5"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrSelfAction       = errors.New("admins cannot change their own account")
+	ErrInvalidUntil     = errors.New("suspension end must be in the future")
+	ErrAccountActive    = errors.New("account is already active")
+	ErrImpersonateAdmin = errors.New("admins cannot be impersonated")
+)
+
+const (
+	defaultPageSize = 20
+	// maxPageSize bounds how much of a collection one list request loads.
+	maxPageSize = 100
+)
+
+// AdminUseCase lets admins manage other accounts. Every change is written to
+// the admin action log with the acting admin's ID.
+type AdminUseCase struct {
+	users       domain.UserRepository
+	sessions    domain.SessionRepository
+	revocations auth.RevocationStore
+	apiKeys     auth.APIKeyStore
+	actions     domain.AdminActionRepository
+	userUC      *UserUseCase
+	emailUC     *EmailUseCase
+	accessTTL   time.Duration
+	log         *zap.Logger
+}
+
+func NewAdminUseCase(users domain.UserRepository, sessions domain.SessionRepository, revocations auth.RevocationStore, apiKeys auth.APIKeyStore, actions domain.AdminActionRepository, userUC *UserUseCase, emailUC *EmailUseCase, accessTTL time.Duration, log *zap.Logger) *AdminUseCase {
+	return &AdminUseCase{
+		users:       users,
+		sessions:    sessions,
+		revocations: revocations,
+		apiKeys:     apiKeys,
+		actions:     actions,
+		userUC:      userUC,
+		emailUC:     emailUC,
+		accessTTL:   accessTTL,
+		log:         log,
+	}
+}
+
+func (uc *AdminUseCase) ListUsers(ctx context.Context, req ListUsersRequest) (*UserListResponse, error) {
+	filter := domain.UserFilter{
+		Email:    req.Email,
+		Verified: req.Verified,
+		Page:     req.Page,
+		PageSize: req.PageSize,
+	}
+	if req.Role != "" {
+		role := domain.Role(req.Role)
+		filter.Role = &role
+	}
+	if req.Status != "" {
+		status := domain.UserStatus(req.Status)
+		filter.Status = &status
+	}
+	filter.Page, filter.PageSize = pageBounds(filter.Page, filter.PageSize)
+
+	list, total, err := uc.users.List(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	resp := &UserListResponse{
+		Items:    make([]*AdminUserResponse, len(list)),
+		Total:    total,
+		Page:     filter.Page,
+		PageSize: filter.PageSize,
+	}
+	for i, u := range list {
+		resp.Items[i] = toAdminUser(u)
+	}
+	return resp, nil
+}
+
+func (uc *AdminUseCase) GetUser(ctx context.Context, id string) (*AdminUserResponse, error) {
+	u, err := uc.getUser(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return toAdminUser(u), nil
+}
+
+// ChangeRole updates the user's role. Outstanding access tokens still carry
+// the old role, so they are revoked; the next refresh picks up the new one.
+func (uc *AdminUseCase) ChangeRole(ctx context.Context, actorID, id string, role domain.Role) (*AdminUserResponse, error) {
+	if actorID == id {
+		return nil, ErrSelfAction
+	}
+	if !role.Valid() {
+		return nil, errors.New("invalid role")
+	}
+	u, err := uc.getUser(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if u.Role == role {
+		return toAdminUser(u), nil
+	}
+	if err := uc.users.UpdateRole(ctx, id, role); err != nil {
+		return nil, err
+	}
+	if err := uc.apiKeys.SetUserAPIKeyRole(ctx, id, string(role)); err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if err := uc.revocations.RevokeUser(ctx, id, now, now.Add(uc.accessTTL)); err != nil {
+		return nil, err
+	}
+	details := map[string]string{
+		"from": string(u.Role),
+		"to":   string(role),
+	}
+	uc.record(ctx, actorID, domain.ActionRoleChanged, id, details)
+	uc.userUC.cfg.Events.Record(ctx, domain.AuthEvent{
+		Type: domain.EventRoleChanged, Outcome: domain.OutcomeSuccess, UserID: id, ActorID: actorID, Details: details,
+	})
+	u.Role = role
+	return toAdminUser(u), nil
+}
+
+// Suspend blocks sign-in until the given time, or until reactivated when
+// until is nil, and ends everything the user is currently signed in with.
+func (uc *AdminUseCase) Suspend(ctx context.Context, actorID, id string, req AccountStatusRequest) (*AdminUserResponse, error) {
+	if req.Until != nil && !req.Until.After(time.Now()) {
+		return nil, ErrInvalidUntil
+	}
+	details := map[string]string{"reason": req.Reason}
+	if req.Until != nil {
+		details["until"] = req.Until.UTC().Format(time.RFC3339)
+	}
+	return uc.setStatus(ctx, actorID, id, domain.StatusSuspended, req.Reason, req.Until, domain.ActionSuspended, details)
+}
+
+// Ban permanently blocks the account and ends all of its sessions.
+func (uc *AdminUseCase) Ban(ctx context.Context, actorID, id, reason string) (*AdminUserResponse, error) {
+	return uc.setStatus(ctx, actorID, id, domain.StatusBanned, reason, nil, domain.ActionBanned, map[string]string{"reason": reason})
+}
+
+// Reactivate lifts a suspension or ban. Revoked sessions and tokens stay
+// revoked; the user signs in again.
+func (uc *AdminUseCase) Reactivate(ctx context.Context, actorID, id string) (*AdminUserResponse, error) {
+	if actorID == id {
+		return nil, ErrSelfAction
+	}
+	u, err := uc.getUser(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if u.Status == "" || u.Status == domain.StatusActive {
+		return nil, ErrAccountActive
+	}
+	if err := uc.users.SetStatus(ctx, id, domain.StatusActive, "", nil); err != nil {
+		return nil, err
+	}
+	uc.record(ctx, actorID, domain.ActionReactivated, id, map[string]string{"from": string(u.Status)})
+	u.Status, u.StatusReason, u.SuspendedUntil = domain.StatusActive, "", nil
+	return toAdminUser(u), nil
+}
+
+// ForceVerify marks the user's email as verified without a token.
+func (uc *AdminUseCase) ForceVerify(ctx context.Context, actorID, id string) (*AdminUserResponse, error) {
+	u, err := uc.getUser(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !u.Verified {
+		if err := uc.users.UpdateVerified(ctx, id, true); err != nil {
+			return nil, err
+		}
+		uc.record(ctx, actorID, domain.ActionVerified, id, nil)
+		uc.userUC.cfg.Events.Record(ctx, domain.AuthEvent{Type: domain.EventEmailVerified, Outcome: domain.OutcomeSuccess, UserID: id, ActorID: actorID})
+		u.Verified = true
+	}
+	return toAdminUser(u), nil
+}
+
+// SendPasswordReset emails the user a reset link. The password itself is
+// never set by admins.
+func (uc *AdminUseCase) SendPasswordReset(ctx context.Context, actorID, id string) error {
+	u, err := uc.getUser(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := uc.emailUC.SendPasswordResetEmailFor(ctx, u); err != nil {
+		return err
+	}
+	uc.record(ctx, actorID, domain.ActionPasswordResetSent, id, nil)
+	return nil
+}
+
+// Unlock clears the user's login lockout.
+func (uc *AdminUseCase) Unlock(ctx context.Context, actorID, id string) error {
+	if err := uc.userUC.Unlock(ctx, id); err != nil {
+		return err
+	}
+	uc.record(ctx, actorID, domain.ActionUnlocked, id, nil)
+	return nil
+}
+
+// Impersonate issues a short-lived access token for the user that names the
+// admin in its act claim. It has no session or refresh token; services log
+// the admin next to the user, and the auth service refuses it for password,
+// email and 2FA changes. Signing the admin out also ends it.
+func (uc *AdminUseCase) Impersonate(ctx context.Context, actorID, id string) (*ImpersonationResponse, error) {
+	if actorID == id {
+		return nil, ErrSelfAction
+	}
+	u, err := uc.getUser(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if u.Role == domain.RoleAdmin {
+		return nil, ErrImpersonateAdmin
+	}
+	now := time.Now()
+	if !u.CanSignIn(now) {
+		return nil, ErrAccountSuspended
+	}
+	ttl := time.Duration(uc.userUC.cfg.ImpersonationTTLMin) * time.Minute
+	claims := &auth.Claims{
+		UserID: u.ID,
+		Role:   string(u.Role),
+		Actor:  &auth.Actor{Subject: actorID},
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   u.ID,
+			ID:        auth.NewTokenID(),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+		},
+	}
+	token, err := uc.userUC.cfg.AccessSigner.Sign(claims)
+	if err != nil {
+		return nil, err
+	}
+	uc.record(ctx, actorID, domain.ActionImpersonated, id, map[string]string{
+		"token_id":   claims.ID,
+		"expires_at": claims.ExpiresAt.Time.UTC().Format(time.RFC3339),
+	})
+	return &ImpersonationResponse{
+		AccessToken: token,
+		ExpiresAt:   claims.ExpiresAt.Time,
+		UserID:      u.ID,
+		ActorID:     actorID,
+	}, nil
+}
+
+func (uc *AdminUseCase) ListActions(ctx context.Context, req ListAdminActionsRequest) (*AdminActionListResponse, error) {
+	filter := domain.AdminActionFilter{
+		ActorID:  req.ActorID,
+		TargetID: req.TargetID,
+		Action:   req.Action,
+	}
+	filter.Page, filter.PageSize = pageBounds(req.Page, req.PageSize)
+	list, total, err := uc.actions.List(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	resp := &AdminActionListResponse{
+		Items:    make([]*AdminActionResponse, len(list)),
+		Total:    total,
+		Page:     filter.Page,
+		PageSize: filter.PageSize,
+	}
+	for i, a := range list {
+		resp.Items[i] = &AdminActionResponse{
+			ID:        a.ID,
+			ActorID:   a.ActorID,
+			Action:    a.Action,
+			TargetID:  a.TargetID,
+			Details:   a.Details,
+			IP:        a.IP,
+			CreatedAt: a.CreatedAt,
+		}
+	}
+	return resp, nil
+}
+
+func (uc *AdminUseCase) setStatus(ctx context.Context, actorID, id string, status domain.UserStatus, reason string, until *time.Time, action string, details map[string]string) (*AdminUserResponse, error) {
+	if actorID == id {
+		return nil, ErrSelfAction
+	}
+	u, err := uc.getUser(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := uc.users.SetStatus(ctx, id, status, reason, until); err != nil {
+		return nil, err
+	}
+	if err := uc.signOut(ctx, id); err != nil {
+		return nil, err
+	}
+	uc.record(ctx, actorID, action, id, details)
+	u.Status, u.StatusReason, u.SuspendedUntil = status, reason, until
+	return toAdminUser(u), nil
+}
+
+// signOut ends every session, access token and API key of the user, so a
+// blocked account stops working at once rather than when tokens expire.
+func (uc *AdminUseCase) signOut(ctx context.Context, userID string) error {
+	now := time.Now()
+	if _, err := uc.sessions.RevokeAllByUser(ctx, userID, domain.RevokeReasonAdmin, now); err != nil {
+		return err
+	}
+	if err := uc.revocations.RevokeUser(ctx, userID, now, now.Add(uc.accessTTL)); err != nil {
+		return err
+	}
+	if _, err := uc.apiKeys.RevokeUserAPIKeys(ctx, userID, now); err != nil {
+		return err
+	}
+	return nil
+}
+
+// record appends to the admin action log. The change itself has already
+// happened, so a failed write is logged rather than reported to the caller.
+func (uc *AdminUseCase) record(ctx context.Context, actorID, action, targetID string, details map[string]string) {
+	a := &domain.AdminAction{
+		ActorID:   actorID,
+		Action:    action,
+		TargetID:  targetID,
+		Details:   details,
+		IP:        ClientInfoFrom(ctx).IP,
+		CreatedAt: time.Now(),
+	}
+	if err := uc.actions.Create(ctx, a); err != nil {
+		uc.log.Error("record admin action",
+			zap.String("actor_id", actorID),
+			zap.String("action", action),
+			zap.String("target_id", targetID),
+			zap.Error(err))
+		return
+	}
+	uc.log.Info("admin action",
+		zap.String("actor_id", actorID),
+		zap.String("action", action),
+		zap.String("target_id", targetID))
+}
+
+func (uc *AdminUseCase) getUser(ctx context.Context, id string) (*domain.User, error) {
+	u, err := uc.users.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if u == nil {
+		return nil, domain.ErrUserNotFound
+	}
+	return u, nil
+}
+
+func pageBounds(page, size int) (int, int) {
+	if page <= 0 {
+		page = 1
+	}
+	if size <= 0 {
+		size = defaultPageSize
+	}
+	return page, min(size, maxPageSize)
+}
+
+func toAdminUser(u *domain.User) *AdminUserResponse {
+	status := u.Status
+	if status == "" {
+		status = domain.StatusActive
+	}
+	return &AdminUserResponse{
+		ID:               u.ID,
+		Email:            u.Email,
+		Role:             string(u.Role),
+		Verified:         u.Verified,
+		Status:           string(status),
+		StatusReason:     u.StatusReason,
+		SuspendedUntil:   u.SuspendedUntil,
+		TwoFactorEnabled: u.TwoFactorEnabled(),
+		CreatedAt:        u.CreatedAt,
+		UpdatedAt:        u.UpdatedAt,
+	}
+}
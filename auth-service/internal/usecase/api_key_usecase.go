@@ -0,0 +1,118 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/HatefBarari/microblog-auth/internal/domain"
+	"github.com/HatefBarari/microblog-shared/pkg/auth"
+	"go.uber.org/zap"
+)
+
+var ErrAPIKeyNotFound = errors.New("api key not found")
+
+type APIKeyConfig struct {
+	DefaultTTLDays int
+	MaxTTLDays     int
+}
+
+// APIKeyUseCase manages personal access tokens for automation clients.
+// Keys carry the owner's role at creation, narrowed by their scopes.
+type APIKeyUseCase struct {
+	keys  auth.APIKeyStore
+	users domain.UserRepository
+	cfg   APIKeyConfig
+	log   *zap.Logger
+}
+
+func NewAPIKeyUseCase(keys auth.APIKeyStore, users domain.UserRepository, cfg APIKeyConfig, log *zap.Logger) *APIKeyUseCase {
+	return &APIKeyUseCase{keys: keys, users: users, cfg: cfg, log: log}
+}
+
+// Create issues a new key. The token is only returned here; afterwards
+// just its hash is kept.
+func (uc *APIKeyUseCase) Create(ctx context.Context, userID string, req CreateAPIKeyRequest) (*CreateAPIKeyResponse, error) {
+	for _, s := range req.Scopes {
+		if !slices.Contains(auth.KnownScopes, s) {
+			return nil, fmt.Errorf("unknown scope %q", s)
+		}
+	}
+	days := req.ExpiresInDays
+	if days == 0 {
+		days = uc.cfg.DefaultTTLDays
+	}
+	if days < 0 || days > uc.cfg.MaxTTLDays {
+		return nil, fmt.Errorf("expiry must be between 1 and %d days", uc.cfg.MaxTTLDays)
+	}
+	u, err := uc.users.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if u == nil {
+		return nil, domain.ErrUserNotFound
+	}
+
+	token, hash, err := auth.NewAPIKeyToken()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	expiresAt := now.AddDate(0, 0, days)
+	k := &auth.APIKey{
+		ID:        auth.NewTokenID(),
+		UserID:    u.ID,
+		Role:      string(u.Role),
+		Name:      req.Name,
+		Hint:      token[:len(auth.APIKeyPrefix)+4],
+		Hash:      hash,
+		Scopes:    slices.Compact(slices.Sorted(slices.Values(req.Scopes))),
+		CreatedAt: now,
+		ExpiresAt: &expiresAt,
+	}
+	if err := uc.keys.CreateAPIKey(ctx, k); err != nil {
+		return nil, err
+	}
+	uc.log.Info("api key created", zap.String("user_id", u.ID), zap.String("key_id", k.ID), zap.Strings("scopes", k.Scopes))
+	return &CreateAPIKeyResponse{APIKeyResponse: *toAPIKeyResponse(k), Token: token}, nil
+}
+
+// List returns the user's keys, including revoked and expired ones.
+func (uc *APIKeyUseCase) List(ctx context.Context, userID string) ([]*APIKeyResponse, error) {
+	list, err := uc.keys.ListAPIKeys(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	resp := make([]*APIKeyResponse, len(list))
+	for i, k := range list {
+		resp[i] = toAPIKeyResponse(k)
+	}
+	return resp, nil
+}
+
+func (uc *APIKeyUseCase) Revoke(ctx context.Context, userID, id string) error {
+	ok, err := uc.keys.RevokeAPIKey(ctx, userID, id, time.Now())
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrAPIKeyNotFound
+	}
+	uc.log.Info("api key revoked", zap.String("user_id", userID), zap.String("key_id", id))
+	return nil
+}
+
+func toAPIKeyResponse(k *auth.APIKey) *APIKeyResponse {
+	return &APIKeyResponse{
+		ID:         k.ID,
+		Name:       k.Name,
+		Hint:       k.Hint,
+		Scopes:     k.Scopes,
+		CreatedAt:  k.CreatedAt,
+		ExpiresAt:  k.ExpiresAt,
+		LastUsedAt: k.LastUsedAt,
+		RevokedAt:  k.RevokedAt,
+	}
+}
@@ -0,0 +1,113 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"time"
+
+	"github.com/HatefBarari/microblog-auth/internal/domain"
+	"go.uber.org/zap"
+)
+
+// AuthEventUseCase keeps the security audit log: sign-ins, failed sign-ins,
+// refreshes, password resets, verifications and role changes. Users see
+// their own events; admins query and export everyone's.
+type AuthEventUseCase struct {
+	repo domain.AuthEventRepository
+	log  *zap.Logger
+}
+
+func NewAuthEventUseCase(repo domain.AuthEventRepository, log *zap.Logger) *AuthEventUseCase {
+	return &AuthEventUseCase{repo: repo, log: log}
+}
+
+// Record appends e, taking the IP and user agent from ctx. The event has
+// already happened, so a failed write is logged rather than returned. A
+// nil AuthEventUseCase records nothing.
+func (uc *AuthEventUseCase) Record(ctx context.Context, e domain.AuthEvent) {
+	if uc == nil {
+		return
+	}
+	ci := ClientInfoFrom(ctx)
+	e.IP, e.UserAgent = ci.IP, ci.UserAgent
+	e.CreatedAt = time.Now()
+	if err := uc.repo.Create(ctx, &e); err != nil {
+		uc.log.Error("record auth event",
+			zap.String("type", string(e.Type)),
+			zap.String("user_id", e.UserID),
+			zap.Error(err))
+	}
+}
+
+// ListMine returns a page of the user's own events, newest first.
+func (uc *AuthEventUseCase) ListMine(ctx context.Context, userID string, req ListSecurityEventsRequest) (*AuthEventListResponse, error) {
+	return uc.list(ctx, domain.AuthEventFilter{
+		UserID:   userID,
+		Type:     domain.AuthEventType(req.Type),
+		Page:     req.Page,
+		PageSize: req.PageSize,
+	})
+}
+
+// List returns a page of everyone's events, newest first (admin only).
+func (uc *AuthEventUseCase) List(ctx context.Context, req ListAuthEventsRequest) (*AuthEventListResponse, error) {
+	filter := req.filter()
+	filter.Page, filter.PageSize = req.Page, req.PageSize
+	return uc.list(ctx, filter)
+}
+
+// Export writes every matching event to w as newline-delimited JSON, oldest
+// first, for SIEM ingestion.
+func (uc *AuthEventUseCase) Export(ctx context.Context, req ListAuthEventsRequest, w io.Writer) error {
+	enc := json.NewEncoder(w)
+	return uc.repo.Each(ctx, req.filter(), func(e *domain.AuthEvent) error {
+		return enc.Encode(toAuthEventResponse(e))
+	})
+}
+
+func (uc *AuthEventUseCase) list(ctx context.Context, filter domain.AuthEventFilter) (*AuthEventListResponse, error) {
+	filter.Page, filter.PageSize = pageBounds(filter.Page, filter.PageSize)
+	list, total, err := uc.repo.List(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	resp := &AuthEventListResponse{
+		Items:    make([]*AuthEventResponse, len(list)),
+		Total:    total,
+		Page:     filter.Page,
+		PageSize: filter.PageSize,
+	}
+	for i, e := range list {
+		resp.Items[i] = toAuthEventResponse(e)
+	}
+	return resp, nil
+}
+
+func (r ListAuthEventsRequest) filter() domain.AuthEventFilter {
+	return domain.AuthEventFilter{
+		UserID:  r.UserID,
+		Email:   r.Email,
+		Type:    domain.AuthEventType(r.Type),
+		Outcome: domain.AuthOutcome(r.Outcome),
+		IP:      r.IP,
+		From:    r.From,
+		To:      r.To,
+	}
+}
+
+func toAuthEventResponse(e *domain.AuthEvent) *AuthEventResponse {
+	return &AuthEventResponse{
+		ID:        e.ID,
+		Type:      string(e.Type),
+		Outcome:   string(e.Outcome),
+		UserID:    e.UserID,
+		Email:     e.Email,
+		ActorID:   e.ActorID,
+		Reason:    e.Reason,
+		IP:        e.IP,
+		UserAgent: e.UserAgent,
+		Details:   e.Details,
+		CreatedAt: e.CreatedAt,
+	}
+}
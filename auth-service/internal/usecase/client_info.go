@@ -0,0 +1,22 @@
+package usecase
+
+import "context"
+
+// ClientInfo describes the caller of a request. The presenter attaches it to
+// the request context so use cases can record where an action came from.
+type ClientInfo struct {
+	IP        string
+	UserAgent string
+	Device    string
+}
+
+type clientInfoKey struct{}
+
+func WithClientInfo(ctx context.Context, ci ClientInfo) context.Context {
+	return context.WithValue(ctx, clientInfoKey{}, ci)
+}
+
+func ClientInfoFrom(ctx context.Context) ClientInfo {
+	ci, _ := ctx.Value(clientInfoKey{}).(ClientInfo)
+	return ci
+}
@@ -1,8 +1,28 @@
 package usecase
 
+import (
+	"time"
+
+	"github.com/HatefBarari/microblog-shared/pkg/auth"
+)
+
 type RegisterRequest struct {
 	Email    string `json:"email" validate:"required,email"`
 	Password string `json:"password" validate:"required,min=6"`
+	// Invitation is the code from an invitation link.
+	Invitation string `json:"invitation"`
+	// Locale is the language emails to the account are written in; empty
+	// is Persian. It can be changed later in the profile.
+	Locale string `json:"locale" validate:"omitempty,oneof=fa en"`
+}
+
+type MagicLinkRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+type ChangeEmailRequest struct {
+	NewEmail string `json:"new_email" validate:"required,email"`
+	Password string `json:"password" validate:"required"`
 }
 
 type RegisterResponse struct {
@@ -15,12 +35,428 @@ type LoginRequest struct {
 	Password string `json:"password" validate:"required,min=6"`
 }
 
-type LoginResponse = RegisterResponse
+type LoginResponse struct {
+	AccessToken  string `json:"access_token,omitempty"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	// set instead of the tokens when the password alone is not enough;
+	// the challenge token is exchanged at /login/2fa
+	TwoFactorRequired      bool   `json:"two_factor_required,omitempty"`
+	TwoFactorSetupRequired bool   `json:"two_factor_setup_required,omitempty"`
+	ChallengeToken         string `json:"challenge_token,omitempty"`
+	// returned once, when a required enrollment completes at login
+	RecoveryCodes []string `json:"recovery_codes,omitempty"`
+}
+
+type LoginTwoFactorRequest struct {
+	ChallengeToken string `json:"challenge_token" validate:"required"`
+	Code           string `json:"code" validate:"required"`
+}
+
+type TwoFactorSetupRequest struct {
+	ChallengeToken string `json:"challenge_token" validate:"required"`
+}
 
 type RefreshRequest struct {
 	RefreshToken string `json:"refresh_token" validate:"required"`
 }
 
 type RefreshResponse struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+}
+type SessionResponse struct {
+	ID         string    `json:"id"`
+	Device     string    `json:"device,omitempty"`
+	IP         string    `json:"ip"`
+	UserAgent  string    `json:"user_agent"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	Current    bool      `json:"current"`
+}
+
+type TwoFactorEnrollResponse struct {
+	Secret     string `json:"secret"`
+	OTPAuthURL string `json:"otpauth_url"`
+	QRCodePNG  []byte `json:"qr_code_png"` // base64 in JSON
+}
+
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" validate:"required"`
+}
+
+type TwoFactorDisableRequest struct {
+	Password string `json:"password" validate:"required"`
+	Code     string `json:"code" validate:"required"`
+}
+
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+type CreateAPIKeyRequest struct {
+	Name          string   `json:"name" validate:"required,max=100"`
+	Scopes        []string `json:"scopes" validate:"required,min=1"`
+	ExpiresInDays int      `json:"expires_in_days"`
+}
+
+type APIKeyResponse struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Hint       string     `json:"hint"`
+	Scopes     []string   `json:"scopes"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
+
+type CreateAPIKeyResponse struct {
+	APIKeyResponse
+	Token string `json:"token"`
+}
+
+// ListUsersRequest is bound from the query string of GET /admin/users.
+type ListUsersRequest struct {
+	Email    string `query:"email"`
+	Role     string `query:"role" validate:"omitempty,oneof=guest user manager admin"`
+	Verified *bool  `query:"verified"`
+	Status   string `query:"status" validate:"omitempty,oneof=active suspended banned"`
+	Page     int    `query:"page" validate:"omitempty,min=1"`
+	PageSize int    `query:"page_size" validate:"omitempty,min=1,max=100"`
+}
+
+type AdminUserResponse struct {
+	ID               string     `json:"id"`
+	Email            string     `json:"email"`
+	Role             string     `json:"role"`
+	Verified         bool       `json:"verified"`
+	Status           string     `json:"status"`
+	StatusReason     string     `json:"status_reason,omitempty"`
+	SuspendedUntil   *time.Time `json:"suspended_until,omitempty"`
+	TwoFactorEnabled bool       `json:"two_factor_enabled"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+}
+
+type UserListResponse struct {
+	Items    []*AdminUserResponse `json:"items"`
+	Total    int                  `json:"total"`
+	Page     int                  `json:"page"`
+	PageSize int                  `json:"page_size"`
+}
+
+type ChangeRoleRequest struct {
+	Role string `json:"role" validate:"required,oneof=guest user manager admin"`
+}
+
+// AccountStatusRequest is the body of suspend and ban. Until only applies to
+// suspensions; without it the suspension lasts until lifted.
+type AccountStatusRequest struct {
+	Reason string     `json:"reason" validate:"required,max=500"`
+	Until  *time.Time `json:"until"`
+}
+
+// ImpersonationResponse is an access token for UserID used by the admin
+// ActorID. There is no refresh token; a new one is requested when it expires.
+type ImpersonationResponse struct {
+	AccessToken string    `json:"access_token"`
+	ExpiresAt   time.Time `json:"expires_at"`
+	UserID      string    `json:"user_id"`
+	ActorID     string    `json:"actor_id"`
+}
+
+type ListAdminActionsRequest struct {
+	ActorID  string `query:"actor_id"`
+	TargetID string `query:"target_id"`
+	Action   string `query:"action"`
+	Page     int    `query:"page" validate:"omitempty,min=1"`
+	PageSize int    `query:"page_size" validate:"omitempty,min=1,max=100"`
+}
+
+type AdminActionResponse struct {
+	ID        string            `json:"id"`
+	ActorID   string            `json:"actor_id"`
+	Action    string            `json:"action"`
+	TargetID  string            `json:"target_id"`
+	Details   map[string]string `json:"details,omitempty"`
+	IP        string            `json:"ip,omitempty"`
+	CreatedAt time.Time         `json:"created_at"`
+}
+
+type AdminActionListResponse struct {
+	Items    []*AdminActionResponse `json:"items"`
+	Total    int                    `json:"total"`
+	Page     int                    `json:"page"`
+	PageSize int                    `json:"page_size"`
+}
+
+type ProfileLinkDTO struct {
+	Label string `json:"label" validate:"required,max=40"`
+	URL   string `json:"url" validate:"required,url,max=300"`
+}
+
+type UpdateProfileRequest struct {
+	DisplayName   string           `json:"display_name" validate:"required,min=1,max=60"`
+	Handle        string           `json:"handle" validate:"omitempty,min=3,max=30"`
+	Bio           string           `json:"bio" validate:"max=500"`
+	AvatarMediaID string           `json:"avatar_media_id" validate:"max=64"`
+	Links         []ProfileLinkDTO `json:"links" validate:"max=5,dive"`
+	Locale        string           `json:"locale" validate:"omitempty,oneof=fa en"`
+}
+
+type ProfileResponse struct {
+	UserID        string           `json:"user_id"`
+	DisplayName   string           `json:"display_name"`
+	Handle        string           `json:"handle,omitempty"`
+	Bio           string           `json:"bio,omitempty"`
+	AvatarMediaID string           `json:"avatar_media_id,omitempty"`
+	Links         []ProfileLinkDTO `json:"links,omitempty"`
+	Locale        string           `json:"locale,omitempty"`
+	JoinedAt      time.Time        `json:"joined_at"`
+}
+
+// AuthorSummary is the part of a profile shown next to content.
+type AuthorSummary struct {
+	ID            string `json:"id"`
+	DisplayName   string `json:"display_name"`
+	Handle        string `json:"handle,omitempty"`
+	AvatarMediaID string `json:"avatar_media_id,omitempty"`
+}
+
+// DeleteAccountRequest schedules the caller's account for deletion. Mode
+// decides whether authored content is removed or kept under an anonymous
+// author.
+type DeleteAccountRequest struct {
+	Password string `json:"password" validate:"required"`
+	Mode     string `json:"mode" validate:"required,oneof=anonymize remove"`
+}
+
+type DeletionResponse struct {
+	Mode        string    `json:"mode"`
+	RequestedAt time.Time `json:"requested_at"`
+	ScheduledAt time.Time `json:"scheduled_at"`
+}
+
+// AccountExportProfile is profile.json in a data export.
+type AccountExportProfile struct {
+	ID               string             `json:"id"`
+	Email            string             `json:"email"`
+	Role             string             `json:"role"`
+	Verified         bool               `json:"verified"`
+	Status           string             `json:"status"`
+	TwoFactorEnabled bool               `json:"two_factor_enabled"`
+	Profile          *ProfileResponse   `json:"profile"`
+	Sessions         []*SessionResponse `json:"sessions"`
+	CreatedAt        time.Time          `json:"created_at"`
+	UpdatedAt        time.Time          `json:"updated_at"`
+}
+
+type ListOutboxRequest struct {
+	Status   string `query:"status" validate:"omitempty,oneof=pending sent dead"`
+	To       string `query:"to"`
+	Page     int    `query:"page" validate:"omitempty,min=1"`
+	PageSize int    `query:"page_size" validate:"omitempty,min=1,max=100"`
+}
+
+type OutboxEmailResponse struct {
+	ID            string     `json:"id"`
+	To            string     `json:"to"`
+	Subject       string     `json:"subject"`
+	Status        string     `json:"status"`
+	Attempts      int        `json:"attempts"`
+	NextAttemptAt time.Time  `json:"next_attempt_at"`
+	LastError     string     `json:"last_error,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	SentAt        *time.Time `json:"sent_at,omitempty"`
+}
+
+type OutboxListResponse struct {
+	Items    []*OutboxEmailResponse `json:"items"`
+	Total    int                    `json:"total"`
+	Page     int                    `json:"page"`
+	PageSize int                    `json:"page_size"`
+}
+
+// CreateOAuthClientRequest registers a service (Scopes), an app that signs
+// users in (RedirectURIs), or both.
+type CreateOAuthClientRequest struct {
+	Name         string   `json:"name" validate:"required,max=100"`
+	Scopes       []string `json:"scopes"`
+	RedirectURIs []string `json:"redirect_uris" validate:"max=10"`
+	Public       bool     `json:"public"`
+}
+
+type OAuthClientResponse struct {
+	ClientID     string     `json:"client_id"`
+	Name         string     `json:"name"`
+	Public       bool       `json:"public,omitempty"`
+	Scopes       []string   `json:"scopes"`
+	RedirectURIs []string   `json:"redirect_uris,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+	LastUsedAt   *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt    *time.Time `json:"revoked_at,omitempty"`
+}
+
+type CreateOAuthClientResponse struct {
+	OAuthClientResponse
+	ClientSecret string `json:"client_secret,omitempty"`
+}
+
+// OAuthTokenResponse is the token endpoint's success body (RFC 6749 5.1).
+type OAuthTokenResponse struct {
 	AccessToken string `json:"access_token"`
-}
\ No newline at end of file
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int    `json:"expires_in"`
+	Scope       string `json:"scope,omitempty"`
+	IDToken     string `json:"id_token,omitempty"`
+}
+
+// IntrospectionResponse describes a token (RFC 7662). Inactive tokens
+// carry nothing but Active. Role, sid and act are our own claims.
+type IntrospectionResponse struct {
+	Active    bool        `json:"active"`
+	Subject   string      `json:"sub,omitempty"`
+	ClientID  string      `json:"client_id,omitempty"`
+	Role      string      `json:"role,omitempty"`
+	Scope     string      `json:"scope,omitempty"`
+	SessionID string      `json:"sid,omitempty"`
+	TokenType string      `json:"token_type,omitempty"`
+	ExpiresAt int64       `json:"exp,omitempty"`
+	IssuedAt  int64       `json:"iat,omitempty"`
+	Issuer    string      `json:"iss,omitempty"`
+	Audience  []string    `json:"aud,omitempty"`
+	TokenID   string      `json:"jti,omitempty"`
+	Actor     *auth.Actor `json:"act,omitempty"`
+}
+
+// AuthorizeRequest is an OpenID Connect authorization request, bound from
+// the query string and carried through the consent form.
+type AuthorizeRequest struct {
+	ResponseType        string `query:"response_type" form:"response_type"`
+	ClientID            string `query:"client_id" form:"client_id"`
+	RedirectURI         string `query:"redirect_uri" form:"redirect_uri"`
+	Scope               string `query:"scope" form:"scope"`
+	State               string `query:"state" form:"state"`
+	Nonce               string `query:"nonce" form:"nonce"`
+	CodeChallenge       string `query:"code_challenge" form:"code_challenge"`
+	CodeChallengeMethod string `query:"code_challenge_method" form:"code_challenge_method"`
+}
+
+// ConsentForm is what the user submits on the consent screen: a password,
+// or on the second round a 2FA code for the challenge.
+type ConsentForm struct {
+	Approve        bool
+	Email          string
+	Password       string
+	ChallengeToken string
+	Code           string
+}
+
+// ConsentScreen asks the user to sign in and allow ClientName the scopes.
+type ConsentScreen struct {
+	ClientName string
+	Scopes     []string
+	Request    AuthorizeRequest
+	// ChallengeToken is set when a 2FA code is needed next.
+	ChallengeToken string
+	// Err is why the last attempt failed.
+	Err error
+}
+
+// AuthorizeResult is either a redirect back to the app or a consent screen.
+type AuthorizeResult struct {
+	RedirectURL string
+	Consent     *ConsentScreen
+}
+
+type OIDCDiscovery struct {
+	Issuer                            string   `json:"issuer"`
+	AuthorizationEndpoint             string   `json:"authorization_endpoint"`
+	TokenEndpoint                     string   `json:"token_endpoint"`
+	IntrospectionEndpoint             string   `json:"introspection_endpoint"`
+	UserInfoEndpoint                  string   `json:"userinfo_endpoint"`
+	JWKSURI                           string   `json:"jwks_uri"`
+	ResponseTypesSupported            []string `json:"response_types_supported"`
+	GrantTypesSupported               []string `json:"grant_types_supported"`
+	SubjectTypesSupported             []string `json:"subject_types_supported"`
+	IDTokenSigningAlgValuesSupported  []string `json:"id_token_signing_alg_values_supported"`
+	ScopesSupported                   []string `json:"scopes_supported"`
+	TokenEndpointAuthMethodsSupported []string `json:"token_endpoint_auth_methods_supported"`
+	CodeChallengeMethodsSupported     []string `json:"code_challenge_methods_supported"`
+	ClaimsSupported                   []string `json:"claims_supported"`
+}
+
+// CreateInvitationRequest invites Email with Role, or user when empty.
+type CreateInvitationRequest struct {
+	Email         string `json:"email" validate:"required,email"`
+	Role          string `json:"role" validate:"omitempty,oneof=guest user manager admin"`
+	ExpiresInDays int    `json:"expires_in_days"`
+}
+
+type ListInvitationsRequest struct {
+	Email    string `query:"email"`
+	Pending  bool   `query:"pending"`
+	Page     int    `query:"page" validate:"omitempty,min=1"`
+	PageSize int    `query:"page_size" validate:"omitempty,min=1,max=100"`
+}
+
+type InvitationResponse struct {
+	ID         string     `json:"id"`
+	Email      string     `json:"email"`
+	Role       string     `json:"role"`
+	InvitedBy  string     `json:"invited_by"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	AcceptedAt *time.Time `json:"accepted_at,omitempty"`
+	AcceptedBy string     `json:"accepted_by,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
+
+type InvitationListResponse struct {
+	Items    []*InvitationResponse `json:"items"`
+	Total    int                   `json:"total"`
+	Page     int                   `json:"page"`
+	PageSize int                   `json:"page_size"`
+}
+
+type ListSecurityEventsRequest struct {
+	Type     string `query:"type"`
+	Page     int    `query:"page" validate:"omitempty,min=1"`
+	PageSize int    `query:"page_size" validate:"omitempty,min=1,max=100"`
+}
+
+// ListAuthEventsRequest filters the admin view and export of the security
+// audit log. From and To are RFC 3339 times; the export ignores paging.
+type ListAuthEventsRequest struct {
+	UserID   string     `query:"user_id"`
+	Email    string     `query:"email"`
+	Type     string     `query:"type"`
+	Outcome  string     `query:"outcome" validate:"omitempty,oneof=success failure"`
+	IP       string     `query:"ip"`
+	From     *time.Time `query:"from"`
+	To       *time.Time `query:"to"`
+	Page     int        `query:"page" validate:"omitempty,min=1"`
+	PageSize int        `query:"page_size" validate:"omitempty,min=1,max=100"`
+}
+
+type AuthEventResponse struct {
+	ID        string            `json:"id"`
+	Type      string            `json:"type"`
+	Outcome   string            `json:"outcome"`
+	UserID    string            `json:"user_id,omitempty"`
+	Email     string            `json:"email,omitempty"`
+	ActorID   string            `json:"actor_id,omitempty"`
+	Reason    string            `json:"reason,omitempty"`
+	IP        string            `json:"ip,omitempty"`
+	UserAgent string            `json:"user_agent,omitempty"`
+	Details   map[string]string `json:"details,omitempty"`
+	CreatedAt time.Time         `json:"created_at"`
+}
+
+type AuthEventListResponse struct {
+	Items    []*AuthEventResponse `json:"items"`
+	Total    int                  `json:"total"`
+	Page     int                  `json:"page"`
+	PageSize int                  `json:"page_size"`
+}
@@ -0,0 +1,196 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/HatefBarari/microblog-auth/internal/domain"
+	"github.com/HatefBarari/microblog-shared/pkg/auth"
+	"go.uber.org/zap"
+)
+
+var ErrSameEmail = errors.New("new email is the current email")
+
+// EmailChangeUseCase moves an account to a new address. The change waits
+// for a link sent to the new address; the old address gets a notice with an
+// undo link that keeps working after the change is confirmed.
+type EmailChangeUseCase struct {
+	users     domain.UserRepository
+	emailUC   *EmailUseCase
+	sessionUC *SessionUseCase
+	userUC    *UserUseCase
+	log       *zap.Logger
+}
+
+func NewEmailChangeUseCase(users domain.UserRepository, emailUC *EmailUseCase, sessionUC *SessionUseCase, userUC *UserUseCase, log *zap.Logger) *EmailChangeUseCase {
+	return &EmailChangeUseCase{
+		users:     users,
+		emailUC:   emailUC,
+		sessionUC: sessionUC,
+		userUC:    userUC,
+		log:       log,
+	}
+}
+
+// Request starts a change to req.NewEmail after checking the password.
+// Nothing changes until the link sent to the new address is followed.
+func (uc *EmailChangeUseCase) Request(ctx context.Context, userID string, req ChangeEmailRequest) error {
+	u, err := uc.getUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !auth.CheckPassword(u.PasswordHash, req.Password) {
+		return ErrInvalidCredentials
+	}
+	newEmail := strings.TrimSpace(req.NewEmail)
+	if strings.EqualFold(newEmail, u.Email) {
+		return ErrSameEmail
+	}
+	existing, err := uc.users.GetByEmail(ctx, newEmail)
+	if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+	if existing != nil {
+		return domain.ErrEmailTaken
+	}
+
+	cfg := uc.emailUC.cfg
+	// only the latest pending change can be confirmed
+	if err := uc.emailUC.tokens.DeleteByUser(ctx, u.ID, domain.PurposeChangeEmail); err != nil {
+		return err
+	}
+	confirm, err := uc.emailUC.storeToken(ctx, &domain.AuthToken{
+		UserID:  u.ID,
+		Purpose: domain.PurposeChangeEmail,
+		Email:   newEmail,
+	}, time.Duration(cfg.TokenTTLHours)*time.Hour)
+	if err != nil {
+		return fmt.Errorf("failed to generate confirmation token: %w", err)
+	}
+	// earlier undo links are kept: each one must still be able to win the
+	// account back if a later request came from someone else
+	undo, err := uc.emailUC.storeToken(ctx, &domain.AuthToken{
+		UserID:  u.ID,
+		Purpose: domain.PurposeUndoEmailChange,
+		Email:   u.Email,
+	}, time.Duration(cfg.UndoTTLHours)*time.Hour)
+	if err != nil {
+		return fmt.Errorf("failed to generate undo token: %w", err)
+	}
+
+	locale := userLocale(u)
+	if err := sendTemplate(uc.emailUC.email, cfg.Templates, newEmail, locale, "email_change_confirm", map[string]interface{}{
+		"Link":  fmt.Sprintf("%s/email/confirm?token=%s", cfg.BaseURL, confirm),
+		"Hours": cfg.TokenTTLHours,
+	}); err != nil {
+		uc.log.Error("failed to send email change confirmation",
+			zap.String("user_id", u.ID),
+			zap.Error(err))
+		return fmt.Errorf("failed to send confirmation email: %w", err)
+	}
+
+	if err := sendTemplate(uc.emailUC.email, cfg.Templates, u.Email, locale, "email_change_notice", map[string]interface{}{
+		"NewEmail": newEmail,
+		"Link":     fmt.Sprintf("%s/email/undo?token=%s", cfg.BaseURL, undo),
+		"Hours":    cfg.UndoTTLHours,
+	}); err != nil {
+		// the old address must hear about it, so don't let the change proceed
+		_ = uc.emailUC.tokens.DeleteByUser(ctx, u.ID, domain.PurposeChangeEmail)
+		uc.log.Error("failed to send email change notice",
+			zap.String("user_id", u.ID),
+			zap.Error(err))
+		return fmt.Errorf("failed to send notice email: %w", err)
+	}
+
+	uc.log.Info("email change requested", zap.String("user_id", u.ID))
+	return nil
+}
+
+// Confirm applies the change a confirmation link was issued for. Every
+// existing session is ended and a new one is issued, subject to the same
+// rules as Login.
+func (uc *EmailChangeUseCase) Confirm(ctx context.Context, token string) (*LoginResponse, error) {
+	t, err := uc.emailUC.consume(ctx, token, domain.PurposeChangeEmail)
+	if err != nil {
+		return nil, err
+	}
+	u, err := uc.getUser(ctx, t.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if err := uc.users.UpdateEmail(ctx, u.ID, t.Email); err != nil {
+		return nil, err
+	}
+	old := u.Email
+	u.Email, u.Verified = t.Email, true
+	uc.discardLinks(ctx, u.ID)
+	if _, err := uc.sessionUC.RevokeAll(ctx, u.ID, domain.RevokeReasonEmail); err != nil {
+		return nil, err
+	}
+	uc.log.Info("email changed",
+		zap.String("user_id", u.ID),
+		zap.String("from", old),
+		zap.String("to", u.Email))
+	return uc.userUC.signIn(ctx, u, time.Now())
+}
+
+// Undo cancels a pending change or, once confirmed, moves the account back
+// to the address the undo link was sent to. Either way the owner is signed
+// out everywhere, since the request may not have been theirs.
+func (uc *EmailChangeUseCase) Undo(ctx context.Context, token string) error {
+	t, err := uc.emailUC.consume(ctx, token, domain.PurposeUndoEmailChange)
+	if err != nil {
+		return err
+	}
+	u, err := uc.getUser(ctx, t.UserID)
+	if err != nil {
+		return err
+	}
+	if err := uc.emailUC.tokens.DeleteByUser(ctx, u.ID, domain.PurposeChangeEmail); err != nil {
+		return err
+	}
+	if u.Email != t.Email {
+		if err := uc.users.UpdateEmail(ctx, u.ID, t.Email); err != nil {
+			return err
+		}
+		uc.discardLinks(ctx, u.ID)
+	}
+	if _, err := uc.sessionUC.RevokeAll(ctx, u.ID, domain.RevokeReasonEmail); err != nil {
+		return err
+	}
+	uc.log.Warn("email change undone",
+		zap.String("user_id", u.ID),
+		zap.String("email", t.Email))
+	return nil
+}
+
+// discardLinks drops sign-in, reset and verification links that went to
+// the address the account just left.
+func (uc *EmailChangeUseCase) discardLinks(ctx context.Context, userID string) {
+	for _, purpose := range []domain.TokenPurpose{
+		domain.PurposeVerifyEmail,
+		domain.PurposeResetPassword,
+		domain.PurposeMagicLogin,
+	} {
+		if err := uc.emailUC.tokens.DeleteByUser(ctx, userID, purpose); err != nil {
+			uc.log.Error("discard email links",
+				zap.String("user_id", userID),
+				zap.String("purpose", string(purpose)),
+				zap.Error(err))
+		}
+	}
+}
+
+func (uc *EmailChangeUseCase) getUser(ctx context.Context, id string) (*domain.User, error) {
+	u, err := uc.users.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if u == nil {
+		return nil, domain.ErrUserNotFound
+	}
+	return u, nil
+}
@@ -0,0 +1,38 @@
+package usecase
+
+import (
+	"github.com/HatefBarari/microblog-auth/internal/domain"
+	"github.com/HatefBarari/microblog-shared/pkg/email"
+)
+
+// MessageSender is implemented by senders that can deliver HTML email, such
+// as *email.Sender. Other senders get the plain-text part only.
+type MessageSender interface {
+	SendMessage(m *email.Message) error
+}
+
+// sendTemplate renders the named template in locale and sends it to to. A
+// nil templates uses the built-in ones.
+func sendTemplate(sender EmailSender, templates *email.Templates, to, locale, name string, data map[string]interface{}) error {
+	if templates == nil {
+		templates = email.DefaultTemplates()
+	}
+	m, err := templates.Render(name, locale, data)
+	if err != nil {
+		return err
+	}
+	m.To = to
+	if ms, ok := sender.(MessageSender); ok {
+		return ms.SendMessage(m)
+	}
+	return sender.Send(to, m.Subject, m.Text)
+}
+
+// userLocale is the locale emails to u are written in; empty means the
+// default.
+func userLocale(u *domain.User) string {
+	if u.Profile == nil {
+		return ""
+	}
+	return u.Profile.Locale
+}
@@ -2,20 +2,34 @@ package usecase
 
 import (
 	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"time"
 
 	"github.com/HatefBarari/microblog-auth/internal/domain"
+	"github.com/HatefBarari/microblog-shared/pkg/auth"
 	"github.com/HatefBarari/microblog-shared/pkg/email"
 	"go.uber.org/zap"
 )
 
+var ErrInvalidToken = errors.New("token is invalid, expired or already used")
+
+// EmailSender is satisfied by *email.Sender.
+type EmailSender interface {
+	Send(to, subject, body string) error
+}
+
 type EmailUseCase struct {
 	repo   domain.UserRepository
-	email  *email.Sender
-	cfg    *EmailConfig
-	log    *zap.Logger
+	tokens   domain.AuthTokenRepository
+	throttle *Throttler
+	email    EmailSender
+	cfg      *EmailConfig
+	log      *zap.Logger
 }
 
 type EmailConfig struct {
@@ -23,21 +37,43 @@ type EmailConfig struct {
 	BaseURL       string
 	TokenSecret   string
 	TokenTTLHours int
+	// how long the undo link sent to the old address works after an
+	// email change request
+	UndoTTLHours int
+	// Hasher and Passwords apply to password resets, as in Config.
+	Hasher    auth.PasswordHasher
+	Passwords *PasswordPolicy
+	// Templates renders outgoing emails; nil uses the built-in ones.
+	Templates *email.Templates
+	// Events records verifications and resets, as in Config.
+	Events *AuthEventUseCase
 }
 
-func NewEmailUseCase(repo domain.UserRepository, emailSender *email.Sender, cfg *EmailConfig, log *zap.Logger) *EmailUseCase {
+func NewEmailUseCase(repo domain.UserRepository, tokens domain.AuthTokenRepository, throttle *Throttler, emailSender EmailSender, cfg *EmailConfig, log *zap.Logger) *EmailUseCase {
 	return &EmailUseCase{
-		repo:  repo,
-		email: emailSender,
-		cfg:   cfg,
-		log:   log,
+		repo:     repo,
+		tokens:   tokens,
+		throttle: throttle,
+		email:    emailSender,
+		cfg:      cfg,
+		log:      log,
 	}
 }
 
 // SendVerificationEmail sends verification email to user
 func (uc *EmailUseCase) SendVerificationEmail(ctx context.Context, userID, email string) error {
+	return uc.sendVerificationEmail(ctx, userID, email, "")
+}
+
+// SendVerificationEmailFor sends a verification link to user in their
+// locale.
+func (uc *EmailUseCase) SendVerificationEmailFor(ctx context.Context, user *domain.User) error {
+	return uc.sendVerificationEmail(ctx, user.ID, user.Email, userLocale(user))
+}
+
+func (uc *EmailUseCase) sendVerificationEmail(ctx context.Context, userID, email, locale string) error {
 	// Generate verification token
-	token, err := uc.generateVerificationToken(userID)
+	token, err := uc.generateVerificationToken(ctx, userID)
 	if err != nil {
 		return fmt.Errorf("failed to generate verification token: %w", err)
 	}
@@ -45,23 +81,11 @@ func (uc *EmailUseCase) SendVerificationEmail(ctx context.Context, userID, email
 	// Create verification URL
 	verificationURL := fmt.Sprintf("%s/verify?token=%s", uc.cfg.BaseURL, token)
 
-	// Create email content
-	subject := "تایید حساب کاربری - Microblog"
-	body := fmt.Sprintf(`
-سلام،
-
-برای تایید حساب کاربری خود روی لینک زیر کلیک کنید:
-
-%s
-
-این لینک تا %d ساعت معتبر است.
-
-با تشکر،
-تیم Microblog
-`, verificationURL, uc.cfg.TokenTTLHours)
-
 	// Send email
-	if err := uc.email.Send(email, subject, body); err != nil {
+	if err := sendTemplate(uc.email, uc.cfg.Templates, email, locale, "verify_email", map[string]interface{}{
+		"Link":  verificationURL,
+		"Hours": uc.cfg.TokenTTLHours,
+	}); err != nil {
 		uc.log.Error("failed to send verification email", 
 			zap.String("user_id", userID),
 			zap.String("email", email),
@@ -76,10 +100,25 @@ func (uc *EmailUseCase) SendVerificationEmail(ctx context.Context, userID, email
 	return nil
 }
 
+// ResendVerificationEmail sends a fresh verification link to an unverified user
+func (uc *EmailUseCase) ResendVerificationEmail(ctx context.Context, userID string) error {
+	user, err := uc.repo.GetByID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+	if user == nil {
+		return domain.ErrUserNotFound
+	}
+	if user.Verified {
+		return errors.New("email already verified")
+	}
+	return uc.SendVerificationEmailFor(ctx, user)
+}
+
 // VerifyEmail verifies user email with token
 func (uc *EmailUseCase) VerifyEmail(ctx context.Context, token string) error {
 	// Validate token
-	userID, err := uc.validateVerificationToken(token)
+	userID, err := uc.validateVerificationToken(ctx, token)
 	if err != nil {
 		return fmt.Errorf("invalid verification token: %w", err)
 	}
@@ -104,6 +143,7 @@ func (uc *EmailUseCase) VerifyEmail(ctx context.Context, token string) error {
 		return fmt.Errorf("failed to update user verification: %w", err)
 	}
 
+	uc.cfg.Events.Record(ctx, domain.AuthEvent{Type: domain.EventEmailVerified, Outcome: domain.OutcomeSuccess, UserID: userID})
 	uc.log.Info("email verified successfully",
 		zap.String("user_id", userID),
 		zap.String("email", user.Email))
@@ -113,6 +153,20 @@ func (uc *EmailUseCase) VerifyEmail(ctx context.Context, token string) error {
 
 // SendPasswordResetEmail sends password reset email
 func (uc *EmailUseCase) SendPasswordResetEmail(ctx context.Context, email string) error {
+	// Throttle per address and per IP, whether or not the address exists
+	now := time.Now()
+	accountKey := throttleKey(throttleResetAccount, email)
+	ipKey := throttleKey(throttleResetIP, ClientInfoFrom(ctx).IP)
+	if err := uc.throttle.Allow(ctx, now, accountKey, ipKey); err != nil {
+		return err
+	}
+	if _, err := uc.throttle.Record(ctx, now, accountKey, uc.throttle.cfg.MaxResetRequests); err != nil {
+		return err
+	}
+	if _, err := uc.throttle.Record(ctx, now, ipKey, uc.throttle.cfg.MaxResetIPRequests); err != nil {
+		return err
+	}
+
 	// Get user by email
 	user, err := uc.repo.GetByEmail(ctx, email)
 	if err != nil {
@@ -124,9 +178,16 @@ func (uc *EmailUseCase) SendPasswordResetEmail(ctx context.Context, email string
 			zap.String("email", email))
 		return nil
 	}
+	return uc.SendPasswordResetEmailFor(ctx, user)
+}
+
+// SendPasswordResetEmailFor sends a reset link to a known user without
+// throttling; callers are trusted (e.g. admins).
+func (uc *EmailUseCase) SendPasswordResetEmailFor(ctx context.Context, user *domain.User) error {
+	email := user.Email
 
 	// Generate reset token
-	token, err := uc.generatePasswordResetToken(user.ID)
+	token, err := uc.generatePasswordResetToken(ctx, user.ID)
 	if err != nil {
 		return fmt.Errorf("failed to generate reset token: %w", err)
 	}
@@ -134,25 +195,11 @@ func (uc *EmailUseCase) SendPasswordResetEmail(ctx context.Context, email string
 	// Create reset URL
 	resetURL := fmt.Sprintf("%s/reset-password?token=%s", uc.cfg.BaseURL, token)
 
-	// Create email content
-	subject := "بازیابی رمز عبور - Microblog"
-	body := fmt.Sprintf(`
-سلام،
-
-برای بازیابی رمز عبور خود روی لینک زیر کلیک کنید:
-
-%s
-
-این لینک تا %d ساعت معتبر است.
-
-اگر شما این درخواست را نکرده‌اید، این ایمیل را نادیده بگیرید.
-
-با تشکر،
-تیم Microblog
-`, resetURL, uc.cfg.TokenTTLHours)
-
 	// Send email
-	if err := uc.email.Send(email, subject, body); err != nil {
+	if err := sendTemplate(uc.email, uc.cfg.Templates, email, userLocale(user), "reset_password", map[string]interface{}{
+		"Link":  resetURL,
+		"Hours": uc.cfg.TokenTTLHours,
+	}); err != nil {
 		uc.log.Error("failed to send password reset email",
 			zap.String("user_id", user.ID),
 			zap.String("email", email),
@@ -160,6 +207,7 @@ func (uc *EmailUseCase) SendPasswordResetEmail(ctx context.Context, email string
 		return fmt.Errorf("failed to send password reset email: %w", err)
 	}
 
+	uc.cfg.Events.Record(ctx, domain.AuthEvent{Type: domain.EventPasswordResetRequested, Outcome: domain.OutcomeSuccess, UserID: user.ID})
 	uc.log.Info("password reset email sent successfully",
 		zap.String("user_id", user.ID),
 		zap.String("email", email))
@@ -169,8 +217,15 @@ func (uc *EmailUseCase) SendPasswordResetEmail(ctx context.Context, email string
 
 // ResetPassword resets user password with token
 func (uc *EmailUseCase) ResetPassword(ctx context.Context, token, newPassword string) error {
+	// checked first so a rejected password doesn't spend the link
+	if uc.cfg.Passwords != nil {
+		if err := uc.cfg.Passwords.Validate(newPassword); err != nil {
+			return err
+		}
+	}
+
 	// Validate token
-	userID, err := uc.validatePasswordResetToken(token)
+	userID, err := uc.validatePasswordResetToken(ctx, token)
 	if err != nil {
 		return fmt.Errorf("invalid reset token: %w", err)
 	}
@@ -184,6 +239,12 @@ func (uc *EmailUseCase) ResetPassword(ctx context.Context, token, newPassword st
 		return errors.New("user not found")
 	}
 
+	if uc.cfg.Passwords != nil {
+		if err := uc.cfg.Passwords.CheckReuse(newPassword, user.PasswordHash, user.PasswordHistory); err != nil {
+			return err
+		}
+	}
+
 	// Hash new password
 	hash, err := uc.hashPassword(newPassword)
 	if err != nil {
@@ -191,61 +252,101 @@ func (uc *EmailUseCase) ResetPassword(ctx context.Context, token, newPassword st
 	}
 
 	// Update user password
+	if uc.cfg.Passwords != nil {
+		user.PasswordHistory = uc.cfg.Passwords.History(user.PasswordHash, user.PasswordHistory)
+	}
 	user.PasswordHash = hash
 	if err := uc.repo.Update(ctx, user); err != nil {
 		return fmt.Errorf("failed to update password: %w", err)
 	}
 
+	uc.cfg.Events.Record(ctx, domain.AuthEvent{Type: domain.EventPasswordReset, Outcome: domain.OutcomeSuccess, UserID: userID})
 	uc.log.Info("password reset successfully",
 		zap.String("user_id", userID))
 
 	return nil
 }
 
-// generateVerificationToken generates a verification token for user
-func (uc *EmailUseCase) generateVerificationToken(userID string) (string, error) {
-	// In a real implementation, you would use JWT or a secure token
-	// For now, we'll use a simple approach
-	token := fmt.Sprintf("verify_%s_%d", userID, time.Now().Unix())
-	return token, nil
+// generateVerificationToken issues a single-use email verification token
+func (uc *EmailUseCase) generateVerificationToken(ctx context.Context, userID string) (string, error) {
+	return uc.issueToken(ctx, userID, domain.PurposeVerifyEmail)
+}
+
+// validateVerificationToken consumes a verification token and returns its user ID
+func (uc *EmailUseCase) validateVerificationToken(ctx context.Context, token string) (string, error) {
+	return uc.consumeToken(ctx, token, domain.PurposeVerifyEmail)
+}
+
+// generatePasswordResetToken issues a single-use password reset token
+func (uc *EmailUseCase) generatePasswordResetToken(ctx context.Context, userID string) (string, error) {
+	return uc.issueToken(ctx, userID, domain.PurposeResetPassword)
+}
+
+// validatePasswordResetToken consumes a password reset token and returns its user ID
+func (uc *EmailUseCase) validatePasswordResetToken(ctx context.Context, token string) (string, error) {
+	return uc.consumeToken(ctx, token, domain.PurposeResetPassword)
 }
 
-// validateVerificationToken validates a verification token
-func (uc *EmailUseCase) validateVerificationToken(token string) (string, error) {
-	// In a real implementation, you would validate JWT or check database
-	// For now, we'll use a simple approach
-	if len(token) < 10 {
-		return "", errors.New("invalid token format")
+// issueToken stores the HMAC of a fresh random token and returns the raw
+// value. Earlier unused tokens of the same purpose are discarded so only the
+// latest link works.
+func (uc *EmailUseCase) issueToken(ctx context.Context, userID string, purpose domain.TokenPurpose) (string, error) {
+	if err := uc.tokens.DeleteByUser(ctx, userID, purpose); err != nil {
+		return "", err
 	}
-	
-	// Extract user ID from token (simplified)
-	// In production, use proper JWT validation
-	userID := "user123" // This should be extracted from token
-	return userID, nil
+	return uc.storeToken(ctx, &domain.AuthToken{UserID: userID, Purpose: purpose}, time.Duration(uc.cfg.TokenTTLHours)*time.Hour)
 }
 
-// generatePasswordResetToken generates a password reset token
-func (uc *EmailUseCase) generatePasswordResetToken(userID string) (string, error) {
-	// In a real implementation, you would use JWT or a secure token
-	token := fmt.Sprintf("reset_%s_%d", userID, time.Now().Unix())
-	return token, nil
+// storeToken completes t with the hash of a fresh random token and its
+// expiry, saves it and returns the raw value.
+func (uc *EmailUseCase) storeToken(ctx context.Context, t *domain.AuthToken, ttl time.Duration) (string, error) {
+	raw, err := randomToken(32)
+	if err != nil {
+		return "", err
+	}
+	now := time.Now()
+	t.TokenHash = uc.hashToken(raw)
+	t.ExpiresAt = now.Add(ttl)
+	t.CreatedAt = now
+	if err := uc.tokens.Create(ctx, t); err != nil {
+		return "", err
+	}
+	return raw, nil
 }
 
-// validatePasswordResetToken validates a password reset token
-func (uc *EmailUseCase) validatePasswordResetToken(token string) (string, error) {
-	// In a real implementation, you would validate JWT or check database
-	if len(token) < 10 {
-		return "", errors.New("invalid token format")
+// consumeToken marks the token as used and returns the owning user ID
+func (uc *EmailUseCase) consumeToken(ctx context.Context, raw string, purpose domain.TokenPurpose) (string, error) {
+	t, err := uc.consume(ctx, raw, purpose)
+	if err != nil {
+		return "", err
 	}
-	
-	// Extract user ID from token (simplified)
-	userID := "user123" // This should be extracted from token
-	return userID, nil
+	return t.UserID, nil
+}
+
+func (uc *EmailUseCase) consume(ctx context.Context, raw string, purpose domain.TokenPurpose) (*domain.AuthToken, error) {
+	if len(raw) != base64.RawURLEncoding.EncodedLen(32) {
+		return nil, ErrInvalidToken
+	}
+	t, err := uc.tokens.Consume(ctx, uc.hashToken(raw), purpose, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if t == nil {
+		return nil, ErrInvalidToken
+	}
+	return t, nil
+}
+
+func (uc *EmailUseCase) hashToken(raw string) string {
+	mac := hmac.New(sha256.New, []byte(uc.cfg.TokenSecret))
+	mac.Write([]byte(raw))
+	return hex.EncodeToString(mac.Sum(nil))
 }
 
 // hashPassword hashes a password
 func (uc *EmailUseCase) hashPassword(password string) (string, error) {
-	// Import the hash function from shared package
-	// This is a placeholder - you should use the actual hash function
-	return "hashed_" + password, nil
-}
\ No newline at end of file
+	if uc.cfg.Hasher == nil {
+		return auth.HashPassword(password)
+	}
+	return uc.cfg.Hasher.Hash(password)
+}
@@ -0,0 +1,174 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/HatefBarari/microblog-auth/internal/domain"
+	"github.com/HatefBarari/microblog-shared/pkg/email"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrInvitationNotFound = errors.New("invitation not found")
+	ErrRoleTooHigh        = errors.New("cannot invite with a role above your own")
+)
+
+type InvitationConfig struct {
+	BaseURL        string
+	DefaultTTLDays int
+	MaxTTLDays     int
+	// Registration is the same mode as Config.Registration; nobody can be
+	// invited while registration is closed.
+	Registration RegistrationMode
+	// Templates renders the email; nil uses the built-in ones.
+	Templates *email.Templates
+}
+
+// InvitationUseCase lets admins and managers invite people by email with a
+// preset role. The code only works for the invited address, once, and
+// UserUseCase.Register is what consumes it.
+type InvitationUseCase struct {
+	invitations domain.InvitationRepository
+	users       domain.UserRepository
+	email       EmailSender
+	cfg         InvitationConfig
+	log         *zap.Logger
+}
+
+func NewInvitationUseCase(invitations domain.InvitationRepository, users domain.UserRepository, emailSender EmailSender, cfg InvitationConfig, log *zap.Logger) *InvitationUseCase {
+	return &InvitationUseCase{invitations: invitations, users: users, email: emailSender, cfg: cfg, log: log}
+}
+
+// Create stores an invitation from inviterID and emails its link. Nobody
+// can invite with a role above their own.
+func (uc *InvitationUseCase) Create(ctx context.Context, inviterID string, req CreateInvitationRequest) (*InvitationResponse, error) {
+	if uc.cfg.Registration == RegistrationClosed {
+		return nil, ErrRegistrationClosed
+	}
+	days := req.ExpiresInDays
+	if days == 0 {
+		days = uc.cfg.DefaultTTLDays
+	}
+	if days < 0 || days > uc.cfg.MaxTTLDays {
+		return nil, fmt.Errorf("expiry must be between 1 and %d days", uc.cfg.MaxTTLDays)
+	}
+	role := domain.Role(req.Role)
+	if role == "" {
+		role = domain.RoleUser
+	}
+	inviter, err := uc.users.GetByID(ctx, inviterID)
+	if err != nil {
+		return nil, err
+	}
+	if inviter == nil {
+		return nil, domain.ErrUserNotFound
+	}
+	// the stored role, not the one in the token, which may be stale
+	if !inviter.Role.AtLeast(role) {
+		return nil, ErrRoleTooHigh
+	}
+	address := strings.ToLower(req.Email)
+	existing, err := uc.users.GetByEmail(ctx, address)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, errors.New("email already exists")
+	}
+
+	code, err := randomToken(32)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	inv := &domain.Invitation{
+		CodeHash:  hashSecret(code),
+		Email:     address,
+		Role:      role,
+		InvitedBy: inviter.ID,
+		CreatedAt: now,
+		ExpiresAt: now.AddDate(0, 0, days),
+	}
+	if err := uc.invitations.Create(ctx, inv); err != nil {
+		return nil, err
+	}
+
+	link := fmt.Sprintf("%s/register?invitation=%s&email=%s", uc.cfg.BaseURL, code, url.QueryEscape(address))
+	// the invitee has no locale yet; the inviter's is the best guess
+	if err := sendTemplate(uc.email, uc.cfg.Templates, address, userLocale(inviter), "invitation", map[string]interface{}{
+		"Link":      link,
+		"Role":      string(role),
+		"ExpiresAt": inv.ExpiresAt.Format("2006-01-02 15:04 MST"),
+	}); err != nil {
+		uc.log.Error("failed to send invitation email",
+			zap.String("invitation_id", inv.ID),
+			zap.Error(err))
+		return nil, fmt.Errorf("failed to send invitation email: %w", err)
+	}
+	uc.log.Info("invitation sent", zap.String("invitation_id", inv.ID), zap.String("invited_by", inviter.ID), zap.String("role", string(role)))
+	return toInvitationResponse(inv), nil
+}
+
+// List returns invitations, newest first. Admins see everyone's; others
+// only their own.
+func (uc *InvitationUseCase) List(ctx context.Context, userID string, role domain.Role, req ListInvitationsRequest) (*InvitationListResponse, error) {
+	filter := domain.InvitationFilter{
+		Email:    strings.ToLower(req.Email),
+		Pending:  req.Pending,
+		Page:     req.Page,
+		PageSize: req.PageSize,
+	}
+	if role != domain.RoleAdmin {
+		filter.InvitedBy = userID
+	}
+	filter.Page, filter.PageSize = pageBounds(filter.Page, filter.PageSize)
+	list, total, err := uc.invitations.List(ctx, filter, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	items := make([]*InvitationResponse, len(list))
+	for i, inv := range list {
+		items[i] = toInvitationResponse(inv)
+	}
+	return &InvitationListResponse{Items: items, Total: total, Page: filter.Page, PageSize: filter.PageSize}, nil
+}
+
+// Revoke stops a pending invitation from being used. Admins can revoke any
+// invitation; others only their own.
+func (uc *InvitationUseCase) Revoke(ctx context.Context, userID string, role domain.Role, id string) error {
+	inv, err := uc.invitations.GetByID(ctx, id)
+	if err != nil {
+		return ErrInvitationNotFound
+	}
+	if inv == nil || (role != domain.RoleAdmin && inv.InvitedBy != userID) {
+		return ErrInvitationNotFound
+	}
+	ok, err := uc.invitations.Revoke(ctx, id, time.Now())
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrInvitationNotFound
+	}
+	uc.log.Info("invitation revoked", zap.String("invitation_id", id), zap.String("by", userID))
+	return nil
+}
+
+func toInvitationResponse(inv *domain.Invitation) *InvitationResponse {
+	return &InvitationResponse{
+		ID:         inv.ID,
+		Email:      inv.Email,
+		Role:       string(inv.Role),
+		InvitedBy:  inv.InvitedBy,
+		CreatedAt:  inv.CreatedAt,
+		ExpiresAt:  inv.ExpiresAt,
+		AcceptedAt: inv.AcceptedAt,
+		AcceptedBy: inv.AcceptedBy,
+		RevokedAt:  inv.RevokedAt,
+	}
+}
@@ -0,0 +1,165 @@
+package usecase
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/HatefBarari/microblog-auth/internal/domain"
+	"github.com/HatefBarari/microblog-shared/pkg/email"
+	"go.uber.org/zap"
+)
+
+type MagicLinkConfig struct {
+	BaseURL     string
+	TokenSecret string
+	TTL         time.Duration
+	// Templates renders the email; nil uses the built-in ones.
+	Templates *email.Templates
+}
+
+// MagicLinkNonce binds a sign-in link to the browser that asked for it. It
+// is handed to that browser only (as a cookie) and never put in the email.
+type MagicLinkNonce struct {
+	Value     string
+	ExpiresAt time.Time
+}
+
+// MagicLinkUseCase signs users in through a one-time link sent by email.
+// The stored hash covers both the link token and the requesting browser's
+// nonce, so a link opened anywhere else (or fetched by a mail scanner)
+// neither works nor burns the token.
+type MagicLinkUseCase struct {
+	users    domain.UserRepository
+	tokens   domain.AuthTokenRepository
+	throttle *Throttler
+	email    EmailSender
+	userUC   *UserUseCase
+	cfg      MagicLinkConfig
+	log      *zap.Logger
+}
+
+func NewMagicLinkUseCase(users domain.UserRepository, tokens domain.AuthTokenRepository, throttle *Throttler, emailSender EmailSender, userUC *UserUseCase, cfg MagicLinkConfig, log *zap.Logger) *MagicLinkUseCase {
+	return &MagicLinkUseCase{
+		users:    users,
+		tokens:   tokens,
+		throttle: throttle,
+		email:    emailSender,
+		userUC:   userUC,
+		cfg:      cfg,
+		log:      log,
+	}
+}
+
+// Request emails a sign-in link to address and returns the nonce the
+// caller must keep for Complete. Unknown, unverified and blocked accounts
+// get no email but the same answer, so the response says nothing about
+// whether the address is registered.
+func (uc *MagicLinkUseCase) Request(ctx context.Context, address string) (*MagicLinkNonce, error) {
+	now := time.Now()
+	accountKey := throttleKey(throttleMagicAccount, address)
+	ipKey := throttleKey(throttleMagicIP, ClientInfoFrom(ctx).IP)
+	if err := uc.throttle.Allow(ctx, now, accountKey, ipKey); err != nil {
+		return nil, err
+	}
+	if _, err := uc.throttle.Record(ctx, now, accountKey, uc.throttle.cfg.MaxMagicLinkRequests); err != nil {
+		return nil, err
+	}
+	if _, err := uc.throttle.Record(ctx, now, ipKey, uc.throttle.cfg.MaxMagicLinkIPRequests); err != nil {
+		return nil, err
+	}
+
+	nonce, err := randomToken(16)
+	if err != nil {
+		return nil, err
+	}
+	result := &MagicLinkNonce{Value: nonce, ExpiresAt: now.Add(uc.cfg.TTL)}
+
+	u, err := uc.users.GetByEmail(ctx, address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	switch {
+	case u == nil:
+		uc.log.Info("magic link requested for non-existent email", zap.String("email", address))
+		return result, nil
+	case !u.Verified || !u.CanSignIn(now):
+		uc.log.Info("magic link refused", zap.String("user_id", u.ID))
+		return result, nil
+	}
+
+	token, err := randomToken(32)
+	if err != nil {
+		return nil, err
+	}
+	// only the latest link works
+	if err := uc.tokens.DeleteByUser(ctx, u.ID, domain.PurposeMagicLogin); err != nil {
+		return nil, err
+	}
+	if err := uc.tokens.Create(ctx, &domain.AuthToken{
+		UserID:    u.ID,
+		Purpose:   domain.PurposeMagicLogin,
+		TokenHash: uc.hashToken(token, nonce),
+		ExpiresAt: result.ExpiresAt,
+		CreatedAt: now,
+	}); err != nil {
+		return nil, err
+	}
+
+	link := fmt.Sprintf("%s/login/magic/verify?token=%s", uc.cfg.BaseURL, token)
+	if err := sendTemplate(uc.email, uc.cfg.Templates, u.Email, userLocale(u), "magic_link", map[string]interface{}{
+		"Link":    link,
+		"Minutes": int(uc.cfg.TTL.Minutes()),
+	}); err != nil {
+		uc.log.Error("failed to send magic link email",
+			zap.String("user_id", u.ID),
+			zap.Error(err))
+		return nil, fmt.Errorf("failed to send magic link email: %w", err)
+	}
+	uc.log.Info("magic link sent", zap.String("user_id", u.ID))
+	return result, nil
+}
+
+// Complete exchanges a link token and the nonce of the browser that
+// requested it for the same response Login gives, including the 2FA
+// challenge when the account needs one.
+func (uc *MagicLinkUseCase) Complete(ctx context.Context, token, nonce string) (*LoginResponse, error) {
+	if len(token) != base64.RawURLEncoding.EncodedLen(32) || nonce == "" {
+		return nil, ErrInvalidToken
+	}
+	now := time.Now()
+	t, err := uc.tokens.Consume(ctx, uc.hashToken(token, nonce), domain.PurposeMagicLogin, now)
+	if err != nil {
+		return nil, err
+	}
+	if t == nil {
+		return nil, ErrInvalidToken
+	}
+	u, err := uc.users.GetByID(ctx, t.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if u == nil {
+		return nil, ErrInvalidToken
+	}
+	return uc.userUC.signIn(ctx, u, now)
+}
+
+func (uc *MagicLinkUseCase) hashToken(token, nonce string) string {
+	mac := hmac.New(sha256.New, []byte(uc.cfg.TokenSecret))
+	mac.Write([]byte(token + "." + nonce))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func randomToken(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
@@ -0,0 +1,305 @@
+package usecase
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/HatefBarari/microblog-auth/internal/domain"
+	"github.com/HatefBarari/microblog-shared/pkg/auth"
+	"github.com/golang-jwt/jwt/v5"
+	"go.uber.org/zap"
+)
+
+var ErrOAuthClientNotFound = errors.New("oauth client not found")
+
+// OAuthError is an error response of the token endpoint (RFC 6749 5.2).
+type OAuthError struct {
+	Code        string `json:"error"`
+	Description string `json:"error_description,omitempty"`
+}
+
+func (e *OAuthError) Error() string {
+	if e.Description == "" {
+		return e.Code
+	}
+	return e.Code + ": " + e.Description
+}
+
+var (
+	ErrInvalidClient        = &OAuthError{Code: "invalid_client", Description: "unknown client or wrong secret"}
+	ErrUnsupportedGrantType = &OAuthError{Code: "unsupported_grant_type"}
+)
+
+// clientIDPrefix marks client IDs so they can't be mistaken for user IDs.
+const clientIDPrefix = "mbc_"
+
+type OAuthConfig struct {
+	ClientTokenTTL time.Duration
+}
+
+// OAuthUseCase registers OAuth2 clients, the other services that call this
+// one or each other and the apps that sign users in, issues service tokens
+// and answers introspection requests. The OpenID Connect flows are in
+// OIDCUseCase.
+type OAuthUseCase struct {
+	clients     domain.OAuthClientRepository
+	keys        *auth.KeySet
+	revocations auth.RevocationStore
+	apiKeys     auth.APIKeyResolver
+	cfg         OAuthConfig
+	log         *zap.Logger
+}
+
+func NewOAuthUseCase(clients domain.OAuthClientRepository, keys *auth.KeySet, revocations auth.RevocationStore, apiKeys auth.APIKeyResolver, cfg OAuthConfig, log *zap.Logger) *OAuthUseCase {
+	return &OAuthUseCase{clients: clients, keys: keys, revocations: revocations, apiKeys: apiKeys, cfg: cfg, log: log}
+}
+
+// CreateClient registers a client. The secret is only returned here;
+// afterwards just its hash is kept. Public clients get none.
+func (uc *OAuthUseCase) CreateClient(ctx context.Context, req CreateOAuthClientRequest) (*CreateOAuthClientResponse, error) {
+	for _, s := range req.Scopes {
+		if !slices.Contains(auth.KnownScopes, s) {
+			return nil, fmt.Errorf("unknown scope %q", s)
+		}
+	}
+	for _, u := range req.RedirectURIs {
+		if err := validateRedirectURI(u); err != nil {
+			return nil, err
+		}
+	}
+	if len(req.Scopes) == 0 && len(req.RedirectURIs) == 0 {
+		return nil, errors.New("a client needs scopes, redirect uris or both")
+	}
+	if req.Public && (len(req.RedirectURIs) == 0 || len(req.Scopes) > 0) {
+		return nil, errors.New("public clients can only sign users in and need redirect uris")
+	}
+	c := &domain.OAuthClient{
+		ID:           clientIDPrefix + auth.NewTokenID(),
+		Name:         req.Name,
+		Public:       req.Public,
+		Scopes:       slices.Compact(slices.Sorted(slices.Values(req.Scopes))),
+		RedirectURIs: req.RedirectURIs,
+		CreatedAt:    time.Now(),
+	}
+	var secret string
+	if !c.Public {
+		var err error
+		if secret, err = randomToken(32); err != nil {
+			return nil, err
+		}
+		c.SecretHash = hashSecret(secret)
+	}
+	if err := uc.clients.Create(ctx, c); err != nil {
+		return nil, err
+	}
+	uc.log.Info("oauth client created", zap.String("client_id", c.ID), zap.Strings("scopes", c.Scopes), zap.Strings("redirect_uris", c.RedirectURIs))
+	return &CreateOAuthClientResponse{OAuthClientResponse: *toOAuthClientResponse(c), ClientSecret: secret}, nil
+}
+
+// ListClients returns every client, including revoked ones.
+func (uc *OAuthUseCase) ListClients(ctx context.Context) ([]*OAuthClientResponse, error) {
+	list, err := uc.clients.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	resp := make([]*OAuthClientResponse, len(list))
+	for i, c := range list {
+		resp[i] = toOAuthClientResponse(c)
+	}
+	return resp, nil
+}
+
+// RevokeClient stops the client getting tokens and denies the ones it
+// already has.
+func (uc *OAuthUseCase) RevokeClient(ctx context.Context, id string) error {
+	now := time.Now()
+	ok, err := uc.clients.Revoke(ctx, id, now)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrOAuthClientNotFound
+	}
+	if err := uc.revocations.RevokeUser(ctx, id, now, now.Add(uc.cfg.ClientTokenTTL)); err != nil {
+		return err
+	}
+	uc.log.Info("oauth client revoked", zap.String("client_id", id))
+	return nil
+}
+
+// ClientCredentials implements the client_credentials grant. scope is the
+// space-separated list asked for; empty grants all of the client's scopes.
+func (uc *OAuthUseCase) ClientCredentials(ctx context.Context, clientID, secret, scope string) (*OAuthTokenResponse, error) {
+	c, err := authenticateClient(ctx, uc.clients, clientID, secret)
+	if err != nil {
+		return nil, err
+	}
+	if c.Public || len(c.Scopes) == 0 {
+		return nil, &OAuthError{Code: "unauthorized_client", Description: "client may not use client_credentials"}
+	}
+	scopes := c.Scopes
+	if requested := strings.Fields(scope); len(requested) > 0 {
+		for _, s := range requested {
+			if !slices.Contains(c.Scopes, s) {
+				return nil, &OAuthError{Code: "invalid_scope", Description: fmt.Sprintf("scope %q is not granted to this client", s)}
+			}
+		}
+		scopes = slices.Compact(slices.Sorted(slices.Values(requested)))
+	}
+
+	now := time.Now()
+	token, err := uc.keys.Sign(&auth.Claims{
+		Role:   auth.RoleService,
+		Scopes: scopes,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   c.ID,
+			ID:        auth.NewTokenID(),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(uc.cfg.ClientTokenTTL)),
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := uc.clients.TouchLastUsed(ctx, c.ID, now); err != nil {
+		uc.log.Warn("touch oauth client", zap.String("client_id", c.ID), zap.Error(err))
+	}
+	return &OAuthTokenResponse{
+		AccessToken: token,
+		TokenType:   "Bearer",
+		ExpiresIn:   int(uc.cfg.ClientTokenTTL.Seconds()),
+		Scope:       strings.Join(scopes, " "),
+	}, nil
+}
+
+// Introspect implements token introspection (RFC 7662) for consumers that
+// can't verify tokens themselves. Only confidential clients may ask. Access
+// tokens and API keys are reported with their claims; anything else, and
+// tokens that are expired or revoked, is just inactive, without saying why.
+func (uc *OAuthUseCase) Introspect(ctx context.Context, clientID, secret, token string) (*IntrospectionResponse, error) {
+	c, err := authenticateClient(ctx, uc.clients, clientID, secret)
+	if err != nil {
+		return nil, err
+	}
+	if c.Public {
+		return nil, ErrInvalidClient
+	}
+	claims, err := uc.activeClaims(ctx, token)
+	if err != nil || claims == nil {
+		return &IntrospectionResponse{}, err
+	}
+
+	resp := &IntrospectionResponse{
+		Active:    true,
+		Subject:   claims.UserID,
+		Role:      claims.Role,
+		Scope:     strings.Join(claims.Scopes, " "),
+		SessionID: claims.SessionID,
+		TokenType: "Bearer",
+		Issuer:    claims.Issuer,
+		Audience:  claims.Audience,
+		TokenID:   claims.ID,
+		Actor:     claims.Actor,
+	}
+	if claims.IsClient() {
+		resp.Subject, resp.ClientID = claims.Subject, claims.Subject
+	}
+	if claims.ExpiresAt != nil {
+		resp.ExpiresAt = claims.ExpiresAt.Unix()
+	}
+	if claims.IssuedAt != nil {
+		resp.IssuedAt = claims.IssuedAt.Unix()
+	}
+	return resp, nil
+}
+
+// activeClaims returns the claims of token, or nil when it isn't a usable
+// access token. Like the middleware, API keys are read live from their
+// store and JWTs are checked against the revocation store.
+func (uc *OAuthUseCase) activeClaims(ctx context.Context, token string) (*auth.Claims, error) {
+	if auth.IsAPIKey(token) {
+		claims, err := uc.apiKeys.ResolveAPIKey(ctx, token)
+		if errors.Is(err, auth.ErrInvalidAPIKey) {
+			return nil, nil
+		}
+		return claims, err
+	}
+	claims, err := uc.keys.Verify(token)
+	// ID tokens verify too, but only say who signed in; they aren't
+	// credentials
+	if err != nil || claims.AuthTime != nil {
+		return nil, nil
+	}
+	revoked, err := uc.revocations.IsRevoked(ctx, claims)
+	if err != nil || revoked {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// authenticateClient checks a client's secret. Public clients have none and
+// are identified by their ID alone; callers decide whether that is enough.
+func authenticateClient(ctx context.Context, clients domain.OAuthClientRepository, clientID, secret string) (*domain.OAuthClient, error) {
+	if clientID == "" {
+		return nil, ErrInvalidClient
+	}
+	c, err := clients.GetByID(ctx, clientID)
+	if err != nil {
+		return nil, err
+	}
+	if c == nil || c.RevokedAt != nil {
+		return nil, ErrInvalidClient
+	}
+	if c.Public {
+		if secret != "" {
+			return nil, ErrInvalidClient
+		}
+		return c, nil
+	}
+	if secret == "" || subtle.ConstantTimeCompare([]byte(hashSecret(secret)), []byte(c.SecretHash)) != 1 {
+		return nil, ErrInvalidClient
+	}
+	return c, nil
+}
+
+// validateRedirectURI accepts absolute https URLs, and plain http only on
+// the loopback interface for local development.
+func validateRedirectURI(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" || u.Fragment != "" {
+		return fmt.Errorf("invalid redirect uri %q", raw)
+	}
+	switch {
+	case u.Scheme == "https":
+	case u.Scheme == "http" && (u.Hostname() == "localhost" || u.Hostname() == "127.0.0.1"):
+	default:
+		return fmt.Errorf("redirect uri %q must use https", raw)
+	}
+	return nil
+}
+
+func hashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+func toOAuthClientResponse(c *domain.OAuthClient) *OAuthClientResponse {
+	return &OAuthClientResponse{
+		ClientID:     c.ID,
+		Name:         c.Name,
+		Public:       c.Public,
+		Scopes:       c.Scopes,
+		RedirectURIs: c.RedirectURIs,
+		CreatedAt:    c.CreatedAt,
+		LastUsedAt:   c.LastUsedAt,
+		RevokedAt:    c.RevokedAt,
+	}
+}
@@ -0,0 +1,348 @@
+package usecase
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/HatefBarari/microblog-auth/internal/domain"
+	"github.com/HatefBarari/microblog-shared/pkg/auth"
+	"github.com/golang-jwt/jwt/v5"
+	"go.uber.org/zap"
+)
+
+// ErrInvalidAuthorizeRequest means the client or its redirect URI can't be
+// trusted, so the error is shown to the user rather than sent back to the
+// client.
+var ErrInvalidAuthorizeRequest = errors.New("unknown client or redirect uri")
+
+// OpenID Connect scopes an app may ask for.
+const (
+	ScopeOpenID  = "openid"
+	ScopeProfile = "profile"
+	ScopeEmail   = "email"
+)
+
+var oidcScopes = []string{ScopeOpenID, ScopeProfile, ScopeEmail}
+
+type OIDCConfig struct {
+	// Issuer is the public base URL of this service and the iss of its
+	// tokens.
+	Issuer  string
+	CodeTTL time.Duration
+	// TokenTTL applies to both access and ID tokens.
+	TokenTTL time.Duration
+}
+
+// OIDCUseCase lets registered apps sign users in with the authorization
+// code flow and PKCE. Users authenticate with the same checks as /login,
+// 2FA included, and each sign-in starts a session they can see and revoke.
+type OIDCUseCase struct {
+	clients domain.OAuthClientRepository
+	codes   domain.AuthorizationCodeRepository
+	users   domain.UserRepository
+	userUC  *UserUseCase
+	keys    *auth.KeySet
+	cfg     OIDCConfig
+	log     *zap.Logger
+}
+
+func NewOIDCUseCase(clients domain.OAuthClientRepository, codes domain.AuthorizationCodeRepository, users domain.UserRepository, userUC *UserUseCase, keys *auth.KeySet, cfg OIDCConfig, log *zap.Logger) *OIDCUseCase {
+	return &OIDCUseCase{clients: clients, codes: codes, users: users, userUC: userUC, keys: keys, cfg: cfg, log: log}
+}
+
+// UserInfoAudience is the aud of access tokens issued to apps. Only
+// /userinfo accepts them; they are refused everywhere else.
+func (uc *OIDCUseCase) UserInfoAudience() string {
+	return uc.cfg.Issuer + "/userinfo"
+}
+
+// Discovery is the /.well-known/openid-configuration document.
+func (uc *OIDCUseCase) Discovery() *OIDCDiscovery {
+	var algs []string
+	for _, k := range uc.keys.JWKS().Keys {
+		if !slices.Contains(algs, k.Alg) {
+			algs = append(algs, k.Alg)
+		}
+	}
+	return &OIDCDiscovery{
+		Issuer:                            uc.cfg.Issuer,
+		AuthorizationEndpoint:             uc.cfg.Issuer + "/oauth/authorize",
+		TokenEndpoint:                     uc.cfg.Issuer + "/oauth/token",
+		IntrospectionEndpoint:             uc.cfg.Issuer + "/oauth/introspect",
+		UserInfoEndpoint:                  uc.cfg.Issuer + "/userinfo",
+		JWKSURI:                           uc.cfg.Issuer + "/.well-known/jwks.json",
+		ResponseTypesSupported:            []string{"code"},
+		GrantTypesSupported:               []string{"authorization_code", "client_credentials"},
+		SubjectTypesSupported:             []string{"public"},
+		IDTokenSigningAlgValuesSupported:  algs,
+		ScopesSupported:                   oidcScopes,
+		TokenEndpointAuthMethodsSupported: []string{"client_secret_basic", "client_secret_post", "none"},
+		CodeChallengeMethodsSupported:     []string{"S256"},
+		ClaimsSupported: []string{
+			"iss", "sub", "aud", "exp", "iat", "auth_time", "nonce", "sid", "role",
+			"name", "preferred_username", "locale", "email", "email_verified",
+		},
+	}
+}
+
+// Authorize checks an authorization request. It returns the consent screen
+// to show, or a redirect carrying the error when the request is invalid
+// but the client and redirect URI are known.
+func (uc *OIDCUseCase) Authorize(ctx context.Context, req AuthorizeRequest) (*AuthorizeResult, error) {
+	c, scopes, redirect, err := uc.checkAuthorize(ctx, req)
+	if err != nil || redirect != nil {
+		return redirect, err
+	}
+	return &AuthorizeResult{Consent: &ConsentScreen{ClientName: c.Name, Scopes: scopes, Request: req}}, nil
+}
+
+// Approve signs the user in with the credentials from the consent screen
+// and, once they are accepted, sends them back to the app with a code. A
+// denial sends them back with access_denied.
+func (uc *OIDCUseCase) Approve(ctx context.Context, req AuthorizeRequest, form ConsentForm) (*AuthorizeResult, error) {
+	c, scopes, redirect, err := uc.checkAuthorize(ctx, req)
+	if err != nil || redirect != nil {
+		return redirect, err
+	}
+	if !form.Approve {
+		return authorizeError(req, &OAuthError{Code: "access_denied", Description: "the user declined"}), nil
+	}
+	screen := &ConsentScreen{ClientName: c.Name, Scopes: scopes, Request: req}
+
+	// the session is named after the app in the user's session list
+	info := ClientInfoFrom(ctx)
+	info.Device = c.Name
+	ctx = WithClientInfo(ctx, info)
+	var login *LoginResponse
+	if form.ChallengeToken != "" {
+		login, err = uc.userUC.LoginTwoFactor(ctx, form.ChallengeToken, form.Code)
+	} else {
+		login, err = uc.userUC.Login(ctx, LoginRequest{Email: form.Email, Password: form.Password})
+	}
+	switch {
+	case err != nil:
+		if isSignInError(err) {
+			screen.Err = err
+			return &AuthorizeResult{Consent: screen}, nil
+		}
+		return nil, err
+	case login.TwoFactorSetupRequired:
+		screen.Err = ErrTwoFactorRequired
+		return &AuthorizeResult{Consent: screen}, nil
+	case login.TwoFactorRequired:
+		screen.ChallengeToken = login.ChallengeToken
+		return &AuthorizeResult{Consent: screen}, nil
+	}
+
+	session, err := uc.keys.Verify(login.AccessToken)
+	if err != nil {
+		return nil, err
+	}
+	code, err := randomToken(32)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if err := uc.codes.Create(ctx, &domain.AuthorizationCode{
+		CodeHash:      hashSecret(code),
+		ClientID:      c.ID,
+		UserID:        session.UserID,
+		SessionID:     session.SessionID,
+		RedirectURI:   req.RedirectURI,
+		Scopes:        scopes,
+		Nonce:         req.Nonce,
+		CodeChallenge: req.CodeChallenge,
+		AuthTime:      now,
+		ExpiresAt:     now.Add(uc.cfg.CodeTTL),
+	}); err != nil {
+		return nil, err
+	}
+	uc.log.Info("oidc sign-in", zap.String("client_id", c.ID), zap.String("user_id", session.UserID))
+	params := url.Values{"code": {code}}
+	if req.State != "" {
+		params.Set("state", req.State)
+	}
+	return &AuthorizeResult{RedirectURL: withQuery(req.RedirectURI, params)}, nil
+}
+
+// ExchangeCode implements the authorization_code grant, returning an access
+// token for /userinfo and an ID token.
+func (uc *OIDCUseCase) ExchangeCode(ctx context.Context, clientID, secret, code, redirectURI, verifier string) (*OAuthTokenResponse, error) {
+	c, err := authenticateClient(ctx, uc.clients, clientID, secret)
+	if err != nil {
+		return nil, err
+	}
+	invalidGrant := &OAuthError{Code: "invalid_grant", Description: "invalid, expired or used code"}
+	now := time.Now()
+	ac, err := uc.codes.Consume(ctx, hashSecret(code), now)
+	if err != nil {
+		return nil, err
+	}
+	if ac == nil || ac.ClientID != c.ID || ac.RedirectURI != redirectURI {
+		return nil, invalidGrant
+	}
+	sum := sha256.Sum256([]byte(verifier))
+	challenge := base64.RawURLEncoding.EncodeToString(sum[:])
+	if subtle.ConstantTimeCompare([]byte(challenge), []byte(ac.CodeChallenge)) != 1 {
+		return nil, &OAuthError{Code: "invalid_grant", Description: "code_verifier does not match"}
+	}
+	u, err := uc.users.GetByID(ctx, ac.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if u == nil || !u.CanSignIn(now) {
+		return nil, invalidGrant
+	}
+
+	expires := jwt.NewNumericDate(now.Add(uc.cfg.TokenTTL))
+	access, err := uc.keys.Sign(&auth.Claims{
+		UserID:    u.ID,
+		Role:      string(u.Role),
+		SessionID: ac.SessionID,
+		Scopes:    ac.Scopes,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    uc.cfg.Issuer,
+			Subject:   u.ID,
+			Audience:  jwt.ClaimStrings{uc.UserInfoAudience()},
+			ID:        auth.NewTokenID(),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: expires,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	idToken, err := uc.keys.Sign(&auth.Claims{
+		UserID:    u.ID,
+		Role:      string(u.Role),
+		SessionID: ac.SessionID,
+		Nonce:     ac.Nonce,
+		AuthTime:  jwt.NewNumericDate(ac.AuthTime),
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    uc.cfg.Issuer,
+			Subject:   u.ID,
+			Audience:  jwt.ClaimStrings{c.ID},
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: expires,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := uc.clients.TouchLastUsed(ctx, c.ID, now); err != nil {
+		uc.log.Warn("touch oauth client", zap.String("client_id", c.ID), zap.Error(err))
+	}
+	return &OAuthTokenResponse{
+		AccessToken: access,
+		TokenType:   "Bearer",
+		ExpiresIn:   int(uc.cfg.TokenTTL.Seconds()),
+		Scope:       strings.Join(ac.Scopes, " "),
+		IDToken:     idToken,
+	}, nil
+}
+
+// UserInfo returns the claims about the user that scopes allow.
+func (uc *OIDCUseCase) UserInfo(ctx context.Context, userID string, scopes []string) (map[string]interface{}, error) {
+	u, err := uc.users.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if u == nil {
+		return nil, domain.ErrUserNotFound
+	}
+	info := map[string]interface{}{"sub": u.ID}
+	if slices.Contains(scopes, ScopeProfile) {
+		info["updated_at"] = u.UpdatedAt.Unix()
+		if p := u.Profile; p != nil {
+			info["name"] = p.DisplayName
+			if p.Handle != "" {
+				info["preferred_username"] = p.Handle
+			}
+			if p.Locale != "" {
+				info["locale"] = p.Locale
+			}
+		}
+	}
+	if slices.Contains(scopes, ScopeEmail) {
+		info["email"] = u.Email
+		info["email_verified"] = u.Verified
+	}
+	return info, nil
+}
+
+// checkAuthorize validates req. A client or redirect URI that can't be
+// trusted is an error; anything else wrong with the request comes back as
+// a redirect to the client.
+func (uc *OIDCUseCase) checkAuthorize(ctx context.Context, req AuthorizeRequest) (*domain.OAuthClient, []string, *AuthorizeResult, error) {
+	c, err := uc.clients.GetByID(ctx, req.ClientID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if c == nil || c.RevokedAt != nil || !slices.Contains(c.RedirectURIs, req.RedirectURI) {
+		return nil, nil, nil, ErrInvalidAuthorizeRequest
+	}
+	if req.ResponseType != "code" {
+		return nil, nil, authorizeError(req, &OAuthError{Code: "unsupported_response_type", Description: "only code is supported"}), nil
+	}
+	scopes := slices.Compact(slices.Sorted(slices.Values(strings.Fields(req.Scope))))
+	if !slices.Contains(scopes, ScopeOpenID) {
+		return nil, nil, authorizeError(req, &OAuthError{Code: "invalid_scope", Description: "openid scope is required"}), nil
+	}
+	for _, s := range scopes {
+		if !slices.Contains(oidcScopes, s) {
+			return nil, nil, authorizeError(req, &OAuthError{Code: "invalid_scope", Description: fmt.Sprintf("unknown scope %q", s)}), nil
+		}
+	}
+	if req.CodeChallenge == "" || req.CodeChallengeMethod != "S256" {
+		return nil, nil, authorizeError(req, &OAuthError{Code: "invalid_request", Description: "PKCE with S256 is required"}), nil
+	}
+	return c, scopes, nil, nil
+}
+
+// isSignInError reports whether err is the user's to fix on the consent
+// screen rather than a failure of the service.
+func isSignInError(err error) bool {
+	for _, target := range []error{
+		ErrInvalidCredentials, ErrAccountNotVerified, ErrAccountSuspended,
+		ErrTooManyAttempts, ErrInvalidChallenge, ErrInvalidTwoFactorCode,
+	} {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+func authorizeError(req AuthorizeRequest, e *OAuthError) *AuthorizeResult {
+	params := url.Values{"error": {e.Code}}
+	if e.Description != "" {
+		params.Set("error_description", e.Description)
+	}
+	if req.State != "" {
+		params.Set("state", req.State)
+	}
+	return &AuthorizeResult{RedirectURL: withQuery(req.RedirectURI, params)}
+}
+
+// withQuery adds params to a registered redirect URI, keeping any query it
+// already has.
+func withQuery(raw string, params url.Values) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return raw
+	}
+	q := u.Query()
+	for k, v := range params {
+		q[k] = v
+	}
+	u.RawQuery = q.Encode()
+	return u.String()
+}
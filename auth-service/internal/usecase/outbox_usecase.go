@@ -0,0 +1,218 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/HatefBarari/microblog-auth/internal/domain"
+	"github.com/HatefBarari/microblog-shared/pkg/email"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrOutboxNotFound  = errors.New("email not found")
+	ErrOutboxNotFailed = errors.New("email has not failed")
+)
+
+const (
+	// how long queueing a message may take; Send has no context of its own
+	enqueueTimeout = 5 * time.Second
+	// how long a message being sent is hidden from other dispatchers. One
+	// that dies mid-send leaves it to be retried after that, so delivery is
+	// at least once.
+	outboxLease = 5 * time.Minute
+)
+
+type OutboxConfig struct {
+	// MaxAttempts is how many times a message is tried before it is
+	// marked dead.
+	MaxAttempts int
+	// BaseDelay doubles after each failed attempt, up to MaxDelay.
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+}
+
+// OutboxUseCase queues outgoing email in the database and delivers it in
+// the background, retrying failures. It satisfies EmailSender and
+// MessageSender, so use cases hand it their mail instead of the SMTP sender.
+type OutboxUseCase struct {
+	repo   domain.EmailOutboxRepository
+	sender MessageSender
+	cfg    OutboxConfig
+	log    *zap.Logger
+	wake   chan struct{}
+}
+
+func NewOutboxUseCase(repo domain.EmailOutboxRepository, sender MessageSender, cfg OutboxConfig, log *zap.Logger) *OutboxUseCase {
+	return &OutboxUseCase{
+		repo:   repo,
+		sender: sender,
+		cfg:    cfg,
+		log:    log,
+		wake:   make(chan struct{}, 1),
+	}
+}
+
+// Send queues a plain-text email.
+func (uc *OutboxUseCase) Send(to, subject, body string) error {
+	return uc.SendMessage(&email.Message{To: to, Subject: subject, Text: body})
+}
+
+// SendMessage queues m; an error means it was not stored.
+func (uc *OutboxUseCase) SendMessage(m *email.Message) error {
+	ctx, cancel := context.WithTimeout(context.Background(), enqueueTimeout)
+	defer cancel()
+	now := time.Now()
+	if err := uc.repo.Create(ctx, &domain.OutboxEmail{
+		To:            m.To,
+		Subject:       m.Subject,
+		Text:          m.Text,
+		HTML:          m.HTML,
+		Status:        domain.OutboxPending,
+		NextAttemptAt: now,
+		CreatedAt:     now,
+	}); err != nil {
+		return err
+	}
+	// let Run deliver it now rather than at the next tick
+	select {
+	case uc.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// DispatchDue sends every message whose attempt is due and returns how many
+// were delivered.
+func (uc *OutboxUseCase) DispatchDue(ctx context.Context, now time.Time) (int, error) {
+	sent := 0
+	for {
+		m, err := uc.repo.ClaimDue(ctx, now, now.Add(outboxLease))
+		if err != nil {
+			return sent, err
+		}
+		if m == nil {
+			return sent, nil
+		}
+		if uc.deliver(ctx, m, now) {
+			sent++
+		}
+	}
+}
+
+// Run calls DispatchDue every interval, and whenever a message is queued,
+// until ctx is done.
+func (uc *OutboxUseCase) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if _, err := uc.DispatchDue(ctx, time.Now()); err != nil {
+			uc.log.Error("dispatch email outbox", zap.Error(err))
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-uc.wake:
+		}
+	}
+}
+
+func (uc *OutboxUseCase) deliver(ctx context.Context, m *domain.OutboxEmail, now time.Time) bool {
+	err := uc.sender.SendMessage(&email.Message{To: m.To, Subject: m.Subject, Text: m.Text, HTML: m.HTML})
+	if err == nil {
+		if err := uc.repo.MarkSent(ctx, m.ID, time.Now()); err != nil {
+			uc.log.Error("mark email sent", zap.String("email_id", m.ID), zap.Error(err))
+		}
+		return true
+	}
+
+	status, next := domain.OutboxPending, now.Add(uc.backoff(m.Attempts))
+	if m.Attempts >= uc.cfg.MaxAttempts {
+		status = domain.OutboxDead
+	}
+	uc.log.Warn("email delivery failed",
+		zap.String("email_id", m.ID),
+		zap.Int("attempts", m.Attempts),
+		zap.String("status", string(status)),
+		zap.Error(err))
+	if err := uc.repo.MarkFailed(ctx, m.ID, status, next, err.Error()); err != nil {
+		uc.log.Error("mark email failed", zap.String("email_id", m.ID), zap.Error(err))
+	}
+	return false
+}
+
+// backoff is the wait before the attempt after the given one.
+func (uc *OutboxUseCase) backoff(attempts int) time.Duration {
+	d := uc.cfg.BaseDelay
+	for i := 1; i < attempts && d < uc.cfg.MaxDelay; i++ {
+		d *= 2
+	}
+	if uc.cfg.MaxDelay > 0 && d > uc.cfg.MaxDelay {
+		d = uc.cfg.MaxDelay
+	}
+	return d
+}
+
+// List shows queued and past messages without their bodies, which may hold
+// sign-in or reset links.
+func (uc *OutboxUseCase) List(ctx context.Context, req ListOutboxRequest) (*OutboxListResponse, error) {
+	filter := domain.OutboxFilter{
+		Status: domain.OutboxStatus(req.Status),
+		To:     req.To,
+	}
+	filter.Page, filter.PageSize = pageBounds(req.Page, req.PageSize)
+	list, total, err := uc.repo.List(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	resp := &OutboxListResponse{
+		Items:    make([]*OutboxEmailResponse, len(list)),
+		Total:    total,
+		Page:     filter.Page,
+		PageSize: filter.PageSize,
+	}
+	for i, m := range list {
+		resp.Items[i] = toOutboxEmail(m)
+	}
+	return resp, nil
+}
+
+// Resend gives a dead message a fresh set of attempts.
+func (uc *OutboxUseCase) Resend(ctx context.Context, id string) (*OutboxEmailResponse, error) {
+	ok, err := uc.repo.Requeue(ctx, id, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	m, err := uc.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if m == nil {
+		return nil, ErrOutboxNotFound
+	}
+	if !ok {
+		return nil, ErrOutboxNotFailed
+	}
+	select {
+	case uc.wake <- struct{}{}:
+	default:
+	}
+	uc.log.Info("email requeued", zap.String("email_id", id))
+	return toOutboxEmail(m), nil
+}
+
+func toOutboxEmail(m *domain.OutboxEmail) *OutboxEmailResponse {
+	return &OutboxEmailResponse{
+		ID:            m.ID,
+		To:            m.To,
+		Subject:       m.Subject,
+		Status:        string(m.Status),
+		Attempts:      m.Attempts,
+		NextAttemptAt: m.NextAttemptAt,
+		LastError:     m.LastError,
+		CreatedAt:     m.CreatedAt,
+		SentAt:        m.SentAt,
+	}
+}
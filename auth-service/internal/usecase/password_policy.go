@@ -0,0 +1,111 @@
+package usecase
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/HatefBarari/microblog-shared/pkg/auth"
+)
+
+var (
+	ErrPasswordTooShort = errors.New("password is too short")
+	ErrPasswordTooLong  = errors.New("password is too long")
+	ErrPasswordBreached = errors.New("password appears in a list of breached passwords")
+	ErrPasswordReused   = errors.New("password was used recently")
+)
+
+type PasswordPolicyConfig struct {
+	MinLength int
+	MaxLength int
+	// BreachedListFile has one known-breached password per line; blank
+	// lines and lines starting with # are skipped. Empty disables the check.
+	BreachedListFile string
+	// HistorySize is how many recent passwords, the current one included,
+	// cannot be chosen again. Zero allows any.
+	HistorySize int
+}
+
+// PasswordPolicy decides which new passwords are acceptable.
+type PasswordPolicy struct {
+	cfg      PasswordPolicyConfig
+	breached map[string]struct{}
+}
+
+func NewPasswordPolicy(cfg PasswordPolicyConfig) (*PasswordPolicy, error) {
+	p := &PasswordPolicy{cfg: cfg, breached: map[string]struct{}{}}
+	if cfg.BreachedListFile == "" {
+		return p, nil
+	}
+	f, err := os.Open(cfg.BreachedListFile)
+	if err != nil {
+		return nil, fmt.Errorf("open breached password list: %w", err)
+	}
+	defer f.Close()
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		p.breached[strings.ToLower(line)] = struct{}{}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("read breached password list: %w", err)
+	}
+	return p, nil
+}
+
+// Validate checks the rules that don't depend on the account.
+func (p *PasswordPolicy) Validate(password string) error {
+	n := utf8.RuneCountInString(password)
+	if p.cfg.MinLength > 0 && n < p.cfg.MinLength {
+		return fmt.Errorf("%w: at least %d characters", ErrPasswordTooShort, p.cfg.MinLength)
+	}
+	if p.cfg.MaxLength > 0 && n > p.cfg.MaxLength {
+		return fmt.Errorf("%w: at most %d characters", ErrPasswordTooLong, p.cfg.MaxLength)
+	}
+	if _, ok := p.breached[strings.ToLower(password)]; ok {
+		return ErrPasswordBreached
+	}
+	return nil
+}
+
+// CheckReuse refuses password if it matches the current hash or one of the
+// recent ones in history.
+func (p *PasswordPolicy) CheckReuse(password, current string, history []string) error {
+	for _, hash := range p.recent(current, history) {
+		if auth.CheckPassword(hash, password) {
+			return ErrPasswordReused
+		}
+	}
+	return nil
+}
+
+// History returns the history to store once current is replaced.
+func (p *PasswordPolicy) History(current string, history []string) []string {
+	recent := p.recent(current, history)
+	if len(recent) == 0 {
+		return nil
+	}
+	// the new hash becomes current, so one fewer is kept
+	if len(recent) == p.cfg.HistorySize {
+		recent = recent[:len(recent)-1]
+	}
+	return recent
+}
+
+// recent is the current hash followed by history, cut to HistorySize.
+func (p *PasswordPolicy) recent(current string, history []string) []string {
+	if p.cfg.HistorySize <= 0 {
+		return nil
+	}
+	all := append([]string{current}, history...)
+	if len(all) > p.cfg.HistorySize {
+		all = all[:p.cfg.HistorySize]
+	}
+	return all
+}
@@ -0,0 +1,142 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/HatefBarari/microblog-auth/internal/domain"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrProfileNotFound = errors.New("profile not found")
+	ErrInvalidHandle   = errors.New("handle may only contain a-z, 0-9 and _, 3 to 30 characters")
+	ErrTooManyAuthors  = errors.New("too many ids")
+	ErrInvalidLink     = errors.New("links must be http or https URLs")
+)
+
+// MaxAuthorBatch bounds one Authors lookup.
+const MaxAuthorBatch = 100
+
+var handlePattern = regexp.MustCompile(`^[a-z0-9_]{3,30}$`)
+
+// ProfileUseCase manages the public profile of an account and resolves
+// author summaries for other services.
+type ProfileUseCase struct {
+	users domain.UserRepository
+	log   *zap.Logger
+}
+
+func NewProfileUseCase(users domain.UserRepository, log *zap.Logger) *ProfileUseCase {
+	return &ProfileUseCase{users: users, log: log}
+}
+
+// Get returns the caller's own profile. Accounts without one get an empty
+// profile rather than an error.
+func (uc *ProfileUseCase) Get(ctx context.Context, userID string) (*ProfileResponse, error) {
+	u, err := uc.users.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if u == nil {
+		return nil, domain.ErrUserNotFound
+	}
+	return toProfileResponse(u), nil
+}
+
+// Update replaces the caller's profile.
+func (uc *ProfileUseCase) Update(ctx context.Context, userID string, req UpdateProfileRequest) (*ProfileResponse, error) {
+	handle := strings.ToLower(strings.TrimPrefix(strings.TrimSpace(req.Handle), "@"))
+	if handle != "" && !handlePattern.MatchString(handle) {
+		return nil, ErrInvalidHandle
+	}
+	p := &domain.Profile{
+		DisplayName:   strings.TrimSpace(req.DisplayName),
+		Handle:        handle,
+		Bio:           strings.TrimSpace(req.Bio),
+		AvatarMediaID: req.AvatarMediaID,
+		Locale:        req.Locale,
+	}
+	for _, l := range req.Links {
+		// only web links; no javascript: or data: URLs on public pages
+		u, err := url.Parse(l.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			return nil, ErrInvalidLink
+		}
+		p.Links = append(p.Links, domain.ProfileLink{Label: l.Label, URL: l.URL})
+	}
+	if err := uc.users.SetProfile(ctx, userID, p); err != nil {
+		return nil, err
+	}
+	u, err := uc.users.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if u == nil {
+		return nil, domain.ErrUserNotFound
+	}
+	return toProfileResponse(u), nil
+}
+
+// GetByHandle returns the public profile behind a handle. Banned and
+// suspended accounts are hidden.
+func (uc *ProfileUseCase) GetByHandle(ctx context.Context, handle string) (*ProfileResponse, error) {
+	handle = strings.ToLower(strings.TrimPrefix(handle, "@"))
+	if !handlePattern.MatchString(handle) {
+		return nil, ErrProfileNotFound
+	}
+	u, err := uc.users.GetByHandle(ctx, handle)
+	if err != nil {
+		return nil, err
+	}
+	if u == nil || !u.CanSignIn(time.Now()) {
+		return nil, ErrProfileNotFound
+	}
+	return toProfileResponse(u), nil
+}
+
+// Authors resolves summaries for up to MaxAuthorBatch user IDs. Unknown IDs
+// are left out; hidden accounts come back without profile details.
+func (uc *ProfileUseCase) Authors(ctx context.Context, ids []string) ([]*AuthorSummary, error) {
+	if len(ids) > MaxAuthorBatch {
+		return nil, ErrTooManyAuthors
+	}
+	if len(ids) == 0 {
+		return []*AuthorSummary{}, nil
+	}
+	users, err := uc.users.GetByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	resp := make([]*AuthorSummary, 0, len(users))
+	for _, u := range users {
+		s := &AuthorSummary{ID: u.ID}
+		if u.Profile != nil && u.CanSignIn(now) {
+			s.DisplayName = u.Profile.DisplayName
+			s.Handle = u.Profile.Handle
+			s.AvatarMediaID = u.Profile.AvatarMediaID
+		}
+		resp = append(resp, s)
+	}
+	return resp, nil
+}
+
+func toProfileResponse(u *domain.User) *ProfileResponse {
+	resp := &ProfileResponse{UserID: u.ID, JoinedAt: u.CreatedAt}
+	if p := u.Profile; p != nil {
+		resp.DisplayName = p.DisplayName
+		resp.Handle = p.Handle
+		resp.Bio = p.Bio
+		resp.AvatarMediaID = p.AvatarMediaID
+		resp.Locale = p.Locale
+		for _, l := range p.Links {
+			resp.Links = append(resp.Links, ProfileLinkDTO{Label: l.Label, URL: l.URL})
+		}
+	}
+	return resp
+}
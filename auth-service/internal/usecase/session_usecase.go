@@ -0,0 +1,107 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/HatefBarari/microblog-auth/internal/domain"
+	"github.com/HatefBarari/microblog-shared/pkg/auth"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrSessionNotFound = errors.New("session not found")
+	ErrNoSession       = errors.New("token is not bound to a session")
+)
+
+// SessionUseCase lets users inspect and end their own sessions. Ending a
+// session also revokes the access tokens already issued for it.
+type SessionUseCase struct {
+	sessions    domain.SessionRepository
+	revocations auth.RevocationStore
+	accessTTL   time.Duration
+	log         *zap.Logger
+}
+
+func NewSessionUseCase(sessions domain.SessionRepository, revocations auth.RevocationStore, accessTTL time.Duration, log *zap.Logger) *SessionUseCase {
+	return &SessionUseCase{sessions: sessions, revocations: revocations, accessTTL: accessTTL, log: log}
+}
+
+// Logout ends the session the current access token belongs to.
+func (uc *SessionUseCase) Logout(ctx context.Context, userID, sessionID string) error {
+	if sessionID == "" {
+		return ErrNoSession
+	}
+	return uc.revokeOwned(ctx, userID, sessionID, domain.RevokeReasonLogout)
+}
+
+// LogoutAll ends every session of the user, including the current one.
+func (uc *SessionUseCase) LogoutAll(ctx context.Context, userID string) (int, error) {
+	return uc.RevokeAll(ctx, userID, domain.RevokeReasonLogoutAll)
+}
+
+// RevokeAll ends every session and outstanding access token of the user,
+// recording reason on the sessions.
+func (uc *SessionUseCase) RevokeAll(ctx context.Context, userID, reason string) (int, error) {
+	now := time.Now()
+	n, err := uc.sessions.RevokeAllByUser(ctx, userID, reason, now)
+	if err != nil {
+		return 0, err
+	}
+	if err := uc.revocations.RevokeUser(ctx, userID, now, now.Add(uc.accessTTL)); err != nil {
+		return 0, err
+	}
+	uc.log.Info("all sessions revoked", zap.String("user_id", userID), zap.String("reason", reason), zap.Int("count", n))
+	return n, nil
+}
+
+// List returns the user's active sessions, flagging the one in use.
+func (uc *SessionUseCase) List(ctx context.Context, userID, currentSessionID string) ([]*SessionResponse, error) {
+	list, err := uc.sessions.ListActiveByUser(ctx, userID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	resp := make([]*SessionResponse, len(list))
+	for i, s := range list {
+		resp[i] = &SessionResponse{
+			ID:         s.ID,
+			Device:     s.Device,
+			IP:         s.IP,
+			UserAgent:  s.UserAgent,
+			CreatedAt:  s.CreatedAt,
+			LastUsedAt: s.LastUsedAt,
+			ExpiresAt:  s.ExpiresAt,
+			Current:    s.ID == currentSessionID,
+		}
+	}
+	return resp, nil
+}
+
+// Revoke ends one of the user's sessions by ID.
+func (uc *SessionUseCase) Revoke(ctx context.Context, userID, sessionID string) error {
+	return uc.revokeOwned(ctx, userID, sessionID, domain.RevokeReasonByUser)
+}
+
+func (uc *SessionUseCase) revokeOwned(ctx context.Context, userID, sessionID, reason string) error {
+	now := time.Now()
+	s, err := uc.sessions.GetByID(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+	// don't reveal other users' sessions
+	if s == nil || s.UserID != userID || !s.Active(now) {
+		return ErrSessionNotFound
+	}
+	if err := uc.sessions.Revoke(ctx, s.ID, reason, now); err != nil {
+		return err
+	}
+	if err := uc.revocations.RevokeToken(ctx, s.ID, s.ExpiresAt); err != nil {
+		return err
+	}
+	uc.log.Info("session revoked",
+		zap.String("user_id", userID),
+		zap.String("session_id", s.ID),
+		zap.String("reason", reason))
+	return nil
+}
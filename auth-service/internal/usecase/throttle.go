@@ -0,0 +1,145 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/HatefBarari/microblog-auth/internal/domain"
+	"go.uber.org/zap"
+)
+
+type ThrottleConfig struct {
+	// Window is how long a counter survives without new events.
+	Window time.Duration
+	// BaseDelay doubles with each counted event, up to MaxDelay, before
+	// the next attempt on the same key is accepted.
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// Lockout is how long a key stays locked once it reaches its limit.
+	Lockout time.Duration
+
+	MaxAccountFailures     int
+	MaxIPFailures          int
+	MaxResetRequests       int // per account
+	MaxResetIPRequests     int
+	MaxMagicLinkRequests   int // per account
+	MaxMagicLinkIPRequests int
+}
+
+var ErrTooManyAttempts = errors.New("too many attempts, try again later")
+
+// RetryError wraps ErrTooManyAttempts with the time left until the next
+// attempt is accepted.
+type RetryError struct {
+	RetryAfter time.Duration
+	Locked     bool
+}
+
+func (e *RetryError) Error() string {
+	return fmt.Sprintf("%s (retry after %s)", ErrTooManyAttempts, e.RetryAfter.Round(time.Second))
+}
+
+func (e *RetryError) Unwrap() error { return ErrTooManyAttempts }
+
+// Throttler applies exponential backoff and temporary lockout to keys such
+// as an account or an IP address.
+type Throttler struct {
+	store domain.AttemptRepository
+	cfg   ThrottleConfig
+	log   *zap.Logger
+}
+
+func NewThrottler(store domain.AttemptRepository, cfg ThrottleConfig, log *zap.Logger) *Throttler {
+	return &Throttler{store: store, cfg: cfg, log: log}
+}
+
+// Allow returns a *RetryError if any key is locked or still backing off.
+// Empty keys are ignored.
+func (t *Throttler) Allow(ctx context.Context, now time.Time, keys ...string) error {
+	for _, key := range keys {
+		if key == "" {
+			continue
+		}
+		a, err := t.store.Get(ctx, key, now)
+		if err != nil {
+			return err
+		}
+		if a == nil {
+			continue
+		}
+		if a.Locked(now) {
+			return &RetryError{RetryAfter: a.LockedUntil.Sub(now), Locked: true}
+		}
+		if wait := a.Last.Add(t.delay(a.Count)).Sub(now); wait > 0 {
+			return &RetryError{RetryAfter: wait}
+		}
+	}
+	return nil
+}
+
+// Record counts an event against key and locks it once limit is reached.
+// It reports whether this event caused the lock.
+func (t *Throttler) Record(ctx context.Context, now time.Time, key string, limit int) (bool, error) {
+	if key == "" {
+		return false, nil
+	}
+	a, err := t.store.Increment(ctx, key, now, t.cfg.Window)
+	if err != nil {
+		return false, err
+	}
+	if limit <= 0 || a.Count < limit || a.Locked(now) {
+		return false, nil
+	}
+	if err := t.store.Lock(ctx, key, now.Add(t.cfg.Lockout)); err != nil {
+		return false, err
+	}
+	t.log.Warn("attempt limit reached, locking", zap.String("key", key), zap.Int("count", a.Count))
+	return true, nil
+}
+
+func (t *Throttler) Reset(ctx context.Context, keys ...string) error {
+	for _, key := range keys {
+		if key == "" {
+			continue
+		}
+		if err := t.store.Reset(ctx, key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *Throttler) delay(count int) time.Duration {
+	if count <= 0 || t.cfg.BaseDelay <= 0 {
+		return 0
+	}
+	d := t.cfg.BaseDelay
+	for i := 1; i < count && d < t.cfg.MaxDelay; i++ {
+		d *= 2
+	}
+	if t.cfg.MaxDelay > 0 && d > t.cfg.MaxDelay {
+		d = t.cfg.MaxDelay
+	}
+	return d
+}
+
+// throttle keys; an empty part yields an empty key, which is skipped
+func throttleKey(kind, id string) string {
+	if id == "" {
+		return ""
+	}
+	return kind + ":" + strings.ToLower(id)
+}
+
+const (
+	throttleLoginAccount = "login:account"
+	throttleLoginIP      = "login:ip"
+	throttleTwoFactor    = "login:2fa"
+	throttleResetAccount = "reset:account"
+	throttleResetIP      = "reset:ip"
+	throttleMagicAccount = "magic:account"
+	throttleMagicIP      = "magic:ip"
+)
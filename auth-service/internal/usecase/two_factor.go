@@ -0,0 +1,457 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"image/png"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/HatefBarari/microblog-auth/internal/domain"
+	"github.com/HatefBarari/microblog-shared/pkg/auth"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/pquerna/otp"
+	"github.com/pquerna/otp/totp"
+	"go.uber.org/zap"
+)
+
+type TwoFactorConfig struct {
+	Issuer string
+	// Secret encrypts stored TOTP seeds and signs login challenges.
+	Secret          string
+	ChallengeTTLMin int
+	// RequiredRoles must enroll before they can finish logging in.
+	RequiredRoles []domain.Role
+}
+
+var (
+	ErrTwoFactorEnabled     = errors.New("two-factor authentication is already enabled")
+	ErrTwoFactorNotEnabled  = errors.New("two-factor authentication is not enabled")
+	ErrTwoFactorNotEnrolled = errors.New("two-factor enrollment has not been started")
+	ErrInvalidTwoFactorCode = errors.New("invalid two-factor code")
+	ErrInvalidChallenge     = errors.New("invalid or expired challenge")
+	ErrTwoFactorRequired    = errors.New("two-factor authentication is required for this role")
+)
+
+const (
+	totpPeriod        = 30
+	recoveryCodeCount = 10
+
+	// challenge audiences: a second factor is due, or enrollment is
+	challengeAudience = "2fa"
+	setupAudience     = "2fa-setup"
+)
+
+// EnrollTwoFactor starts (or restarts) TOTP enrollment. It has no effect on
+// login until ConfirmTwoFactor succeeds.
+func (uc *UserUseCase) EnrollTwoFactor(ctx context.Context, userID string) (*TwoFactorEnrollResponse, error) {
+	u, err := uc.getUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if u.TwoFactorEnabled() {
+		return nil, ErrTwoFactorEnabled
+	}
+	return uc.enroll(ctx, u)
+}
+
+// ConfirmTwoFactor enables 2FA once the user proves their authenticator
+// works, and returns the recovery codes. They are never shown again.
+func (uc *UserUseCase) ConfirmTwoFactor(ctx context.Context, userID, code string) (*RecoveryCodesResponse, error) {
+	u, err := uc.getUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return uc.confirm(ctx, u, code)
+}
+
+// DisableTwoFactor requires both the password and a current code.
+func (uc *UserUseCase) DisableTwoFactor(ctx context.Context, userID, password, code string) error {
+	u, err := uc.getUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !u.TwoFactorEnabled() {
+		return ErrTwoFactorNotEnabled
+	}
+	if slices.Contains(uc.cfg.TwoFactor.RequiredRoles, u.Role) {
+		return ErrTwoFactorRequired
+	}
+	if !auth.CheckPassword(u.PasswordHash, password) {
+		return ErrInvalidCredentials
+	}
+	if err := uc.verifySecondFactor(ctx, u, code); err != nil {
+		return err
+	}
+	uc.log.Info("two-factor disabled", zap.String("user_id", u.ID))
+	return uc.repo.SetTwoFactor(ctx, u.ID, nil)
+}
+
+// RegenerateRecoveryCodes replaces all remaining recovery codes.
+func (uc *UserUseCase) RegenerateRecoveryCodes(ctx context.Context, userID, code string) (*RecoveryCodesResponse, error) {
+	u, err := uc.getUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !u.TwoFactorEnabled() {
+		return nil, ErrTwoFactorNotEnabled
+	}
+	if err := uc.verifySecondFactor(ctx, u, code); err != nil {
+		return nil, err
+	}
+	// re-read so the step just recorded is not overwritten
+	if u, err = uc.getUser(ctx, userID); err != nil {
+		return nil, err
+	}
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	tf := *u.TwoFactor
+	tf.RecoveryCodes = hashes
+	if err := uc.repo.SetTwoFactor(ctx, u.ID, &tf); err != nil {
+		return nil, err
+	}
+	return &RecoveryCodesResponse{RecoveryCodes: codes}, nil
+}
+
+// SetupTwoFactor starts enrollment for a user whose role requires 2FA,
+// authenticated by the setup challenge Login returned instead of tokens.
+func (uc *UserUseCase) SetupTwoFactor(ctx context.Context, challenge string) (*TwoFactorEnrollResponse, error) {
+	u, aud, err := uc.openChallenge(ctx, challenge)
+	if err != nil {
+		return nil, err
+	}
+	if aud != setupAudience || u.TwoFactorEnabled() {
+		return nil, ErrInvalidChallenge
+	}
+	return uc.enroll(ctx, u)
+}
+
+// LoginTwoFactor finishes a login that Login answered with a challenge.
+// For a setup challenge the code confirms the new enrollment, and the
+// recovery codes are returned with the tokens.
+func (uc *UserUseCase) LoginTwoFactor(ctx context.Context, challenge, code string) (*LoginResponse, error) {
+	u, aud, err := uc.openChallenge(ctx, challenge)
+	if err != nil {
+		return nil, err
+	}
+	resp := &LoginResponse{}
+	switch {
+	case aud == challengeAudience && u.TwoFactorEnabled():
+		if err := uc.verifySecondFactor(ctx, u, code); err != nil {
+			uc.cfg.Events.Record(ctx, domain.AuthEvent{Type: domain.EventLoginFailed, Outcome: domain.OutcomeFailure, UserID: u.ID, Reason: "invalid_second_factor"})
+			return nil, err
+		}
+	case aud == setupAudience && !u.TwoFactorEnabled():
+		rc, err := uc.confirm(ctx, u, code)
+		if err != nil {
+			return nil, err
+		}
+		resp.RecoveryCodes = rc.RecoveryCodes
+	default:
+		return nil, ErrInvalidChallenge
+	}
+	resp.AccessToken, resp.RefreshToken, err = uc.startSession(ctx, u)
+	if err != nil {
+		return nil, err
+	}
+	uc.cfg.Events.Record(ctx, domain.AuthEvent{
+		Type: domain.EventLogin, Outcome: domain.OutcomeSuccess, UserID: u.ID,
+		Details: map[string]string{"second_factor": "true"},
+	})
+	return resp, nil
+}
+
+// loginChallenge answers a correct password with a challenge token instead
+// of a token pair when u needs a second step.
+func (uc *UserUseCase) loginChallenge(u *domain.User) (*LoginResponse, error) {
+	aud := ""
+	switch {
+	case u.TwoFactorEnabled():
+		aud = challengeAudience
+	case slices.Contains(uc.cfg.TwoFactor.RequiredRoles, u.Role):
+		aud = setupAudience
+	default:
+		return nil, nil
+	}
+	now := time.Now()
+	token, err := uc.challengeKey().Sign(&auth.Claims{
+		UserID: u.ID,
+		Role:   string(u.Role),
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        auth.NewTokenID(),
+			Audience:  jwt.ClaimStrings{aud},
+			ExpiresAt: jwt.NewNumericDate(now.Add(time.Duration(uc.cfg.TwoFactor.ChallengeTTLMin) * time.Minute)),
+			IssuedAt:  jwt.NewNumericDate(now),
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &LoginResponse{
+		TwoFactorRequired:      aud == challengeAudience,
+		TwoFactorSetupRequired: aud == setupAudience,
+		ChallengeToken:         token,
+	}, nil
+}
+
+func (uc *UserUseCase) openChallenge(ctx context.Context, challenge string) (*domain.User, string, error) {
+	claims, err := uc.challengeKey().Verify(challenge)
+	if err != nil || len(claims.Audience) != 1 {
+		return nil, "", ErrInvalidChallenge
+	}
+	u, err := uc.repo.GetByID(ctx, claims.UserID)
+	if err != nil {
+		return nil, "", err
+	}
+	if u == nil {
+		return nil, "", ErrInvalidChallenge
+	}
+	return u, claims.Audience[0], nil
+}
+
+func (uc *UserUseCase) enroll(ctx context.Context, u *domain.User) (*TwoFactorEnrollResponse, error) {
+	key, err := totp.Generate(totp.GenerateOpts{
+		Issuer:      uc.cfg.TwoFactor.Issuer,
+		AccountName: u.Email,
+		Period:      totpPeriod,
+	})
+	if err != nil {
+		return nil, err
+	}
+	sealed, err := uc.sealSecret(key.Secret())
+	if err != nil {
+		return nil, err
+	}
+	if err := uc.repo.SetTwoFactor(ctx, u.ID, &domain.TwoFactor{Secret: sealed}); err != nil {
+		return nil, err
+	}
+	img, err := key.Image(256, 256)
+	if err != nil {
+		return nil, err
+	}
+	var qr bytes.Buffer
+	if err := png.Encode(&qr, img); err != nil {
+		return nil, err
+	}
+	return &TwoFactorEnrollResponse{
+		Secret:     key.Secret(),
+		OTPAuthURL: key.URL(),
+		QRCodePNG:  qr.Bytes(),
+	}, nil
+}
+
+func (uc *UserUseCase) confirm(ctx context.Context, u *domain.User, code string) (*RecoveryCodesResponse, error) {
+	if u.TwoFactorEnabled() {
+		return nil, ErrTwoFactorEnabled
+	}
+	if u.TwoFactor == nil {
+		return nil, ErrTwoFactorNotEnrolled
+	}
+	step, ok := uc.matchTOTP(u.TwoFactor, code, time.Now())
+	if !ok {
+		return nil, ErrInvalidTwoFactorCode
+	}
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	tf := *u.TwoFactor
+	tf.Enabled = true
+	tf.RecoveryCodes = hashes
+	tf.LastStep = step
+	tf.EnabledAt = &now
+	if err := uc.repo.SetTwoFactor(ctx, u.ID, &tf); err != nil {
+		return nil, err
+	}
+	uc.log.Info("two-factor enabled", zap.String("user_id", u.ID))
+	return &RecoveryCodesResponse{RecoveryCodes: codes}, nil
+}
+
+// verifySecondFactor accepts a TOTP code, each time step at most once, or
+// an unused recovery code.
+func (uc *UserUseCase) verifySecondFactor(ctx context.Context, u *domain.User, code string) error {
+	now := time.Now()
+	key := throttleKey(throttleTwoFactor, u.ID)
+	if err := uc.throttle.Allow(ctx, now, key); err != nil {
+		return err
+	}
+	err := uc.checkSecondFactor(ctx, u, code, now)
+	switch {
+	case errors.Is(err, ErrInvalidTwoFactorCode):
+		if locked, rerr := uc.throttle.Record(ctx, now, key, uc.throttle.cfg.MaxAccountFailures); rerr != nil {
+			uc.log.Error("record 2fa failure", zap.Error(rerr))
+		} else if locked {
+			uc.notifyLocked(u, uc.throttle.cfg.Lockout)
+		}
+	case err == nil:
+		if rerr := uc.throttle.Reset(ctx, key); rerr != nil {
+			uc.log.Error("reset 2fa attempts", zap.Error(rerr))
+		}
+	}
+	return err
+}
+
+func (uc *UserUseCase) checkSecondFactor(ctx context.Context, u *domain.User, code string, now time.Time) error {
+	code = strings.TrimSpace(code)
+	if !isTOTPCode(code) {
+		ok, err := uc.repo.ConsumeRecoveryCode(ctx, u.ID, hashRecoveryCode(code))
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return ErrInvalidTwoFactorCode
+		}
+		uc.log.Info("recovery code used", zap.String("user_id", u.ID),
+			zap.Int("remaining", len(u.TwoFactor.RecoveryCodes)-1))
+		return nil
+	}
+	step, ok := uc.matchTOTP(u.TwoFactor, code, now)
+	if !ok {
+		return ErrInvalidTwoFactorCode
+	}
+	fresh, err := uc.repo.AdvanceTOTPStep(ctx, u.ID, step)
+	if err != nil {
+		return err
+	}
+	if !fresh {
+		return ErrInvalidTwoFactorCode
+	}
+	return nil
+}
+
+// matchTOTP checks code against the current time step and one step either
+// side for clock drift, returning the step that matched.
+func (uc *UserUseCase) matchTOTP(tf *domain.TwoFactor, code string, now time.Time) (int64, bool) {
+	if !isTOTPCode(code) {
+		return 0, false
+	}
+	secret, err := uc.openSecret(tf.Secret)
+	if err != nil {
+		uc.log.Error("open totp secret", zap.Error(err))
+		return 0, false
+	}
+	current := now.Unix() / totpPeriod
+	for _, step := range []int64{current, current - 1, current + 1} {
+		if step <= tf.LastStep {
+			continue
+		}
+		want, err := totp.GenerateCodeCustom(secret, time.Unix(step*totpPeriod, 0), totp.ValidateOpts{
+			Period:    totpPeriod,
+			Digits:    otp.DigitsSix,
+			Algorithm: otp.AlgorithmSHA1,
+		})
+		if err == nil && subtle.ConstantTimeCompare([]byte(want), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+	return 0, false
+}
+
+func (uc *UserUseCase) getUser(ctx context.Context, userID string) (*domain.User, error) {
+	u, err := uc.repo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if u == nil {
+		return nil, domain.ErrUserNotFound
+	}
+	return u, nil
+}
+
+// twoFactorKey derives an independent key per use from the 2FA secret.
+func (uc *UserUseCase) twoFactorKey(label string) []byte {
+	mac := hmac.New(sha256.New, []byte(uc.cfg.TwoFactor.Secret))
+	mac.Write([]byte(label))
+	return mac.Sum(nil)
+}
+
+func (uc *UserUseCase) challengeKey() auth.HMACKey {
+	return auth.HMACKey(uc.twoFactorKey("login-challenge"))
+}
+
+func (uc *UserUseCase) sealSecret(secret string) (string, error) {
+	gcm, err := uc.seedCipher()
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	return base64.RawStdEncoding.EncodeToString(gcm.Seal(nonce, nonce, []byte(secret), nil)), nil
+}
+
+func (uc *UserUseCase) openSecret(sealed string) (string, error) {
+	gcm, err := uc.seedCipher()
+	if err != nil {
+		return "", err
+	}
+	raw, err := base64.RawStdEncoding.DecodeString(sealed)
+	if err != nil {
+		return "", err
+	}
+	if len(raw) < gcm.NonceSize() {
+		return "", errors.New("sealed secret too short")
+	}
+	plain, err := gcm.Open(nil, raw[:gcm.NonceSize()], raw[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", err
+	}
+	return string(plain), nil
+}
+
+func (uc *UserUseCase) seedCipher() (cipher.AEAD, error) {
+	block, err := aes.NewCipher(uc.twoFactorKey("totp-seed"))
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func isTOTPCode(code string) bool {
+	if len(code) != 6 {
+		return false
+	}
+	for _, r := range code {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// newRecoveryCodes returns codes formatted xxxxx-xxxxx and their hashes.
+func newRecoveryCodes() (codes, hashes []string, err error) {
+	enc := base32.StdEncoding.WithPadding(base32.NoPadding)
+	for i := 0; i < recoveryCodeCount; i++ {
+		b := make([]byte, 7)
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, err
+		}
+		s := strings.ToLower(enc.EncodeToString(b))[:10]
+		code := s[:5] + "-" + s[5:]
+		codes = append(codes, code)
+		hashes = append(hashes, hashRecoveryCode(code))
+	}
+	return codes, hashes, nil
+}
+
+func hashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
@@ -3,6 +3,7 @@ package usecase
 import (
 	"context"
 	"errors"
+	"strings"
 	"time"
 
 	"github.com/HatefBarari/microblog-auth/internal/domain"
@@ -12,32 +13,95 @@ import (
 )
 
 type UserUseCase struct {
-	repo   domain.UserRepository
-	email  *email.Sender
-	cfg    *Config
-	log    *zap.Logger
+	repo        domain.UserRepository
+	sessions    domain.SessionRepository
+	invitations domain.InvitationRepository
+	revocations auth.RevocationStore
+	throttle    *Throttler
+	email       EmailSender
+	cfg         *Config
+	log         *zap.Logger
 }
 
 type Config struct {
-	AccessSecret   string
+	AccessSigner   auth.Signer
 	RefreshSecret  string
 	AccessTTLMin   int
 	RefreshTTLHour int
 	EmailFrom      string
+	TwoFactor      TwoFactorConfig
+	// Hasher makes new password hashes; nil uses auth.DefaultHasher.
+	Hasher auth.PasswordHasher
+	// Passwords is checked on registration; nil accepts any password.
+	Passwords *PasswordPolicy
+	// Templates renders the lockout notice; nil uses the built-in ones.
+	Templates *email.Templates
+	// Registration says who may use /register; empty is open.
+	Registration RegistrationMode
+	// ImpersonationTTLMin is the lifetime of tokens admins get to act as
+	// another user.
+	ImpersonationTTLMin int
+	// Events records sign-ins and other security events; nil records none.
+	Events *AuthEventUseCase
+	// Verification mails new accounts their verification link; nil sends
+	// none.
+	Verification *EmailUseCase
 }
 
-func NewUserUseCase(repo domain.UserRepository, email *email.Sender, cfg *Config, log *zap.Logger) *UserUseCase {
-	return &UserUseCase{repo: repo, email: email, cfg: cfg, log: log}
+// RegistrationMode controls self sign-up.
+type RegistrationMode string
+
+const (
+	RegistrationOpen RegistrationMode = ""
+	// RegistrationInviteOnly requires an invitation code.
+	RegistrationInviteOnly RegistrationMode = "invite_only"
+	// RegistrationClosed refuses all sign-ups, invited or not.
+	RegistrationClosed RegistrationMode = "closed"
+)
+
+func (c *Config) hasher() auth.PasswordHasher {
+	if c.Hasher == nil {
+		return auth.DefaultHasher
+	}
+	return c.Hasher
+}
+
+var (
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	ErrInvalidCredentials  = errors.New("invalid credentials")
+	ErrAccountSuspended    = errors.New("account suspended")
+	ErrAccountNotVerified  = errors.New("account not verified")
+	ErrRegistrationClosed  = errors.New("registration is closed")
+	ErrInvitationRequired  = errors.New("registration requires an invitation")
+	ErrInvalidInvitation   = errors.New("invalid, expired or used invitation")
+)
+
+func NewUserUseCase(repo domain.UserRepository, sessions domain.SessionRepository, invitations domain.InvitationRepository, revocations auth.RevocationStore, throttle *Throttler, email EmailSender, cfg *Config, log *zap.Logger) *UserUseCase {
+	return &UserUseCase{repo: repo, sessions: sessions, invitations: invitations, revocations: revocations, throttle: throttle, email: email, cfg: cfg, log: log}
 }
 
+// Register creates an account. With an invitation code the account gets
+// the invitation's role and is verified already, since the invitation was
+// emailed to that address.
 func (uc *UserUseCase) Register(ctx context.Context, req RegisterRequest) (*RegisterResponse, error) {
+	switch {
+	case uc.cfg.Registration == RegistrationClosed:
+		return nil, ErrRegistrationClosed
+	case uc.cfg.Registration == RegistrationInviteOnly && req.Invitation == "":
+		return nil, ErrInvitationRequired
+	}
 	// duplicate email?
 	existing, _ := uc.repo.GetByEmail(ctx, req.Email)
 	if existing != nil {
 		return nil, errors.New("email already exists")
 	}
+	if uc.cfg.Passwords != nil {
+		if err := uc.cfg.Passwords.Validate(req.Password); err != nil {
+			return nil, err
+		}
+	}
 	// hash password
-	hash, err := auth.HashPassword(req.Password)
+	hash, err := uc.cfg.hasher().Hash(req.Password)
 	if err != nil {
 		return nil, err
 	}
@@ -47,26 +111,54 @@ func (uc *UserUseCase) Register(ctx context.Context, req RegisterRequest) (*Regi
 		PasswordHash: hash,
 		Role:         domain.RoleUser,
 		Verified:     false,
+		Status:       domain.StatusActive,
 		CreatedAt:    time.Now(),
 	}
+	if req.Locale != "" {
+		u.Profile = &domain.Profile{Locale: req.Locale}
+	}
+	var inv *domain.Invitation
+	if req.Invitation != "" {
+		// claimed before the user exists so a code can't be used twice
+		inv, err = uc.invitations.Accept(ctx, hashSecret(req.Invitation), strings.ToLower(req.Email), u.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		if inv == nil {
+			return nil, ErrInvalidInvitation
+		}
+		u.Role = inv.Role
+		u.Verified = true
+	}
 	if err := uc.repo.Create(ctx, u); err != nil {
+		if inv != nil {
+			if rerr := uc.invitations.Release(ctx, inv.ID); rerr != nil {
+				uc.log.Error("release invitation", zap.String("invitation_id", inv.ID), zap.Error(rerr))
+			}
+		}
 		return nil, err
 	}
+	registered := domain.AuthEvent{Type: domain.EventRegister, Outcome: domain.OutcomeSuccess, UserID: u.ID}
+	if inv != nil {
+		if err := uc.invitations.SetAcceptedBy(ctx, inv.ID, u.ID); err != nil {
+			uc.log.Error("record invitation user", zap.String("invitation_id", inv.ID), zap.Error(err))
+		}
+		uc.log.Info("invitation accepted", zap.String("invitation_id", inv.ID), zap.String("user_id", u.ID), zap.String("role", string(u.Role)))
+		registered.Details = map[string]string{"invitation_id": inv.ID, "role": string(u.Role)}
+	}
+	uc.cfg.Events.Record(ctx, registered)
 	// generate tokens
-	acc, ref, err := auth.GenerateTokens(
-		u.ID, string(u.Role),
-		uc.cfg.AccessSecret, uc.cfg.RefreshSecret,
-		uc.cfg.AccessTTLMin, uc.cfg.RefreshTTLHour,
-	)
+	acc, ref, err := uc.startSession(ctx, u)
 	if err != nil {
 		return nil, err
 	}
-	// send verification email
-	go func() {
-		if err := uc.email.Send(u.Email, "Verify your account", "Click here: ..."); err != nil {
-			uc.log.Error("send email", zap.Error(err))
+	// send verification email; the account exists either way and the
+	// user can ask for another link
+	if !u.Verified && uc.cfg.Verification != nil {
+		if err := uc.cfg.Verification.SendVerificationEmailFor(ctx, u); err != nil {
+			uc.log.Error("send verification email", zap.String("user_id", u.ID), zap.Error(err))
 		}
-	}()
+	}
 	return &RegisterResponse{
 		AccessToken:  acc,
 		RefreshToken: ref,
@@ -74,43 +166,215 @@ func (uc *UserUseCase) Register(ctx context.Context, req RegisterRequest) (*Regi
 }
 
 func (uc *UserUseCase) Login(ctx context.Context, req LoginRequest) (*LoginResponse, error) {
+	now := time.Now()
+	accountKey := throttleKey(throttleLoginAccount, req.Email)
+	ipKey := throttleKey(throttleLoginIP, ClientInfoFrom(ctx).IP)
+	if err := uc.throttle.Allow(ctx, now, accountKey, ipKey); err != nil {
+		uc.cfg.Events.Record(ctx, domain.AuthEvent{Type: domain.EventLoginFailed, Outcome: domain.OutcomeFailure, Email: req.Email, Reason: "too_many_attempts"})
+		return nil, err
+	}
 	u, err := uc.repo.GetByEmail(ctx, req.Email)
 	if err != nil {
 		return nil, err
 	}
-	if u == nil || !auth.CheckPassword(u.PasswordHash, req.Password) {
-		return nil, errors.New("invalid credentials")
+	var ok, rehash bool
+	if u != nil {
+		ok, rehash = uc.cfg.hasher().Verify(u.PasswordHash, req.Password)
+	}
+	if !ok {
+		failed := domain.AuthEvent{Type: domain.EventLoginFailed, Outcome: domain.OutcomeFailure, Email: req.Email, Reason: "invalid_credentials"}
+		if u != nil {
+			failed.UserID, failed.Email = u.ID, ""
+		}
+		uc.cfg.Events.Record(ctx, failed)
+		uc.loginFailed(ctx, u, accountKey, ipKey, now)
+		return nil, ErrInvalidCredentials
+	}
+	if err := uc.throttle.Reset(ctx, accountKey); err != nil {
+		uc.log.Error("reset login attempts", zap.Error(err))
+	}
+	if rehash {
+		uc.upgradeHash(ctx, u, req.Password)
+	}
+	return uc.signIn(ctx, u, now)
+}
+
+// upgradeHash re-hashes a just-verified password made with an outdated
+// algorithm or parameters. Failing to do so doesn't stop the login.
+func (uc *UserUseCase) upgradeHash(ctx context.Context, u *domain.User, password string) {
+	hash, err := uc.cfg.hasher().Hash(password)
+	if err == nil {
+		err = uc.repo.UpdatePasswordHash(ctx, u.ID, u.PasswordHash, hash)
 	}
+	if err != nil {
+		uc.log.Error("upgrade password hash", zap.String("user_id", u.ID), zap.Error(err))
+		return
+	}
+	u.PasswordHash = hash
+	uc.log.Info("password hash upgraded", zap.String("user_id", u.ID))
+}
+
+// signIn applies the rules every first factor shares once it has identified
+// u: the account must be verified and active, and a second factor is asked
+// for when needed before a session is started.
+func (uc *UserUseCase) signIn(ctx context.Context, u *domain.User, now time.Time) (*LoginResponse, error) {
 	if !u.Verified {
-		return nil, errors.New("account not verified")
+		uc.cfg.Events.Record(ctx, domain.AuthEvent{Type: domain.EventLoginFailed, Outcome: domain.OutcomeFailure, UserID: u.ID, Reason: "not_verified"})
+		return nil, ErrAccountNotVerified
 	}
-	acc, ref, err := auth.GenerateTokens(
-		u.ID, string(u.Role),
-		uc.cfg.AccessSecret, uc.cfg.RefreshSecret,
-		uc.cfg.AccessTTLMin, uc.cfg.RefreshTTLHour,
-	)
+	if !u.CanSignIn(now) {
+		uc.cfg.Events.Record(ctx, domain.AuthEvent{Type: domain.EventLoginFailed, Outcome: domain.OutcomeFailure, UserID: u.ID, Reason: "suspended"})
+		return nil, ErrAccountSuspended
+	}
+	if challenge, err := uc.loginChallenge(u); challenge != nil || err != nil {
+		return challenge, err
+	}
+	acc, ref, err := uc.startSession(ctx, u)
 	if err != nil {
 		return nil, err
 	}
+	uc.cfg.Events.Record(ctx, domain.AuthEvent{Type: domain.EventLogin, Outcome: domain.OutcomeSuccess, UserID: u.ID})
 	return &LoginResponse{
 		AccessToken:  acc,
 		RefreshToken: ref,
 	}, nil
 }
 
+// Refresh exchanges a refresh token for a new token pair. The presented
+// token is spent: using it again revokes the whole session.
 func (uc *UserUseCase) Refresh(ctx context.Context, refreshToken string) (*RefreshResponse, error) {
 	claims, err := auth.ValidateToken(refreshToken, uc.cfg.RefreshSecret)
+	if err != nil || claims.SessionID == "" || claims.ID == "" {
+		return nil, ErrInvalidRefreshToken
+	}
+	now := time.Now()
+	s, err := uc.sessions.GetByID(ctx, claims.SessionID)
 	if err != nil {
-		return nil, errors.New("invalid refresh token")
+		return nil, err
+	}
+	if s == nil || s.UserID != claims.UserID || !s.Active(now) {
+		return nil, ErrInvalidRefreshToken
+	}
+	if s.RefreshJTI != claims.ID {
+		uc.revokeReplayed(ctx, s, now)
+		return nil, ErrInvalidRefreshToken
+	}
+	// pick up role changes made since the session started
+	u, err := uc.repo.GetByID(ctx, claims.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if u == nil {
+		return nil, ErrInvalidRefreshToken
+	}
+	if !u.CanSignIn(now) {
+		return nil, ErrAccountSuspended
+	}
+
+	ci := ClientInfoFrom(ctx)
+	newJTI := auth.NewTokenID()
+	expiresAt := now.Add(time.Duration(uc.cfg.RefreshTTLHour) * time.Hour)
+	ok, err := uc.sessions.Rotate(ctx, s.ID, claims.ID, newJTI, expiresAt, ci.IP, ci.UserAgent, now)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		// another request spent this token between our read and write
+		uc.revokeReplayed(ctx, s, now)
+		return nil, ErrInvalidRefreshToken
 	}
-	// generate new access only
-	acc, _, err := auth.GenerateTokens(
-		claims.UserID, claims.Role,
-		uc.cfg.AccessSecret, uc.cfg.RefreshSecret,
+	acc, ref, err := auth.SignSessionTokens(
+		uc.cfg.AccessSigner, auth.HMAC(uc.cfg.RefreshSecret),
+		u.ID, string(u.Role), s.ID, newJTI,
 		uc.cfg.AccessTTLMin, uc.cfg.RefreshTTLHour,
 	)
 	if err != nil {
 		return nil, err
 	}
-	return &RefreshResponse{AccessToken: acc}, nil
-}
\ No newline at end of file
+	uc.cfg.Events.Record(ctx, domain.AuthEvent{
+		Type: domain.EventTokenRefreshed, Outcome: domain.OutcomeSuccess, UserID: u.ID,
+		Details: map[string]string{"session_id": s.ID},
+	})
+	return &RefreshResponse{AccessToken: acc, RefreshToken: ref}, nil
+}
+
+// loginFailed counts a failed password against the account and the IP, and
+// tells the owner when their account gets locked.
+func (uc *UserUseCase) loginFailed(ctx context.Context, u *domain.User, accountKey, ipKey string, now time.Time) {
+	cfg := uc.throttle.cfg
+	if _, err := uc.throttle.Record(ctx, now, ipKey, cfg.MaxIPFailures); err != nil {
+		uc.log.Error("record login failure", zap.Error(err))
+	}
+	locked, err := uc.throttle.Record(ctx, now, accountKey, cfg.MaxAccountFailures)
+	if err != nil {
+		uc.log.Error("record login failure", zap.Error(err))
+	}
+	if locked && u != nil {
+		uc.notifyLocked(u, cfg.Lockout)
+	}
+}
+
+func (uc *UserUseCase) notifyLocked(u *domain.User, lockout time.Duration) {
+	if err := sendTemplate(uc.email, uc.cfg.Templates, u.Email, userLocale(u), "account_locked", map[string]interface{}{
+		"Minutes": int(lockout.Minutes()),
+	}); err != nil {
+		uc.log.Error("send lockout email", zap.String("user_id", u.ID), zap.Error(err))
+	}
+}
+
+// Unlock clears the login lockout and failure counters of a user.
+func (uc *UserUseCase) Unlock(ctx context.Context, userID string) error {
+	u, err := uc.getUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	return uc.throttle.Reset(ctx,
+		throttleKey(throttleLoginAccount, u.Email),
+		throttleKey(throttleTwoFactor, u.ID),
+	)
+}
+
+// startSession records a new session for u and issues its first token pair.
+func (uc *UserUseCase) startSession(ctx context.Context, u *domain.User) (acc, ref string, err error) {
+	ci := ClientInfoFrom(ctx)
+	now := time.Now()
+	if !u.CanSignIn(now) {
+		return "", "", ErrAccountSuspended
+	}
+	s := &domain.Session{
+		ID:         auth.NewTokenID(),
+		UserID:     u.ID,
+		RefreshJTI: auth.NewTokenID(),
+		Device:     ci.Device,
+		IP:         ci.IP,
+		UserAgent:  ci.UserAgent,
+		CreatedAt:  now,
+		LastUsedAt: now,
+		ExpiresAt:  now.Add(time.Duration(uc.cfg.RefreshTTLHour) * time.Hour),
+	}
+	if err := uc.sessions.Create(ctx, s); err != nil {
+		return "", "", err
+	}
+	return auth.SignSessionTokens(
+		uc.cfg.AccessSigner, auth.HMAC(uc.cfg.RefreshSecret),
+		u.ID, string(u.Role), s.ID, s.RefreshJTI,
+		uc.cfg.AccessTTLMin, uc.cfg.RefreshTTLHour,
+	)
+}
+
+func (uc *UserUseCase) revokeReplayed(ctx context.Context, s *domain.Session, now time.Time) {
+	uc.log.Warn("refresh token reuse detected, revoking session",
+		zap.String("user_id", s.UserID),
+		zap.String("session_id", s.ID),
+		zap.String("ip", ClientInfoFrom(ctx).IP))
+	uc.cfg.Events.Record(ctx, domain.AuthEvent{
+		Type: domain.EventTokenRefreshed, Outcome: domain.OutcomeFailure, UserID: s.UserID, Reason: "refresh_token_reuse",
+		Details: map[string]string{"session_id": s.ID},
+	})
+	if err := uc.sessions.Revoke(ctx, s.ID, domain.RevokeReasonReuse, now); err != nil {
+		uc.log.Error("revoke session", zap.String("session_id", s.ID), zap.Error(err))
+	}
+	if err := uc.revocations.RevokeToken(ctx, s.ID, s.ExpiresAt); err != nil {
+		uc.log.Error("revoke session tokens", zap.String("session_id", s.ID), zap.Error(err))
+	}
+}
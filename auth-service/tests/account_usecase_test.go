@@ -0,0 +1,196 @@
+package tests
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/HatefBarari/microblog-auth/internal/domain"
+	"github.com/HatefBarari/microblog-auth/internal/usecase"
+	"github.com/HatefBarari/microblog-shared/pkg/auth"
+	"github.com/HatefBarari/microblog-shared/pkg/userdata"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type MockUserDataService struct {
+	mock.Mock
+	name string
+}
+
+func (m *MockUserDataService) Name() string { return m.name }
+
+func (m *MockUserDataService) Export(ctx context.Context, userID string) (*userdata.Export, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*userdata.Export), args.Error(1)
+}
+
+func (m *MockUserDataService) Fetch(ctx context.Context, path string) (io.ReadCloser, error) {
+	args := m.Called(ctx, path)
+	return io.NopCloser(strings.NewReader(args.String(0))), args.Error(1)
+}
+
+func (m *MockUserDataService) Delete(ctx context.Context, userID string, mode userdata.Mode) error {
+	args := m.Called(ctx, userID, mode)
+	return args.Error(0)
+}
+
+type accountFixture struct {
+	uc       *usecase.AccountUseCase
+	users    *MockUserRepository
+	tokens   *MockAuthTokenRepository
+	sessions *MockSessionRepository
+	apiKeys  *auth.MemoryAPIKeyStore
+	sender   *MockEmailSender
+	blog     *MockUserDataService
+	media    *MockUserDataService
+}
+
+func newAccountFixture() *accountFixture {
+	logger, _ := zap.NewDevelopment()
+	f := &accountFixture{
+		users:    new(MockUserRepository),
+		tokens:   new(MockAuthTokenRepository),
+		sessions: new(MockSessionRepository),
+		apiKeys:  auth.NewMemoryAPIKeyStore(),
+		sender:   new(MockEmailSender),
+		blog:     &MockUserDataService{name: "blog"},
+		media:    &MockUserDataService{name: "media"},
+	}
+	f.sender.On("Send", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	sessionUC := usecase.NewSessionUseCase(f.sessions, auth.NewMemoryRevocationStore(), 15*time.Minute, logger)
+	f.uc = usecase.NewAccountUseCase(f.users, f.tokens, f.sessions, sessionUC, f.apiKeys, f.sender,
+		[]usecase.UserDataService{f.blog, f.media},
+		usecase.AccountConfig{GracePeriod: 14 * 24 * time.Hour}, logger)
+	return f
+}
+
+func TestAccountUseCase_ScheduleDeletion(t *testing.T) {
+	f := newAccountFixture()
+	ctx := context.Background()
+	hash, err := auth.HashPassword("secret123")
+	require.NoError(t, err)
+	user := &domain.User{ID: "user123", Email: "user@example.com", PasswordHash: hash}
+	f.users.On("GetByID", mock.Anything, "user123").Return(user, nil)
+
+	_, err = f.uc.ScheduleDeletion(ctx, "user123", usecase.DeleteAccountRequest{Password: "wrong", Mode: "remove"})
+	assert.ErrorIs(t, err, usecase.ErrInvalidCredentials)
+
+	var stored *domain.DeletionRequest
+	f.users.On("SetDeletion", mock.Anything, "user123", mock.AnythingOfType("*domain.DeletionRequest")).
+		Run(func(args mock.Arguments) { stored = args.Get(2).(*domain.DeletionRequest) }).
+		Return(nil)
+	resp, err := f.uc.ScheduleDeletion(ctx, "user123", usecase.DeleteAccountRequest{Password: "secret123", Mode: "anonymize"})
+	require.NoError(t, err)
+	require.NotNil(t, stored)
+	assert.Equal(t, "anonymize", resp.Mode)
+	assert.WithinDuration(t, time.Now().Add(14*24*time.Hour), resp.ScheduledAt, time.Minute)
+	f.sender.AssertCalled(t, "Send", "user@example.com", mock.Anything, mock.Anything)
+
+	user.Deletion = stored
+	_, err = f.uc.ScheduleDeletion(ctx, "user123", usecase.DeleteAccountRequest{Password: "secret123", Mode: "remove"})
+	assert.ErrorIs(t, err, usecase.ErrDeletionPending)
+}
+
+func TestAccountUseCase_ExportZip(t *testing.T) {
+	f := newAccountFixture()
+	ctx := context.Background()
+	user := &domain.User{ID: "user123", Email: "user@example.com", PasswordHash: "hash", Role: domain.RoleUser}
+	f.users.On("GetByID", mock.Anything, "user123").Return(user, nil)
+	f.sessions.On("ListActiveByUser", mock.Anything, "user123", mock.Anything).Return([]*domain.Session{}, nil)
+
+	blogExport := &userdata.Export{}
+	require.NoError(t, blogExport.AddDocument("articles", []map[string]string{{"title": "hello"}}))
+	f.blog.On("Export", mock.Anything, "user123").Return(blogExport, nil)
+	mediaExport := &userdata.Export{Files: []userdata.ExportFile{{Name: "files/m1_a.png", Path: "/internal/users/user123/media/m1"}}}
+	require.NoError(t, mediaExport.AddDocument("media", []string{"m1"}))
+	f.media.On("Export", mock.Anything, "user123").Return(mediaExport, nil)
+	f.media.On("Fetch", mock.Anything, "/internal/users/user123/media/m1").Return("png-bytes", nil)
+
+	export, err := f.uc.Export(ctx, "user123")
+	require.NoError(t, err)
+	var buf bytes.Buffer
+	require.NoError(t, export.WriteZip(ctx, &buf))
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+	entries := map[string]string{}
+	for _, file := range zr.File {
+		r, err := file.Open()
+		require.NoError(t, err)
+		data, err := io.ReadAll(r)
+		require.NoError(t, err)
+		r.Close()
+		entries[file.Name] = string(data)
+	}
+	assert.Len(t, entries, 4)
+	assert.JSONEq(t, `[{"title":"hello"}]`, entries["blog/articles.json"])
+	assert.JSONEq(t, `["m1"]`, entries["media/media.json"])
+	assert.Equal(t, "png-bytes", entries["media/files/m1_a.png"])
+
+	var profile map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(entries["profile.json"]), &profile))
+	assert.Equal(t, "user@example.com", profile["email"])
+	assert.Equal(t, "active", profile["status"])
+	assert.NotContains(t, entries["profile.json"], "hash")
+}
+
+func TestAccountUseCase_ExportRejectsEscapingEntries(t *testing.T) {
+	f := newAccountFixture()
+	ctx := context.Background()
+	f.users.On("GetByID", mock.Anything, "user123").Return(&domain.User{ID: "user123"}, nil)
+	f.sessions.On("ListActiveByUser", mock.Anything, "user123", mock.Anything).Return([]*domain.Session{}, nil)
+	f.blog.On("Export", mock.Anything, "user123").Return(&userdata.Export{
+		Files: []userdata.ExportFile{{Name: "../profile.json", Path: "/x"}},
+	}, nil)
+	f.media.On("Export", mock.Anything, "user123").Return(&userdata.Export{}, nil)
+
+	export, err := f.uc.Export(ctx, "user123")
+	require.NoError(t, err)
+	assert.Error(t, export.WriteZip(ctx, io.Discard))
+	f.blog.AssertNotCalled(t, "Fetch", mock.Anything, mock.Anything)
+}
+
+func TestAccountUseCase_PurgeDue(t *testing.T) {
+	f := newAccountFixture()
+	ctx := context.Background()
+	now := time.Now()
+	due := &domain.User{ID: "user123", Email: "user@example.com", Deletion: &domain.DeletionRequest{Mode: "remove", ScheduledAt: now.Add(-time.Hour)}}
+	stuck := &domain.User{ID: "user456", Email: "other@example.com", Deletion: &domain.DeletionRequest{Mode: "anonymize", ScheduledAt: now.Add(-time.Hour)}}
+	require.NoError(t, f.apiKeys.CreateAPIKey(ctx, &auth.APIKey{ID: "k1", UserID: "user123", Hash: auth.HashAPIKey("mbp_test")}))
+
+	f.users.On("ListDueDeletions", mock.Anything, now, mock.Anything).Return([]*domain.User{due, stuck}, nil)
+	f.blog.On("Delete", mock.Anything, "user123", userdata.ModeRemove).Return(nil)
+	f.media.On("Delete", mock.Anything, "user123", userdata.ModeRemove).Return(nil)
+	f.blog.On("Delete", mock.Anything, "user456", userdata.ModeAnonymize).Return(errors.New("blog unavailable"))
+	f.sessions.On("RevokeAllByUser", mock.Anything, "user123", domain.RevokeReasonDeleted, mock.Anything).Return(1, nil)
+	f.sessions.On("DeleteByUser", mock.Anything, "user123").Return(nil)
+	f.tokens.On("PurgeUser", mock.Anything, "user123").Return(nil)
+	f.users.On("Delete", mock.Anything, "user123").Return(nil)
+
+	n, err := f.uc.PurgeDue(ctx, now)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	f.blog.AssertExpectations(t)
+	f.media.AssertExpectations(t)
+	f.sessions.AssertExpectations(t)
+	f.tokens.AssertExpectations(t)
+	f.users.AssertExpectations(t)
+	// a service that failed keeps the account for the next run
+	f.users.AssertNotCalled(t, "Delete", mock.Anything, "user456")
+	f.media.AssertNotCalled(t, "Delete", mock.Anything, "user456", mock.Anything)
+
+	_, err = f.apiKeys.ResolveAPIKey(ctx, "mbp_test")
+	assert.ErrorIs(t, err, auth.ErrInvalidAPIKey)
+}
@@ -0,0 +1,193 @@
+package tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/HatefBarari/microblog-auth/internal/domain"
+	"github.com/HatefBarari/microblog-auth/internal/usecase"
+	"github.com/HatefBarari/microblog-shared/pkg/auth"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type MockAdminActionRepository struct {
+	mock.Mock
+}
+
+func (m *MockAdminActionRepository) Create(ctx context.Context, a *domain.AdminAction) error {
+	args := m.Called(ctx, a)
+	return args.Error(0)
+}
+
+func (m *MockAdminActionRepository) List(ctx context.Context, filter domain.AdminActionFilter) ([]*domain.AdminAction, int, error) {
+	args := m.Called(ctx, filter)
+	return args.Get(0).([]*domain.AdminAction), args.Int(1), args.Error(2)
+}
+
+type adminFixture struct {
+	uc          *usecase.AdminUseCase
+	users       *MockUserRepository
+	sessions    *MockSessionRepository
+	actions     *MockAdminActionRepository
+	revocations *auth.MemoryRevocationStore
+	apiKeys     *auth.MemoryAPIKeyStore
+	recorded    []*domain.AdminAction
+}
+
+func newAdminFixture() *adminFixture {
+	logger, _ := zap.NewDevelopment()
+	f := &adminFixture{
+		users:       new(MockUserRepository),
+		sessions:    new(MockSessionRepository),
+		actions:     new(MockAdminActionRepository),
+		revocations: auth.NewMemoryRevocationStore(),
+		apiKeys:     auth.NewMemoryAPIKeyStore(),
+	}
+	f.actions.On("Create", mock.Anything, mock.AnythingOfType("*domain.AdminAction")).
+		Run(func(args mock.Arguments) { f.recorded = append(f.recorded, args.Get(1).(*domain.AdminAction)) }).
+		Return(nil)
+	userUC := usecase.NewUserUseCase(f.users, f.sessions, nil, f.revocations, newTestThrottler(), new(MockEmailSender), testUserConfig, logger)
+	f.uc = usecase.NewAdminUseCase(f.users, f.sessions, f.revocations, f.apiKeys, f.actions, userUC, nil, 15*time.Minute, logger)
+	return f
+}
+
+func TestAdminUseCase_SuspendInvalidatesTokens(t *testing.T) {
+	f := newAdminFixture()
+	ctx := usecase.WithClientInfo(context.Background(), usecase.ClientInfo{IP: "10.0.0.9"})
+	user := &domain.User{ID: "user123", Email: "user@example.com", Role: domain.RoleUser, Verified: true}
+	until := time.Now().Add(24 * time.Hour)
+
+	require.NoError(t, f.apiKeys.CreateAPIKey(ctx, &auth.APIKey{ID: "k1", UserID: "user123", Hash: auth.HashAPIKey("mbp_test")}))
+	f.users.On("GetByID", mock.Anything, "user123").Return(user, nil)
+	f.users.On("SetStatus", mock.Anything, "user123", domain.StatusSuspended, "spam", &until).Return(nil)
+	f.sessions.On("RevokeAllByUser", mock.Anything, "user123", domain.RevokeReasonAdmin, mock.Anything).Return(2, nil)
+
+	resp, err := f.uc.Suspend(ctx, "admin1", "user123", usecase.AccountStatusRequest{Reason: "spam", Until: &until})
+	require.NoError(t, err)
+	assert.Equal(t, string(domain.StatusSuspended), resp.Status)
+	f.users.AssertExpectations(t)
+	f.sessions.AssertExpectations(t)
+
+	issued := &auth.Claims{UserID: "user123", RegisteredClaims: jwt.RegisteredClaims{IssuedAt: jwt.NewNumericDate(time.Now().Add(-time.Minute))}}
+	revoked, err := f.revocations.IsRevoked(ctx, issued)
+	require.NoError(t, err)
+	assert.True(t, revoked)
+	_, err = f.apiKeys.ResolveAPIKey(ctx, "mbp_test")
+	assert.ErrorIs(t, err, auth.ErrInvalidAPIKey)
+
+	require.Len(t, f.recorded, 1)
+	assert.Equal(t, "admin1", f.recorded[0].ActorID)
+	assert.Equal(t, domain.ActionSuspended, f.recorded[0].Action)
+	assert.Equal(t, "user123", f.recorded[0].TargetID)
+	assert.Equal(t, "spam", f.recorded[0].Details["reason"])
+	assert.Equal(t, "10.0.0.9", f.recorded[0].IP)
+}
+
+func TestAdminUseCase_ChangeRole(t *testing.T) {
+	f := newAdminFixture()
+	ctx := context.Background()
+	user := &domain.User{ID: "user123", Role: domain.RoleUser}
+
+	require.NoError(t, f.apiKeys.CreateAPIKey(ctx, &auth.APIKey{ID: "k1", UserID: "user123", Role: "user", Hash: auth.HashAPIKey("mbp_test")}))
+	f.users.On("GetByID", mock.Anything, "user123").Return(user, nil)
+	f.users.On("UpdateRole", mock.Anything, "user123", domain.RoleManager).Return(nil)
+
+	_, err := f.uc.ChangeRole(ctx, "user123", "user123", domain.RoleAdmin)
+	assert.ErrorIs(t, err, usecase.ErrSelfAction)
+
+	resp, err := f.uc.ChangeRole(ctx, "admin1", "user123", domain.RoleManager)
+	require.NoError(t, err)
+	assert.Equal(t, "manager", resp.Role)
+	f.users.AssertExpectations(t)
+
+	claims, err := f.apiKeys.ResolveAPIKey(ctx, "mbp_test")
+	require.NoError(t, err)
+	assert.Equal(t, "manager", claims.Role)
+
+	require.Len(t, f.recorded, 1)
+	assert.Equal(t, map[string]string{"from": "user", "to": "manager"}, f.recorded[0].Details)
+}
+
+func TestAdminUseCase_ListUsers(t *testing.T) {
+	f := newAdminFixture()
+	verified := true
+	f.users.On("List", mock.Anything, mock.MatchedBy(func(filter domain.UserFilter) bool {
+		return filter.Role != nil && *filter.Role == domain.RoleManager &&
+			filter.Status != nil && *filter.Status == domain.StatusActive &&
+			filter.Verified == &verified &&
+			filter.Email == "example" &&
+			filter.Page == 1 && filter.PageSize == 20
+	})).Return([]*domain.User{{ID: "u1", Email: "a@example.com", Role: domain.RoleManager}}, 41, nil)
+
+	resp, err := f.uc.ListUsers(context.Background(), usecase.ListUsersRequest{
+		Email: "example", Role: "manager", Verified: &verified, Status: "active",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 41, resp.Total)
+	assert.Equal(t, 1, resp.Page)
+	require.Len(t, resp.Items, 1)
+	// accounts created before statuses existed read as active
+	assert.Equal(t, "active", resp.Items[0].Status)
+}
+
+func TestAdminUseCase_ListUsersCapsPageSize(t *testing.T) {
+	f := newAdminFixture()
+	f.users.On("List", mock.Anything, mock.MatchedBy(func(filter domain.UserFilter) bool {
+		return filter.Page == 2 && filter.PageSize == 100
+	})).Return([]*domain.User{}, 0, nil)
+
+	resp, err := f.uc.ListUsers(context.Background(), usecase.ListUsersRequest{Page: 2, PageSize: 1000000})
+	require.NoError(t, err)
+	assert.Equal(t, 100, resp.PageSize)
+	f.users.AssertExpectations(t)
+}
+
+func TestUserUseCase_LoginRefusesSuspended(t *testing.T) {
+	hash, err := auth.HashPassword("secret123")
+	require.NoError(t, err)
+	until := time.Now().Add(time.Hour)
+	user := &domain.User{ID: "user123", Email: "user@example.com", PasswordHash: hash, Role: domain.RoleUser, Verified: true,
+		Status: domain.StatusSuspended, SuspendedUntil: &until}
+
+	mockRepo := new(MockUserRepository)
+	mockRepo.On("GetByEmail", mock.Anything, "user@example.com").Return(user, nil)
+	uc := newTestUserUseCase(mockRepo, new(MockSessionRepository))
+
+	_, err = uc.Login(context.Background(), usecase.LoginRequest{Email: "user@example.com", Password: "secret123"})
+	assert.ErrorIs(t, err, usecase.ErrAccountSuspended)
+
+	// an elapsed suspension no longer blocks sign-in
+	past := time.Now().Add(-time.Minute)
+	user.SuspendedUntil = &past
+	assert.True(t, user.CanSignIn(time.Now()))
+}
+
+func TestAdminUseCase_Impersonate(t *testing.T) {
+	f := newAdminFixture()
+	ctx := context.Background()
+	f.users.On("GetByID", mock.Anything, "user123").Return(&domain.User{ID: "user123", Role: domain.RoleUser, Verified: true}, nil)
+	f.users.On("GetByID", mock.Anything, "admin2").Return(&domain.User{ID: "admin2", Role: domain.RoleAdmin, Verified: true}, nil)
+
+	_, err := f.uc.Impersonate(ctx, "admin1", "admin2")
+	assert.ErrorIs(t, err, usecase.ErrImpersonateAdmin)
+
+	resp, err := f.uc.Impersonate(ctx, "admin1", "user123")
+	require.NoError(t, err)
+	claims, err := testAccessKeys.Verify(resp.AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, "user123", claims.UserID)
+	assert.Equal(t, "user", claims.Role)
+	require.True(t, claims.Impersonated())
+	assert.Equal(t, "admin1", claims.Actor.Subject)
+	assert.Empty(t, claims.SessionID)
+	assert.WithinDuration(t, time.Now().Add(15*time.Minute), claims.ExpiresAt.Time, time.Minute)
+
+	require.Len(t, f.recorded, 1)
+	assert.Equal(t, domain.ActionImpersonated, f.recorded[0].Action)
+	assert.Equal(t, claims.ID, f.recorded[0].Details["token_id"])
+}
@@ -0,0 +1,57 @@
+package tests
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/HatefBarari/microblog-auth/internal/domain"
+	"github.com/HatefBarari/microblog-auth/internal/usecase"
+	"github.com/HatefBarari/microblog-shared/pkg/auth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestAPIKeyUseCase(t *testing.T) {
+	logger, _ := zap.NewDevelopment()
+	ctx := context.Background()
+	store := auth.NewMemoryAPIKeyStore()
+	mockRepo := new(MockUserRepository)
+	mockRepo.On("GetByID", mock.Anything, "user123").
+		Return(&domain.User{ID: "user123", Role: domain.RoleManager}, nil)
+	uc := usecase.NewAPIKeyUseCase(store, mockRepo, usecase.APIKeyConfig{DefaultTTLDays: 90, MaxTTLDays: 365}, logger)
+
+	t.Run("rejects unknown scopes and long expiry", func(t *testing.T) {
+		_, err := uc.Create(ctx, "user123", usecase.CreateAPIKeyRequest{Name: "ci", Scopes: []string{"admin:all"}})
+		assert.Error(t, err)
+		_, err = uc.Create(ctx, "user123", usecase.CreateAPIKeyRequest{Name: "ci", Scopes: []string{auth.ScopeArticlesWrite}, ExpiresInDays: 400})
+		assert.Error(t, err)
+	})
+
+	created, err := uc.Create(ctx, "user123", usecase.CreateAPIKeyRequest{
+		Name:   "publisher",
+		Scopes: []string{auth.ScopeMediaUpload, auth.ScopeArticlesWrite, auth.ScopeMediaUpload},
+	})
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(created.Token, auth.APIKeyPrefix))
+	assert.True(t, strings.HasPrefix(created.Token, created.Hint))
+	assert.Equal(t, []string{auth.ScopeArticlesWrite, auth.ScopeMediaUpload}, created.Scopes)
+	require.NotNil(t, created.ExpiresAt)
+
+	claims, err := store.ResolveAPIKey(ctx, created.Token)
+	require.NoError(t, err)
+	assert.Equal(t, "user123", claims.UserID)
+	assert.Equal(t, string(domain.RoleManager), claims.Role)
+
+	list, err := uc.List(ctx, "user123")
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	assert.Equal(t, "publisher", list[0].Name)
+
+	assert.ErrorIs(t, uc.Revoke(ctx, "other", created.ID), usecase.ErrAPIKeyNotFound)
+	require.NoError(t, uc.Revoke(ctx, "user123", created.ID))
+	_, err = store.ResolveAPIKey(ctx, created.Token)
+	assert.ErrorIs(t, err, auth.ErrInvalidAPIKey)
+}
@@ -0,0 +1,114 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/HatefBarari/microblog-auth/internal/domain"
+	"github.com/HatefBarari/microblog-auth/internal/usecase"
+	"github.com/HatefBarari/microblog-shared/pkg/auth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type MockAuthEventRepository struct {
+	mock.Mock
+}
+
+func (m *MockAuthEventRepository) Create(ctx context.Context, e *domain.AuthEvent) error {
+	args := m.Called(ctx, e)
+	return args.Error(0)
+}
+
+func (m *MockAuthEventRepository) List(ctx context.Context, filter domain.AuthEventFilter) ([]*domain.AuthEvent, int, error) {
+	args := m.Called(ctx, filter)
+	return args.Get(0).([]*domain.AuthEvent), args.Int(1), args.Error(2)
+}
+
+func (m *MockAuthEventRepository) Each(ctx context.Context, filter domain.AuthEventFilter, fn func(*domain.AuthEvent) error) error {
+	args := m.Called(ctx, filter)
+	for _, e := range args.Get(0).([]*domain.AuthEvent) {
+		if err := fn(e); err != nil {
+			return err
+		}
+	}
+	return args.Error(1)
+}
+
+func TestAuthEventUseCase_RecordsLogins(t *testing.T) {
+	hash, err := auth.HashPassword("secret123")
+	require.NoError(t, err)
+	user := &domain.User{ID: "user123", Email: "user@example.com", PasswordHash: hash, Role: domain.RoleUser, Verified: true}
+	users := new(MockUserRepository)
+	users.On("GetByEmail", mock.Anything, "user@example.com").Return(user, nil)
+	users.On("GetByEmail", mock.Anything, "nobody@example.com").Return(nil, nil)
+	sessions := new(MockSessionRepository)
+	sessions.On("Create", mock.Anything, mock.AnythingOfType("*domain.Session")).Return(nil)
+
+	repo := new(MockAuthEventRepository)
+	var events []*domain.AuthEvent
+	repo.On("Create", mock.Anything, mock.AnythingOfType("*domain.AuthEvent")).
+		Run(func(args mock.Arguments) { events = append(events, args.Get(1).(*domain.AuthEvent)) }).
+		Return(nil)
+	logger, _ := zap.NewDevelopment()
+	cfg := *testUserConfig
+	cfg.Events = usecase.NewAuthEventUseCase(repo, logger)
+	uc := usecase.NewUserUseCase(users, sessions, nil, auth.NewMemoryRevocationStore(), newTestThrottler(), new(MockEmailSender), &cfg, logger)
+	ctx := usecase.WithClientInfo(context.Background(), usecase.ClientInfo{IP: "10.0.0.1", UserAgent: "curl/8"})
+
+	_, err = uc.Login(ctx, usecase.LoginRequest{Email: "user@example.com", Password: "wrong"})
+	assert.ErrorIs(t, err, usecase.ErrInvalidCredentials)
+	_, err = uc.Login(ctx, usecase.LoginRequest{Email: "nobody@example.com", Password: "secret123"})
+	assert.ErrorIs(t, err, usecase.ErrInvalidCredentials)
+	_, err = uc.Login(ctx, usecase.LoginRequest{Email: "user@example.com", Password: "secret123"})
+	require.NoError(t, err)
+
+	require.Len(t, events, 3)
+	assert.Equal(t, domain.EventLoginFailed, events[0].Type)
+	assert.Equal(t, domain.OutcomeFailure, events[0].Outcome)
+	assert.Equal(t, "user123", events[0].UserID)
+	assert.Equal(t, "invalid_credentials", events[0].Reason)
+	assert.Equal(t, "10.0.0.1", events[0].IP)
+	assert.Equal(t, "curl/8", events[0].UserAgent)
+	assert.False(t, events[0].CreatedAt.IsZero())
+	// unknown accounts are recorded by the address tried
+	assert.Empty(t, events[1].UserID)
+	assert.Equal(t, "nobody@example.com", events[1].Email)
+	assert.Equal(t, domain.EventLogin, events[2].Type)
+	assert.Equal(t, domain.OutcomeSuccess, events[2].Outcome)
+}
+
+func TestAuthEventUseCase_ListAndExport(t *testing.T) {
+	repo := new(MockAuthEventRepository)
+	logger, _ := zap.NewDevelopment()
+	uc := usecase.NewAuthEventUseCase(repo, logger)
+	ctx := context.Background()
+	list := []*domain.AuthEvent{
+		{ID: "e1", Type: domain.EventLogin, Outcome: domain.OutcomeSuccess, UserID: "user123", IP: "10.0.0.1"},
+		{ID: "e2", Type: domain.EventRoleChanged, Outcome: domain.OutcomeSuccess, UserID: "user123", ActorID: "admin1", Details: map[string]string{"to": "manager"}},
+	}
+
+	// users only ever see their own events
+	repo.On("List", mock.Anything, domain.AuthEventFilter{UserID: "user123", Type: domain.EventLogin, Page: 1, PageSize: 20}).
+		Return(list[:1], 1, nil)
+	resp, err := uc.ListMine(ctx, "user123", usecase.ListSecurityEventsRequest{Type: "login"})
+	require.NoError(t, err)
+	require.Len(t, resp.Items, 1)
+	assert.Equal(t, "login", resp.Items[0].Type)
+
+	repo.On("Each", mock.Anything, domain.AuthEventFilter{UserID: "user123", Outcome: domain.OutcomeSuccess}).Return(list, nil)
+	var out bytes.Buffer
+	require.NoError(t, uc.Export(ctx, usecase.ListAuthEventsRequest{UserID: "user123", Outcome: "success", Page: 3}, &out))
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(t, lines, 2)
+	var second usecase.AuthEventResponse
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &second))
+	assert.Equal(t, "role_changed", second.Type)
+	assert.Equal(t, "admin1", second.ActorID)
+	assert.Equal(t, "manager", second.Details["to"])
+}
@@ -0,0 +1,152 @@
+package tests
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/HatefBarari/microblog-auth/internal/domain"
+	"github.com/HatefBarari/microblog-auth/internal/usecase"
+	"github.com/HatefBarari/microblog-shared/pkg/auth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+var (
+	confirmLinkPattern = regexp.MustCompile(`/email/confirm\?token=([A-Za-z0-9_-]+)`)
+	undoLinkPattern    = regexp.MustCompile(`/email/undo\?token=([A-Za-z0-9_-]+)`)
+)
+
+type emailChangeFixture struct {
+	uc       *usecase.EmailChangeUseCase
+	users    *MockUserRepository
+	sessions *MockSessionRepository
+	tokens   *MockAuthTokenRepository
+	sender   *MockEmailSender
+	stored   []*domain.AuthToken
+	sent     map[string]string // address -> body
+}
+
+func newEmailChangeFixture() *emailChangeFixture {
+	logger, _ := zap.NewDevelopment()
+	f := &emailChangeFixture{
+		users:    new(MockUserRepository),
+		sessions: new(MockSessionRepository),
+		tokens:   new(MockAuthTokenRepository),
+		sender:   new(MockEmailSender),
+		sent:     map[string]string{},
+	}
+	f.tokens.On("Create", mock.Anything, mock.AnythingOfType("*domain.AuthToken")).
+		Run(func(args mock.Arguments) { f.stored = append(f.stored, args.Get(1).(*domain.AuthToken)) }).
+		Return(nil)
+	f.tokens.On("DeleteByUser", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	f.sender.On("Send", mock.Anything, mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) { f.sent[args.String(0)] = args.String(2) }).
+		Return(nil)
+
+	emailUC := usecase.NewEmailUseCase(f.users, f.tokens, newTestThrottler(), f.sender, &usecase.EmailConfig{
+		BaseURL:       "http://localhost:8001",
+		TokenSecret:   "test-secret",
+		TokenTTLHours: 24,
+		UndoTTLHours:  168,
+	}, logger)
+	sessionUC := usecase.NewSessionUseCase(f.sessions, auth.NewMemoryRevocationStore(), 15*time.Minute, logger)
+	userUC := newTestUserUseCaseWith(f.users, f.sessions, newTestThrottler(), f.sender)
+	f.uc = usecase.NewEmailChangeUseCase(f.users, emailUC, sessionUC, userUC, logger)
+	return f
+}
+
+func (f *emailChangeFixture) token(purpose domain.TokenPurpose) *domain.AuthToken {
+	for _, t := range f.stored {
+		if t.Purpose == purpose {
+			return t
+		}
+	}
+	return nil
+}
+
+func TestEmailChangeUseCase_RequestNotifiesBothAddresses(t *testing.T) {
+	hash, err := auth.HashPassword("secret123")
+	require.NoError(t, err)
+	user := &domain.User{ID: "user123", Email: "old@example.com", PasswordHash: hash, Verified: true}
+
+	f := newEmailChangeFixture()
+	f.users.On("GetByID", mock.Anything, "user123").Return(user, nil)
+	f.users.On("GetByEmail", mock.Anything, "taken@example.com").Return(&domain.User{ID: "other"}, nil)
+	f.users.On("GetByEmail", mock.Anything, "new@example.com").Return(nil, nil)
+	ctx := context.Background()
+
+	err = f.uc.Request(ctx, "user123", usecase.ChangeEmailRequest{NewEmail: "new@example.com", Password: "wrong"})
+	assert.ErrorIs(t, err, usecase.ErrInvalidCredentials)
+	err = f.uc.Request(ctx, "user123", usecase.ChangeEmailRequest{NewEmail: "OLD@example.com", Password: "secret123"})
+	assert.ErrorIs(t, err, usecase.ErrSameEmail)
+	err = f.uc.Request(ctx, "user123", usecase.ChangeEmailRequest{NewEmail: "taken@example.com", Password: "secret123"})
+	assert.ErrorIs(t, err, domain.ErrEmailTaken)
+	assert.Empty(t, f.sent)
+
+	require.NoError(t, f.uc.Request(ctx, "user123", usecase.ChangeEmailRequest{NewEmail: "new@example.com", Password: "secret123"}))
+	assert.Regexp(t, confirmLinkPattern, f.sent["new@example.com"])
+	assert.Regexp(t, undoLinkPattern, f.sent["old@example.com"])
+	assert.NotRegexp(t, confirmLinkPattern, f.sent["old@example.com"])
+
+	confirm := f.token(domain.PurposeChangeEmail)
+	require.NotNil(t, confirm)
+	assert.Equal(t, "new@example.com", confirm.Email)
+	undo := f.token(domain.PurposeUndoEmailChange)
+	require.NotNil(t, undo)
+	assert.Equal(t, "old@example.com", undo.Email)
+	assert.WithinDuration(t, time.Now().Add(168*time.Hour), undo.ExpiresAt, time.Minute)
+	// nothing changes before confirmation
+	f.users.AssertNotCalled(t, "UpdateEmail", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestEmailChangeUseCase_ConfirmReissuesSessions(t *testing.T) {
+	user := &domain.User{ID: "user123", Email: "old@example.com", Role: domain.RoleUser, Verified: true}
+	f := newEmailChangeFixture()
+	f.users.On("GetByID", mock.Anything, "user123").Return(user, nil)
+	f.users.On("UpdateEmail", mock.Anything, "user123", "new@example.com").Return(nil)
+	f.tokens.On("Consume", mock.Anything, mock.Anything, domain.PurposeChangeEmail, mock.Anything).
+		Return(&domain.AuthToken{UserID: "user123", Purpose: domain.PurposeChangeEmail, Email: "new@example.com"}, nil)
+	f.sessions.On("RevokeAllByUser", mock.Anything, "user123", domain.RevokeReasonEmail, mock.Anything).Return(3, nil)
+	f.sessions.On("Create", mock.Anything, mock.AnythingOfType("*domain.Session")).Return(nil)
+
+	resp, err := f.uc.Confirm(context.Background(), wellFormedToken)
+	require.NoError(t, err)
+	assert.NotEmpty(t, resp.AccessToken)
+	assert.NotEmpty(t, resp.RefreshToken)
+	f.users.AssertExpectations(t)
+	f.sessions.AssertExpectations(t)
+	// links sent to the old address stop working
+	f.tokens.AssertCalled(t, "DeleteByUser", mock.Anything, "user123", domain.PurposeResetPassword)
+	f.tokens.AssertCalled(t, "DeleteByUser", mock.Anything, "user123", domain.PurposeMagicLogin)
+}
+
+func TestEmailChangeUseCase_ConfirmConflict(t *testing.T) {
+	f := newEmailChangeFixture()
+	f.users.On("GetByID", mock.Anything, "user123").Return(&domain.User{ID: "user123", Email: "old@example.com", Verified: true}, nil)
+	// someone registered the address after the request was made
+	f.users.On("UpdateEmail", mock.Anything, "user123", "new@example.com").Return(domain.ErrEmailTaken)
+	f.tokens.On("Consume", mock.Anything, mock.Anything, domain.PurposeChangeEmail, mock.Anything).
+		Return(&domain.AuthToken{UserID: "user123", Email: "new@example.com"}, nil)
+
+	_, err := f.uc.Confirm(context.Background(), wellFormedToken)
+	assert.ErrorIs(t, err, domain.ErrEmailTaken)
+	f.sessions.AssertNotCalled(t, "RevokeAllByUser", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestEmailChangeUseCase_UndoRestoresOldAddress(t *testing.T) {
+	f := newEmailChangeFixture()
+	f.users.On("GetByID", mock.Anything, "user123").Return(&domain.User{ID: "user123", Email: "attacker@example.com", Verified: true}, nil)
+	f.users.On("UpdateEmail", mock.Anything, "user123", "old@example.com").Return(nil)
+	f.tokens.On("Consume", mock.Anything, mock.Anything, domain.PurposeUndoEmailChange, mock.Anything).
+		Return(&domain.AuthToken{UserID: "user123", Email: "old@example.com"}, nil)
+	f.sessions.On("RevokeAllByUser", mock.Anything, "user123", domain.RevokeReasonEmail, mock.Anything).Return(1, nil)
+
+	require.NoError(t, f.uc.Undo(context.Background(), wellFormedToken))
+	f.users.AssertExpectations(t)
+	f.sessions.AssertExpectations(t)
+	f.tokens.AssertCalled(t, "DeleteByUser", mock.Anything, "user123", domain.PurposeChangeEmail)
+}
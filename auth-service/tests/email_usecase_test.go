@@ -3,12 +3,16 @@ package tests
 import (
 	"context"
 	"errors"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/HatefBarari/microblog-auth/internal/domain"
 	"github.com/HatefBarari/microblog-auth/internal/usecase"
+	"github.com/HatefBarari/microblog-shared/pkg/email"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
 )
 
@@ -38,11 +42,128 @@ func (m *MockUserRepository) GetByEmail(ctx context.Context, email string) (*dom
 	return args.Get(0).(*domain.User), args.Error(1)
 }
 
+func (m *MockUserRepository) GetByHandle(ctx context.Context, handle string) (*domain.User, error) {
+	args := m.Called(ctx, handle)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.User), args.Error(1)
+}
+
+func (m *MockUserRepository) GetByIDs(ctx context.Context, ids []string) ([]*domain.User, error) {
+	args := m.Called(ctx, ids)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.User), args.Error(1)
+}
+
+func (m *MockUserRepository) SetProfile(ctx context.Context, userID string, p *domain.Profile) error {
+	args := m.Called(ctx, userID, p)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) SetDeletion(ctx context.Context, userID string, d *domain.DeletionRequest) error {
+	args := m.Called(ctx, userID, d)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) ListDueDeletions(ctx context.Context, now time.Time, limit int) ([]*domain.User, error) {
+	args := m.Called(ctx, now, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.User), args.Error(1)
+}
+
 func (m *MockUserRepository) Update(ctx context.Context, user *domain.User) error {
 	args := m.Called(ctx, user)
 	return args.Error(0)
 }
 
+func (m *MockUserRepository) List(ctx context.Context, filter domain.UserFilter) ([]*domain.User, int, error) {
+	args := m.Called(ctx, filter)
+	if args.Get(0) == nil {
+		return nil, args.Int(1), args.Error(2)
+	}
+	return args.Get(0).([]*domain.User), args.Int(1), args.Error(2)
+}
+
+func (m *MockUserRepository) UpdateVerified(ctx context.Context, userID string, verified bool) error {
+	args := m.Called(ctx, userID, verified)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) UpdatePasswordHash(ctx context.Context, userID, oldHash, newHash string) error {
+	args := m.Called(ctx, userID, oldHash, newHash)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) UpdateEmail(ctx context.Context, userID, email string) error {
+	args := m.Called(ctx, userID, email)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) UpdateRole(ctx context.Context, userID string, role domain.Role) error {
+	args := m.Called(ctx, userID, role)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) SetStatus(ctx context.Context, userID string, status domain.UserStatus, reason string, until *time.Time) error {
+	args := m.Called(ctx, userID, status, reason, until)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) Delete(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) SetTwoFactor(ctx context.Context, userID string, tf *domain.TwoFactor) error {
+	args := m.Called(ctx, userID, tf)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) ConsumeRecoveryCode(ctx context.Context, userID, codeHash string) (bool, error) {
+	args := m.Called(ctx, userID, codeHash)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockUserRepository) AdvanceTOTPStep(ctx context.Context, userID string, step int64) (bool, error) {
+	args := m.Called(ctx, userID, step)
+	return args.Bool(0), args.Error(1)
+}
+
+type MockAuthTokenRepository struct {
+	mock.Mock
+}
+
+func (m *MockAuthTokenRepository) Create(ctx context.Context, t *domain.AuthToken) error {
+	args := m.Called(ctx, t)
+	return args.Error(0)
+}
+
+func (m *MockAuthTokenRepository) Consume(ctx context.Context, tokenHash string, purpose domain.TokenPurpose, now time.Time) (*domain.AuthToken, error) {
+	args := m.Called(ctx, tokenHash, purpose, now)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.AuthToken), args.Error(1)
+}
+
+func (m *MockAuthTokenRepository) DeleteByUser(ctx context.Context, userID string, purpose domain.TokenPurpose) error {
+	args := m.Called(ctx, userID, purpose)
+	return args.Error(0)
+}
+
+func (m *MockAuthTokenRepository) PurgeUser(ctx context.Context, userID string) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+// wellFormedToken has the length of a generated token but is never issued
+var wellFormedToken = strings.Repeat("a", 43)
+
 type MockEmailSender struct {
 	mock.Mock
 }
@@ -87,11 +208,18 @@ func TestEmailUseCase_SendVerificationEmail(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			mockRepo := new(MockUserRepository)
+			mockTokens := new(MockAuthTokenRepository)
 			mockSender := new(MockEmailSender)
+			mockTokens.On("DeleteByUser", mock.Anything, tt.userID, domain.PurposeVerifyEmail).Return(nil)
+			mockTokens.On("Create", mock.Anything, mock.MatchedBy(func(tok *domain.AuthToken) bool {
+				return tok.UserID == tt.userID && tok.Purpose == domain.PurposeVerifyEmail && tok.TokenHash != ""
+			})).Return(nil)
 			tt.mockSetup(mockSender)
 
 			uc := usecase.NewEmailUseCase(
 				mockRepo,
+				mockTokens,
+				newTestThrottler(),
 				mockSender,
 				&usecase.EmailConfig{
 					FromEmail:     "noreply@microblog.com",
@@ -122,18 +250,20 @@ func TestEmailUseCase_VerifyEmail(t *testing.T) {
 	tests := []struct {
 		name           string
 		token          string
-		mockSetup      func(*MockUserRepository)
+		mockSetup      func(*MockUserRepository, *MockAuthTokenRepository)
 		expectedError  string
 	}{
 		{
 			name:  "successful email verification",
-			token: "valid_token",
-			mockSetup: func(mockRepo *MockUserRepository) {
+			token: wellFormedToken,
+			mockSetup: func(mockRepo *MockUserRepository, mockTokens *MockAuthTokenRepository) {
 				user := &domain.User{
 					ID:       "user123",
 					Email:    "user@example.com",
 					Verified: false,
 				}
+				mockTokens.On("Consume", mock.Anything, mock.AnythingOfType("string"), domain.PurposeVerifyEmail, mock.Anything).
+					Return(&domain.AuthToken{UserID: "user123", Purpose: domain.PurposeVerifyEmail}, nil)
 				mockRepo.On("GetByID", mock.Anything, "user123").Return(user, nil)
 				mockRepo.On("Update", mock.Anything, mock.MatchedBy(func(u *domain.User) bool {
 					return u.ID == "user123" && u.Verified == true
@@ -144,28 +274,41 @@ func TestEmailUseCase_VerifyEmail(t *testing.T) {
 		{
 			name:  "invalid token",
 			token: "invalid_token",
-			mockSetup: func(mockRepo *MockUserRepository) {
+			mockSetup: func(mockRepo *MockUserRepository, mockTokens *MockAuthTokenRepository) {
 				// No repository calls expected for invalid token
 			},
 			expectedError: "invalid verification token",
 		},
+		{
+			name:  "expired or already used token",
+			token: wellFormedToken,
+			mockSetup: func(mockRepo *MockUserRepository, mockTokens *MockAuthTokenRepository) {
+				mockTokens.On("Consume", mock.Anything, mock.AnythingOfType("string"), domain.PurposeVerifyEmail, mock.Anything).
+					Return(nil, nil)
+			},
+			expectedError: "invalid verification token",
+		},
 		{
 			name:  "user not found",
-			token: "valid_token",
-			mockSetup: func(mockRepo *MockUserRepository) {
+			token: wellFormedToken,
+			mockSetup: func(mockRepo *MockUserRepository, mockTokens *MockAuthTokenRepository) {
+				mockTokens.On("Consume", mock.Anything, mock.AnythingOfType("string"), domain.PurposeVerifyEmail, mock.Anything).
+					Return(&domain.AuthToken{UserID: "user123", Purpose: domain.PurposeVerifyEmail}, nil)
 				mockRepo.On("GetByID", mock.Anything, "user123").Return(nil, nil)
 			},
 			expectedError: "user not found",
 		},
 		{
 			name:  "email already verified",
-			token: "valid_token",
-			mockSetup: func(mockRepo *MockUserRepository) {
+			token: wellFormedToken,
+			mockSetup: func(mockRepo *MockUserRepository, mockTokens *MockAuthTokenRepository) {
 				user := &domain.User{
 					ID:       "user123",
 					Email:    "user@example.com",
 					Verified: true, // already verified
 				}
+				mockTokens.On("Consume", mock.Anything, mock.AnythingOfType("string"), domain.PurposeVerifyEmail, mock.Anything).
+					Return(&domain.AuthToken{UserID: "user123", Purpose: domain.PurposeVerifyEmail}, nil)
 				mockRepo.On("GetByID", mock.Anything, "user123").Return(user, nil)
 			},
 			expectedError: "email already verified",
@@ -175,11 +318,14 @@ func TestEmailUseCase_VerifyEmail(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			mockRepo := new(MockUserRepository)
+			mockTokens := new(MockAuthTokenRepository)
 			mockSender := new(MockEmailSender)
-			tt.mockSetup(mockRepo)
+			tt.mockSetup(mockRepo, mockTokens)
 
 			uc := usecase.NewEmailUseCase(
 				mockRepo,
+				mockTokens,
+				newTestThrottler(),
 				mockSender,
 				&usecase.EmailConfig{
 					FromEmail:     "noreply@microblog.com",
@@ -200,6 +346,7 @@ func TestEmailUseCase_VerifyEmail(t *testing.T) {
 			}
 
 			mockRepo.AssertExpectations(t)
+			mockTokens.AssertExpectations(t)
 		})
 	}
 }
@@ -210,18 +357,20 @@ func TestEmailUseCase_SendPasswordResetEmail(t *testing.T) {
 	tests := []struct {
 		name           string
 		email          string
-		mockSetup      func(*MockUserRepository, *MockEmailSender)
+		mockSetup      func(*MockUserRepository, *MockAuthTokenRepository, *MockEmailSender)
 		expectedError  string
 	}{
 		{
 			name:  "successful password reset email",
 			email: "user@example.com",
-			mockSetup: func(mockRepo *MockUserRepository, mockSender *MockEmailSender) {
+			mockSetup: func(mockRepo *MockUserRepository, mockTokens *MockAuthTokenRepository, mockSender *MockEmailSender) {
 				user := &domain.User{
 					ID:    "user123",
 					Email: "user@example.com",
 				}
 				mockRepo.On("GetByEmail", mock.Anything, "user@example.com").Return(user, nil)
+				mockTokens.On("DeleteByUser", mock.Anything, "user123", domain.PurposeResetPassword).Return(nil)
+				mockTokens.On("Create", mock.Anything, mock.AnythingOfType("*domain.AuthToken")).Return(nil)
 				mockSender.On("Send", "user@example.com", mock.AnythingOfType("string"), mock.AnythingOfType("string")).
 					Return(nil)
 			},
@@ -230,7 +379,7 @@ func TestEmailUseCase_SendPasswordResetEmail(t *testing.T) {
 		{
 			name:  "user not found (should not reveal)",
 			email: "nonexistent@example.com",
-			mockSetup: func(mockRepo *MockUserRepository, mockSender *MockEmailSender) {
+			mockSetup: func(mockRepo *MockUserRepository, mockTokens *MockAuthTokenRepository, mockSender *MockEmailSender) {
 				mockRepo.On("GetByEmail", mock.Anything, "nonexistent@example.com").Return(nil, nil)
 				// No email should be sent
 			},
@@ -239,12 +388,14 @@ func TestEmailUseCase_SendPasswordResetEmail(t *testing.T) {
 		{
 			name:  "email send failure",
 			email: "user@example.com",
-			mockSetup: func(mockRepo *MockUserRepository, mockSender *MockEmailSender) {
+			mockSetup: func(mockRepo *MockUserRepository, mockTokens *MockAuthTokenRepository, mockSender *MockEmailSender) {
 				user := &domain.User{
 					ID:    "user123",
 					Email: "user@example.com",
 				}
 				mockRepo.On("GetByEmail", mock.Anything, "user@example.com").Return(user, nil)
+				mockTokens.On("DeleteByUser", mock.Anything, "user123", domain.PurposeResetPassword).Return(nil)
+				mockTokens.On("Create", mock.Anything, mock.AnythingOfType("*domain.AuthToken")).Return(nil)
 				mockSender.On("Send", "user@example.com", mock.AnythingOfType("string"), mock.AnythingOfType("string")).
 					Return(errors.New("smtp error"))
 			},
@@ -255,11 +406,14 @@ func TestEmailUseCase_SendPasswordResetEmail(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			mockRepo := new(MockUserRepository)
+			mockTokens := new(MockAuthTokenRepository)
 			mockSender := new(MockEmailSender)
-			tt.mockSetup(mockRepo, mockSender)
+			tt.mockSetup(mockRepo, mockTokens, mockSender)
 
 			uc := usecase.NewEmailUseCase(
 				mockRepo,
+				mockTokens,
+				newTestThrottler(),
 				mockSender,
 				&usecase.EmailConfig{
 					FromEmail:     "noreply@microblog.com",
@@ -280,6 +434,7 @@ func TestEmailUseCase_SendPasswordResetEmail(t *testing.T) {
 			}
 
 			mockRepo.AssertExpectations(t)
+			mockTokens.AssertExpectations(t)
 			mockSender.AssertExpectations(t)
 		})
 	}
@@ -292,19 +447,21 @@ func TestEmailUseCase_ResetPassword(t *testing.T) {
 		name           string
 		token          string
 		newPassword    string
-		mockSetup      func(*MockUserRepository)
+		mockSetup      func(*MockUserRepository, *MockAuthTokenRepository)
 		expectedError  string
 	}{
 		{
 			name:        "successful password reset",
-			token:       "valid_token",
+			token:       wellFormedToken,
 			newPassword: "newpassword123",
-			mockSetup: func(mockRepo *MockUserRepository) {
+			mockSetup: func(mockRepo *MockUserRepository, mockTokens *MockAuthTokenRepository) {
 				user := &domain.User{
 					ID:           "user123",
 					Email:        "user@example.com",
 					PasswordHash: "old_hash",
 				}
+				mockTokens.On("Consume", mock.Anything, mock.AnythingOfType("string"), domain.PurposeResetPassword, mock.Anything).
+					Return(&domain.AuthToken{UserID: "user123", Purpose: domain.PurposeResetPassword}, nil)
 				mockRepo.On("GetByID", mock.Anything, "user123").Return(user, nil)
 				mockRepo.On("Update", mock.Anything, mock.MatchedBy(func(u *domain.User) bool {
 					return u.ID == "user123" && u.PasswordHash != "old_hash"
@@ -316,16 +473,28 @@ func TestEmailUseCase_ResetPassword(t *testing.T) {
 			name:        "invalid token",
 			token:       "invalid_token",
 			newPassword: "newpassword123",
-			mockSetup: func(mockRepo *MockUserRepository) {
+			mockSetup: func(mockRepo *MockUserRepository, mockTokens *MockAuthTokenRepository) {
 				// No repository calls expected for invalid token
 			},
 			expectedError: "invalid reset token",
 		},
+		{
+			name:        "expired or already used token",
+			token:       wellFormedToken,
+			newPassword: "newpassword123",
+			mockSetup: func(mockRepo *MockUserRepository, mockTokens *MockAuthTokenRepository) {
+				mockTokens.On("Consume", mock.Anything, mock.AnythingOfType("string"), domain.PurposeResetPassword, mock.Anything).
+					Return(nil, nil)
+			},
+			expectedError: "invalid reset token",
+		},
 		{
 			name:        "user not found",
-			token:       "valid_token",
+			token:       wellFormedToken,
 			newPassword: "newpassword123",
-			mockSetup: func(mockRepo *MockUserRepository) {
+			mockSetup: func(mockRepo *MockUserRepository, mockTokens *MockAuthTokenRepository) {
+				mockTokens.On("Consume", mock.Anything, mock.AnythingOfType("string"), domain.PurposeResetPassword, mock.Anything).
+					Return(&domain.AuthToken{UserID: "user123", Purpose: domain.PurposeResetPassword}, nil)
 				mockRepo.On("GetByID", mock.Anything, "user123").Return(nil, nil)
 			},
 			expectedError: "user not found",
@@ -335,11 +504,14 @@ func TestEmailUseCase_ResetPassword(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			mockRepo := new(MockUserRepository)
+			mockTokens := new(MockAuthTokenRepository)
 			mockSender := new(MockEmailSender)
-			tt.mockSetup(mockRepo)
+			tt.mockSetup(mockRepo, mockTokens)
 
 			uc := usecase.NewEmailUseCase(
 				mockRepo,
+				mockTokens,
+				newTestThrottler(),
 				mockSender,
 				&usecase.EmailConfig{
 					FromEmail:     "noreply@microblog.com",
@@ -360,6 +532,113 @@ func TestEmailUseCase_ResetPassword(t *testing.T) {
 			}
 
 			mockRepo.AssertExpectations(t)
+			mockTokens.AssertExpectations(t)
+		})
+	}
+}
+
+func TestEmailUseCase_ResendVerificationEmail(t *testing.T) {
+	logger, _ := zap.NewDevelopment()
+
+	tests := []struct {
+		name          string
+		user          *domain.User
+		expectedError string
+	}{
+		{
+			name:          "unverified user gets a new link",
+			user:          &domain.User{ID: "user123", Email: "user@example.com"},
+			expectedError: "",
+		},
+		{
+			name:          "already verified",
+			user:          &domain.User{ID: "user123", Email: "user@example.com", Verified: true},
+			expectedError: "email already verified",
+		},
+		{
+			name:          "user not found",
+			user:          nil,
+			expectedError: "user not found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockUserRepository)
+			mockTokens := new(MockAuthTokenRepository)
+			mockSender := new(MockEmailSender)
+			if tt.user == nil {
+				mockRepo.On("GetByID", mock.Anything, "user123").Return(nil, nil)
+			} else {
+				mockRepo.On("GetByID", mock.Anything, "user123").Return(tt.user, nil)
+			}
+			if tt.expectedError == "" {
+				mockTokens.On("DeleteByUser", mock.Anything, "user123", domain.PurposeVerifyEmail).Return(nil)
+				mockTokens.On("Create", mock.Anything, mock.AnythingOfType("*domain.AuthToken")).Return(nil)
+				mockSender.On("Send", "user@example.com", mock.AnythingOfType("string"), mock.AnythingOfType("string")).Return(nil)
+			}
+
+			uc := usecase.NewEmailUseCase(mockRepo, mockTokens, newTestThrottler(), mockSender, &usecase.EmailConfig{
+				BaseURL:       "http://localhost:8081",
+				TokenSecret:   "secret",
+				TokenTTLHours: 24,
+			}, logger)
+
+			err := uc.ResendVerificationEmail(context.Background(), "user123")
+			if tt.expectedError != "" {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedError)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			mockRepo.AssertExpectations(t)
+			mockTokens.AssertExpectations(t)
+			mockSender.AssertExpectations(t)
 		})
 	}
 }
+
+// MockMessageSender also takes multipart messages.
+type MockMessageSender struct {
+	MockEmailSender
+}
+
+func (m *MockMessageSender) SendMessage(msg *email.Message) error {
+	args := m.Called(msg)
+	return args.Error(0)
+}
+
+func TestEmailUseCase_SendsInUserLocale(t *testing.T) {
+	logger, _ := zap.NewDevelopment()
+	cfg := &usecase.EmailConfig{BaseURL: "http://localhost:8081", TokenSecret: "secret", TokenTTLHours: 24}
+	user := &domain.User{ID: "user123", Email: "user@example.com", Profile: &domain.Profile{Locale: "en-GB"}}
+
+	mockTokens := new(MockAuthTokenRepository)
+	mockTokens.On("DeleteByUser", mock.Anything, "user123", domain.PurposeResetPassword).Return(nil)
+	mockTokens.On("Create", mock.Anything, mock.AnythingOfType("*domain.AuthToken")).Return(nil)
+
+	// plain senders get the text part
+	mockSender := new(MockEmailSender)
+	mockSender.On("Send", "user@example.com", "Reset your password - Microblog", mock.MatchedBy(func(body string) bool {
+		return strings.Contains(body, "http://localhost:8081/reset-password?token=")
+	})).Return(nil)
+	uc := usecase.NewEmailUseCase(new(MockUserRepository), mockTokens, newTestThrottler(), mockSender, cfg, logger)
+	require.NoError(t, uc.SendPasswordResetEmailFor(context.Background(), user))
+	mockSender.AssertExpectations(t)
+
+	// message senders get both parts; no locale falls back to Persian
+	user.Profile = nil
+	var sent *email.Message
+	mockMessages := new(MockMessageSender)
+	mockMessages.On("SendMessage", mock.AnythingOfType("*email.Message")).
+		Run(func(args mock.Arguments) { sent = args.Get(0).(*email.Message) }).
+		Return(nil)
+	uc = usecase.NewEmailUseCase(new(MockUserRepository), mockTokens, newTestThrottler(), mockMessages, cfg, logger)
+	require.NoError(t, uc.SendPasswordResetEmailFor(context.Background(), user))
+	require.NotNil(t, sent)
+	assert.Equal(t, "user@example.com", sent.To)
+	assert.Equal(t, "بازیابی رمز عبور - Microblog", sent.Subject)
+	assert.Contains(t, sent.HTML, `href="http://localhost:8081/reset-password?token=`)
+	mockMessages.AssertNotCalled(t, "Send", mock.Anything, mock.Anything, mock.Anything)
+}
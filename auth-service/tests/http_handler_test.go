@@ -6,6 +6,7 @@ import (
 
 	"github.com/HatefBarari/microblog-auth/internal/repository"
 	"github.com/HatefBarari/microblog-auth/internal/usecase"
+	"github.com/HatefBarari/microblog-shared/pkg/auth"
 	"github.com/HatefBarari/microblog-shared/pkg/email"
 	"github.com/HatefBarari/microblog-shared/pkg/logger"
 	"github.com/HatefBarari/microblog-shared/pkg/mongo"
@@ -16,20 +17,15 @@ func TestRegisterDuplicateEmail(t *testing.T) {
 	log, _ := logger.NewFile("debug", "logs/test.log")
 	_ = mongo.Connect("mongodb://localhost:27017", "testdb", log)
 	repo := repository.NewMongoUserRepo()
-	emailSender := email.NewSender(email.Config{
-		Host: "localhost",
-		Port: "1025",
-		User: "",
-		Pass: "",
-	})
+	emailSender := email.NewSenderWithTransport(email.NewRecorder(), "test@local")
 	cfg := &usecase.Config{
-		AccessSecret:   "test-access",
+		AccessSigner:   testAccessKeys,
 		RefreshSecret:  "test-refresh",
 		AccessTTLMin:   15,
 		RefreshTTLHour: 168,
 		EmailFrom:      "test@local",
 	}
-	uc := usecase.NewUserUseCase(repo, emailSender, cfg, log)
+	uc := usecase.NewUserUseCase(repo, repository.NewMongoSessionRepo(), repository.NewMongoInvitationRepo(), auth.NewMemoryRevocationStore(), newTestThrottler(), emailSender, cfg, log)
 
 	ctx := context.Background()
 	req1 := usecase.RegisterRequest{Email: "a@x.com", Password: "123456"}
@@ -45,22 +41,7 @@ func TestEmailVerification(t *testing.T) {
 	log, _ := logger.NewFile("debug", "logs/test.log")
 	_ = mongo.Connect("mongodb://localhost:27017", "testdb", log)
 	repo := repository.NewMongoUserRepo()
-	emailSender := email.NewSender(email.Config{
-		Host: "localhost",
-		Port: "1025",
-		User: "",
-		Pass: "",
-	})
-	
-	// User usecase
-	cfg := &usecase.Config{
-		AccessSecret:   "test-access",
-		RefreshSecret:  "test-refresh",
-		AccessTTLMin:   15,
-		RefreshTTLHour: 168,
-		EmailFrom:      "test@local",
-	}
-	uc := usecase.NewUserUseCase(repo, emailSender, cfg, log)
+	emailSender := email.NewSenderWithTransport(email.NewRecorder(), "test@local")
 	
 	// Email usecase
 	emailCfg := &usecase.EmailConfig{
@@ -69,7 +50,7 @@ func TestEmailVerification(t *testing.T) {
 		TokenSecret:   "test-secret",
 		TokenTTLHours: 24,
 	}
-	emailUC := usecase.NewEmailUseCase(repo, emailSender, emailCfg, log)
+	emailUC := usecase.NewEmailUseCase(repo, repository.NewMongoAuthTokenRepo(), newTestThrottler(), emailSender, emailCfg, log)
 
 	ctx := context.Background()
 	
@@ -77,8 +58,7 @@ func TestEmailVerification(t *testing.T) {
 	err := emailUC.SendVerificationEmail(ctx, "user123", "test@example.com")
 	assert.NoError(t, err)
 	
-	// Test email verification (this will fail with current implementation)
-	// In a real implementation, you would need proper token validation
+	// Unknown tokens are rejected
 	err = emailUC.VerifyEmail(ctx, "invalid_token")
 	assert.Error(t, err)
 }
@@ -87,12 +67,7 @@ func TestPasswordReset(t *testing.T) {
 	log, _ := logger.NewFile("debug", "logs/test.log")
 	_ = mongo.Connect("mongodb://localhost:27017", "testdb", log)
 	repo := repository.NewMongoUserRepo()
-	emailSender := email.NewSender(email.Config{
-		Host: "localhost",
-		Port: "1025",
-		User: "",
-		Pass: "",
-	})
+	emailSender := email.NewSenderWithTransport(email.NewRecorder(), "test@local")
 	
 	// Email usecase
 	emailCfg := &usecase.EmailConfig{
@@ -101,7 +76,7 @@ func TestPasswordReset(t *testing.T) {
 		TokenSecret:   "test-secret",
 		TokenTTLHours: 24,
 	}
-	emailUC := usecase.NewEmailUseCase(repo, emailSender, emailCfg, log)
+	emailUC := usecase.NewEmailUseCase(repo, repository.NewMongoAuthTokenRepo(), newTestThrottler(), emailSender, emailCfg, log)
 
 	ctx := context.Background()
 	
@@ -109,8 +84,7 @@ func TestPasswordReset(t *testing.T) {
 	err := emailUC.SendPasswordResetEmail(ctx, "test@example.com")
 	assert.NoError(t, err)
 	
-	// Test password reset (this will fail with current implementation)
-	// In a real implementation, you would need proper token validation
+	// Unknown tokens are rejected
 	err = emailUC.ResetPassword(ctx, "invalid_token", "newpassword123")
 	assert.Error(t, err)
 }
\ No newline at end of file
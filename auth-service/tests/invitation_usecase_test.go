@@ -0,0 +1,169 @@
+package tests
+
+import (
+	"context"
+	"net/url"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/HatefBarari/microblog-auth/internal/domain"
+	"github.com/HatefBarari/microblog-auth/internal/usecase"
+	"github.com/HatefBarari/microblog-shared/pkg/auth"
+	"github.com/HatefBarari/microblog-shared/pkg/email"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type MockInvitationRepository struct {
+	mock.Mock
+}
+
+func (m *MockInvitationRepository) Create(ctx context.Context, inv *domain.Invitation) error {
+	args := m.Called(ctx, inv)
+	return args.Error(0)
+}
+
+func (m *MockInvitationRepository) GetByID(ctx context.Context, id string) (*domain.Invitation, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Invitation), args.Error(1)
+}
+
+func (m *MockInvitationRepository) Accept(ctx context.Context, codeHash, email string, now time.Time) (*domain.Invitation, error) {
+	args := m.Called(ctx, codeHash, email, now)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Invitation), args.Error(1)
+}
+
+func (m *MockInvitationRepository) SetAcceptedBy(ctx context.Context, id, userID string) error {
+	args := m.Called(ctx, id, userID)
+	return args.Error(0)
+}
+
+func (m *MockInvitationRepository) Release(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockInvitationRepository) Revoke(ctx context.Context, id string, now time.Time) (bool, error) {
+	args := m.Called(ctx, id, now)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockInvitationRepository) List(ctx context.Context, filter domain.InvitationFilter, now time.Time) ([]*domain.Invitation, int, error) {
+	args := m.Called(ctx, filter, now)
+	return args.Get(0).([]*domain.Invitation), args.Int(1), args.Error(2)
+}
+
+func newInvitationUserUseCase(users *MockUserRepository, sessions *MockSessionRepository, invitations *MockInvitationRepository, mode usecase.RegistrationMode) *usecase.UserUseCase {
+	logger, _ := zap.NewDevelopment()
+	cfg := *testUserConfig
+	cfg.Registration = mode
+	sender := email.NewSenderWithTransport(email.NewRecorder(), "noreply@microblog.local")
+	return usecase.NewUserUseCase(users, sessions, invitations, auth.NewMemoryRevocationStore(), newTestThrottler(), sender, &cfg, logger)
+}
+
+func TestUserUseCase_RegistrationModes(t *testing.T) {
+	ctx := context.Background()
+	req := usecase.RegisterRequest{Email: "new@example.com", Password: "secret123"}
+
+	_, err := newInvitationUserUseCase(new(MockUserRepository), nil, nil, usecase.RegistrationClosed).Register(ctx, req)
+	assert.ErrorIs(t, err, usecase.ErrRegistrationClosed)
+
+	_, err = newInvitationUserUseCase(new(MockUserRepository), nil, nil, usecase.RegistrationInviteOnly).Register(ctx, req)
+	assert.ErrorIs(t, err, usecase.ErrInvitationRequired)
+
+	// a code that doesn't match a pending invitation for this address
+	users := new(MockUserRepository)
+	users.On("GetByEmail", mock.Anything, "new@example.com").Return(nil, nil)
+	invitations := new(MockInvitationRepository)
+	invitations.On("Accept", mock.Anything, mock.Anything, "new@example.com", mock.Anything).Return(nil, nil)
+	req.Invitation = "bogus"
+	_, err = newInvitationUserUseCase(users, nil, invitations, usecase.RegistrationInviteOnly).Register(ctx, req)
+	assert.ErrorIs(t, err, usecase.ErrInvalidInvitation)
+	users.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestInvitationUseCase_InviteAndRegister(t *testing.T) {
+	manager := &domain.User{ID: "mgr1", Email: "mgr@example.com", Role: domain.RoleManager, Verified: true}
+	users := new(MockUserRepository)
+	users.On("GetByID", mock.Anything, "mgr1").Return(manager, nil)
+	users.On("GetByEmail", mock.Anything, "new@example.com").Return(nil, nil)
+	invitations := new(MockInvitationRepository)
+	var stored *domain.Invitation
+	invitations.On("Create", mock.Anything, mock.AnythingOfType("*domain.Invitation")).
+		Run(func(args mock.Arguments) {
+			stored = args.Get(1).(*domain.Invitation)
+			stored.ID = "inv1"
+		}).
+		Return(nil)
+
+	recorder := email.NewRecorder()
+	logger, _ := zap.NewDevelopment()
+	uc := usecase.NewInvitationUseCase(invitations, users, email.NewSenderWithTransport(recorder, "noreply@microblog.local"), usecase.InvitationConfig{
+		BaseURL:        "https://microblog.example.com",
+		DefaultTTLDays: 7,
+		MaxTTLDays:     30,
+		Registration:   usecase.RegistrationInviteOnly,
+	}, logger)
+	ctx := context.Background()
+
+	// managers can't hand out admin
+	_, err := uc.Create(ctx, "mgr1", usecase.CreateInvitationRequest{Email: "new@example.com", Role: "admin"})
+	assert.ErrorIs(t, err, usecase.ErrRoleTooHigh)
+
+	resp, err := uc.Create(ctx, "mgr1", usecase.CreateInvitationRequest{Email: "New@Example.com", Role: "manager"})
+	require.NoError(t, err)
+	assert.Equal(t, "new@example.com", resp.Email)
+	assert.Equal(t, "manager", resp.Role)
+	assert.WithinDuration(t, time.Now().AddDate(0, 0, 7), resp.ExpiresAt, time.Minute)
+
+	sent := recorder.Deliveries()
+	require.Len(t, sent, 1)
+	assert.Equal(t, "new@example.com", sent[0].Message.To)
+	link := regexp.MustCompile(`https://microblog\.example\.com/register\?\S+`).FindString(sent[0].Message.Text)
+	require.NotEmpty(t, link)
+	u, err := url.Parse(link)
+	require.NoError(t, err)
+	code := u.Query().Get("invitation")
+	require.NotEmpty(t, code)
+	assert.NotEqual(t, code, stored.CodeHash)
+
+	// registering with the code applies the invitation's role
+	sessions := new(MockSessionRepository)
+	sessions.On("Create", mock.Anything, mock.AnythingOfType("*domain.Session")).Return(nil)
+	users.On("Create", mock.Anything, mock.MatchedBy(func(u *domain.User) bool {
+		return u.Role == domain.RoleManager && u.Verified
+	})).Run(func(args mock.Arguments) { args.Get(1).(*domain.User).ID = "user2" }).Return(nil)
+	invitations.On("Accept", mock.Anything, stored.CodeHash, "new@example.com", mock.Anything).Return(stored, nil)
+	invitations.On("SetAcceptedBy", mock.Anything, "inv1", "user2").Return(nil)
+
+	userUC := newInvitationUserUseCase(users, sessions, invitations, usecase.RegistrationInviteOnly)
+	reg, err := userUC.Register(ctx, usecase.RegisterRequest{Email: "new@example.com", Password: "secret123", Invitation: code})
+	require.NoError(t, err)
+	claims, err := testAccessKeys.Verify(reg.AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, "manager", claims.Role)
+	invitations.AssertExpectations(t)
+}
+
+func TestInvitationUseCase_RevokeOnlyOwn(t *testing.T) {
+	invitations := new(MockInvitationRepository)
+	invitations.On("GetByID", mock.Anything, "inv1").Return(&domain.Invitation{ID: "inv1", InvitedBy: "mgr1"}, nil)
+	invitations.On("Revoke", mock.Anything, "inv1", mock.Anything).Return(true, nil)
+	logger, _ := zap.NewDevelopment()
+	uc := usecase.NewInvitationUseCase(invitations, new(MockUserRepository), new(MockEmailSender), usecase.InvitationConfig{}, logger)
+	ctx := context.Background()
+
+	err := uc.Revoke(ctx, "mgr2", domain.RoleManager, "inv1")
+	assert.ErrorIs(t, err, usecase.ErrInvitationNotFound)
+	require.NoError(t, uc.Revoke(ctx, "admin1", domain.RoleAdmin, "inv1"))
+	invitations.AssertNumberOfCalls(t, "Revoke", 1)
+}
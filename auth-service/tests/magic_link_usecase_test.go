@@ -0,0 +1,111 @@
+package tests
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/HatefBarari/microblog-auth/internal/domain"
+	"github.com/HatefBarari/microblog-auth/internal/usecase"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+var magicLinkPattern = regexp.MustCompile(`/login/magic/verify\?token=([A-Za-z0-9_-]+)`)
+
+func newTestMagicLinkUseCase(repo *MockUserRepository, sessions *MockSessionRepository, tokens *MockAuthTokenRepository, sender *MockEmailSender) *usecase.MagicLinkUseCase {
+	logger, _ := zap.NewDevelopment()
+	userUC := newTestUserUseCaseWith(repo, sessions, newTestThrottler(), sender)
+	return usecase.NewMagicLinkUseCase(repo, tokens, newTestThrottler(), sender, userUC, usecase.MagicLinkConfig{
+		BaseURL:     "http://localhost:8001",
+		TokenSecret: "test-secret",
+		TTL:         15 * time.Minute,
+	}, logger)
+}
+
+func TestMagicLinkUseCase_BoundToRequestingBrowser(t *testing.T) {
+	user := &domain.User{ID: "user123", Email: "user@example.com", Role: domain.RoleUser, Verified: true}
+	mockRepo := new(MockUserRepository)
+	mockSessions := new(MockSessionRepository)
+	mockTokens := new(MockAuthTokenRepository)
+	mockSender := new(MockEmailSender)
+
+	var stored *domain.AuthToken
+	var body string
+	mockRepo.On("GetByEmail", mock.Anything, "user@example.com").Return(user, nil)
+	mockRepo.On("GetByID", mock.Anything, "user123").Return(user, nil)
+	mockTokens.On("DeleteByUser", mock.Anything, "user123", domain.PurposeMagicLogin).Return(nil)
+	mockTokens.On("Create", mock.Anything, mock.AnythingOfType("*domain.AuthToken")).
+		Run(func(args mock.Arguments) { stored = args.Get(1).(*domain.AuthToken) }).
+		Return(nil)
+	mockSender.On("Send", "user@example.com", mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) { body = args.String(2) }).
+		Return(nil)
+	mockSessions.On("Create", mock.Anything, mock.AnythingOfType("*domain.Session")).Return(nil)
+
+	uc := newTestMagicLinkUseCase(mockRepo, mockSessions, mockTokens, mockSender)
+	ctx := context.Background()
+	nonce, err := uc.Request(ctx, "user@example.com")
+	require.NoError(t, err)
+	require.NotEmpty(t, nonce.Value)
+	require.NotNil(t, stored)
+	assert.Equal(t, domain.PurposeMagicLogin, stored.Purpose)
+	assert.WithinDuration(t, time.Now().Add(15*time.Minute), stored.ExpiresAt, time.Minute)
+
+	m := magicLinkPattern.FindStringSubmatch(body)
+	require.Len(t, m, 2)
+	token := m[1]
+	assert.NotContains(t, body, nonce.Value)
+
+	// another browser has no (or a different) nonce, so the hash never
+	// matches and the token is left for the right one
+	mockTokens.On("Consume", mock.Anything, stored.TokenHash, domain.PurposeMagicLogin, mock.Anything).Return(stored, nil).Once()
+	mockTokens.On("Consume", mock.Anything, mock.Anything, domain.PurposeMagicLogin, mock.Anything).Return(nil, nil)
+
+	_, err = uc.Complete(ctx, token, "")
+	assert.ErrorIs(t, err, usecase.ErrInvalidToken)
+	_, err = uc.Complete(ctx, token, "other-browser")
+	assert.ErrorIs(t, err, usecase.ErrInvalidToken)
+
+	resp, err := uc.Complete(ctx, token, nonce.Value)
+	require.NoError(t, err)
+	assert.NotEmpty(t, resp.AccessToken)
+	assert.NotEmpty(t, resp.RefreshToken)
+
+	// single use
+	_, err = uc.Complete(ctx, token, nonce.Value)
+	assert.ErrorIs(t, err, usecase.ErrInvalidToken)
+}
+
+func TestMagicLinkUseCase_SilentForUnusableAccounts(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	mockSender := new(MockEmailSender)
+	mockRepo.On("GetByEmail", mock.Anything, "nobody@example.com").Return(nil, nil)
+	mockRepo.On("GetByEmail", mock.Anything, "new@example.com").
+		Return(&domain.User{ID: "user456", Email: "new@example.com", Verified: false}, nil)
+
+	uc := newTestMagicLinkUseCase(mockRepo, new(MockSessionRepository), new(MockAuthTokenRepository), mockSender)
+	for _, address := range []string{"nobody@example.com", "new@example.com"} {
+		nonce, err := uc.Request(context.Background(), address)
+		require.NoError(t, err)
+		assert.NotEmpty(t, nonce.Value)
+	}
+	mockSender.AssertNotCalled(t, "Send", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestMagicLinkUseCase_RateLimitedPerAddress(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	mockRepo.On("GetByEmail", mock.Anything, "nobody@example.com").Return(nil, nil)
+	uc := newTestMagicLinkUseCase(mockRepo, new(MockSessionRepository), new(MockAuthTokenRepository), new(MockEmailSender))
+
+	ctx := context.Background()
+	for i := 0; i < testThrottleConfig.MaxMagicLinkRequests; i++ {
+		_, err := uc.Request(ctx, "nobody@example.com")
+		require.NoError(t, err)
+	}
+	_, err := uc.Request(ctx, "NOBODY@example.com")
+	assert.ErrorIs(t, err, usecase.ErrTooManyAttempts)
+}
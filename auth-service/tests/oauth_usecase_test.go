@@ -0,0 +1,173 @@
+package tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/HatefBarari/microblog-auth/internal/domain"
+	"github.com/HatefBarari/microblog-auth/internal/usecase"
+	"github.com/HatefBarari/microblog-shared/pkg/auth"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type MockOAuthClientRepository struct {
+	mock.Mock
+}
+
+func (m *MockOAuthClientRepository) Create(ctx context.Context, c *domain.OAuthClient) error {
+	args := m.Called(ctx, c)
+	return args.Error(0)
+}
+
+func (m *MockOAuthClientRepository) GetByID(ctx context.Context, id string) (*domain.OAuthClient, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.OAuthClient), args.Error(1)
+}
+
+func (m *MockOAuthClientRepository) List(ctx context.Context) ([]*domain.OAuthClient, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]*domain.OAuthClient), args.Error(1)
+}
+
+func (m *MockOAuthClientRepository) TouchLastUsed(ctx context.Context, id string, now time.Time) error {
+	args := m.Called(ctx, id, now)
+	return args.Error(0)
+}
+
+func (m *MockOAuthClientRepository) Revoke(ctx context.Context, id string, now time.Time) (bool, error) {
+	args := m.Called(ctx, id, now)
+	return args.Bool(0), args.Error(1)
+}
+
+func newTestOAuth(t *testing.T, repo *MockOAuthClientRepository, revocations auth.RevocationStore) (*usecase.OAuthUseCase, *auth.KeySet) {
+	key, err := auth.GenerateSigningKey("k1", "EdDSA")
+	require.NoError(t, err)
+	keys, err := auth.NewKeySet("k1", key)
+	require.NoError(t, err)
+	logger, _ := zap.NewDevelopment()
+	return usecase.NewOAuthUseCase(repo, keys, revocations, auth.NewMemoryAPIKeyStore(), usecase.OAuthConfig{ClientTokenTTL: 15 * time.Minute}, logger), keys
+}
+
+func TestOAuthUseCase_ClientCredentials(t *testing.T) {
+	repo := new(MockOAuthClientRepository)
+	var client *domain.OAuthClient
+	repo.On("Create", mock.Anything, mock.AnythingOfType("*domain.OAuthClient")).
+		Run(func(args mock.Arguments) { client = args.Get(1).(*domain.OAuthClient) }).
+		Return(nil)
+	revocations := auth.NewMemoryRevocationStore()
+	oauth, keys := newTestOAuth(t, repo, revocations)
+
+	created, err := oauth.CreateClient(context.Background(), usecase.CreateOAuthClientRequest{
+		Name:   "blog-service",
+		Scopes: []string{auth.ScopeMediaRead, auth.ScopeMediaUpload},
+	})
+	require.NoError(t, err)
+	require.NotNil(t, client)
+	assert.NotEmpty(t, created.ClientSecret)
+	assert.NotContains(t, client.SecretHash, created.ClientSecret)
+	repo.On("GetByID", mock.Anything, client.ID).Return(client, nil)
+	repo.On("TouchLastUsed", mock.Anything, client.ID, mock.Anything).Return(nil)
+
+	// the token is scoped to what was asked for and names the client
+	resp, err := oauth.ClientCredentials(context.Background(), client.ID, created.ClientSecret, auth.ScopeMediaRead)
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer", resp.TokenType)
+	assert.Equal(t, 900, resp.ExpiresIn)
+	claims, err := keys.Verify(resp.AccessToken)
+	require.NoError(t, err)
+	assert.True(t, claims.IsClient())
+	assert.Equal(t, client.ID, claims.Subject)
+	assert.Equal(t, auth.RoleService, claims.Role)
+	assert.Equal(t, []string{auth.ScopeMediaRead}, claims.Scopes)
+
+	_, err = oauth.ClientCredentials(context.Background(), client.ID, "wrong", "")
+	assert.ErrorIs(t, err, usecase.ErrInvalidClient)
+	_, err = oauth.ClientCredentials(context.Background(), client.ID, created.ClientSecret, auth.ScopeArticlesWrite)
+	var oauthErr *usecase.OAuthError
+	require.ErrorAs(t, err, &oauthErr)
+	assert.Equal(t, "invalid_scope", oauthErr.Code)
+
+	// revoking the client denies the tokens it already holds
+	repo.On("Revoke", mock.Anything, client.ID, mock.Anything).Return(true, nil)
+	require.NoError(t, oauth.RevokeClient(context.Background(), client.ID))
+	// revocation has whole-second precision; pretend the token is older
+	claims.IssuedAt = jwt.NewNumericDate(time.Now().Add(-time.Minute))
+	revoked, err := revocations.IsRevoked(context.Background(), claims)
+	require.NoError(t, err)
+	assert.True(t, revoked)
+
+	_, err = oauth.CreateClient(context.Background(), usecase.CreateOAuthClientRequest{Name: "x", Scopes: []string{"admin"}})
+	assert.Error(t, err)
+}
+
+func TestOAuthUseCase_Introspect(t *testing.T) {
+	repo := new(MockOAuthClientRepository)
+	var client *domain.OAuthClient
+	repo.On("Create", mock.Anything, mock.AnythingOfType("*domain.OAuthClient")).
+		Run(func(args mock.Arguments) { client = args.Get(1).(*domain.OAuthClient) }).
+		Return(nil)
+	revocations := auth.NewMemoryRevocationStore()
+	oauth, keys := newTestOAuth(t, repo, revocations)
+	ctx := context.Background()
+
+	created, err := oauth.CreateClient(ctx, usecase.CreateOAuthClientRequest{Name: "reports", Scopes: []string{auth.ScopeMediaRead}})
+	require.NoError(t, err)
+	repo.On("GetByID", mock.Anything, client.ID).Return(client, nil)
+	repo.On("GetByID", mock.Anything, "mbc_app").Return(&domain.OAuthClient{ID: "mbc_app", Public: true, RedirectURIs: []string{"https://app.example.com/cb"}}, nil)
+	repo.On("TouchLastUsed", mock.Anything, client.ID, mock.Anything).Return(nil)
+
+	now := time.Now()
+	token, err := keys.Sign(&auth.Claims{
+		UserID:    "user123",
+		Role:      "manager",
+		SessionID: "sess1",
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        "jti1",
+			IssuedAt:  jwt.NewNumericDate(now.Add(-time.Minute)),
+			ExpiresAt: jwt.NewNumericDate(now.Add(time.Hour)),
+		},
+	})
+	require.NoError(t, err)
+
+	// public clients have no secret to authenticate with
+	_, err = oauth.Introspect(ctx, "mbc_app", "", token)
+	assert.ErrorIs(t, err, usecase.ErrInvalidClient)
+	_, err = oauth.Introspect(ctx, client.ID, "wrong", token)
+	assert.ErrorIs(t, err, usecase.ErrInvalidClient)
+
+	resp, err := oauth.Introspect(ctx, client.ID, created.ClientSecret, token)
+	require.NoError(t, err)
+	assert.True(t, resp.Active)
+	assert.Equal(t, "user123", resp.Subject)
+	assert.Equal(t, "manager", resp.Role)
+	assert.Equal(t, "sess1", resp.SessionID)
+	assert.Equal(t, now.Add(time.Hour).Unix(), resp.ExpiresAt)
+	assert.Empty(t, resp.ClientID)
+
+	// service tokens name their client and scopes
+	service, err := oauth.ClientCredentials(ctx, client.ID, created.ClientSecret, "")
+	require.NoError(t, err)
+	resp, err = oauth.Introspect(ctx, client.ID, created.ClientSecret, service.AccessToken)
+	require.NoError(t, err)
+	assert.True(t, resp.Active)
+	assert.Equal(t, client.ID, resp.ClientID)
+	assert.Equal(t, auth.ScopeMediaRead, resp.Scope)
+
+	// a valid signature isn't enough once the token is revoked
+	require.NoError(t, revocations.RevokeToken(ctx, "jti1", now.Add(time.Hour)))
+	resp, err = oauth.Introspect(ctx, client.ID, created.ClientSecret, token)
+	require.NoError(t, err)
+	assert.Equal(t, &usecase.IntrospectionResponse{}, resp)
+
+	resp, err = oauth.Introspect(ctx, client.ID, created.ClientSecret, "not-a-token")
+	require.NoError(t, err)
+	assert.False(t, resp.Active)
+}
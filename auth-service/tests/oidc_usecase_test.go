@@ -0,0 +1,153 @@
+package tests
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/HatefBarari/microblog-auth/internal/domain"
+	"github.com/HatefBarari/microblog-auth/internal/usecase"
+	"github.com/HatefBarari/microblog-shared/pkg/auth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type MockAuthorizationCodeRepository struct {
+	mock.Mock
+}
+
+func (m *MockAuthorizationCodeRepository) Create(ctx context.Context, c *domain.AuthorizationCode) error {
+	args := m.Called(ctx, c)
+	return args.Error(0)
+}
+
+func (m *MockAuthorizationCodeRepository) Consume(ctx context.Context, codeHash string, now time.Time) (*domain.AuthorizationCode, error) {
+	args := m.Called(ctx, codeHash, now)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.AuthorizationCode), args.Error(1)
+}
+
+func TestOIDCUseCase_AuthorizationCodeFlow(t *testing.T) {
+	hash, err := auth.HashPassword("secret123")
+	require.NoError(t, err)
+	user := &domain.User{
+		ID: "user123", Email: "user@example.com", PasswordHash: hash, Role: domain.RoleUser, Verified: true,
+		Profile: &domain.Profile{DisplayName: "Sara", Handle: "sara"},
+	}
+	users := new(MockUserRepository)
+	users.On("GetByEmail", mock.Anything, "user@example.com").Return(user, nil)
+	users.On("GetByID", mock.Anything, "user123").Return(user, nil)
+	sessions := new(MockSessionRepository)
+	var session *domain.Session
+	sessions.On("Create", mock.Anything, mock.AnythingOfType("*domain.Session")).
+		Run(func(args mock.Arguments) { session = args.Get(1).(*domain.Session) }).
+		Return(nil)
+
+	forum := &domain.OAuthClient{ID: "mbc_forum", Name: "Forum", Public: true, RedirectURIs: []string{"https://forum.example.com/cb"}}
+	clients := new(MockOAuthClientRepository)
+	clients.On("GetByID", mock.Anything, "mbc_forum").Return(forum, nil)
+	clients.On("GetByID", mock.Anything, mock.Anything).Return(nil, nil)
+	clients.On("TouchLastUsed", mock.Anything, "mbc_forum", mock.Anything).Return(nil)
+	codes := new(MockAuthorizationCodeRepository)
+	var stored *domain.AuthorizationCode
+	codes.On("Create", mock.Anything, mock.AnythingOfType("*domain.AuthorizationCode")).
+		Run(func(args mock.Arguments) { stored = args.Get(1).(*domain.AuthorizationCode) }).
+		Return(nil)
+
+	logger, _ := zap.NewDevelopment()
+	oidc := usecase.NewOIDCUseCase(clients, codes, users, newTestUserUseCase(users, sessions), testAccessKeys, usecase.OIDCConfig{
+		Issuer:   "https://auth.example.com",
+		CodeTTL:  time.Minute,
+		TokenTTL: 15 * time.Minute,
+	}, logger)
+	ctx := context.Background()
+
+	verifier := "a-long-random-code-verifier-of-at-least-43-characters"
+	sum := sha256.Sum256([]byte(verifier))
+	req := usecase.AuthorizeRequest{
+		ResponseType:        "code",
+		ClientID:            "mbc_forum",
+		RedirectURI:         "https://forum.example.com/cb",
+		Scope:               "openid email",
+		State:               "xyz",
+		Nonce:               "n-0S6",
+		CodeChallenge:       base64.RawURLEncoding.EncodeToString(sum[:]),
+		CodeChallengeMethod: "S256",
+	}
+
+	// an unregistered redirect URI is never redirected to
+	bad := req
+	bad.RedirectURI = "https://evil.example.com/cb"
+	_, err = oidc.Authorize(ctx, bad)
+	assert.ErrorIs(t, err, usecase.ErrInvalidAuthorizeRequest)
+	// PKCE is required; the error goes back to the app
+	bad = req
+	bad.CodeChallenge = ""
+	res, err := oidc.Authorize(ctx, bad)
+	require.NoError(t, err)
+	assert.Contains(t, res.RedirectURL, "error=invalid_request")
+
+	res, err = oidc.Authorize(ctx, req)
+	require.NoError(t, err)
+	require.NotNil(t, res.Consent)
+	assert.Equal(t, "Forum", res.Consent.ClientName)
+	assert.Equal(t, []string{"email", "openid"}, res.Consent.Scopes)
+
+	// a wrong password keeps the user on the consent screen
+	res, err = oidc.Approve(ctx, req, usecase.ConsentForm{Approve: true, Email: "user@example.com", Password: "wrong"})
+	require.NoError(t, err)
+	require.NotNil(t, res.Consent)
+	assert.ErrorIs(t, res.Consent.Err, usecase.ErrInvalidCredentials)
+
+	res, err = oidc.Approve(ctx, req, usecase.ConsentForm{Approve: true, Email: "user@example.com", Password: "secret123"})
+	require.NoError(t, err)
+	redirect, err := url.Parse(res.RedirectURL)
+	require.NoError(t, err)
+	assert.Equal(t, "forum.example.com", redirect.Host)
+	assert.Equal(t, "xyz", redirect.Query().Get("state"))
+	code := redirect.Query().Get("code")
+	require.NotEmpty(t, code)
+	require.NotNil(t, session)
+	assert.Equal(t, "Forum", session.Device)
+	assert.NotEqual(t, code, stored.CodeHash)
+
+	codes.On("Consume", mock.Anything, stored.CodeHash, mock.Anything).Return(stored, nil).Once()
+	_, err = oidc.ExchangeCode(ctx, "mbc_forum", "", code, req.RedirectURI, "wrong-verifier")
+	assert.ErrorContains(t, err, "invalid_grant")
+
+	codes.On("Consume", mock.Anything, stored.CodeHash, mock.Anything).Return(stored, nil).Once()
+	tokens, err := oidc.ExchangeCode(ctx, "mbc_forum", "", code, req.RedirectURI, verifier)
+	require.NoError(t, err)
+	idToken, err := testAccessKeys.Verify(tokens.IDToken)
+	require.NoError(t, err)
+	assert.Equal(t, "user123", idToken.Subject)
+	assert.Equal(t, "https://auth.example.com", idToken.Issuer)
+	assert.Equal(t, []string{"mbc_forum"}, []string(idToken.Audience))
+	assert.Equal(t, "n-0S6", idToken.Nonce)
+	assert.Equal(t, session.ID, idToken.SessionID)
+
+	// the access token is only good for /userinfo
+	access, err := testAccessKeys.Verify(tokens.AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, []string{oidc.UserInfoAudience()}, []string(access.Audience))
+	info, err := oidc.UserInfo(ctx, access.UserID, access.Scopes)
+	require.NoError(t, err)
+	assert.Equal(t, "user@example.com", info["email"])
+	assert.NotContains(t, info, "name")
+
+	// codes are single use
+	codes.On("Consume", mock.Anything, stored.CodeHash, mock.Anything).Return(nil, nil)
+	_, err = oidc.ExchangeCode(ctx, "mbc_forum", "", code, req.RedirectURI, verifier)
+	assert.ErrorContains(t, err, "invalid_grant")
+
+	res, err = oidc.Approve(ctx, req, usecase.ConsentForm{})
+	require.NoError(t, err)
+	assert.Contains(t, res.RedirectURL, "error=access_denied")
+}
@@ -0,0 +1,188 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/HatefBarari/microblog-auth/internal/domain"
+	"github.com/HatefBarari/microblog-auth/internal/usecase"
+	"github.com/HatefBarari/microblog-shared/pkg/auth"
+	"github.com/HatefBarari/microblog-shared/pkg/email"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type MockOutboxRepository struct {
+	mock.Mock
+}
+
+func (m *MockOutboxRepository) Create(ctx context.Context, msg *domain.OutboxEmail) error {
+	args := m.Called(ctx, msg)
+	return args.Error(0)
+}
+
+func (m *MockOutboxRepository) GetByID(ctx context.Context, id string) (*domain.OutboxEmail, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.OutboxEmail), args.Error(1)
+}
+
+func (m *MockOutboxRepository) ClaimDue(ctx context.Context, now, leaseUntil time.Time) (*domain.OutboxEmail, error) {
+	args := m.Called(ctx, now, leaseUntil)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.OutboxEmail), args.Error(1)
+}
+
+func (m *MockOutboxRepository) MarkSent(ctx context.Context, id string, now time.Time) error {
+	args := m.Called(ctx, id, now)
+	return args.Error(0)
+}
+
+func (m *MockOutboxRepository) MarkFailed(ctx context.Context, id string, status domain.OutboxStatus, next time.Time, lastErr string) error {
+	args := m.Called(ctx, id, status, next, lastErr)
+	return args.Error(0)
+}
+
+func (m *MockOutboxRepository) Requeue(ctx context.Context, id string, now time.Time) (bool, error) {
+	args := m.Called(ctx, id, now)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockOutboxRepository) List(ctx context.Context, filter domain.OutboxFilter) ([]*domain.OutboxEmail, int, error) {
+	args := m.Called(ctx, filter)
+	return args.Get(0).([]*domain.OutboxEmail), args.Int(1), args.Error(2)
+}
+
+func newTestOutbox(repo *MockOutboxRepository, sender *MockMessageSender) *usecase.OutboxUseCase {
+	logger, _ := zap.NewDevelopment()
+	return usecase.NewOutboxUseCase(repo, sender, usecase.OutboxConfig{
+		MaxAttempts: 3,
+		BaseDelay:   time.Minute,
+		MaxDelay:    10 * time.Minute,
+	}, logger)
+}
+
+func TestOutboxUseCase_QueuesInsteadOfSending(t *testing.T) {
+	repo := new(MockOutboxRepository)
+	sender := new(MockMessageSender)
+	var queued *domain.OutboxEmail
+	repo.On("Create", mock.Anything, mock.AnythingOfType("*domain.OutboxEmail")).
+		Run(func(args mock.Arguments) { queued = args.Get(1).(*domain.OutboxEmail) }).
+		Return(nil)
+
+	outbox := newTestOutbox(repo, sender)
+	require.NoError(t, outbox.SendMessage(&email.Message{To: "user@example.com", Subject: "Hi", Text: "text", HTML: "<p>html</p>"}))
+	require.NotNil(t, queued)
+	assert.Equal(t, domain.OutboxPending, queued.Status)
+	assert.Equal(t, "<p>html</p>", queued.HTML)
+	assert.WithinDuration(t, time.Now(), queued.NextAttemptAt, time.Second)
+	sender.AssertNotCalled(t, "SendMessage", mock.Anything)
+
+	// a store failure reaches the caller instead of being lost
+	repo.ExpectedCalls = nil
+	repo.On("Create", mock.Anything, mock.Anything).Return(errors.New("mongo down"))
+	assert.Error(t, outbox.Send("user@example.com", "Hi", "text"))
+}
+
+func TestOutboxUseCase_DispatchRetriesThenDies(t *testing.T) {
+	now := time.Now()
+	repo := new(MockOutboxRepository)
+	sender := new(MockMessageSender)
+	sent := &domain.OutboxEmail{ID: "m1", To: "a@example.com", Subject: "A", Text: "a", Attempts: 1}
+	retry := &domain.OutboxEmail{ID: "m2", To: "b@example.com", Subject: "B", Text: "b", Attempts: 2}
+	dead := &domain.OutboxEmail{ID: "m3", To: "c@example.com", Subject: "C", Text: "c", Attempts: 3}
+	for _, m := range []*domain.OutboxEmail{sent, retry, dead} {
+		repo.On("ClaimDue", mock.Anything, now, mock.AnythingOfType("time.Time")).Return(m, nil).Once()
+	}
+	repo.On("ClaimDue", mock.Anything, now, mock.AnythingOfType("time.Time")).Return(nil, nil).Once()
+	sender.On("SendMessage", mock.MatchedBy(func(m *email.Message) bool { return m.To == "a@example.com" })).Return(nil)
+	sender.On("SendMessage", mock.Anything).Return(errors.New("connection refused"))
+	repo.On("MarkSent", mock.Anything, "m1", mock.AnythingOfType("time.Time")).Return(nil)
+	// second attempt failed: wait twice the base delay
+	repo.On("MarkFailed", mock.Anything, "m2", domain.OutboxPending, now.Add(2*time.Minute), "connection refused").Return(nil)
+	repo.On("MarkFailed", mock.Anything, "m3", domain.OutboxDead, mock.AnythingOfType("time.Time"), "connection refused").Return(nil)
+
+	n, err := newTestOutbox(repo, sender).DispatchDue(context.Background(), now)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	repo.AssertExpectations(t)
+}
+
+func TestOutboxUseCase_Resend(t *testing.T) {
+	repo := new(MockOutboxRepository)
+	repo.On("Requeue", mock.Anything, "dead1", mock.Anything).Return(true, nil)
+	repo.On("GetByID", mock.Anything, "dead1").
+		Return(&domain.OutboxEmail{ID: "dead1", Status: domain.OutboxPending, Text: "secret link"}, nil)
+	repo.On("Requeue", mock.Anything, "sent1", mock.Anything).Return(false, nil)
+	repo.On("GetByID", mock.Anything, "sent1").Return(&domain.OutboxEmail{ID: "sent1", Status: domain.OutboxSent}, nil)
+	repo.On("Requeue", mock.Anything, "missing", mock.Anything).Return(false, nil)
+	repo.On("GetByID", mock.Anything, "missing").Return(nil, nil)
+
+	outbox := newTestOutbox(repo, new(MockMessageSender))
+	resp, err := outbox.Resend(context.Background(), "dead1")
+	require.NoError(t, err)
+	assert.Equal(t, "pending", resp.Status)
+
+	_, err = outbox.Resend(context.Background(), "sent1")
+	assert.ErrorIs(t, err, usecase.ErrOutboxNotFailed)
+	_, err = outbox.Resend(context.Background(), "missing")
+	assert.ErrorIs(t, err, usecase.ErrOutboxNotFound)
+}
+
+func TestOutboxUseCase_QueuesSignUpVerification(t *testing.T) {
+	users := new(MockUserRepository)
+	users.On("GetByEmail", mock.Anything, "new@example.com").Return(nil, nil)
+	var user *domain.User
+	users.On("Create", mock.Anything, mock.AnythingOfType("*domain.User")).
+		Run(func(args mock.Arguments) {
+			user = args.Get(1).(*domain.User)
+			user.ID = "user2"
+		}).
+		Return(nil)
+	sessions := new(MockSessionRepository)
+	sessions.On("Create", mock.Anything, mock.AnythingOfType("*domain.Session")).Return(nil)
+	tokens := new(MockAuthTokenRepository)
+	tokens.On("DeleteByUser", mock.Anything, "user2", domain.PurposeVerifyEmail).Return(nil)
+	var stored *domain.AuthToken
+	tokens.On("Create", mock.Anything, mock.AnythingOfType("*domain.AuthToken")).
+		Run(func(args mock.Arguments) { stored = args.Get(1).(*domain.AuthToken) }).
+		Return(nil)
+	repo := new(MockOutboxRepository)
+	var queued *domain.OutboxEmail
+	repo.On("Create", mock.Anything, mock.AnythingOfType("*domain.OutboxEmail")).
+		Run(func(args mock.Arguments) { queued = args.Get(1).(*domain.OutboxEmail) }).
+		Return(nil)
+
+	logger, _ := zap.NewDevelopment()
+	outbox := newTestOutbox(repo, new(MockMessageSender))
+	emailUC := usecase.NewEmailUseCase(users, tokens, newTestThrottler(), outbox, &usecase.EmailConfig{
+		BaseURL: "http://localhost:8081", TokenSecret: "secret", TokenTTLHours: 24,
+	}, logger)
+	cfg := *testUserConfig
+	cfg.Verification = emailUC
+	uc := usecase.NewUserUseCase(users, sessions, nil, auth.NewMemoryRevocationStore(), newTestThrottler(), outbox, &cfg, logger)
+	ctx := context.Background()
+
+	_, err := uc.Register(ctx, usecase.RegisterRequest{Email: "new@example.com", Password: "secret123"})
+	require.NoError(t, err)
+	require.NotNil(t, queued)
+	assert.Equal(t, "new@example.com", queued.To)
+	assert.Equal(t, "تایید حساب کاربری - Microblog", queued.Subject)
+	assert.Contains(t, queued.HTML, `href="http://localhost:8081/verify?token=`)
+
+	// the queued link verifies the account
+	m := verifyLinkPattern.FindStringSubmatch(queued.Text)
+	require.NotNil(t, m)
+	tokens.On("Consume", mock.Anything, stored.TokenHash, domain.PurposeVerifyEmail, mock.Anything).Return(stored, nil)
+	users.On("GetByID", mock.Anything, "user2").Return(user, nil)
+	users.On("Update", mock.Anything, mock.MatchedBy(func(u *domain.User) bool { return u.Verified })).Return(nil)
+	require.NoError(t, emailUC.VerifyEmail(ctx, m[1]))
+}
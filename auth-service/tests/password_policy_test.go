@@ -0,0 +1,139 @@
+package tests
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/HatefBarari/microblog-auth/internal/domain"
+	"github.com/HatefBarari/microblog-auth/internal/usecase"
+	"github.com/HatefBarari/microblog-shared/pkg/auth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func newTestPasswordPolicy(t *testing.T) *usecase.PasswordPolicy {
+	list := filepath.Join(t.TempDir(), "breached.txt")
+	require.NoError(t, os.WriteFile(list, []byte("# comment\nPassword123\n\nletmein2024\n"), 0o600))
+	p, err := usecase.NewPasswordPolicy(usecase.PasswordPolicyConfig{
+		MinLength:        8,
+		MaxLength:        64,
+		BreachedListFile: list,
+		HistorySize:      3,
+	})
+	require.NoError(t, err)
+	return p
+}
+
+func TestPasswordPolicy_Validate(t *testing.T) {
+	p := newTestPasswordPolicy(t)
+	assert.ErrorIs(t, p.Validate("short"), usecase.ErrPasswordTooShort)
+	assert.ErrorIs(t, p.Validate(string(make([]byte, 65))), usecase.ErrPasswordTooLong)
+	assert.ErrorIs(t, p.Validate("PASSWORD123"), usecase.ErrPasswordBreached)
+	assert.NoError(t, p.Validate("correct horse battery"))
+	// length counts characters, not bytes
+	assert.NoError(t, p.Validate("رمزعبورمن"))
+}
+
+func TestPasswordPolicy_History(t *testing.T) {
+	p := newTestPasswordPolicy(t)
+	hash := func(pw string) string {
+		h, err := auth.HashPassword(pw)
+		require.NoError(t, err)
+		return h
+	}
+	current, older, oldest, expired := hash("current-pass"), hash("older-pass"), hash("oldest-pass"), hash("expired-pass")
+	history := []string{older, oldest, expired}
+
+	assert.ErrorIs(t, p.CheckReuse("current-pass", current, history), usecase.ErrPasswordReused)
+	assert.ErrorIs(t, p.CheckReuse("oldest-pass", current, history), usecase.ErrPasswordReused)
+	// only the last three passwords count
+	assert.NoError(t, p.CheckReuse("expired-pass", current, history))
+
+	// with the new password as current, two earlier ones are kept
+	assert.Equal(t, []string{current, older}, p.History(current, history))
+	assert.Equal(t, []string{current}, p.History(current, nil))
+}
+
+func TestUserUseCase_LoginUpgradesHash(t *testing.T) {
+	bcryptHasher, err := auth.NewHasher(auth.HasherConfig{Algorithm: auth.AlgorithmBcrypt, BcryptCost: 4})
+	require.NoError(t, err)
+	legacy, err := bcryptHasher.Hash("secret123")
+	require.NoError(t, err)
+	user := &domain.User{ID: "user123", Email: "user@example.com", PasswordHash: legacy, Role: domain.RoleUser, Verified: true}
+
+	mockRepo := new(MockUserRepository)
+	mockSessions := new(MockSessionRepository)
+	mockRepo.On("GetByEmail", mock.Anything, "user@example.com").Return(user, nil)
+	mockSessions.On("Create", mock.Anything, mock.AnythingOfType("*domain.Session")).Return(nil)
+	var upgraded string
+	mockRepo.On("UpdatePasswordHash", mock.Anything, "user123", legacy, mock.AnythingOfType("string")).
+		Run(func(args mock.Arguments) { upgraded = args.String(3) }).
+		Return(nil)
+
+	uc := newTestUserUseCase(mockRepo, mockSessions)
+	_, err = uc.Login(context.Background(), usecase.LoginRequest{Email: "user@example.com", Password: "secret123"})
+	require.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+	assert.Regexp(t, `^\$argon2id\$`, upgraded)
+	assert.True(t, auth.CheckPassword(upgraded, "secret123"))
+
+	// an up-to-date hash is left alone
+	mockRepo.ExpectedCalls = nil
+	mockRepo.Calls = nil
+	user.PasswordHash = upgraded
+	mockRepo.On("GetByEmail", mock.Anything, "user@example.com").Return(user, nil)
+	_, err = uc.Login(context.Background(), usecase.LoginRequest{Email: "user@example.com", Password: "secret123"})
+	require.NoError(t, err)
+	mockRepo.AssertNotCalled(t, "UpdatePasswordHash", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestUserUseCase_RegisterEnforcesPolicy(t *testing.T) {
+	logger, _ := zap.NewDevelopment()
+	cfg := *testUserConfig
+	cfg.Passwords = newTestPasswordPolicy(t)
+	mockRepo := new(MockUserRepository)
+	mockRepo.On("GetByEmail", mock.Anything, "user@example.com").Return(nil, nil)
+	uc := usecase.NewUserUseCase(mockRepo, new(MockSessionRepository), nil, auth.NewMemoryRevocationStore(), newTestThrottler(), new(MockEmailSender), &cfg, logger)
+
+	_, err := uc.Register(context.Background(), usecase.RegisterRequest{Email: "user@example.com", Password: "password123"})
+	assert.ErrorIs(t, err, usecase.ErrPasswordBreached)
+	mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestEmailUseCase_ResetPasswordRefusesReuse(t *testing.T) {
+	logger, _ := zap.NewDevelopment()
+	current, err := auth.HashPassword("current-pass")
+	require.NoError(t, err)
+	older, err := auth.HashPassword("older-pass")
+	require.NoError(t, err)
+	user := &domain.User{ID: "user123", PasswordHash: current, PasswordHistory: []string{older}}
+
+	mockRepo := new(MockUserRepository)
+	mockTokens := new(MockAuthTokenRepository)
+	mockTokens.On("Consume", mock.Anything, mock.AnythingOfType("string"), domain.PurposeResetPassword, mock.Anything).
+		Return(&domain.AuthToken{UserID: "user123", Purpose: domain.PurposeResetPassword}, nil)
+	mockRepo.On("GetByID", mock.Anything, "user123").Return(user, nil)
+	var saved *domain.User
+	mockRepo.On("Update", mock.Anything, mock.AnythingOfType("*domain.User")).
+		Run(func(args mock.Arguments) { saved = args.Get(1).(*domain.User) }).
+		Return(nil)
+
+	uc := usecase.NewEmailUseCase(mockRepo, mockTokens, newTestThrottler(), new(MockEmailSender), &usecase.EmailConfig{
+		TokenSecret: "test-secret",
+		Passwords:   newTestPasswordPolicy(t),
+	}, logger)
+
+	// rejected before the link is spent
+	assert.ErrorIs(t, uc.ResetPassword(context.Background(), wellFormedToken, "short"), usecase.ErrPasswordTooShort)
+	mockTokens.AssertNotCalled(t, "Consume", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+
+	assert.ErrorIs(t, uc.ResetPassword(context.Background(), wellFormedToken, "older-pass"), usecase.ErrPasswordReused)
+	require.NoError(t, uc.ResetPassword(context.Background(), wellFormedToken, "brand-new-pass"))
+	require.NotNil(t, saved)
+	assert.True(t, auth.CheckPassword(saved.PasswordHash, "brand-new-pass"))
+	assert.Equal(t, []string{current, older}, saved.PasswordHistory)
+}
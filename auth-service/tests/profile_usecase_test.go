@@ -0,0 +1,88 @@
+package tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/HatefBarari/microblog-auth/internal/domain"
+	"github.com/HatefBarari/microblog-auth/internal/usecase"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestProfileUseCase_Update(t *testing.T) {
+	logger, _ := zap.NewDevelopment()
+	ctx := context.Background()
+
+	t.Run("normalizes handle and stores profile", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		user := &domain.User{ID: "user123"}
+		mockRepo.On("SetProfile", mock.Anything, "user123", mock.AnythingOfType("*domain.Profile")).
+			Run(func(args mock.Arguments) { user.Profile = args.Get(2).(*domain.Profile) }).
+			Return(nil)
+		mockRepo.On("GetByID", mock.Anything, "user123").Return(user, nil)
+		uc := usecase.NewProfileUseCase(mockRepo, logger)
+
+		resp, err := uc.Update(ctx, "user123", usecase.UpdateProfileRequest{
+			DisplayName: " Sara ",
+			Handle:      "@Sara_K",
+			Links:       []usecase.ProfileLinkDTO{{Label: "site", URL: "https://sara.dev"}},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "sara_k", user.Profile.Handle)
+		assert.Equal(t, "Sara", resp.DisplayName)
+		assert.Equal(t, "sara_k", resp.Handle)
+	})
+
+	t.Run("rejects bad handles and links", func(t *testing.T) {
+		uc := usecase.NewProfileUseCase(new(MockUserRepository), logger)
+		_, err := uc.Update(ctx, "user123", usecase.UpdateProfileRequest{DisplayName: "x", Handle: "no spaces"})
+		assert.ErrorIs(t, err, usecase.ErrInvalidHandle)
+		_, err = uc.Update(ctx, "user123", usecase.UpdateProfileRequest{
+			DisplayName: "x",
+			Links:       []usecase.ProfileLinkDTO{{Label: "x", URL: "javascript:alert(1)"}},
+		})
+		assert.ErrorIs(t, err, usecase.ErrInvalidLink)
+	})
+
+	t.Run("handle conflict", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		mockRepo.On("SetProfile", mock.Anything, "user123", mock.Anything).Return(domain.ErrHandleTaken)
+		uc := usecase.NewProfileUseCase(mockRepo, logger)
+		_, err := uc.Update(ctx, "user123", usecase.UpdateProfileRequest{DisplayName: "x", Handle: "taken"})
+		assert.ErrorIs(t, err, domain.ErrHandleTaken)
+	})
+}
+
+func TestProfileUseCase_PublicLookups(t *testing.T) {
+	logger, _ := zap.NewDevelopment()
+	ctx := context.Background()
+	until := time.Now().Add(time.Hour)
+	active := &domain.User{ID: "u1", Profile: &domain.Profile{DisplayName: "Sara", Handle: "sara", AvatarMediaID: "m1"}}
+	suspended := &domain.User{ID: "u2", Status: domain.StatusSuspended, SuspendedUntil: &until,
+		Profile: &domain.Profile{DisplayName: "Spam", Handle: "spam"}}
+
+	mockRepo := new(MockUserRepository)
+	mockRepo.On("GetByHandle", mock.Anything, "sara").Return(active, nil)
+	mockRepo.On("GetByHandle", mock.Anything, "spam").Return(suspended, nil)
+	mockRepo.On("GetByIDs", mock.Anything, []string{"u1", "u2", "missing"}).Return([]*domain.User{active, suspended}, nil)
+	uc := usecase.NewProfileUseCase(mockRepo, logger)
+
+	resp, err := uc.GetByHandle(ctx, "@Sara")
+	require.NoError(t, err)
+	assert.Equal(t, "u1", resp.UserID)
+	_, err = uc.GetByHandle(ctx, "spam")
+	assert.ErrorIs(t, err, usecase.ErrProfileNotFound)
+
+	authors, err := uc.Authors(ctx, []string{"u1", "u2", "missing"})
+	require.NoError(t, err)
+	require.Len(t, authors, 2)
+	assert.Equal(t, &usecase.AuthorSummary{ID: "u1", DisplayName: "Sara", Handle: "sara", AvatarMediaID: "m1"}, authors[0])
+	assert.Equal(t, &usecase.AuthorSummary{ID: "u2"}, authors[1])
+
+	_, err = uc.Authors(ctx, make([]string, usecase.MaxAuthorBatch+1))
+	assert.ErrorIs(t, err, usecase.ErrTooManyAuthors)
+}
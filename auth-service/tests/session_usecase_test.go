@@ -0,0 +1,117 @@
+package tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/HatefBarari/microblog-auth/internal/domain"
+	"github.com/HatefBarari/microblog-auth/internal/usecase"
+	"github.com/HatefBarari/microblog-shared/pkg/auth"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestSessionUseCase_Revoke(t *testing.T) {
+	logger, _ := zap.NewDevelopment()
+	live := func(userID string) *domain.Session {
+		return &domain.Session{ID: "sess1", UserID: userID, ExpiresAt: time.Now().Add(time.Hour)}
+	}
+
+	tests := []struct {
+		name          string
+		mockSetup     func(*MockSessionRepository)
+		expectedError error
+	}{
+		{
+			name: "own session",
+			mockSetup: func(m *MockSessionRepository) {
+				m.On("GetByID", mock.Anything, "sess1").Return(live("user123"), nil)
+				m.On("Revoke", mock.Anything, "sess1", domain.RevokeReasonByUser, mock.Anything).Return(nil)
+			},
+		},
+		{
+			name: "someone else's session",
+			mockSetup: func(m *MockSessionRepository) {
+				m.On("GetByID", mock.Anything, "sess1").Return(live("other"), nil)
+			},
+			expectedError: usecase.ErrSessionNotFound,
+		},
+		{
+			name: "unknown session",
+			mockSetup: func(m *MockSessionRepository) {
+				m.On("GetByID", mock.Anything, "sess1").Return(nil, nil)
+			},
+			expectedError: usecase.ErrSessionNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockSessions := new(MockSessionRepository)
+			tt.mockSetup(mockSessions)
+
+			err := usecase.NewSessionUseCase(mockSessions, auth.NewMemoryRevocationStore(), 15*time.Minute, logger).Revoke(context.Background(), "user123", "sess1")
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+			} else {
+				assert.NoError(t, err)
+			}
+			mockSessions.AssertExpectations(t)
+		})
+	}
+}
+
+func TestSessionUseCase_LogoutWithoutSession(t *testing.T) {
+	logger, _ := zap.NewDevelopment()
+	err := usecase.NewSessionUseCase(new(MockSessionRepository), auth.NewMemoryRevocationStore(), 15*time.Minute, logger).Logout(context.Background(), "user123", "")
+	assert.ErrorIs(t, err, usecase.ErrNoSession)
+}
+
+func TestSessionUseCase_ListMarksCurrent(t *testing.T) {
+	logger, _ := zap.NewDevelopment()
+	mockSessions := new(MockSessionRepository)
+	mockSessions.On("ListActiveByUser", mock.Anything, "user123", mock.Anything).Return([]*domain.Session{
+		{ID: "a", UserID: "user123", Device: "phone"},
+		{ID: "b", UserID: "user123", Device: "laptop"},
+	}, nil)
+
+	list, err := usecase.NewSessionUseCase(mockSessions, auth.NewMemoryRevocationStore(), 15*time.Minute, logger).List(context.Background(), "user123", "b")
+	require.NoError(t, err)
+	require.Len(t, list, 2)
+	assert.False(t, list[0].Current)
+	assert.True(t, list[1].Current)
+}
+
+func TestSessionUseCase_RevocationsReachAccessTokens(t *testing.T) {
+	logger, _ := zap.NewDevelopment()
+	ctx := context.Background()
+	issued := &auth.Claims{UserID: "user123", SessionID: "sess1"}
+	issued.IssuedAt = jwt.NewNumericDate(time.Now().Add(-time.Minute))
+
+	store := auth.NewMemoryRevocationStore()
+	mockSessions := new(MockSessionRepository)
+	mockSessions.On("GetByID", mock.Anything, "sess1").
+		Return(&domain.Session{ID: "sess1", UserID: "user123", ExpiresAt: time.Now().Add(time.Hour)}, nil)
+	mockSessions.On("Revoke", mock.Anything, "sess1", domain.RevokeReasonLogout, mock.Anything).Return(nil)
+	uc := usecase.NewSessionUseCase(mockSessions, store, 15*time.Minute, logger)
+
+	require.NoError(t, uc.Logout(ctx, "user123", "sess1"))
+	revoked, _ := store.IsRevoked(ctx, issued)
+	assert.True(t, revoked, "logout revokes the session's access tokens")
+
+	store = auth.NewMemoryRevocationStore()
+	mockSessions = new(MockSessionRepository)
+	mockSessions.On("RevokeAllByUser", mock.Anything, "user123", domain.RevokeReasonLogoutAll, mock.Anything).Return(2, nil)
+	uc = usecase.NewSessionUseCase(mockSessions, store, 15*time.Minute, logger)
+
+	n, err := uc.LogoutAll(ctx, "user123")
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	issued.SessionID = "other"
+	revoked, _ = store.IsRevoked(ctx, issued)
+	assert.True(t, revoked, "logout-all revokes every earlier token of the user")
+}
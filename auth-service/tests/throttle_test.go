@@ -0,0 +1,175 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/HatefBarari/microblog-auth/internal/domain"
+	"github.com/HatefBarari/microblog-auth/internal/infrastructure"
+	"github.com/HatefBarari/microblog-auth/internal/presenter"
+	"github.com/HatefBarari/microblog-auth/internal/repository"
+	"github.com/HatefBarari/microblog-auth/internal/usecase"
+	"github.com/HatefBarari/microblog-shared/pkg/auth"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+var testThrottleConfig = usecase.ThrottleConfig{
+	Window:                 time.Hour,
+	Lockout:                15 * time.Minute,
+	MaxAccountFailures:     3,
+	MaxIPFailures:          10,
+	MaxResetRequests:       2,
+	MaxResetIPRequests:     10,
+	MaxMagicLinkRequests:   2,
+	MaxMagicLinkIPRequests: 10,
+}
+
+func newTestThrottler() *usecase.Throttler {
+	logger, _ := zap.NewDevelopment()
+	return usecase.NewThrottler(repository.NewMemoryAttemptRepo(), testThrottleConfig, logger)
+}
+
+func TestThrottler_Backoff(t *testing.T) {
+	logger, _ := zap.NewDevelopment()
+	cfg := testThrottleConfig
+	cfg.BaseDelay = time.Second
+	cfg.MaxDelay = 4 * time.Second
+	th := usecase.NewThrottler(repository.NewMemoryAttemptRepo(), cfg, logger)
+	ctx := context.Background()
+	now := time.Now()
+
+	require.NoError(t, th.Allow(ctx, now, "k"))
+	_, err := th.Record(ctx, now, "k", 0)
+	require.NoError(t, err)
+	_, err = th.Record(ctx, now, "k", 0)
+	require.NoError(t, err)
+
+	// two events: 2s backoff
+	err = th.Allow(ctx, now.Add(time.Second), "k")
+	var re *usecase.RetryError
+	require.True(t, errors.As(err, &re))
+	assert.Equal(t, time.Second, re.RetryAfter)
+	assert.False(t, re.Locked)
+	assert.NoError(t, th.Allow(ctx, now.Add(2*time.Second), "k"))
+
+	// the delay is capped
+	for i := 0; i < 5; i++ {
+		_, _ = th.Record(ctx, now, "k", 0)
+	}
+	assert.NoError(t, th.Allow(ctx, now.Add(4*time.Second), "k"))
+
+	// empty keys are ignored
+	assert.NoError(t, th.Allow(ctx, now, ""))
+}
+
+func TestUserUseCase_LoginLockout(t *testing.T) {
+	hash, err := auth.HashPassword("secret123")
+	require.NoError(t, err)
+	user := &domain.User{ID: "user123", Email: "user@example.com", PasswordHash: hash, Role: domain.RoleUser, Verified: true}
+
+	mockRepo := new(MockUserRepository)
+	mockSessions := new(MockSessionRepository)
+	mockSender := new(MockEmailSender)
+	mockRepo.On("GetByEmail", mock.Anything, "user@example.com").Return(user, nil)
+	mockRepo.On("GetByID", mock.Anything, "user123").Return(user, nil)
+	mockSessions.On("Create", mock.Anything, mock.AnythingOfType("*domain.Session")).Return(nil)
+	notified := make(chan string, 1)
+	mockSender.On("Send", "user@example.com", mock.AnythingOfType("string"), mock.AnythingOfType("string")).
+		Run(func(args mock.Arguments) { notified <- args.String(0) }).
+		Return(nil).Once()
+
+	uc := newTestUserUseCaseWith(mockRepo, mockSessions, newTestThrottler(), mockSender)
+	ctx := usecase.WithClientInfo(context.Background(), usecase.ClientInfo{IP: "10.0.0.1"})
+	wrong := usecase.LoginRequest{Email: "user@example.com", Password: "wrong-password"}
+	right := usecase.LoginRequest{Email: "user@example.com", Password: "secret123"}
+
+	for i := 0; i < testThrottleConfig.MaxAccountFailures; i++ {
+		_, err := uc.Login(ctx, wrong)
+		assert.ErrorIs(t, err, usecase.ErrInvalidCredentials)
+	}
+	select {
+	case to := <-notified:
+		assert.Equal(t, "user@example.com", to)
+	case <-time.After(time.Second):
+		t.Fatal("lockout notification not sent")
+	}
+
+	// even the right password is refused while locked
+	_, err = uc.Login(ctx, right)
+	var re *usecase.RetryError
+	require.True(t, errors.As(err, &re))
+	assert.True(t, re.Locked)
+
+	require.NoError(t, uc.Unlock(context.Background(), "user123"))
+	resp, err := uc.Login(ctx, right)
+	require.NoError(t, err)
+	assert.NotEmpty(t, resp.AccessToken)
+}
+
+func TestEmailUseCase_PasswordResetThrottled(t *testing.T) {
+	logger, _ := zap.NewDevelopment()
+	mockRepo := new(MockUserRepository)
+	mockRepo.On("GetByEmail", mock.Anything, "nobody@example.com").Return(nil, nil)
+	uc := usecase.NewEmailUseCase(mockRepo, new(MockAuthTokenRepository), newTestThrottler(), new(MockEmailSender),
+		&usecase.EmailConfig{TokenSecret: "secret", TokenTTLHours: 24}, logger)
+	ctx := usecase.WithClientInfo(context.Background(), usecase.ClientInfo{IP: "10.0.0.1"})
+
+	for i := 0; i < testThrottleConfig.MaxResetRequests; i++ {
+		require.NoError(t, uc.SendPasswordResetEmail(ctx, "nobody@example.com"))
+	}
+	err := uc.SendPasswordResetEmail(ctx, "Nobody@Example.com")
+	assert.ErrorIs(t, err, usecase.ErrTooManyAttempts)
+}
+
+func TestLoginIPThrottleIgnoresForwardedFor(t *testing.T) {
+	repo := new(MockUserRepository)
+	repo.On("GetByEmail", mock.Anything, mock.Anything).Return(nil, nil)
+	handler := presenter.NewHTTPHandler(newTestUserUseCase(repo, new(MockSessionRepository)), nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil)
+
+	login := func(e *echo.Echo, i int) int {
+		body := fmt.Sprintf(`{"email":"user%d@example.com","password":"wrong-password"}`, i)
+		req := httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.RemoteAddr = "203.0.113.7:40000"
+		// a fresh forged address on every attempt
+		req.Header.Set("X-Forwarded-For", fmt.Sprintf("198.51.100.%d", i))
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec.Code
+	}
+	newServer := func(trusted []string) *echo.Echo {
+		e := echo.New()
+		extractor, err := infrastructure.IPExtractor(trusted)
+		require.NoError(t, err)
+		e.IPExtractor = extractor
+		e.POST("/login", handler.Login)
+		return e
+	}
+
+	// different accounts each time, so only the IP counter can trip
+	e := newServer(nil)
+	for i := 0; i < testThrottleConfig.MaxIPFailures; i++ {
+		require.Equal(t, http.StatusUnauthorized, login(e, i))
+	}
+	assert.Equal(t, http.StatusTooManyRequests, login(e, testThrottleConfig.MaxIPFailures))
+
+	// behind a trusted proxy the forwarded address is the client
+	handler = presenter.NewHTTPHandler(newTestUserUseCase(repo, new(MockSessionRepository)), nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil)
+	e = newServer([]string{"203.0.113.0/24"})
+	for i := 0; i <= testThrottleConfig.MaxIPFailures; i++ {
+		require.Equal(t, http.StatusUnauthorized, login(e, 100+i))
+	}
+
+	_, err := infrastructure.IPExtractor([]string{"not-an-ip"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,147 @@
+package tests
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/HatefBarari/microblog-auth/internal/domain"
+	"github.com/HatefBarari/microblog-auth/internal/usecase"
+	"github.com/HatefBarari/microblog-shared/pkg/auth"
+	"github.com/pquerna/otp/totp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// enrolledUser runs enrollment and confirmation against the mocks and
+// returns the user with 2FA enabled, its TOTP secret and recovery codes.
+func enrolledUser(t *testing.T, uc *usecase.UserUseCase, repo *MockUserRepository, user *domain.User) (string, []string) {
+	t.Helper()
+	ctx := context.Background()
+	repo.On("GetByID", mock.Anything, user.ID).Return(user, nil)
+	repo.On("SetTwoFactor", mock.Anything, user.ID, mock.AnythingOfType("*domain.TwoFactor")).
+		Run(func(args mock.Arguments) { user.TwoFactor = args.Get(2).(*domain.TwoFactor) }).
+		Return(nil)
+
+	enroll, err := uc.EnrollTwoFactor(ctx, user.ID)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(enroll.OTPAuthURL, "otpauth://totp/Microblog:"))
+	assert.NotEmpty(t, enroll.QRCodePNG)
+	require.NotNil(t, user.TwoFactor)
+	assert.False(t, user.TwoFactor.Enabled)
+	assert.NotEqual(t, enroll.Secret, user.TwoFactor.Secret, "seed is encrypted at rest")
+
+	// a wrong code leaves 2FA pending
+	_, err = uc.ConfirmTwoFactor(ctx, user.ID, "000000")
+	assert.ErrorIs(t, err, usecase.ErrInvalidTwoFactorCode)
+
+	code, err := totp.GenerateCode(enroll.Secret, time.Now())
+	require.NoError(t, err)
+	rc, err := uc.ConfirmTwoFactor(ctx, user.ID, code)
+	require.NoError(t, err)
+	assert.True(t, user.TwoFactor.Enabled)
+	assert.Len(t, rc.RecoveryCodes, 10)
+	assert.Len(t, user.TwoFactor.RecoveryCodes, 10)
+	assert.NotContains(t, user.TwoFactor.RecoveryCodes, rc.RecoveryCodes[0])
+	return enroll.Secret, rc.RecoveryCodes
+}
+
+func TestUserUseCase_TwoFactorLogin(t *testing.T) {
+	hash, err := auth.HashPassword("secret123")
+	require.NoError(t, err)
+	user := &domain.User{ID: "user123", Email: "user@example.com", PasswordHash: hash, Role: domain.RoleUser, Verified: true}
+
+	mockRepo := new(MockUserRepository)
+	mockSessions := new(MockSessionRepository)
+	uc := newTestUserUseCase(mockRepo, mockSessions)
+	secret, recovery := enrolledUser(t, uc, mockRepo, user)
+
+	ctx := context.Background()
+	mockRepo.On("GetByEmail", mock.Anything, "user@example.com").Return(user, nil)
+	mockSessions.On("Create", mock.Anything, mock.AnythingOfType("*domain.Session")).Return(nil)
+
+	login := func() string {
+		resp, err := uc.Login(ctx, usecase.LoginRequest{Email: "user@example.com", Password: "secret123"})
+		require.NoError(t, err)
+		assert.True(t, resp.TwoFactorRequired)
+		assert.Empty(t, resp.AccessToken)
+		return resp.ChallengeToken
+	}
+
+	t.Run("totp code", func(t *testing.T) {
+		code, err := totp.GenerateCode(secret, time.Now().Add(30*time.Second))
+		require.NoError(t, err)
+		mockRepo.On("AdvanceTOTPStep", mock.Anything, user.ID, mock.AnythingOfType("int64")).Return(true, nil).Once()
+
+		resp, err := uc.LoginTwoFactor(ctx, login(), code)
+		require.NoError(t, err)
+		assert.NotEmpty(t, resp.AccessToken)
+		assert.NotEmpty(t, resp.RefreshToken)
+	})
+
+	t.Run("replayed totp code", func(t *testing.T) {
+		code, err := totp.GenerateCode(secret, time.Now().Add(30*time.Second))
+		require.NoError(t, err)
+		mockRepo.On("AdvanceTOTPStep", mock.Anything, user.ID, mock.AnythingOfType("int64")).Return(false, nil).Once()
+
+		_, err = uc.LoginTwoFactor(ctx, login(), code)
+		assert.ErrorIs(t, err, usecase.ErrInvalidTwoFactorCode)
+	})
+
+	t.Run("recovery code", func(t *testing.T) {
+		mockRepo.On("ConsumeRecoveryCode", mock.Anything, user.ID, user.TwoFactor.RecoveryCodes[0]).Return(true, nil).Once()
+
+		resp, err := uc.LoginTwoFactor(ctx, login(), strings.ToUpper(recovery[0]))
+		require.NoError(t, err)
+		assert.NotEmpty(t, resp.AccessToken)
+	})
+
+	t.Run("forged challenge", func(t *testing.T) {
+		forged, err := auth.HMAC("test-refresh").Sign(&auth.Claims{UserID: user.ID})
+		require.NoError(t, err)
+		_, err = uc.LoginTwoFactor(ctx, forged, "123456")
+		assert.ErrorIs(t, err, usecase.ErrInvalidChallenge)
+	})
+}
+
+func TestUserUseCase_TwoFactorRequiredRole(t *testing.T) {
+	hash, err := auth.HashPassword("secret123")
+	require.NoError(t, err)
+	admin := &domain.User{ID: "admin1", Email: "admin@example.com", PasswordHash: hash, Role: domain.RoleAdmin, Verified: true}
+
+	mockRepo := new(MockUserRepository)
+	mockSessions := new(MockSessionRepository)
+	mockRepo.On("GetByEmail", mock.Anything, "admin@example.com").Return(admin, nil)
+	mockRepo.On("GetByID", mock.Anything, "admin1").Return(admin, nil)
+	mockRepo.On("SetTwoFactor", mock.Anything, "admin1", mock.AnythingOfType("*domain.TwoFactor")).
+		Run(func(args mock.Arguments) { admin.TwoFactor = args.Get(2).(*domain.TwoFactor) }).
+		Return(nil)
+	mockSessions.On("Create", mock.Anything, mock.AnythingOfType("*domain.Session")).Return(nil)
+	uc := newTestUserUseCase(mockRepo, mockSessions)
+	ctx := context.Background()
+
+	resp, err := uc.Login(ctx, usecase.LoginRequest{Email: "admin@example.com", Password: "secret123"})
+	require.NoError(t, err)
+	assert.True(t, resp.TwoFactorSetupRequired)
+	assert.Empty(t, resp.AccessToken)
+
+	// the setup challenge can't stand in for a second factor
+	_, err = uc.LoginTwoFactor(ctx, resp.ChallengeToken, "123456")
+	assert.ErrorIs(t, err, usecase.ErrTwoFactorNotEnrolled)
+
+	enroll, err := uc.SetupTwoFactor(ctx, resp.ChallengeToken)
+	require.NoError(t, err)
+	code, err := totp.GenerateCode(enroll.Secret, time.Now())
+	require.NoError(t, err)
+
+	done, err := uc.LoginTwoFactor(ctx, resp.ChallengeToken, code)
+	require.NoError(t, err)
+	assert.NotEmpty(t, done.AccessToken)
+	assert.Len(t, done.RecoveryCodes, 10)
+	assert.True(t, admin.TwoFactorEnabled())
+
+	err = uc.DisableTwoFactor(ctx, "admin1", "secret123", code)
+	assert.ErrorIs(t, err, usecase.ErrTwoFactorRequired)
+}
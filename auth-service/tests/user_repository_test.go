@@ -0,0 +1,55 @@
+package tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/HatefBarari/microblog-auth/internal/domain"
+	"github.com/HatefBarari/microblog-auth/internal/repository"
+	"github.com/HatefBarari/microblog-shared/pkg/logger"
+	"github.com/HatefBarari/microblog-shared/pkg/mongo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMongoUserRepoCRUD(t *testing.T) {
+	log, _ := logger.NewFile("debug", "logs/test.log")
+	if err := mongo.Connect("mongodb://localhost:27017", "testdb", log); err != nil {
+		t.Skip("mongo not available: ", err)
+	}
+	ctx := context.Background()
+	repo := repository.NewMongoUserRepo()
+
+	u := &domain.User{
+		Email:     "crud-" + time.Now().Format("150405.000000") + "@x.com",
+		Role:      domain.RoleUser,
+		CreatedAt: time.Now(),
+	}
+	require.NoError(t, repo.Create(ctx, u))
+	defer repo.Delete(ctx, u.ID)
+
+	got, err := repo.GetByID(ctx, u.ID)
+	require.NoError(t, err)
+	require.NotNil(t, got)
+	assert.Equal(t, u.ID, got.ID)
+	assert.Equal(t, u.Email, got.Email)
+
+	got.Role = domain.RoleManager
+	got.Verified = true
+	require.NoError(t, repo.Update(ctx, got))
+
+	role := domain.RoleManager
+	verified := true
+	list, total, err := repo.List(ctx, domain.UserFilter{Email: u.Email, Role: &role, Verified: &verified, Page: 1, PageSize: 10})
+	require.NoError(t, err)
+	assert.Equal(t, 1, total)
+	require.Len(t, list, 1)
+	assert.Equal(t, u.ID, list[0].ID)
+
+	require.NoError(t, repo.Delete(ctx, u.ID))
+	got, err = repo.GetByID(ctx, u.ID)
+	assert.NoError(t, err)
+	assert.Nil(t, got)
+	assert.ErrorIs(t, repo.Delete(ctx, u.ID), domain.ErrUserNotFound)
+}
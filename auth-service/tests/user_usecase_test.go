@@ -0,0 +1,279 @@
+package tests
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/HatefBarari/microblog-auth/internal/domain"
+	"github.com/HatefBarari/microblog-auth/internal/usecase"
+	"github.com/HatefBarari/microblog-shared/pkg/auth"
+	"github.com/HatefBarari/microblog-shared/pkg/email"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type MockSessionRepository struct {
+	mock.Mock
+}
+
+func (m *MockSessionRepository) Create(ctx context.Context, s *domain.Session) error {
+	args := m.Called(ctx, s)
+	return args.Error(0)
+}
+
+func (m *MockSessionRepository) GetByID(ctx context.Context, id string) (*domain.Session, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*domain.Session), args.Error(1)
+}
+
+func (m *MockSessionRepository) Rotate(ctx context.Context, id, oldJTI, newJTI string, expiresAt time.Time, ip, userAgent string, now time.Time) (bool, error) {
+	args := m.Called(ctx, id, oldJTI, newJTI, expiresAt, ip, userAgent, now)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockSessionRepository) Revoke(ctx context.Context, id, reason string, now time.Time) error {
+	args := m.Called(ctx, id, reason, now)
+	return args.Error(0)
+}
+
+func (m *MockSessionRepository) ListActiveByUser(ctx context.Context, userID string, now time.Time) ([]*domain.Session, error) {
+	args := m.Called(ctx, userID, now)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*domain.Session), args.Error(1)
+}
+
+func (m *MockSessionRepository) RevokeAllByUser(ctx context.Context, userID, reason string, now time.Time) (int, error) {
+	args := m.Called(ctx, userID, reason, now)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockSessionRepository) DeleteByUser(ctx context.Context, userID string) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+var testAccessKeys = func() *auth.KeySet {
+	key, err := auth.GenerateSigningKey("test", "EdDSA")
+	if err != nil {
+		panic(err)
+	}
+	ks, err := auth.NewKeySet("test", key)
+	if err != nil {
+		panic(err)
+	}
+	return ks
+}()
+
+var testUserConfig = &usecase.Config{
+	AccessSigner:   testAccessKeys,
+	RefreshSecret:  "test-refresh",
+	AccessTTLMin:   15,
+	RefreshTTLHour: 168,
+	TwoFactor: usecase.TwoFactorConfig{
+		Issuer:          "Microblog",
+		Secret:          "test-2fa",
+		ChallengeTTLMin: 5,
+		RequiredRoles:   []domain.Role{domain.RoleAdmin},
+	},
+	ImpersonationTTLMin: 15,
+}
+
+func newTestUserUseCase(repo *MockUserRepository, sessions *MockSessionRepository) *usecase.UserUseCase {
+	return newTestUserUseCaseWith(repo, sessions, newTestThrottler(), email.NewSenderWithTransport(email.NewRecorder(), "noreply@microblog.local"))
+}
+
+func newTestUserUseCaseWith(repo *MockUserRepository, sessions *MockSessionRepository, throttle *usecase.Throttler, sender usecase.EmailSender) *usecase.UserUseCase {
+	logger, _ := zap.NewDevelopment()
+	return usecase.NewUserUseCase(repo, sessions, nil, auth.NewMemoryRevocationStore(), throttle, sender, testUserConfig, logger)
+}
+
+func TestUserUseCase_LoginStartsSession(t *testing.T) {
+	hash, err := auth.HashPassword("secret123")
+	require.NoError(t, err)
+	user := &domain.User{ID: "user123", Email: "user@example.com", PasswordHash: hash, Role: domain.RoleUser, Verified: true}
+
+	mockRepo := new(MockUserRepository)
+	mockSessions := new(MockSessionRepository)
+	mockRepo.On("GetByEmail", mock.Anything, "user@example.com").Return(user, nil)
+	var created *domain.Session
+	mockSessions.On("Create", mock.Anything, mock.AnythingOfType("*domain.Session")).
+		Run(func(args mock.Arguments) { created = args.Get(1).(*domain.Session) }).
+		Return(nil)
+
+	uc := newTestUserUseCase(mockRepo, mockSessions)
+	ctx := usecase.WithClientInfo(context.Background(), usecase.ClientInfo{IP: "10.0.0.1", UserAgent: "curl/8", Device: "laptop"})
+	resp, err := uc.Login(ctx, usecase.LoginRequest{Email: "user@example.com", Password: "secret123"})
+	require.NoError(t, err)
+
+	require.NotNil(t, created)
+	assert.Equal(t, "user123", created.UserID)
+	assert.Equal(t, "10.0.0.1", created.IP)
+	assert.Equal(t, "curl/8", created.UserAgent)
+	assert.Equal(t, "laptop", created.Device)
+
+	claims, err := auth.ValidateToken(resp.RefreshToken, testUserConfig.RefreshSecret)
+	require.NoError(t, err)
+	assert.Equal(t, created.ID, claims.SessionID)
+	assert.Equal(t, created.RefreshJTI, claims.ID)
+
+	access, err := testAccessKeys.Verify(resp.AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, created.ID, access.SessionID)
+	assert.NotEmpty(t, access.ID)
+}
+
+func TestUserUseCase_Refresh(t *testing.T) {
+	user := &domain.User{ID: "user123", Role: domain.RoleManager}
+	_, refresh, err := auth.SignSessionTokens(testAccessKeys, auth.HMAC(testUserConfig.RefreshSecret),
+		"user123", "user", "sess1", "jti1", 15, 168)
+	require.NoError(t, err)
+
+	liveSession := func() *domain.Session {
+		return &domain.Session{ID: "sess1", UserID: "user123", RefreshJTI: "jti1", ExpiresAt: time.Now().Add(time.Hour)}
+	}
+
+	tests := []struct {
+		name          string
+		token         string
+		mockSetup     func(*MockUserRepository, *MockSessionRepository)
+		expectedError string
+	}{
+		{
+			name:  "rotates the refresh token",
+			token: refresh,
+			mockSetup: func(mockRepo *MockUserRepository, mockSessions *MockSessionRepository) {
+				mockSessions.On("GetByID", mock.Anything, "sess1").Return(liveSession(), nil)
+				mockRepo.On("GetByID", mock.Anything, "user123").Return(user, nil)
+				mockSessions.On("Rotate", mock.Anything, "sess1", "jti1", mock.MatchedBy(func(j string) bool { return j != "jti1" }),
+					mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(true, nil)
+			},
+		},
+		{
+			name:  "replayed token revokes the session",
+			token: refresh,
+			mockSetup: func(mockRepo *MockUserRepository, mockSessions *MockSessionRepository) {
+				s := liveSession()
+				s.RefreshJTI = "jti2"
+				mockSessions.On("GetByID", mock.Anything, "sess1").Return(s, nil)
+				mockSessions.On("Revoke", mock.Anything, "sess1", domain.RevokeReasonReuse, mock.Anything).Return(nil)
+			},
+			expectedError: "invalid refresh token",
+		},
+		{
+			name:  "concurrent rotation revokes the session",
+			token: refresh,
+			mockSetup: func(mockRepo *MockUserRepository, mockSessions *MockSessionRepository) {
+				mockSessions.On("GetByID", mock.Anything, "sess1").Return(liveSession(), nil)
+				mockRepo.On("GetByID", mock.Anything, "user123").Return(user, nil)
+				mockSessions.On("Rotate", mock.Anything, "sess1", "jti1", mock.Anything,
+					mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(false, nil)
+				mockSessions.On("Revoke", mock.Anything, "sess1", domain.RevokeReasonReuse, mock.Anything).Return(nil)
+			},
+			expectedError: "invalid refresh token",
+		},
+		{
+			name:  "revoked session",
+			token: refresh,
+			mockSetup: func(mockRepo *MockUserRepository, mockSessions *MockSessionRepository) {
+				s := liveSession()
+				now := time.Now()
+				s.RevokedAt = &now
+				mockSessions.On("GetByID", mock.Anything, "sess1").Return(s, nil)
+			},
+			expectedError: "invalid refresh token",
+		},
+		{
+			name:          "garbage token",
+			token:         "not-a-jwt",
+			mockSetup:     func(*MockUserRepository, *MockSessionRepository) {},
+			expectedError: "invalid refresh token",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockUserRepository)
+			mockSessions := new(MockSessionRepository)
+			tt.mockSetup(mockRepo, mockSessions)
+
+			resp, err := newTestUserUseCase(mockRepo, mockSessions).Refresh(context.Background(), tt.token)
+			if tt.expectedError != "" {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedError)
+			} else {
+				require.NoError(t, err)
+				claims, err := testAccessKeys.Verify(resp.AccessToken)
+				require.NoError(t, err)
+				assert.Equal(t, string(domain.RoleManager), claims.Role)
+				assert.NotEqual(t, tt.token, resp.RefreshToken)
+			}
+
+			mockRepo.AssertExpectations(t)
+			mockSessions.AssertExpectations(t)
+		})
+	}
+}
+
+var verifyLinkPattern = regexp.MustCompile(`http://localhost:8081/verify\?token=([A-Za-z0-9_-]+)`)
+
+func TestUserUseCase_RegisterSendsVerificationLink(t *testing.T) {
+	users := new(MockUserRepository)
+	users.On("GetByEmail", mock.Anything, "new@example.com").Return(nil, nil)
+	var user *domain.User
+	users.On("Create", mock.Anything, mock.AnythingOfType("*domain.User")).
+		Run(func(args mock.Arguments) {
+			user = args.Get(1).(*domain.User)
+			user.ID = "user2"
+		}).
+		Return(nil)
+	sessions := new(MockSessionRepository)
+	sessions.On("Create", mock.Anything, mock.AnythingOfType("*domain.Session")).Return(nil)
+	tokens := new(MockAuthTokenRepository)
+	tokens.On("DeleteByUser", mock.Anything, "user2", domain.PurposeVerifyEmail).Return(nil)
+	var stored *domain.AuthToken
+	tokens.On("Create", mock.Anything, mock.AnythingOfType("*domain.AuthToken")).
+		Run(func(args mock.Arguments) { stored = args.Get(1).(*domain.AuthToken) }).
+		Return(nil)
+
+	logger, _ := zap.NewDevelopment()
+	recorder := email.NewRecorder()
+	sender := email.NewSenderWithTransport(recorder, "noreply@microblog.local")
+	emailUC := usecase.NewEmailUseCase(users, tokens, newTestThrottler(), sender, &usecase.EmailConfig{
+		BaseURL: "http://localhost:8081", TokenSecret: "secret", TokenTTLHours: 24,
+	}, logger)
+	cfg := *testUserConfig
+	cfg.Verification = emailUC
+	uc := usecase.NewUserUseCase(users, sessions, nil, auth.NewMemoryRevocationStore(), newTestThrottler(), sender, &cfg, logger)
+	ctx := context.Background()
+
+	_, err := uc.Register(ctx, usecase.RegisterRequest{Email: "new@example.com", Password: "secret123", Locale: "en"})
+	require.NoError(t, err)
+	require.NotNil(t, stored)
+	assert.Equal(t, "user2", stored.UserID)
+	assert.Equal(t, domain.PurposeVerifyEmail, stored.Purpose)
+
+	sent := recorder.Deliveries()
+	require.Len(t, sent, 1)
+	assert.Equal(t, "new@example.com", sent[0].Message.To)
+	// the localized template, in the language chosen at sign-up
+	assert.Equal(t, "Verify your account - Microblog", sent[0].Message.Subject)
+	assert.Contains(t, sent[0].Message.HTML, `href="http://localhost:8081/verify?token=`)
+	m := verifyLinkPattern.FindStringSubmatch(sent[0].Message.Text)
+	require.NotNil(t, m)
+
+	// the link is the one stored for the new account
+	tokens.On("Consume", mock.Anything, stored.TokenHash, domain.PurposeVerifyEmail, mock.Anything).Return(stored, nil)
+	users.On("GetByID", mock.Anything, "user2").Return(user, nil)
+	users.On("Update", mock.Anything, mock.MatchedBy(func(u *domain.User) bool { return u.Verified })).Return(nil)
+	require.NoError(t, emailUC.VerifyEmail(ctx, m[1]))
+	users.AssertExpectations(t)
+}
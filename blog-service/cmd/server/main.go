@@ -2,13 +2,16 @@ package main
 
 import (
 	"context"
+	"time"
 
 	"github.com/HatefBarari/microblog-blog/internal/infrastructure"
 	"github.com/HatefBarari/microblog-blog/internal/presenter"
 	"github.com/HatefBarari/microblog-blog/internal/repository"
 	"github.com/HatefBarari/microblog-blog/internal/usecase"
+	"github.com/HatefBarari/microblog-shared/pkg/auth"
 	"github.com/HatefBarari/microblog-shared/pkg/logger"
 	"github.com/HatefBarari/microblog-shared/pkg/mongo"
+	"github.com/HatefBarari/microblog-shared/pkg/rbac"
 	"go.mongodb.org/mongo-driver/bson"
 	mongoOptions "go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
@@ -38,6 +41,13 @@ func main() {
 	_, _ = idx.CreateOne(context.Background(), mongoOptions.IndexModel{
 		Keys: bson.M{"author_id": 1, "created_at": -1},
 	})
+	// comments and ratings are looked up per user for export and deletion
+	_, _ = mongo.DB().Collection("comments").Indexes().CreateOne(context.Background(), mongoOptions.IndexModel{
+		Keys: bson.M{"author_id": 1},
+	})
+	_, _ = mongo.DB().Collection("ratings").Indexes().CreateOne(context.Background(), mongoOptions.IndexModel{
+		Keys: bson.M{"user_id": 1},
+	})
 	_, _ = mongo.DB().Collection("categories").Indexes().CreateOne(context.Background(), mongoOptions.IndexModel{
 		Keys: bson.M{"slug": 1}, Options: (&options.IndexOptions{}).SetUnique(true),
 	})
@@ -48,18 +58,37 @@ func main() {
 	commentRepo := repository.NewMongoCommentRepo()
 	ratingRepo := repository.NewMongoRatingRepo()
 
-	accessSecret := cfg.Auth.AccessToken
-	refreshSecret := cfg.Auth.RefreshToken
 	logLevel := cfg.Log.Level
 
-	articleUC := usecase.NewArticleUseCase(articleRepo, ratingRepo, accessSecret, refreshSecret, logLevel, log)
+	// author names and avatars come from auth-service profiles
+	authors := infrastructure.NewAuthorClient(cfg.Auth.AuthorsURL, time.Duration(cfg.Auth.AuthorsCacheSec)*time.Second)
+
+	articleUC := usecase.NewArticleUseCase(articleRepo, ratingRepo, authors, logLevel, log)
 	categoryUC := usecase.NewCategoryUseCase(categoryRepo, logLevel, log)
 	commentUC := usecase.NewCommentUseCase(commentRepo, logLevel, log)
 	ratingUC := usecase.NewRatingUseCase(ratingRepo, logLevel, log)
+	userDataUC := usecase.NewUserDataUseCase(articleRepo, commentRepo, ratingRepo, log)
+
+	handler := presenter.NewHTTPHandler(articleUC, categoryUC, commentUC, ratingUC, userDataUC)
+
+	// revocations are written by auth-service; cache briefly to spare mongo
+	revocations := auth.NewCachedRevocationChecker(
+		auth.NewMongoRevocationStore(mongo.Client().Database(cfg.Auth.RevocationDB).Collection("revocations")),
+		time.Duration(cfg.Auth.RevocationCacheSec)*time.Second,
+	)
 
-	handler := presenter.NewHTTPHandler(articleUC, categoryUC, commentUC, ratingUC)
+	// access tokens are verified against the keys auth-service publishes
+	verifier := auth.NewJWKSVerifier(cfg.Auth.JWKSURL, time.Duration(cfg.Auth.JWKSRefreshMin)*time.Minute)
+
+	// personal access tokens are issued by auth-service and stored alongside
+	apiKeys := auth.NewMongoAPIKeyStore(mongo.Client().Database(cfg.Auth.RevocationDB).Collection("api_keys"))
+
+	policy, err := rbac.LoadPolicy(cfg.Auth.RBACPolicy)
+	if err != nil {
+		log.Fatal("load rbac policy", zap.Error(err))
+	}
 
-	if err := infrastructure.StartEcho(cfg, log, handler); err != nil {
+	if err := infrastructure.StartEcho(cfg, log, handler, verifier, revocations, apiKeys, policy); err != nil {
 		log.Fatal("start server", zap.Error(err))
 	}
 }
\ No newline at end of file
@@ -0,0 +1,18 @@
+package domain
+
+import "context"
+
+// AuthorSummary is the public profile of an article or comment author, as
+// published by auth-service.
+type AuthorSummary struct {
+	ID            string `json:"id"`
+	DisplayName   string `json:"display_name"`
+	Handle        string `json:"handle,omitempty"`
+	AvatarMediaID string `json:"avatar_media_id,omitempty"`
+}
+
+// AuthorDirectory resolves author summaries by user ID. Unknown IDs are
+// missing from the result.
+type AuthorDirectory interface {
+	Authors(ctx context.Context, ids []string) (map[string]*AuthorSummary, error)
+}
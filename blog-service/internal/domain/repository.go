@@ -11,6 +11,12 @@ type ArticleRepository interface {
 	Delete(ctx context.Context, id string) error
 	UpdateStatus(ctx context.Context, id string, status ArticleStatus) error
 	UpdateViewCount(ctx context.Context, id string) error
+	// UpdateRating stores an article's recomputed rating average.
+	UpdateRating(ctx context.Context, id string, avg float64) error
+	ListByAuthor(ctx context.Context, authorID string) ([]*Article, error)
+	// ReassignAuthor moves every article of one author to another.
+	ReassignAuthor(ctx context.Context, from, to string) error
+	DeleteByAuthor(ctx context.Context, authorID string) error
 }
 
 type CategoryRepository interface {
@@ -26,6 +32,11 @@ type CommentRepository interface {
 	ListByArticle(ctx context.Context, articleID string, status CommentStatus) ([]*Comment, error)
 	UpdateStatus(ctx context.Context, id string, status CommentStatus) error
 	Delete(ctx context.Context, id string) error
+	ListByAuthor(ctx context.Context, authorID string) ([]*Comment, error)
+	ReassignAuthor(ctx context.Context, from, to string) error
+	DeleteByAuthor(ctx context.Context, authorID string) error
+	// DeleteByArticles removes every comment on the given articles.
+	DeleteByArticles(ctx context.Context, articleIDs []string) error
 }
 
 type RatingRepository interface {
@@ -33,6 +44,8 @@ type RatingRepository interface {
 	GetByUserAndTarget(ctx context.Context, userID, targetID, targetType string) (*Rating, error)
 	GetAverage(ctx context.Context, targetID, targetType string) (float64, error)
 	Delete(ctx context.Context, userID, targetID, targetType string) error
+	ListByUser(ctx context.Context, userID string) ([]*Rating, error)
+	DeleteByUser(ctx context.Context, userID string) error
 }
 
 type ListFilter struct {
@@ -0,0 +1,119 @@
+package infrastructure
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/HatefBarari/microblog-blog/internal/domain"
+)
+
+const (
+	// maxAuthorBatch matches the limit of auth-service's /authors endpoint.
+	maxAuthorBatch = 100
+	// past this many entries, expired ones are swept on the next fetch
+	maxCachedAuthors = 10000
+)
+
+// AuthorClient resolves authors through auth-service's public /authors
+// endpoint and caches them for ttl, so listings don't call out per request.
+type AuthorClient struct {
+	url    string
+	ttl    time.Duration
+	client *http.Client
+
+	mu    sync.Mutex
+	cache map[string]cachedAuthor
+}
+
+type cachedAuthor struct {
+	author  *domain.AuthorSummary
+	expires time.Time
+}
+
+func NewAuthorClient(url string, ttl time.Duration) *AuthorClient {
+	return &AuthorClient{
+		url:    url,
+		ttl:    ttl,
+		client: &http.Client{Timeout: 3 * time.Second},
+		cache:  map[string]cachedAuthor{},
+	}
+}
+
+func (a *AuthorClient) Authors(ctx context.Context, ids []string) (map[string]*domain.AuthorSummary, error) {
+	now := time.Now()
+	found := make(map[string]*domain.AuthorSummary, len(ids))
+	var missing []string
+	a.mu.Lock()
+	for _, id := range ids {
+		if _, dup := found[id]; dup {
+			continue
+		}
+		if e, ok := a.cache[id]; ok && now.Before(e.expires) {
+			found[id] = e.author
+			continue
+		}
+		found[id] = nil
+		missing = append(missing, id)
+	}
+	a.mu.Unlock()
+
+	for start := 0; start < len(missing); start += maxAuthorBatch {
+		end := min(start+maxAuthorBatch, len(missing))
+		fetched, err := a.fetch(ctx, missing[start:end])
+		if err != nil {
+			return nil, err
+		}
+		a.mu.Lock()
+		if len(a.cache) > maxCachedAuthors {
+			for id, e := range a.cache {
+				if !now.Before(e.expires) {
+					delete(a.cache, id)
+				}
+			}
+		}
+		for _, id := range missing[start:end] {
+			// unknown ids are cached too, as nil
+			a.cache[id] = cachedAuthor{author: fetched[id], expires: now.Add(a.ttl)}
+			found[id] = fetched[id]
+		}
+		a.mu.Unlock()
+	}
+	for id, author := range found {
+		if author == nil {
+			delete(found, id)
+		}
+	}
+	return found, nil
+}
+
+func (a *AuthorClient) fetch(ctx context.Context, ids []string) (map[string]*domain.AuthorSummary, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, a.url+"?ids="+url.QueryEscape(strings.Join(ids, ",")), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("authors: unexpected status %d", resp.StatusCode)
+	}
+	var body struct {
+		Data []*domain.AuthorSummary `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("authors: %w", err)
+	}
+	out := make(map[string]*domain.AuthorSummary, len(body.Data))
+	for _, s := range body.Data {
+		out[s.ID] = s
+	}
+	return out, nil
+}
@@ -19,9 +19,22 @@ type Config struct {
 		Level string `yaml:"level"`
 	} `yaml:"log"`
 	Auth struct {
-		AccessToken string `yaml:"access_token"`
-		RefreshToken string `yaml:"refresh_token"`
+		// auth-service's published signing keys
+		JWKSURL        string `yaml:"jwks_url"`
+		JWKSRefreshMin int    `yaml:"jwks_refresh_min"`
+		// database of auth-service holding the "revocations" and "api_keys" collections
+		RevocationDB       string `yaml:"revocation_db"`
+		RevocationCacheSec int    `yaml:"revocation_cache_sec"`
+		// role -> permission policy file shared with auth and media
+		RBACPolicy string `yaml:"rbac_policy"`
+		// auth-service's public author lookup, used to show names on articles
+		AuthorsURL      string `yaml:"authors_url"`
+		AuthorsCacheSec int    `yaml:"authors_cache_sec"`
 	} `yaml:"auth"`
+	Internal struct {
+		// shared with auth-service for the /internal user-data routes
+		Secret string `yaml:"secret"`
+	} `yaml:"internal"`
 }
 
 func Load(path string) (*Config, error) {
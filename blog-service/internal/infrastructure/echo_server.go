@@ -5,12 +5,14 @@ import (
 
 	"github.com/HatefBarari/microblog-blog/internal/presenter"
 	"github.com/HatefBarari/microblog-shared/pkg/auth"
+	"github.com/HatefBarari/microblog-shared/pkg/rbac"
+	"github.com/HatefBarari/microblog-shared/pkg/userdata"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
 	"go.uber.org/zap"
 )
 
-func StartEcho(cfg *Config, log *zap.Logger, handler *presenter.HTTPHandler) error {
+func StartEcho(cfg *Config, log *zap.Logger, handler *presenter.HTTPHandler, verifier auth.Verifier, revocations auth.RevocationChecker, apiKeys auth.APIKeyResolver, policy *rbac.Policy) error {
 	e := echo.New()
 	e.HideBanner = true
 	e.Use(middleware.Recover())
@@ -18,17 +20,27 @@ func StartEcho(cfg *Config, log *zap.Logger, handler *presenter.HTTPHandler) err
 		LogURI:    true,
 		LogStatus: true,
 		LogValuesFunc: func(c echo.Context, v middleware.RequestLoggerValues) error {
-			log.Info("request",
+			fields := []zap.Field{
 				zap.String("method", c.Request().Method),
 				zap.String("uri", v.URI),
 				zap.Int("status", v.Status),
-			)
+			}
+			if uid, ok := c.Get("userID").(string); ok && uid != "" {
+				fields = append(fields, zap.String("user_id", uid))
+			}
+			// ادمینی که با توکن impersonation به جای کاربر عمل می‌کند
+			if actor := auth.ActorID(c); actor != "" {
+				fields = append(fields, zap.String("actor_id", actor))
+			}
+			log.Info("request", fields...)
 			return nil
 		},
 	}))
 
-	// JWT Middleware – secret بعداً از auth-service می‌آید (حالا خالی)
-	jwtMid := auth.Middleware("")
+	// JWT Middleware – کلیدهای عمومی از JWKS سرویس auth خوانده می‌شوند
+	// توکن‌های دسترسی شخصی (mbp_) هم پذیرفته می‌شوند و فقط در محدوده‌ی scopeهای خود کار می‌کنند
+	// دسترسی هر نقش از policy مشترک rbac خوانده می‌شود
+	jwtMid := auth.Middleware(verifier, auth.WithRevocationChecker(revocations), auth.WithAPIKeys(apiKeys), auth.WithPolicy(policy))
 
 	// Public
 	e.GET("/articles", handler.ListArticles)
@@ -37,17 +49,25 @@ func StartEcho(cfg *Config, log *zap.Logger, handler *presenter.HTTPHandler) err
 
 	// Protected (نیاز به JWT)
 	articleGroup := e.Group("/articles", jwtMid)
-	articleGroup.POST("", handler.CreateArticle)
-	articleGroup.PUT("/:id", handler.UpdateArticle)
-	articleGroup.DELETE("/:id", handler.DeleteArticle)
+	articleGroup.POST("", handler.CreateArticle, auth.Require(rbac.ArticleWrite), auth.RequireScope(auth.ScopeArticlesWrite))
+	articleGroup.PUT("/:id", handler.UpdateArticle, auth.Require(rbac.ArticleWrite), auth.RequireScope(auth.ScopeArticlesWrite))
+	articleGroup.DELETE("/:id", handler.DeleteArticle, auth.Require(rbac.ArticleWrite), auth.RequireScope(auth.ScopeArticlesWrite))
+	articleGroup.PUT("/:id/status", handler.UpdateArticleStatus, auth.Require(rbac.ArticleWrite), auth.RequireScope(auth.ScopeArticlesWrite))
 	articleGroup.GET("/:id/comments", handler.ListComments)
-	articleGroup.POST("/:id/comments", handler.CreateComment)
-	articleGroup.POST("/:id/rating", handler.RateArticle)
-	articleGroup.DELETE("/:id/rating", handler.DeleteRating)
+	articleGroup.POST("/:id/comments", handler.CreateComment, auth.Require(rbac.CommentWrite), auth.RequireScope(auth.ScopeCommentsWrite))
+	articleGroup.POST("/:id/rating", handler.RateArticle, auth.Require(rbac.RatingWrite), auth.RequireScope(auth.ScopeRatingsWrite))
+	articleGroup.DELETE("/:id/rating", handler.DeleteRating, auth.Require(rbac.RatingWrite), auth.RequireScope(auth.ScopeRatingsWrite))
 
-	// Manager/Admin only
+	// Moderation
 	managerGroup := e.Group("/categories", jwtMid)
-	managerGroup.POST("", handler.CreateCategory)
+	managerGroup.POST("", handler.CreateCategory, auth.Require(rbac.CategoryManage), auth.RequireScope(auth.ScopeCategoriesWrite))
+	commentGroup := e.Group("/comments", jwtMid)
+	commentGroup.PUT("/:id/status", handler.UpdateCommentStatus, auth.Require(rbac.CommentModerate), auth.RequireScope(auth.ScopeCommentsModerate))
+
+	// Internal – خروجی و حذف داده‌های کاربر، فقط برای سرویس auth (pkg/userdata)
+	internal := e.Group("/internal", userdata.Middleware(cfg.Internal.Secret))
+	internal.GET("/users/:id/export", handler.ExportUserData)
+	internal.DELETE("/users/:id", handler.DeleteUserData)
 
 	log.Info("starting blog server", zap.Int("port", cfg.Server.Port))
 	return e.Start(":" + strconv.Itoa(cfg.Server.Port))
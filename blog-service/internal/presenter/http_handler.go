@@ -5,7 +5,9 @@ import (
 
 	"github.com/HatefBarari/microblog-blog/internal/domain"
 	"github.com/HatefBarari/microblog-blog/internal/usecase"
+	"github.com/HatefBarari/microblog-shared/pkg/auth"
 	"github.com/HatefBarari/microblog-shared/pkg/httputil"
+	"github.com/HatefBarari/microblog-shared/pkg/rbac"
 	"github.com/labstack/echo/v4"
 )
 
@@ -14,14 +16,16 @@ type HTTPHandler struct {
 	categoryUC *usecase.CategoryUseCase
 	commentUC  *usecase.CommentUseCase
 	ratingUC   *usecase.RatingUseCase
+	userDataUC *usecase.UserDataUseCase
 }
 
-func NewHTTPHandler(articleUC *usecase.ArticleUseCase, categoryUC *usecase.CategoryUseCase, commentUC *usecase.CommentUseCase, ratingUC *usecase.RatingUseCase) *HTTPHandler {
+func NewHTTPHandler(articleUC *usecase.ArticleUseCase, categoryUC *usecase.CategoryUseCase, commentUC *usecase.CommentUseCase, ratingUC *usecase.RatingUseCase, userDataUC *usecase.UserDataUseCase) *HTTPHandler {
 	return &HTTPHandler{
 		articleUC:  articleUC,
 		categoryUC: categoryUC,
 		commentUC:  commentUC,
 		ratingUC:   ratingUC,
+		userDataUC: userDataUC,
 	}
 }
 
@@ -90,6 +94,31 @@ func (h *HTTPHandler) DeleteArticle(c echo.Context) error {
 	return c.NoContent(http.StatusNoContent)
 }
 
+// UpdateArticleStatus lets authors submit or archive their own articles;
+// approving (publishing) or rejecting one takes article.publish.
+func (h *HTTPHandler) UpdateArticleStatus(c echo.Context) error {
+	userID := c.Get("userID").(string)
+	id := c.Param("id")
+	var req struct {
+		Status domain.ArticleStatus `json:"status" validate:"required,oneof=draft pending approved rejected archived"`
+	}
+	if err := httputil.BindAndValidate(c, &req); err != nil {
+		return c.JSON(http.StatusBadRequest, httputil.NewError(400, err.Error()))
+	}
+	canPublish := auth.HasPermission(c, rbac.ArticlePublish)
+	resp, err := h.articleUC.UpdateStatus(c.Request().Context(), userID, id, req.Status, canPublish)
+	if err != nil {
+		switch err.Error() {
+		case "article not found":
+			return c.JSON(http.StatusNotFound, httputil.NewError(404, err.Error()))
+		case "forbidden":
+			return c.JSON(http.StatusForbidden, httputil.NewError(403, err.Error()))
+		}
+		return c.JSON(http.StatusBadRequest, httputil.NewError(400, err.Error()))
+	}
+	return c.JSON(http.StatusOK, httputil.OK(resp))
+}
+
 // ---------- Category ----------
 func (h *HTTPHandler) ListCategoryTree(c echo.Context) error {
 	tree, err := h.categoryUC.ListTree(c.Request().Context())
@@ -100,10 +129,6 @@ func (h *HTTPHandler) ListCategoryTree(c echo.Context) error {
 }
 
 func (h *HTTPHandler) CreateCategory(c echo.Context) error {
-	role := c.Get("role").(string)
-	if role != "manager" && role != "admin" {
-		return c.JSON(http.StatusForbidden, httputil.NewError(403, "forbidden"))
-	}
 	var req struct {
 		Name     string `json:"name" validate:"required,min=2"`
 		ParentID string `json:"parent_id,omitempty"`
@@ -144,10 +169,6 @@ func (h *HTTPHandler) CreateComment(c echo.Context) error {
 }
 
 func (h *HTTPHandler) UpdateCommentStatus(c echo.Context) error {
-	role := c.Get("role").(string)
-	if role != "manager" && role != "admin" {
-		return c.JSON(http.StatusForbidden, httputil.NewError(403, "forbidden"))
-	}
 	id := c.Param("id")
 	var req struct {
 		Status domain.CommentStatus `json:"status" validate:"required,oneof=approved rejected"`
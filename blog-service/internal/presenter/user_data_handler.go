@@ -0,0 +1,32 @@
+package presenter
+
+import (
+	"net/http"
+
+	"github.com/HatefBarari/microblog-shared/pkg/httputil"
+	"github.com/HatefBarari/microblog-shared/pkg/userdata"
+	"github.com/labstack/echo/v4"
+)
+
+// ---------- Internal (auth-service) ----------
+
+// ExportUserData returns the user's articles, comments and ratings
+func (h *HTTPHandler) ExportUserData(c echo.Context) error {
+	export, err := h.userDataUC.Export(c.Request().Context(), c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, httputil.NewError(500, err.Error()))
+	}
+	return c.JSON(http.StatusOK, httputil.OK(export))
+}
+
+// DeleteUserData removes or anonymizes what the user left behind
+func (h *HTTPHandler) DeleteUserData(c echo.Context) error {
+	mode := userdata.Mode(c.QueryParam("mode"))
+	if !mode.Valid() {
+		return c.JSON(http.StatusBadRequest, httputil.NewError(400, "mode must be anonymize or remove"))
+	}
+	if err := h.userDataUC.Delete(c.Request().Context(), c.Param("id"), mode); err != nil {
+		return c.JSON(http.StatusInternalServerError, httputil.NewError(500, err.Error()))
+	}
+	return c.NoContent(http.StatusNoContent)
+}
@@ -97,6 +97,38 @@ func (r *mongoArticleRepo) UpdateViewCount(ctx context.Context, id string) error
 	return err
 }
 
+func (r *mongoArticleRepo) UpdateRating(ctx context.Context, id string, avg float64) error {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+	_, err = mongoDB.DB().Collection("articles").UpdateOne(ctx, bson.M{"_id": oid}, bson.M{"$set": bson.M{"rating_avg": avg}})
+	return err
+}
+
+func (r *mongoArticleRepo) ListByAuthor(ctx context.Context, authorID string) ([]*domain.Article, error) {
+	cursor, err := mongoDB.DB().Collection("articles").Find(ctx, bson.M{"author_id": authorID}, options.Find().SetSort(bson.M{"created_at": 1}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+	list := []*domain.Article{}
+	if err := cursor.All(ctx, &list); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (r *mongoArticleRepo) ReassignAuthor(ctx context.Context, from, to string) error {
+	_, err := mongoDB.DB().Collection("articles").UpdateMany(ctx, bson.M{"author_id": from}, bson.M{"$set": bson.M{"author_id": to}})
+	return err
+}
+
+func (r *mongoArticleRepo) DeleteByAuthor(ctx context.Context, authorID string) error {
+	_, err := mongoDB.DB().Collection("articles").DeleteMany(ctx, bson.M{"author_id": authorID})
+	return err
+}
+
 // ---------- Category ----------
 type mongoCategoryRepo struct{}
 
@@ -202,6 +234,37 @@ func (r *mongoCommentRepo) Delete(ctx context.Context, id string) error {
 	return err
 }
 
+func (r *mongoCommentRepo) ListByAuthor(ctx context.Context, authorID string) ([]*domain.Comment, error) {
+	cursor, err := mongoDB.DB().Collection("comments").Find(ctx, bson.M{"author_id": authorID}, options.Find().SetSort(bson.M{"created_at": 1}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+	list := []*domain.Comment{}
+	if err := cursor.All(ctx, &list); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (r *mongoCommentRepo) ReassignAuthor(ctx context.Context, from, to string) error {
+	_, err := mongoDB.DB().Collection("comments").UpdateMany(ctx, bson.M{"author_id": from}, bson.M{"$set": bson.M{"author_id": to}})
+	return err
+}
+
+func (r *mongoCommentRepo) DeleteByAuthor(ctx context.Context, authorID string) error {
+	_, err := mongoDB.DB().Collection("comments").DeleteMany(ctx, bson.M{"author_id": authorID})
+	return err
+}
+
+func (r *mongoCommentRepo) DeleteByArticles(ctx context.Context, articleIDs []string) error {
+	if len(articleIDs) == 0 {
+		return nil
+	}
+	_, err := mongoDB.DB().Collection("comments").DeleteMany(ctx, bson.M{"article_id": bson.M{"$in": articleIDs}})
+	return err
+}
+
 // ---------- Rating ----------
 type mongoRatingRepo struct{}
 
@@ -241,4 +304,22 @@ func (r *mongoRatingRepo) GetAverage(ctx context.Context, targetID, targetType s
 func (r *mongoRatingRepo) Delete(ctx context.Context, userID, targetID, targetType string) error {
 	_, err := mongoDB.DB().Collection("ratings").DeleteOne(ctx, bson.M{"user_id": userID, "target_id": targetID, "type": targetType})
 	return err
-}
\ No newline at end of file
+}
+
+func (r *mongoRatingRepo) ListByUser(ctx context.Context, userID string) ([]*domain.Rating, error) {
+	cursor, err := mongoDB.DB().Collection("ratings").Find(ctx, bson.M{"user_id": userID}, options.Find().SetSort(bson.M{"created_at": 1}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+	list := []*domain.Rating{}
+	if err := cursor.All(ctx, &list); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (r *mongoRatingRepo) DeleteByUser(ctx context.Context, userID string) error {
+	_, err := mongoDB.DB().Collection("ratings").DeleteMany(ctx, bson.M{"user_id": userID})
+	return err
+}
@@ -14,18 +14,17 @@ import (
 type ArticleUseCase struct {
 	repo          domain.ArticleRepository
 	ratingRepo    domain.RatingRepository
-	accessSecret  string
-	refreshSecret string
+	authors       domain.AuthorDirectory
 	logLevel      string
 	log           *zap.Logger
 }
 
-func NewArticleUseCase(repo domain.ArticleRepository, ratingRepo domain.RatingRepository, accessSecret, refreshSecret, logLevel string, log *zap.Logger) *ArticleUseCase {
+// authors may be nil, in which case responses carry only author_id.
+func NewArticleUseCase(repo domain.ArticleRepository, ratingRepo domain.RatingRepository, authors domain.AuthorDirectory, logLevel string, log *zap.Logger) *ArticleUseCase {
 	return &ArticleUseCase{
 		repo:          repo,
 		ratingRepo:    ratingRepo,
-		accessSecret:  accessSecret,
-		refreshSecret: refreshSecret,
+		authors:       authors,
 		logLevel:      logLevel,
 		log:           log,
 	}
@@ -94,7 +93,7 @@ func (uc *ArticleUseCase) GetBySlug(ctx context.Context, slug string) (*ArticleR
 	}
 	// bump view
 	_ = uc.repo.UpdateViewCount(ctx, a.ID)
-	return &ArticleResponse{
+	resp := &ArticleResponse{
 		ID:        a.ID,
 		AuthorID:  a.AuthorID,
 		Title:     a.Title,
@@ -108,7 +107,9 @@ func (uc *ArticleUseCase) GetBySlug(ctx context.Context, slug string) (*ArticleR
 		ViewCount: a.ViewCount + 1,
 		RatingAvg: a.RatingAvg,
 		CreatedAt: a.CreatedAt,
-	}, nil
+	}
+	uc.attachAuthors(ctx, []*ArticleResponse{resp})
+	return resp, nil
 }
 
 func (uc *ArticleUseCase) List(ctx context.Context, filter domain.ListFilter) ([]*ArticleResponse, int, error) {
@@ -134,6 +135,7 @@ func (uc *ArticleUseCase) List(ctx context.Context, filter domain.ListFilter) ([
 			CreatedAt: a.CreatedAt,
 		}
 	}
+	uc.attachAuthors(ctx, resp)
 	return resp, total, nil
 }
 
@@ -190,4 +192,73 @@ func (uc *ArticleUseCase) Delete(ctx context.Context, userID string, id string)
 		return errors.New("forbidden")
 	}
 	return uc.repo.Delete(ctx, id)
-}
\ No newline at end of file
+}
+
+// UpdateStatus moves an article through its review workflow. Authors may
+// draft, submit or archive their own articles; approving (publishing) or
+// rejecting one needs canPublish, which the caller derives from the
+// article.publish permission.
+func (uc *ArticleUseCase) UpdateStatus(ctx context.Context, userID, id string, status domain.ArticleStatus, canPublish bool) (*ArticleResponse, error) {
+	a, err := uc.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if a == nil {
+		return nil, errors.New("article not found")
+	}
+	switch status {
+	case domain.StatusApproved, domain.StatusRejected:
+		if !canPublish {
+			return nil, errors.New("forbidden")
+		}
+	default:
+		if a.AuthorID != userID && !canPublish {
+			return nil, errors.New("forbidden")
+		}
+	}
+	now := time.Now()
+	a.Status = status
+	a.UpdatedAt = now
+	if status == domain.StatusApproved && a.PublishedAt == nil {
+		a.PublishedAt = &now
+	}
+	if err := uc.repo.Update(ctx, a); err != nil {
+		return nil, err
+	}
+	return &ArticleResponse{
+		ID:         a.ID,
+		AuthorID:   a.AuthorID,
+		Title:      a.Title,
+		Slug:       a.Slug,
+		Summary:    a.Summary,
+		Content:    a.Content,
+		CoverURL:   a.CoverURL,
+		Status:     string(a.Status),
+		CategoryID: a.CategoryID,
+		Tags:       a.Tags,
+		ViewCount:  a.ViewCount,
+		RatingAvg:  a.RatingAvg,
+		CreatedAt:  a.CreatedAt,
+		UpdatedAt:  a.UpdatedAt,
+	}, nil
+}
+
+// attachAuthors fills in author summaries with one batched lookup. Listings
+// still work without them when auth-service is unavailable.
+func (uc *ArticleUseCase) attachAuthors(ctx context.Context, list []*ArticleResponse) {
+	if uc.authors == nil || len(list) == 0 {
+		return
+	}
+	ids := make([]string, 0, len(list))
+	for _, a := range list {
+		ids = append(ids, a.AuthorID)
+	}
+	authors, err := uc.authors.Authors(ctx, ids)
+	if err != nil {
+		uc.log.Warn("resolve authors", zap.Error(err))
+		return
+	}
+	for _, a := range list {
+		a.Author = authors[a.AuthorID]
+	}
+}
@@ -1,6 +1,10 @@
 package usecase
 
-import "time"
+import (
+	"time"
+
+	"github.com/HatefBarari/microblog-blog/internal/domain"
+)
 
 // Article
 type CreateArticleRequest struct {
@@ -27,6 +31,8 @@ type ArticleResponse struct {
 	RatingAvg   float64   `json:"rating_avg"`
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
+	// Author is filled in when auth-service can be reached
+	Author *domain.AuthorSummary `json:"author,omitempty"`
 }
 
 // Category
@@ -58,6 +64,13 @@ type CommentResponse struct {
 type RatingRequest struct {
 	Stars int `json:"stars" validate:"required,min=1,max=5"`
 }
+
+type RatingResponse struct {
+	TargetID  string    `json:"target_id"`
+	Type      string    `json:"type"`
+	Stars     int       `json:"stars"`
+	CreatedAt time.Time `json:"created_at"`
+}
 type ListFilter struct {
 	AuthorID   *string
 	CategoryID *string
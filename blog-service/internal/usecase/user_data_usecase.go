@@ -0,0 +1,159 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/HatefBarari/microblog-blog/internal/domain"
+	"github.com/HatefBarari/microblog-shared/pkg/userdata"
+	"go.uber.org/zap"
+)
+
+// UserDataUseCase serves auth-service's personal-data export and account
+// deletion for the articles, comments and ratings a user owns here.
+type UserDataUseCase struct {
+	articles domain.ArticleRepository
+	comments domain.CommentRepository
+	ratings  domain.RatingRepository
+	log      *zap.Logger
+}
+
+func NewUserDataUseCase(articles domain.ArticleRepository, comments domain.CommentRepository, ratings domain.RatingRepository, log *zap.Logger) *UserDataUseCase {
+	return &UserDataUseCase{articles: articles, comments: comments, ratings: ratings, log: log}
+}
+
+func (uc *UserDataUseCase) Export(ctx context.Context, userID string) (*userdata.Export, error) {
+	articles, err := uc.articles.ListByAuthor(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	comments, err := uc.comments.ListByAuthor(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	ratings, err := uc.ratings.ListByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	e := &userdata.Export{}
+	articleList := make([]*ArticleResponse, len(articles))
+	for i, a := range articles {
+		articleList[i] = &ArticleResponse{
+			ID:         a.ID,
+			AuthorID:   a.AuthorID,
+			Title:      a.Title,
+			Slug:       a.Slug,
+			Summary:    a.Summary,
+			Content:    a.Content,
+			CoverURL:   a.CoverURL,
+			Status:     string(a.Status),
+			CategoryID: a.CategoryID,
+			Tags:       a.Tags,
+			ViewCount:  a.ViewCount,
+			RatingAvg:  a.RatingAvg,
+			CreatedAt:  a.CreatedAt,
+			UpdatedAt:  a.UpdatedAt,
+		}
+	}
+	commentList := make([]*CommentResponse, len(comments))
+	for i, c := range comments {
+		commentList[i] = &CommentResponse{
+			ID:        c.ID,
+			ArticleID: c.ArticleID,
+			ParentID:  c.ParentID,
+			AuthorID:  c.AuthorID,
+			Content:   c.Content,
+			Status:    string(c.Status),
+			CreatedAt: c.CreatedAt,
+		}
+	}
+	ratingList := make([]*RatingResponse, len(ratings))
+	for i, r := range ratings {
+		ratingList[i] = &RatingResponse{
+			TargetID:  r.TargetID,
+			Type:      r.Type,
+			Stars:     r.Stars,
+			CreatedAt: r.CreatedAt,
+		}
+	}
+	for name, v := range map[string]interface{}{
+		"articles": articleList,
+		"comments": commentList,
+		"ratings":  ratingList,
+	} {
+		if err := e.AddDocument(name, v); err != nil {
+			return nil, err
+		}
+	}
+	return e, nil
+}
+
+// Delete removes the user's ratings, recomputing the average of every
+// article they rated, and either removes or anonymizes their articles and
+// comments. Removing an article takes its comments with it.
+// Every step is safe to repeat.
+func (uc *UserDataUseCase) Delete(ctx context.Context, userID string, mode userdata.Mode) error {
+	if userID == userdata.DeletedUserID {
+		return fmt.Errorf("cannot delete %q", userID)
+	}
+	if err := uc.deleteRatings(ctx, userID); err != nil {
+		return err
+	}
+	switch mode {
+	case userdata.ModeAnonymize:
+		if err := uc.articles.ReassignAuthor(ctx, userID, userdata.DeletedUserID); err != nil {
+			return err
+		}
+		if err := uc.comments.ReassignAuthor(ctx, userID, userdata.DeletedUserID); err != nil {
+			return err
+		}
+	case userdata.ModeRemove:
+		articles, err := uc.articles.ListByAuthor(ctx, userID)
+		if err != nil {
+			return err
+		}
+		ids := make([]string, len(articles))
+		for i, a := range articles {
+			ids[i] = a.ID
+		}
+		if err := uc.comments.DeleteByArticles(ctx, ids); err != nil {
+			return err
+		}
+		if err := uc.comments.DeleteByAuthor(ctx, userID); err != nil {
+			return err
+		}
+		if err := uc.articles.DeleteByAuthor(ctx, userID); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unknown deletion mode %q", mode)
+	}
+	uc.log.Info("user data deleted", zap.String("user_id", userID), zap.String("mode", string(mode)))
+	return nil
+}
+
+// deleteRatings removes the user's ratings and refreshes the averages they
+// counted towards.
+func (uc *UserDataUseCase) deleteRatings(ctx context.Context, userID string) error {
+	ratings, err := uc.ratings.ListByUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if err := uc.ratings.DeleteByUser(ctx, userID); err != nil {
+		return err
+	}
+	for _, r := range ratings {
+		if r.Type != "article" {
+			continue
+		}
+		avg, err := uc.ratings.GetAverage(ctx, r.TargetID, r.Type)
+		if err != nil {
+			return err
+		}
+		if err := uc.articles.UpdateRating(ctx, r.TargetID, avg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -25,6 +25,9 @@ func (m *MockArticleRepository) Create(ctx context.Context, article *domain.Arti
 
 func (m *MockArticleRepository) GetByID(ctx context.Context, id string) (*domain.Article, error) {
 	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
 	return args.Get(0).(*domain.Article), args.Error(1)
 }
 
@@ -51,45 +54,33 @@ func (m *MockArticleRepository) Delete(ctx context.Context, id string) error {
 	return args.Error(0)
 }
 
-func (m *MockArticleRepository) UpdateViewCount(ctx context.Context, id string) error {
-	args := m.Called(ctx, id)
+func (m *MockArticleRepository) UpdateStatus(ctx context.Context, id string, status domain.ArticleStatus) error {
+	args := m.Called(ctx, id, status)
 	return args.Error(0)
 }
 
-type MockRatingRepository struct {
-	mock.Mock
-}
-
-func (m *MockRatingRepository) Create(ctx context.Context, rating *domain.Rating) error {
-	args := m.Called(ctx, rating)
+func (m *MockArticleRepository) UpdateViewCount(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
 	return args.Error(0)
 }
 
-func (m *MockRatingRepository) GetByUserAndTarget(ctx context.Context, userID, targetID, targetType string) (*domain.Rating, error) {
-	args := m.Called(ctx, userID, targetID, targetType)
-	if args.Get(0) == nil {
-		return nil, args.Error(1)
-	}
-	return args.Get(0).(*domain.Rating), args.Error(1)
-}
-
-func (m *MockRatingRepository) Update(ctx context.Context, rating *domain.Rating) error {
-	args := m.Called(ctx, rating)
+func (m *MockArticleRepository) UpdateRating(ctx context.Context, id string, avg float64) error {
+	args := m.Called(ctx, id, avg)
 	return args.Error(0)
 }
 
-func (m *MockRatingRepository) Delete(ctx context.Context, id string) error {
-	args := m.Called(ctx, id)
-	return args.Error(0)
+func (m *MockArticleRepository) ListByAuthor(ctx context.Context, authorID string) ([]*domain.Article, error) {
+	args := m.Called(ctx, authorID)
+	return args.Get(0).([]*domain.Article), args.Error(1)
 }
 
-func (m *MockRatingRepository) GetAverageByTarget(ctx context.Context, targetID, targetType string) (float64, error) {
-	args := m.Called(ctx, targetID, targetType)
-	return args.Get(0).(float64), args.Error(1)
+func (m *MockArticleRepository) ReassignAuthor(ctx context.Context, from, to string) error {
+	args := m.Called(ctx, from, to)
+	return args.Error(0)
 }
 
-func (m *MockRatingRepository) UpdateTargetAverage(ctx context.Context, targetID, targetType string, avg float64) error {
-	args := m.Called(ctx, targetID, targetType, avg)
+func (m *MockArticleRepository) DeleteByAuthor(ctx context.Context, authorID string) error {
+	args := m.Called(ctx, authorID)
 	return args.Error(0)
 }
 
@@ -171,8 +162,7 @@ func TestArticleUseCase_Create(t *testing.T) {
 			uc := usecase.NewArticleUseCase(
 				mockRepo,
 				mockRatingRepo,
-				"access_secret",
-				"refresh_secret",
+				nil,
 				"debug",
 				logger,
 			)
@@ -250,8 +240,7 @@ func TestArticleUseCase_GetBySlug(t *testing.T) {
 			uc := usecase.NewArticleUseCase(
 				mockRepo,
 				mockRatingRepo,
-				"access_secret",
-				"refresh_secret",
+				nil,
 				"debug",
 				logger,
 			)
@@ -356,8 +345,7 @@ func TestArticleUseCase_Update(t *testing.T) {
 			uc := usecase.NewArticleUseCase(
 				mockRepo,
 				mockRatingRepo,
-				"access_secret",
-				"refresh_secret",
+				nil,
 				"debug",
 				logger,
 			)
@@ -436,8 +424,7 @@ func TestArticleUseCase_Delete(t *testing.T) {
 			uc := usecase.NewArticleUseCase(
 				mockRepo,
 				mockRatingRepo,
-				"access_secret",
-				"refresh_secret",
+				nil,
 				"debug",
 				logger,
 			)
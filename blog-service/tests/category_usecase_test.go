@@ -37,16 +37,11 @@ func (m *MockCategoryRepository) GetBySlug(ctx context.Context, slug string) (*d
 	return args.Get(0).(*domain.Category), args.Error(1)
 }
 
-func (m *MockCategoryRepository) List(ctx context.Context) ([]*domain.Category, error) {
+func (m *MockCategoryRepository) ListTree(ctx context.Context) ([]*domain.Category, error) {
 	args := m.Called(ctx)
 	return args.Get(0).([]*domain.Category), args.Error(1)
 }
 
-func (m *MockCategoryRepository) ListByParent(ctx context.Context, parentID string) ([]*domain.Category, error) {
-	args := m.Called(ctx, parentID)
-	return args.Get(0).([]*domain.Category), args.Error(1)
-}
-
 func TestCategoryUseCase_Create(t *testing.T) {
 	logger, _ := zap.NewDevelopment()
 	
@@ -108,7 +103,7 @@ func TestCategoryUseCase_Create(t *testing.T) {
 				}
 				mockRepo.On("GetBySlug", mock.Anything, "technology").Return(existingCategory, nil)
 			},
-			expectedError: "category with this slug already exists",
+			expectedError: "slug already exists",
 		},
 		{
 			name:         "repository error",
@@ -127,7 +122,7 @@ func TestCategoryUseCase_Create(t *testing.T) {
 			mockRepo := new(MockCategoryRepository)
 			tt.mockSetup(mockRepo)
 
-			uc := usecase.NewCategoryUseCase(mockRepo, logger)
+			uc := usecase.NewCategoryUseCase(mockRepo, "debug", logger)
 
 			result, err := uc.Create(context.Background(), tt.categoryName, tt.parentID)
 
@@ -153,7 +148,7 @@ func TestCategoryUseCase_ListTree(t *testing.T) {
 		name           string
 		mockSetup      func(*MockCategoryRepository)
 		expectedError  string
-		expectedResult func([]*usecase.CategoryResponse) bool
+		expectedResult func([]usecase.CategoryResponse) bool
 	}{
 		{
 			name: "successful tree listing",
@@ -163,32 +158,31 @@ func TestCategoryUseCase_ListTree(t *testing.T) {
 					{ID: "cat2", Name: "Programming", Slug: "programming", ParentID: "cat1"},
 					{ID: "cat3", Name: "Science", Slug: "science", ParentID: ""},
 				}
-				mockRepo.On("List", mock.Anything).Return(categories, nil)
+				mockRepo.On("ListTree", mock.Anything).Return(categories, nil)
 			},
 			expectedError: "",
-			expectedResult: func(resp []*usecase.CategoryResponse) bool {
-				return len(resp) == 2 && // 2 root categories
+			expectedResult: func(resp []usecase.CategoryResponse) bool {
+				return len(resp) == 3 &&
 					resp[0].Name == "Technology" &&
-					len(resp[0].Children) == 1 && // 1 child
-					resp[0].Children[0].Name == "Programming" &&
-					resp[1].Name == "Science" &&
-					len(resp[1].Children) == 0 // no children
+					resp[1].Name == "Programming" &&
+					resp[1].ParentID == "cat1" &&
+					resp[2].Name == "Science"
 			},
 		},
 		{
 			name: "empty tree",
 			mockSetup: func(mockRepo *MockCategoryRepository) {
-				mockRepo.On("List", mock.Anything).Return([]*domain.Category{}, nil)
+				mockRepo.On("ListTree", mock.Anything).Return([]*domain.Category{}, nil)
 			},
 			expectedError: "",
-			expectedResult: func(resp []*usecase.CategoryResponse) bool {
+			expectedResult: func(resp []usecase.CategoryResponse) bool {
 				return len(resp) == 0
 			},
 		},
 		{
 			name: "repository error",
 			mockSetup: func(mockRepo *MockCategoryRepository) {
-				mockRepo.On("List", mock.Anything).Return([]*domain.Category{}, errors.New("database error"))
+				mockRepo.On("ListTree", mock.Anything).Return([]*domain.Category{}, errors.New("database error"))
 			},
 			expectedError: "database error",
 		},
@@ -199,7 +193,7 @@ func TestCategoryUseCase_ListTree(t *testing.T) {
 			mockRepo := new(MockCategoryRepository)
 			tt.mockSetup(mockRepo)
 
-			uc := usecase.NewCategoryUseCase(mockRepo, logger)
+			uc := usecase.NewCategoryUseCase(mockRepo, "debug", logger)
 
 			result, err := uc.ListTree(context.Background())
 
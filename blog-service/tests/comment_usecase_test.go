@@ -35,8 +35,8 @@ func (m *MockCommentRepository) ListByArticle(ctx context.Context, articleID str
 	return args.Get(0).([]*domain.Comment), args.Error(1)
 }
 
-func (m *MockCommentRepository) Update(ctx context.Context, comment *domain.Comment) error {
-	args := m.Called(ctx, comment)
+func (m *MockCommentRepository) UpdateStatus(ctx context.Context, id string, status domain.CommentStatus) error {
+	args := m.Called(ctx, id, status)
 	return args.Error(0)
 }
 
@@ -45,6 +45,26 @@ func (m *MockCommentRepository) Delete(ctx context.Context, id string) error {
 	return args.Error(0)
 }
 
+func (m *MockCommentRepository) ListByAuthor(ctx context.Context, authorID string) ([]*domain.Comment, error) {
+	args := m.Called(ctx, authorID)
+	return args.Get(0).([]*domain.Comment), args.Error(1)
+}
+
+func (m *MockCommentRepository) ReassignAuthor(ctx context.Context, from, to string) error {
+	args := m.Called(ctx, from, to)
+	return args.Error(0)
+}
+
+func (m *MockCommentRepository) DeleteByAuthor(ctx context.Context, authorID string) error {
+	args := m.Called(ctx, authorID)
+	return args.Error(0)
+}
+
+func (m *MockCommentRepository) DeleteByArticles(ctx context.Context, articleIDs []string) error {
+	args := m.Called(ctx, articleIDs)
+	return args.Error(0)
+}
+
 func TestCommentUseCase_Create(t *testing.T) {
 	logger, _ := zap.NewDevelopment()
 	
@@ -125,7 +145,7 @@ func TestCommentUseCase_Create(t *testing.T) {
 			mockRepo := new(MockCommentRepository)
 			tt.mockSetup(mockRepo)
 
-			uc := usecase.NewCommentUseCase(mockRepo, logger)
+			uc := usecase.NewCommentUseCase(mockRepo, "debug", logger)
 
 			result, err := uc.Create(context.Background(), tt.userID, tt.articleID, tt.request)
 
@@ -218,7 +238,7 @@ func TestCommentUseCase_ListByArticle(t *testing.T) {
 			mockRepo := new(MockCommentRepository)
 			tt.mockSetup(mockRepo)
 
-			uc := usecase.NewCommentUseCase(mockRepo, logger)
+			uc := usecase.NewCommentUseCase(mockRepo, "debug", logger)
 
 			result, err := uc.ListByArticle(context.Background(), tt.articleID, tt.status)
 
@@ -252,53 +272,17 @@ func TestCommentUseCase_UpdateStatus(t *testing.T) {
 			commentID: "comment123",
 			status:    domain.CommentApproved,
 			mockSetup: func(mockRepo *MockCommentRepository) {
-				existingComment := &domain.Comment{
-					ID:        "comment123",
-					ArticleID: "article123",
-					AuthorID:  "user123",
-					Content:   "Test comment",
-					Status:    domain.CommentPending,
-				}
-				mockRepo.On("GetByID", mock.Anything, "comment123").Return(existingComment, nil)
-				mockRepo.On("Update", mock.Anything, mock.MatchedBy(func(comment *domain.Comment) bool {
-					return comment.ID == "comment123" &&
-						comment.Status == domain.CommentApproved
-				})).Return(nil)
+				mockRepo.On("UpdateStatus", mock.Anything, "comment123", domain.CommentApproved).Return(nil)
 			},
 			expectedError: "",
 		},
-		{
-			name:      "comment not found",
-			commentID: "nonexistent",
-			status:    domain.CommentApproved,
-			mockSetup: func(mockRepo *MockCommentRepository) {
-				mockRepo.On("GetByID", mock.Anything, "nonexistent").Return(nil, nil)
-			},
-			expectedError: "comment not found",
-		},
-		{
-			name:      "repository error on get",
-			commentID: "comment123",
-			status:    domain.CommentApproved,
-			mockSetup: func(mockRepo *MockCommentRepository) {
-				mockRepo.On("GetByID", mock.Anything, "comment123").Return(nil, errors.New("database error"))
-			},
-			expectedError: "database error",
-		},
 		{
 			name:      "repository error on update",
 			commentID: "comment123",
 			status:    domain.CommentApproved,
 			mockSetup: func(mockRepo *MockCommentRepository) {
-				existingComment := &domain.Comment{
-					ID:        "comment123",
-					ArticleID: "article123",
-					AuthorID:  "user123",
-					Content:   "Test comment",
-					Status:    domain.CommentPending,
-				}
-				mockRepo.On("GetByID", mock.Anything, "comment123").Return(existingComment, nil)
-				mockRepo.On("Update", mock.Anything, mock.Anything).Return(errors.New("database error"))
+				mockRepo.On("UpdateStatus", mock.Anything, "comment123", domain.CommentApproved).
+					Return(errors.New("database error"))
 			},
 			expectedError: "database error",
 		},
@@ -309,7 +293,7 @@ func TestCommentUseCase_UpdateStatus(t *testing.T) {
 			mockRepo := new(MockCommentRepository)
 			tt.mockSetup(mockRepo)
 
-			uc := usecase.NewCommentUseCase(mockRepo, logger)
+			uc := usecase.NewCommentUseCase(mockRepo, "debug", logger)
 
 			err := uc.UpdateStatus(context.Background(), tt.commentID, tt.status)
 
@@ -2,7 +2,6 @@ package tests
 
 import (
 	"bytes"
-	"context"
 	"encoding/json"
 	"errors"
 	"net/http"
@@ -12,124 +11,56 @@ import (
 	"github.com/HatefBarari/microblog-blog/internal/domain"
 	"github.com/HatefBarari/microblog-blog/internal/presenter"
 	"github.com/HatefBarari/microblog-blog/internal/usecase"
+	"github.com/HatefBarari/microblog-shared/pkg/rbac"
 	"github.com/labstack/echo/v4"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
 )
 
-// Mock use cases
-type MockArticleUseCase struct {
-	mock.Mock
+// The handler takes concrete use cases, so these tests drive it through real
+// use cases backed by mock repositories.
+type testRepos struct {
+	articles   *MockArticleRepository
+	categories *MockCategoryRepository
+	comments   *MockCommentRepository
+	ratings    *MockRatingRepository
 }
 
-func (m *MockArticleUseCase) Create(ctx context.Context, userID string, req usecase.CreateArticleRequest) (*usecase.ArticleResponse, error) {
-	args := m.Called(ctx, userID, req)
-	if args.Get(0) == nil {
-		return nil, args.Error(1)
-	}
-	return args.Get(0).(*usecase.ArticleResponse), args.Error(1)
+func (r *testRepos) assertExpectations(t *testing.T) {
+	r.articles.AssertExpectations(t)
+	r.categories.AssertExpectations(t)
+	r.comments.AssertExpectations(t)
+	r.ratings.AssertExpectations(t)
 }
 
-func (m *MockArticleUseCase) GetBySlug(ctx context.Context, slug string) (*usecase.ArticleResponse, error) {
-	args := m.Called(ctx, slug)
-	if args.Get(0) == nil {
-		return nil, args.Error(1)
+func setupTestHandler() (*presenter.HTTPHandler, *testRepos) {
+	logger, _ := zap.NewDevelopment()
+	repos := &testRepos{
+		articles:   new(MockArticleRepository),
+		categories: new(MockCategoryRepository),
+		comments:   new(MockCommentRepository),
+		ratings:    new(MockRatingRepository),
 	}
-	return args.Get(0).(*usecase.ArticleResponse), args.Error(1)
-}
-
-func (m *MockArticleUseCase) List(ctx context.Context, filter domain.ListFilter) ([]*usecase.ArticleResponse, int, error) {
-	args := m.Called(ctx, filter)
-	return args.Get(0).([]*usecase.ArticleResponse), args.Int(1), args.Error(2)
-}
-
-func (m *MockArticleUseCase) Update(ctx context.Context, userID string, id string, req usecase.CreateArticleRequest) (*usecase.ArticleResponse, error) {
-	args := m.Called(ctx, userID, id, req)
-	if args.Get(0) == nil {
-		return nil, args.Error(1)
-	}
-	return args.Get(0).(*usecase.ArticleResponse), args.Error(1)
-}
-
-func (m *MockArticleUseCase) Delete(ctx context.Context, userID string, id string) error {
-	args := m.Called(ctx, userID, id)
-	return args.Error(0)
-}
-
-type MockCategoryUseCase struct {
-	mock.Mock
-}
-
-func (m *MockCategoryUseCase) ListTree(ctx context.Context) ([]*usecase.CategoryResponse, error) {
-	args := m.Called(ctx)
-	return args.Get(0).([]*usecase.CategoryResponse), args.Error(1)
-}
-
-func (m *MockCategoryUseCase) Create(ctx context.Context, name, parentID string) (*usecase.CategoryResponse, error) {
-	args := m.Called(ctx, name, parentID)
-	if args.Get(0) == nil {
-		return nil, args.Error(1)
-	}
-	return args.Get(0).(*usecase.CategoryResponse), args.Error(1)
-}
-
-type MockCommentUseCase struct {
-	mock.Mock
-}
-
-func (m *MockCommentUseCase) ListByArticle(ctx context.Context, articleID string, status domain.CommentStatus) ([]*usecase.CommentResponse, error) {
-	args := m.Called(ctx, articleID, status)
-	return args.Get(0).([]*usecase.CommentResponse), args.Error(1)
-}
-
-func (m *MockCommentUseCase) Create(ctx context.Context, userID, articleID string, req usecase.CreateCommentRequest) (*usecase.CommentResponse, error) {
-	args := m.Called(ctx, userID, articleID, req)
-	if args.Get(0) == nil {
-		return nil, args.Error(1)
-	}
-	return args.Get(0).(*usecase.CommentResponse), args.Error(1)
-}
-
-func (m *MockCommentUseCase) UpdateStatus(ctx context.Context, id string, status domain.CommentStatus) error {
-	args := m.Called(ctx, id, status)
-	return args.Error(0)
-}
 
-type MockRatingUseCase struct {
-	mock.Mock
-}
-
-func (m *MockRatingUseCase) RateArticle(ctx context.Context, userID, articleID string, stars int) error {
-	args := m.Called(ctx, userID, articleID, stars)
-	return args.Error(0)
-}
+	handler := presenter.NewHTTPHandler(
+		usecase.NewArticleUseCase(repos.articles, repos.ratings, nil, "debug", logger),
+		usecase.NewCategoryUseCase(repos.categories, "debug", logger),
+		usecase.NewCommentUseCase(repos.comments, "debug", logger),
+		usecase.NewRatingUseCase(repos.ratings, "debug", logger),
+		nil,
+	)
 
-func (m *MockRatingUseCase) Delete(ctx context.Context, userID, targetID, targetType string) error {
-	args := m.Called(ctx, userID, targetID, targetType)
-	return args.Error(0)
-}
-
-func setupTestHandler() (*presenter.HTTPHandler, *MockArticleUseCase, *MockCategoryUseCase, *MockCommentUseCase, *MockRatingUseCase) {
-	mockArticleUC := new(MockArticleUseCase)
-	mockCategoryUC := new(MockCategoryUseCase)
-	mockCommentUC := new(MockCommentUseCase)
-	mockRatingUC := new(MockRatingUseCase)
-	
-	handler := presenter.NewHTTPHandler(mockArticleUC, mockCategoryUC, mockCommentUC, mockRatingUC)
-	
-	return handler, mockArticleUC, mockCategoryUC, mockCommentUC, mockRatingUC
+	return handler, repos
 }
 
 func TestHTTPHandler_CreateArticle(t *testing.T) {
-	handler, mockArticleUC, _, _, _ := setupTestHandler()
-	
 	tests := []struct {
 		name           string
 		userID         string
 		requestBody    interface{}
-		mockSetup      func(*MockArticleUseCase)
+		mockSetup      func(*testRepos)
 		expectedStatus int
-		expectedError  string
 	}{
 		{
 			name:   "successful article creation",
@@ -142,15 +73,11 @@ func TestHTTPHandler_CreateArticle(t *testing.T) {
 				Tags:       []string{"test", "go"},
 				CoverURL:   "https://example.com/cover.jpg",
 			},
-			mockSetup: func(mockUC *MockArticleUseCase) {
-				mockUC.On("Create", mock.Anything, "user123", mock.AnythingOfType("usecase.CreateArticleRequest")).
-					Return(&usecase.ArticleResponse{
-						ID:        "article123",
-						AuthorID:  "user123",
-						Title:     "Test Article",
-						Slug:      "test-article",
-						Status:    "draft",
-					}, nil)
+			mockSetup: func(repos *testRepos) {
+				repos.articles.On("GetBySlug", mock.Anything, "test-article").Return(nil, nil)
+				repos.articles.On("Create", mock.Anything, mock.MatchedBy(func(article *domain.Article) bool {
+					return article.AuthorID == "user123" && article.Slug == "test-article"
+				})).Return(nil)
 			},
 			expectedStatus: http.StatusCreated,
 		},
@@ -160,9 +87,8 @@ func TestHTTPHandler_CreateArticle(t *testing.T) {
 			requestBody: map[string]interface{}{
 				"title": "", // invalid - too short
 			},
-			mockSetup:      func(mockUC *MockArticleUseCase) {},
+			mockSetup:      func(repos *testRepos) {},
 			expectedStatus: http.StatusBadRequest,
-			expectedError:  "validation error",
 		},
 		{
 			name:   "use case error",
@@ -173,20 +99,18 @@ func TestHTTPHandler_CreateArticle(t *testing.T) {
 				Summary:    "Test summary",
 				CategoryID: "cat123",
 			},
-			mockSetup: func(mockUC *MockArticleUseCase) {
-				mockUC.On("Create", mock.Anything, "user123", mock.AnythingOfType("usecase.CreateArticleRequest")).
-					Return(nil, errors.New("database error"))
+			mockSetup: func(repos *testRepos) {
+				repos.articles.On("GetBySlug", mock.Anything, "test-article").Return(nil, nil)
+				repos.articles.On("Create", mock.Anything, mock.Anything).Return(errors.New("database error"))
 			},
 			expectedStatus: http.StatusBadRequest,
-			expectedError:  "database error",
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			// Reset mocks
-			mockArticleUC.ExpectedCalls = nil
-			tt.mockSetup(mockArticleUC)
+			handler, repos := setupTestHandler()
+			tt.mockSetup(repos)
 
 			// Setup request
 			reqBody, _ := json.Marshal(tt.requestBody)
@@ -203,59 +127,50 @@ func TestHTTPHandler_CreateArticle(t *testing.T) {
 			err := handler.CreateArticle(c)
 
 			// Assertions
-			if tt.expectedError != "" {
-				assert.Error(t, err)
-			} else {
-				assert.NoError(t, err)
-				assert.Equal(t, tt.expectedStatus, rec.Code)
-			}
-
-			mockArticleUC.AssertExpectations(t)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+
+			repos.assertExpectations(t)
 		})
 	}
 }
 
 func TestHTTPHandler_GetArticleBySlug(t *testing.T) {
-	handler, mockArticleUC, _, _, _ := setupTestHandler()
-	
 	tests := []struct {
 		name           string
 		slug           string
-		mockSetup      func(*MockArticleUseCase)
+		mockSetup      func(*testRepos)
 		expectedStatus int
-		expectedError  string
 	}{
 		{
 			name: "successful get by slug",
 			slug: "test-article",
-			mockSetup: func(mockUC *MockArticleUseCase) {
-				mockUC.On("GetBySlug", mock.Anything, "test-article").
-					Return(&usecase.ArticleResponse{
+			mockSetup: func(repos *testRepos) {
+				repos.articles.On("GetBySlug", mock.Anything, "test-article").
+					Return(&domain.Article{
 						ID:        "article123",
 						Title:     "Test Article",
 						Slug:      "test-article",
 						ViewCount: 10,
 					}, nil)
+				repos.articles.On("UpdateViewCount", mock.Anything, "article123").Return(nil)
 			},
 			expectedStatus: http.StatusOK,
 		},
 		{
 			name: "article not found",
 			slug: "non-existent",
-			mockSetup: func(mockUC *MockArticleUseCase) {
-				mockUC.On("GetBySlug", mock.Anything, "non-existent").
-					Return(nil, errors.New("article not found"))
+			mockSetup: func(repos *testRepos) {
+				repos.articles.On("GetBySlug", mock.Anything, "non-existent").Return(nil, nil)
 			},
 			expectedStatus: http.StatusNotFound,
-			expectedError:  "article not found",
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			// Reset mocks
-			mockArticleUC.ExpectedCalls = nil
-			tt.mockSetup(mockArticleUC)
+			handler, repos := setupTestHandler()
+			tt.mockSetup(repos)
 
 			// Setup request
 			req := httptest.NewRequest(http.MethodGet, "/articles/"+tt.slug, nil)
@@ -271,43 +186,37 @@ func TestHTTPHandler_GetArticleBySlug(t *testing.T) {
 			err := handler.GetArticleBySlug(c)
 
 			// Assertions
-			if tt.expectedError != "" {
-				assert.Error(t, err)
-			} else {
-				assert.NoError(t, err)
-				assert.Equal(t, tt.expectedStatus, rec.Code)
-			}
-
-			mockArticleUC.AssertExpectations(t)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+
+			repos.assertExpectations(t)
 		})
 	}
 }
 
 func TestHTTPHandler_ListArticles(t *testing.T) {
-	handler, mockArticleUC, _, _, _ := setupTestHandler()
-	
 	tests := []struct {
 		name           string
-		mockSetup      func(*MockArticleUseCase)
+		mockSetup      func(*testRepos)
 		expectedStatus int
 	}{
 		{
 			name: "successful list articles",
-			mockSetup: func(mockUC *MockArticleUseCase) {
-				articles := []*usecase.ArticleResponse{
+			mockSetup: func(repos *testRepos) {
+				articles := []*domain.Article{
 					{ID: "article1", Title: "Article 1"},
 					{ID: "article2", Title: "Article 2"},
 				}
-				mockUC.On("List", mock.Anything, mock.AnythingOfType("domain.ListFilter")).
+				repos.articles.On("List", mock.Anything, mock.AnythingOfType("domain.ListFilter")).
 					Return(articles, 2, nil)
 			},
 			expectedStatus: http.StatusOK,
 		},
 		{
 			name: "use case error",
-			mockSetup: func(mockUC *MockArticleUseCase) {
-				mockUC.On("List", mock.Anything, mock.AnythingOfType("domain.ListFilter")).
-					Return([]*usecase.ArticleResponse{}, 0, errors.New("database error"))
+			mockSetup: func(repos *testRepos) {
+				repos.articles.On("List", mock.Anything, mock.AnythingOfType("domain.ListFilter")).
+					Return([]*domain.Article{}, 0, errors.New("database error"))
 			},
 			expectedStatus: http.StatusInternalServerError,
 		},
@@ -315,9 +224,8 @@ func TestHTTPHandler_ListArticles(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			// Reset mocks
-			mockArticleUC.ExpectedCalls = nil
-			tt.mockSetup(mockArticleUC)
+			handler, repos := setupTestHandler()
+			tt.mockSetup(repos)
 
 			// Setup request
 			req := httptest.NewRequest(http.MethodGet, "/articles", nil)
@@ -334,22 +242,19 @@ func TestHTTPHandler_ListArticles(t *testing.T) {
 			assert.NoError(t, err)
 			assert.Equal(t, tt.expectedStatus, rec.Code)
 
-			mockArticleUC.AssertExpectations(t)
+			repos.assertExpectations(t)
 		})
 	}
 }
 
 func TestHTTPHandler_UpdateArticle(t *testing.T) {
-	handler, mockArticleUC, _, _, _ := setupTestHandler()
-	
 	tests := []struct {
 		name           string
 		userID         string
 		articleID      string
 		requestBody    interface{}
-		mockSetup      func(*MockArticleUseCase)
+		mockSetup      func(*testRepos)
 		expectedStatus int
-		expectedError  string
 	}{
 		{
 			name:      "successful update",
@@ -361,13 +266,12 @@ func TestHTTPHandler_UpdateArticle(t *testing.T) {
 				Summary:    "Updated summary",
 				CategoryID: "cat456",
 			},
-			mockSetup: func(mockUC *MockArticleUseCase) {
-				mockUC.On("Update", mock.Anything, "user123", "article123", mock.AnythingOfType("usecase.CreateArticleRequest")).
-					Return(&usecase.ArticleResponse{
-						ID:     "article123",
-						Title:  "Updated Article",
-						Status: "draft",
-					}, nil)
+			mockSetup: func(repos *testRepos) {
+				repos.articles.On("GetByID", mock.Anything, "article123").
+					Return(&domain.Article{ID: "article123", AuthorID: "user123", Status: domain.StatusDraft}, nil)
+				repos.articles.On("Update", mock.Anything, mock.MatchedBy(func(article *domain.Article) bool {
+					return article.Title == "Updated Article" && article.CategoryID == "cat456"
+				})).Return(nil)
 			},
 			expectedStatus: http.StatusOK,
 		},
@@ -381,20 +285,17 @@ func TestHTTPHandler_UpdateArticle(t *testing.T) {
 				Summary:    "Updated summary",
 				CategoryID: "cat456",
 			},
-			mockSetup: func(mockUC *MockArticleUseCase) {
-				mockUC.On("Update", mock.Anything, "user123", "nonexistent", mock.AnythingOfType("usecase.CreateArticleRequest")).
-					Return(nil, errors.New("article not found"))
+			mockSetup: func(repos *testRepos) {
+				repos.articles.On("GetByID", mock.Anything, "nonexistent").Return(nil, nil)
 			},
 			expectedStatus: http.StatusNotFound,
-			expectedError:  "article not found",
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			// Reset mocks
-			mockArticleUC.ExpectedCalls = nil
-			tt.mockSetup(mockArticleUC)
+			handler, repos := setupTestHandler()
+			tt.mockSetup(repos)
 
 			// Setup request
 			reqBody, _ := json.Marshal(tt.requestBody)
@@ -413,35 +314,30 @@ func TestHTTPHandler_UpdateArticle(t *testing.T) {
 			err := handler.UpdateArticle(c)
 
 			// Assertions
-			if tt.expectedError != "" {
-				assert.Error(t, err)
-			} else {
-				assert.NoError(t, err)
-				assert.Equal(t, tt.expectedStatus, rec.Code)
-			}
-
-			mockArticleUC.AssertExpectations(t)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+
+			repos.assertExpectations(t)
 		})
 	}
 }
 
 func TestHTTPHandler_DeleteArticle(t *testing.T) {
-	handler, mockArticleUC, _, _, _ := setupTestHandler()
-	
 	tests := []struct {
 		name           string
 		userID         string
 		articleID      string
-		mockSetup      func(*MockArticleUseCase)
+		mockSetup      func(*testRepos)
 		expectedStatus int
-		expectedError  string
 	}{
 		{
 			name:      "successful delete",
 			userID:    "user123",
 			articleID: "article123",
-			mockSetup: func(mockUC *MockArticleUseCase) {
-				mockUC.On("Delete", mock.Anything, "user123", "article123").Return(nil)
+			mockSetup: func(repos *testRepos) {
+				repos.articles.On("GetByID", mock.Anything, "article123").
+					Return(&domain.Article{ID: "article123", AuthorID: "user123"}, nil)
+				repos.articles.On("Delete", mock.Anything, "article123").Return(nil)
 			},
 			expectedStatus: http.StatusNoContent,
 		},
@@ -449,20 +345,17 @@ func TestHTTPHandler_DeleteArticle(t *testing.T) {
 			name:      "article not found",
 			userID:    "user123",
 			articleID: "nonexistent",
-			mockSetup: func(mockUC *MockArticleUseCase) {
-				mockUC.On("Delete", mock.Anything, "user123", "nonexistent").
-					Return(errors.New("article not found"))
+			mockSetup: func(repos *testRepos) {
+				repos.articles.On("GetByID", mock.Anything, "nonexistent").Return(nil, nil)
 			},
 			expectedStatus: http.StatusNotFound,
-			expectedError:  "article not found",
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			// Reset mocks
-			mockArticleUC.ExpectedCalls = nil
-			tt.mockSetup(mockArticleUC)
+			handler, repos := setupTestHandler()
+			tt.mockSetup(repos)
 
 			// Setup request
 			req := httptest.NewRequest(http.MethodDelete, "/articles/"+tt.articleID, nil)
@@ -479,29 +372,22 @@ func TestHTTPHandler_DeleteArticle(t *testing.T) {
 			err := handler.DeleteArticle(c)
 
 			// Assertions
-			if tt.expectedError != "" {
-				assert.Error(t, err)
-			} else {
-				assert.NoError(t, err)
-				assert.Equal(t, tt.expectedStatus, rec.Code)
-			}
-
-			mockArticleUC.AssertExpectations(t)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+
+			repos.assertExpectations(t)
 		})
 	}
 }
 
 func TestHTTPHandler_CreateComment(t *testing.T) {
-	handler, _, _, mockCommentUC, _ := setupTestHandler()
-	
 	tests := []struct {
 		name           string
 		userID         string
 		articleID      string
 		requestBody    interface{}
-		mockSetup      func(*MockCommentUseCase)
+		mockSetup      func(*testRepos)
 		expectedStatus int
-		expectedError  string
 	}{
 		{
 			name:      "successful comment creation",
@@ -510,15 +396,12 @@ func TestHTTPHandler_CreateComment(t *testing.T) {
 			requestBody: usecase.CreateCommentRequest{
 				Content: "This is a test comment",
 			},
-			mockSetup: func(mockUC *MockCommentUseCase) {
-				mockUC.On("Create", mock.Anything, "user123", "article123", mock.AnythingOfType("usecase.CreateCommentRequest")).
-					Return(&usecase.CommentResponse{
-						ID:        "comment123",
-						ArticleID: "article123",
-						AuthorID:  "user123",
-						Content:   "This is a test comment",
-						Status:    "pending",
-					}, nil)
+			mockSetup: func(repos *testRepos) {
+				repos.comments.On("Create", mock.Anything, mock.MatchedBy(func(comment *domain.Comment) bool {
+					return comment.ArticleID == "article123" &&
+						comment.AuthorID == "user123" &&
+						comment.Status == domain.CommentPending
+				})).Return(nil)
 			},
 			expectedStatus: http.StatusCreated,
 		},
@@ -529,17 +412,15 @@ func TestHTTPHandler_CreateComment(t *testing.T) {
 			requestBody: map[string]interface{}{
 				"content": "", // invalid - too short
 			},
-			mockSetup:      func(mockUC *MockCommentUseCase) {},
+			mockSetup:      func(repos *testRepos) {},
 			expectedStatus: http.StatusBadRequest,
-			expectedError:  "validation error",
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			// Reset mocks
-			mockCommentUC.ExpectedCalls = nil
-			tt.mockSetup(mockCommentUC)
+			handler, repos := setupTestHandler()
+			tt.mockSetup(repos)
 
 			// Setup request
 			reqBody, _ := json.Marshal(tt.requestBody)
@@ -558,29 +439,22 @@ func TestHTTPHandler_CreateComment(t *testing.T) {
 			err := handler.CreateComment(c)
 
 			// Assertions
-			if tt.expectedError != "" {
-				assert.Error(t, err)
-			} else {
-				assert.NoError(t, err)
-				assert.Equal(t, tt.expectedStatus, rec.Code)
-			}
-
-			mockCommentUC.AssertExpectations(t)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+
+			repos.assertExpectations(t)
 		})
 	}
 }
 
 func TestHTTPHandler_RateArticle(t *testing.T) {
-	handler, _, _, _, mockRatingUC := setupTestHandler()
-	
 	tests := []struct {
 		name           string
 		userID         string
 		articleID      string
 		requestBody    interface{}
-		mockSetup      func(*MockRatingUseCase)
+		mockSetup      func(*testRepos)
 		expectedStatus int
-		expectedError  string
 	}{
 		{
 			name:      "successful rating",
@@ -589,8 +463,11 @@ func TestHTTPHandler_RateArticle(t *testing.T) {
 			requestBody: usecase.RatingRequest{
 				Stars: 5,
 			},
-			mockSetup: func(mockUC *MockRatingUseCase) {
-				mockUC.On("RateArticle", mock.Anything, "user123", "article123", 5).Return(nil)
+			mockSetup: func(repos *testRepos) {
+				repos.ratings.On("GetByUserAndTarget", mock.Anything, "user123", "article123", "article").Return(nil, nil)
+				repos.ratings.On("Save", mock.Anything, mock.MatchedBy(func(rating *domain.Rating) bool {
+					return rating.Stars == 5
+				})).Return(nil)
 			},
 			expectedStatus: http.StatusCreated,
 		},
@@ -601,17 +478,15 @@ func TestHTTPHandler_RateArticle(t *testing.T) {
 			requestBody: map[string]interface{}{
 				"stars": 6, // invalid - too high
 			},
-			mockSetup:      func(mockUC *MockRatingUseCase) {},
+			mockSetup:      func(repos *testRepos) {},
 			expectedStatus: http.StatusBadRequest,
-			expectedError:  "validation error",
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			// Reset mocks
-			mockRatingUC.ExpectedCalls = nil
-			tt.mockSetup(mockRatingUC)
+			handler, repos := setupTestHandler()
+			tt.mockSetup(repos)
 
 			// Setup request
 			reqBody, _ := json.Marshal(tt.requestBody)
@@ -630,14 +505,102 @@ func TestHTTPHandler_RateArticle(t *testing.T) {
 			err := handler.RateArticle(c)
 
 			// Assertions
-			if tt.expectedError != "" {
-				assert.Error(t, err)
-			} else {
-				assert.NoError(t, err)
-				assert.Equal(t, tt.expectedStatus, rec.Code)
-			}
-
-			mockRatingUC.AssertExpectations(t)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+
+			repos.assertExpectations(t)
+		})
+	}
+}
+
+func TestHTTPHandler_UpdateArticleStatus(t *testing.T) {
+	tests := []struct {
+		name           string
+		userID         string
+		permissions    []string
+		status         string
+		mockSetup      func(*testRepos)
+		expectedStatus int
+	}{
+		{
+			name:        "author submits for review",
+			userID:      "user123",
+			permissions: []string{rbac.ArticleWrite},
+			status:      "pending",
+			mockSetup: func(repos *testRepos) {
+				repos.articles.On("Update", mock.Anything, mock.MatchedBy(func(article *domain.Article) bool {
+					return article.Status == domain.StatusPending && article.PublishedAt == nil
+				})).Return(nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "author cannot publish",
+			userID:         "user123",
+			permissions:    []string{rbac.ArticleWrite},
+			status:         "approved",
+			mockSetup:      func(repos *testRepos) {},
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:        "publisher approves",
+			userID:      "manager1",
+			permissions: []string{rbac.ArticleWrite, rbac.ArticlePublish},
+			status:      "approved",
+			mockSetup: func(repos *testRepos) {
+				repos.articles.On("Update", mock.Anything, mock.MatchedBy(func(article *domain.Article) bool {
+					return article.Status == domain.StatusApproved && article.PublishedAt != nil
+				})).Return(nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "other writers cannot archive",
+			userID:         "user456",
+			permissions:    []string{rbac.ArticleWrite},
+			status:         "archived",
+			mockSetup:      func(repos *testRepos) {},
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "unknown status",
+			userID:         "user123",
+			permissions:    []string{rbac.ArticleWrite},
+			status:         "published",
+			mockSetup:      func(repos *testRepos) {},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, repos := setupTestHandler()
+			repos.articles.On("GetByID", mock.Anything, "article123").
+				Return(&domain.Article{ID: "article123", AuthorID: "user123", Status: domain.StatusDraft}, nil).Maybe()
+			tt.mockSetup(repos)
+
+			// Setup request
+			reqBody, _ := json.Marshal(map[string]string{"status": tt.status})
+			req := httptest.NewRequest(http.MethodPut, "/articles/article123/status", bytes.NewBuffer(reqBody))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+
+			// Setup echo context
+			e := echo.New()
+			c := e.NewContext(req, rec)
+			c.Set("userID", tt.userID)
+			c.Set("permissions", tt.permissions)
+			c.SetParamNames("id")
+			c.SetParamValues("article123")
+
+			// Execute
+			err := handler.UpdateArticleStatus(c)
+
+			// Assertions
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+
+			repos.assertExpectations(t)
 		})
 	}
 }
@@ -27,13 +27,13 @@ func TestBlogServiceIntegration(t *testing.T) {
 	mockRatingRepo := new(MockRatingRepository)
 	
 	// Setup use cases
-	articleUC := usecase.NewArticleUseCase(mockArticleRepo, mockRatingRepo, "access_secret", "refresh_secret", "debug", logger)
-	categoryUC := usecase.NewCategoryUseCase(mockCategoryRepo, logger)
-	commentUC := usecase.NewCommentUseCase(mockCommentRepo, logger)
-	ratingUC := usecase.NewRatingUseCase(mockRatingRepo, logger)
+	articleUC := usecase.NewArticleUseCase(mockArticleRepo, mockRatingRepo, nil, "debug", logger)
+	categoryUC := usecase.NewCategoryUseCase(mockCategoryRepo, "debug", logger)
+	commentUC := usecase.NewCommentUseCase(mockCommentRepo, "debug", logger)
+	ratingUC := usecase.NewRatingUseCase(mockRatingRepo, "debug", logger)
 	
 	// Setup handler
-	handler := presenter.NewHTTPHandler(articleUC, categoryUC, commentUC, ratingUC)
+	handler := presenter.NewHTTPHandler(articleUC, categoryUC, commentUC, ratingUC, nil)
 	
 	// Setup echo
 	e := echo.New()
@@ -50,7 +50,7 @@ func TestBlogServiceIntegration(t *testing.T) {
 		}
 		
 		// Mock article creation
-		mockArticleRepo.On("GetBySlug", mock.Anything, "integration-test-article").Return(nil, nil)
+		mockArticleRepo.On("GetBySlug", mock.Anything, "integration-test-article").Return(nil, nil).Once()
 		mockArticleRepo.On("Create", mock.Anything, mock.MatchedBy(func(article *domain.Article) bool {
 			return article.Title == "Integration Test Article" &&
 				article.Slug == "integration-test-article" &&
@@ -128,16 +128,12 @@ func TestBlogServiceIntegration(t *testing.T) {
 		
 		mockRatingRepo.On("GetByUserAndTarget", mock.Anything, "user123", "article123", "article").
 			Return(nil, nil)
-		mockRatingRepo.On("Create", mock.Anything, mock.MatchedBy(func(rating *domain.Rating) bool {
+		mockRatingRepo.On("Save", mock.Anything, mock.MatchedBy(func(rating *domain.Rating) bool {
 			return rating.UserID == "user123" &&
 				rating.TargetID == "article123" &&
 				rating.Type == "article" &&
 				rating.Stars == 5
 		})).Return(nil)
-		mockRatingRepo.On("GetAverageByTarget", mock.Anything, "article123", "article").
-			Return(5.0, nil)
-		mockRatingRepo.On("UpdateTargetAverage", mock.Anything, "article123", "article", 5.0).
-			Return(nil)
 		
 		reqBody, _ = json.Marshal(ratingReq)
 		req = httptest.NewRequest(http.MethodPost, "/articles/article123/rate", bytes.NewBuffer(reqBody))
@@ -166,7 +162,7 @@ func TestBlogServiceIntegration(t *testing.T) {
 			ID:       "article123",
 			AuthorID: "user123",
 			Title:    "Integration Test Article",
-		}, nil)
+		}, nil).Once()
 		mockArticleRepo.On("Update", mock.Anything, mock.MatchedBy(func(article *domain.Article) bool {
 			return article.ID == "article123" &&
 				article.Title == "Updated Integration Test Article"
@@ -281,7 +277,7 @@ func TestBlogServiceIntegration(t *testing.T) {
 		assert.Equal(t, http.StatusCreated, rec.Code)
 		
 		// 3. List category tree
-		mockCategoryRepo.On("List", mock.Anything).Return([]*domain.Category{
+		mockCategoryRepo.On("ListTree", mock.Anything).Return([]*domain.Category{
 			{ID: "cat1", Name: "Technology", Slug: "technology", ParentID: ""},
 			{ID: "cat2", Name: "Programming", Slug: "programming", ParentID: "cat1"},
 			{ID: "cat3", Name: "Science", Slug: "science", ParentID: ""},
@@ -331,17 +327,7 @@ func TestBlogServiceIntegration(t *testing.T) {
 		assert.Equal(t, http.StatusOK, rec.Code)
 		
 		// 2. Update comment status
-		mockCommentRepo.On("GetByID", mock.Anything, "comment1").Return(&domain.Comment{
-			ID:        "comment1",
-			ArticleID: "article123",
-			AuthorID:  "user1",
-			Content:   "Great article!",
-			Status:    domain.CommentPending,
-		}, nil)
-		mockCommentRepo.On("Update", mock.Anything, mock.MatchedBy(func(comment *domain.Comment) bool {
-			return comment.ID == "comment1" &&
-				comment.Status == domain.CommentApproved
-		})).Return(nil)
+		mockCommentRepo.On("UpdateStatus", mock.Anything, "comment1", domain.CommentApproved).Return(nil)
 		
 		reqBody, _ := json.Marshal(map[string]interface{}{
 			"status": "approved",
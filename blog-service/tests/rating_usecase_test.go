@@ -16,7 +16,7 @@ type MockRatingRepository struct {
 	mock.Mock
 }
 
-func (m *MockRatingRepository) Create(ctx context.Context, rating *domain.Rating) error {
+func (m *MockRatingRepository) Save(ctx context.Context, rating *domain.Rating) error {
 	args := m.Called(ctx, rating)
 	return args.Error(0)
 }
@@ -29,23 +29,23 @@ func (m *MockRatingRepository) GetByUserAndTarget(ctx context.Context, userID, t
 	return args.Get(0).(*domain.Rating), args.Error(1)
 }
 
-func (m *MockRatingRepository) Update(ctx context.Context, rating *domain.Rating) error {
-	args := m.Called(ctx, rating)
-	return args.Error(0)
+func (m *MockRatingRepository) GetAverage(ctx context.Context, targetID, targetType string) (float64, error) {
+	args := m.Called(ctx, targetID, targetType)
+	return args.Get(0).(float64), args.Error(1)
 }
 
-func (m *MockRatingRepository) Delete(ctx context.Context, id string) error {
-	args := m.Called(ctx, id)
+func (m *MockRatingRepository) Delete(ctx context.Context, userID, targetID, targetType string) error {
+	args := m.Called(ctx, userID, targetID, targetType)
 	return args.Error(0)
 }
 
-func (m *MockRatingRepository) GetAverageByTarget(ctx context.Context, targetID, targetType string) (float64, error) {
-	args := m.Called(ctx, targetID, targetType)
-	return args.Get(0).(float64), args.Error(1)
+func (m *MockRatingRepository) ListByUser(ctx context.Context, userID string) ([]*domain.Rating, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]*domain.Rating), args.Error(1)
 }
 
-func (m *MockRatingRepository) UpdateTargetAverage(ctx context.Context, targetID, targetType string, avg float64) error {
-	args := m.Called(ctx, targetID, targetType, avg)
+func (m *MockRatingRepository) DeleteByUser(ctx context.Context, userID string) error {
+	args := m.Called(ctx, userID)
 	return args.Error(0)
 }
 
@@ -68,16 +68,12 @@ func TestRatingUseCase_RateArticle(t *testing.T) {
 			mockSetup: func(mockRepo *MockRatingRepository) {
 				mockRepo.On("GetByUserAndTarget", mock.Anything, "user123", "article123", "article").
 					Return(nil, nil) // no existing rating
-				mockRepo.On("Create", mock.Anything, mock.MatchedBy(func(rating *domain.Rating) bool {
+				mockRepo.On("Save", mock.Anything, mock.MatchedBy(func(rating *domain.Rating) bool {
 					return rating.UserID == "user123" &&
 						rating.TargetID == "article123" &&
 						rating.Type == "article" &&
 						rating.Stars == 5
 				})).Return(nil)
-				mockRepo.On("GetAverageByTarget", mock.Anything, "article123", "article").
-					Return(5.0, nil)
-				mockRepo.On("UpdateTargetAverage", mock.Anything, "article123", "article", 5.0).
-					Return(nil)
 			},
 			expectedError: "",
 		},
@@ -96,14 +92,10 @@ func TestRatingUseCase_RateArticle(t *testing.T) {
 				}
 				mockRepo.On("GetByUserAndTarget", mock.Anything, "user123", "article123", "article").
 					Return(existingRating, nil)
-				mockRepo.On("Update", mock.Anything, mock.MatchedBy(func(rating *domain.Rating) bool {
+				mockRepo.On("Save", mock.Anything, mock.MatchedBy(func(rating *domain.Rating) bool {
 					return rating.ID == "rating123" &&
 						rating.Stars == 4
 				})).Return(nil)
-				mockRepo.On("GetAverageByTarget", mock.Anything, "article123", "article").
-					Return(4.0, nil)
-				mockRepo.On("UpdateTargetAverage", mock.Anything, "article123", "article", 4.0).
-					Return(nil)
 			},
 			expectedError: "",
 		},
@@ -128,14 +120,14 @@ func TestRatingUseCase_RateArticle(t *testing.T) {
 			expectedError: "stars must be between 1 and 5",
 		},
 		{
-			name:      "repository error on create",
+			name:      "repository error on save",
 			userID:    "user123",
 			articleID: "article123",
 			stars:     5,
 			mockSetup: func(mockRepo *MockRatingRepository) {
 				mockRepo.On("GetByUserAndTarget", mock.Anything, "user123", "article123", "article").
 					Return(nil, nil)
-				mockRepo.On("Create", mock.Anything, mock.Anything).Return(errors.New("database error"))
+				mockRepo.On("Save", mock.Anything, mock.Anything).Return(errors.New("database error"))
 			},
 			expectedError: "database error",
 		},
@@ -146,7 +138,7 @@ func TestRatingUseCase_RateArticle(t *testing.T) {
 			mockRepo := new(MockRatingRepository)
 			tt.mockSetup(mockRepo)
 
-			uc := usecase.NewRatingUseCase(mockRepo, logger)
+			uc := usecase.NewRatingUseCase(mockRepo, "debug", logger)
 
 			err := uc.RateArticle(context.Background(), tt.userID, tt.articleID, tt.stars)
 
@@ -179,61 +171,18 @@ func TestRatingUseCase_Delete(t *testing.T) {
 			targetID:   "article123",
 			targetType: "article",
 			mockSetup: func(mockRepo *MockRatingRepository) {
-				existingRating := &domain.Rating{
-					ID:       "rating123",
-					UserID:   "user123",
-					TargetID: "article123",
-					Type:     "article",
-					Stars:    5,
-				}
-				mockRepo.On("GetByUserAndTarget", mock.Anything, "user123", "article123", "article").
-					Return(existingRating, nil)
-				mockRepo.On("Delete", mock.Anything, "rating123").Return(nil)
-				mockRepo.On("GetAverageByTarget", mock.Anything, "article123", "article").
-					Return(0.0, nil)
-				mockRepo.On("UpdateTargetAverage", mock.Anything, "article123", "article", 0.0).
-					Return(nil)
+				mockRepo.On("Delete", mock.Anything, "user123", "article123", "article").Return(nil)
 			},
 			expectedError: "",
 		},
-		{
-			name:       "rating not found",
-			userID:     "user123",
-			targetID:   "article123",
-			targetType: "article",
-			mockSetup: func(mockRepo *MockRatingRepository) {
-				mockRepo.On("GetByUserAndTarget", mock.Anything, "user123", "article123", "article").
-					Return(nil, nil)
-			},
-			expectedError: "rating not found",
-		},
-		{
-			name:       "repository error on get",
-			userID:     "user123",
-			targetID:   "article123",
-			targetType: "article",
-			mockSetup: func(mockRepo *MockRatingRepository) {
-				mockRepo.On("GetByUserAndTarget", mock.Anything, "user123", "article123", "article").
-					Return(nil, errors.New("database error"))
-			},
-			expectedError: "database error",
-		},
 		{
 			name:       "repository error on delete",
 			userID:     "user123",
 			targetID:   "article123",
 			targetType: "article",
 			mockSetup: func(mockRepo *MockRatingRepository) {
-				existingRating := &domain.Rating{
-					ID:       "rating123",
-					UserID:   "user123",
-					TargetID: "article123",
-					Type:     "article",
-					Stars:    5,
-				}
-				mockRepo.On("GetByUserAndTarget", mock.Anything, "user123", "article123", "article").
-					Return(existingRating, nil)
-				mockRepo.On("Delete", mock.Anything, "rating123").Return(errors.New("database error"))
+				mockRepo.On("Delete", mock.Anything, "user123", "article123", "article").
+					Return(errors.New("database error"))
 			},
 			expectedError: "database error",
 		},
@@ -244,7 +193,7 @@ func TestRatingUseCase_Delete(t *testing.T) {
 			mockRepo := new(MockRatingRepository)
 			tt.mockSetup(mockRepo)
 
-			uc := usecase.NewRatingUseCase(mockRepo, logger)
+			uc := usecase.NewRatingUseCase(mockRepo, "debug", logger)
 
 			err := uc.Delete(context.Background(), tt.userID, tt.targetID, tt.targetType)
 
@@ -0,0 +1,218 @@
+package tests
+
+import (
+	"context"
+	"testing"
+
+	"github.com/HatefBarari/microblog-blog/internal/domain"
+	"github.com/HatefBarari/microblog-blog/internal/usecase"
+	"github.com/HatefBarari/microblog-shared/pkg/userdata"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// userDataStore backs the repository mocks so the tests can check what is
+// left after a deletion rather than which calls were made.
+type userDataStore struct {
+	articles []*domain.Article
+	comments []*domain.Comment
+	ratings  []*domain.Rating
+}
+
+func newUserDataStore() *userDataStore {
+	return &userDataStore{
+		articles: []*domain.Article{
+			{ID: "a1", AuthorID: "user123", RatingAvg: 4},
+			{ID: "a2", AuthorID: "user123"},
+			{ID: "a3", AuthorID: "other", RatingAvg: 3},
+		},
+		comments: []*domain.Comment{
+			{ID: "c1", ArticleID: "a1", AuthorID: "other"},
+			{ID: "c2", ArticleID: "a3", AuthorID: "user123"},
+			{ID: "c3", ArticleID: "a3", AuthorID: "other"},
+		},
+		ratings: []*domain.Rating{
+			{ID: "r1", UserID: "user123", TargetID: "a3", Type: "article", Stars: 1},
+			{ID: "r2", UserID: "other", TargetID: "a1", Type: "article", Stars: 4},
+			{ID: "r3", UserID: "other", TargetID: "a3", Type: "article", Stars: 5},
+		},
+	}
+}
+
+func (s *userDataStore) useCase() *usecase.UserDataUseCase {
+	articles := new(MockArticleRepository)
+	articles.On("ListByAuthor", mock.Anything, "user123").Return([]*domain.Article{s.articles[0], s.articles[1]}, nil)
+	articles.On("ReassignAuthor", mock.Anything, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		for _, a := range s.articles {
+			if a.AuthorID == args.String(1) {
+				a.AuthorID = args.String(2)
+			}
+		}
+	}).Return(nil)
+	articles.On("DeleteByAuthor", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		kept := s.articles[:0]
+		for _, a := range s.articles {
+			if a.AuthorID != args.String(1) {
+				kept = append(kept, a)
+			}
+		}
+		s.articles = kept
+	}).Return(nil)
+	articles.On("UpdateRating", mock.Anything, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		for _, a := range s.articles {
+			if a.ID == args.String(1) {
+				a.RatingAvg = args.Get(2).(float64)
+			}
+		}
+	}).Return(nil)
+
+	comments := new(MockCommentRepository)
+	comments.On("ReassignAuthor", mock.Anything, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		for _, c := range s.comments {
+			if c.AuthorID == args.String(1) {
+				c.AuthorID = args.String(2)
+			}
+		}
+	}).Return(nil)
+	comments.On("DeleteByAuthor", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		s.removeComments(func(c *domain.Comment) bool { return c.AuthorID == args.String(1) })
+	}).Return(nil)
+	comments.On("DeleteByArticles", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		ids := args.Get(1).([]string)
+		s.removeComments(func(c *domain.Comment) bool {
+			for _, id := range ids {
+				if c.ArticleID == id {
+					return true
+				}
+			}
+			return false
+		})
+	}).Return(nil)
+
+	ratings := new(MockRatingRepository)
+	// the mocks assert concrete return types, so answers from the store are
+	// filled in by Run, which testify calls before reading them
+	byUser := ratings.On("ListByUser", mock.Anything, mock.Anything)
+	byUser.Run(func(args mock.Arguments) {
+		var out []*domain.Rating
+		for _, r := range s.ratings {
+			if r.UserID == args.String(1) {
+				out = append(out, r)
+			}
+		}
+		byUser.ReturnArguments = mock.Arguments{out, nil}
+	})
+	average := ratings.On("GetAverage", mock.Anything, mock.Anything, mock.Anything)
+	average.Run(func(args mock.Arguments) {
+		sum, n := 0, 0
+		for _, r := range s.ratings {
+			if r.TargetID == args.String(1) && r.Type == args.String(2) {
+				sum += r.Stars
+				n++
+			}
+		}
+		avg := 0.0
+		if n > 0 {
+			avg = float64(sum) / float64(n)
+		}
+		average.ReturnArguments = mock.Arguments{avg, nil}
+	})
+	ratings.On("DeleteByUser", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		kept := s.ratings[:0]
+		for _, r := range s.ratings {
+			if r.UserID != args.String(1) {
+				kept = append(kept, r)
+			}
+		}
+		s.ratings = kept
+	}).Return(nil)
+
+	logger, _ := zap.NewDevelopment()
+	return usecase.NewUserDataUseCase(articles, comments, ratings, logger)
+}
+
+func (s *userDataStore) removeComments(match func(*domain.Comment) bool) {
+	kept := s.comments[:0]
+	for _, c := range s.comments {
+		if !match(c) {
+			kept = append(kept, c)
+		}
+	}
+	s.comments = kept
+}
+
+func (s *userDataStore) authors() map[string]string {
+	out := map[string]string{}
+	for _, a := range s.articles {
+		out[a.ID] = a.AuthorID
+	}
+	for _, c := range s.comments {
+		out[c.ID] = c.AuthorID
+	}
+	for _, r := range s.ratings {
+		out[r.ID] = r.UserID
+	}
+	return out
+}
+
+func (s *userDataStore) ratingAvgs() map[string]float64 {
+	out := map[string]float64{}
+	for _, a := range s.articles {
+		out[a.ID] = a.RatingAvg
+	}
+	return out
+}
+
+func TestUserDataUseCase_DeleteAnonymize(t *testing.T) {
+	store := newUserDataStore()
+	uc := store.useCase()
+
+	require.NoError(t, uc.Delete(context.Background(), "user123", userdata.ModeAnonymize))
+
+	// articles and comments stay under the placeholder author; ratings go
+	assert.Equal(t, map[string]string{
+		"a1": userdata.DeletedUserID,
+		"a2": userdata.DeletedUserID,
+		"a3": "other",
+		"c1": "other",
+		"c2": userdata.DeletedUserID,
+		"c3": "other",
+		"r2": "other",
+		"r3": "other",
+	}, store.authors())
+	// a3 no longer counts the user's rating
+	assert.Equal(t, map[string]float64{"a1": 4, "a2": 0, "a3": 5}, store.ratingAvgs())
+}
+
+func TestUserDataUseCase_DeleteRemove(t *testing.T) {
+	store := newUserDataStore()
+	uc := store.useCase()
+
+	require.NoError(t, uc.Delete(context.Background(), "user123", userdata.ModeRemove))
+
+	// c1 belongs to someone else but goes with the article it was left on
+	assert.Equal(t, map[string]string{
+		"a3": "other",
+		"c3": "other",
+		"r2": "other",
+		"r3": "other",
+	}, store.authors())
+	assert.Equal(t, map[string]float64{"a3": 5}, store.ratingAvgs())
+}
+
+func TestUserDataUseCase_DeleteRejects(t *testing.T) {
+	store := newUserDataStore()
+	uc := store.useCase()
+	before := store.authors()
+
+	assert.Error(t, uc.Delete(context.Background(), userdata.DeletedUserID, userdata.ModeRemove))
+	assert.Equal(t, before, store.authors())
+	assert.Equal(t, map[string]float64{"a1": 4, "a2": 0, "a3": 3}, store.ratingAvgs())
+
+	assert.Error(t, uc.Delete(context.Background(), "user123", userdata.Mode("archive")))
+	for id, author := range store.authors() {
+		assert.NotEqual(t, userdata.DeletedUserID, author, id)
+	}
+}
@@ -12,8 +12,10 @@ import (
 	"github.com/HatefBarari/microblog-media/internal/presenter"
 	"github.com/HatefBarari/microblog-media/internal/repository"
 	"github.com/HatefBarari/microblog-media/internal/usecase"
+	"github.com/HatefBarari/microblog-shared/pkg/auth"
 	"github.com/HatefBarari/microblog-shared/pkg/mongo"
-	"go.mongodb.org/mongo-driver/mongo/options"
+	"github.com/HatefBarari/microblog-shared/pkg/rbac"
+	"go.uber.org/zap"
 )
 
 func main() {
@@ -35,13 +37,12 @@ func main() {
 		zap.String("host", config.Server.Host))
 
 	// Connect to MongoDB
-	mongoClient, err := mongo.NewClient(config.Database.URI)
-	if err != nil {
+	if err := mongo.Connect(config.Database.URI, config.Database.Database, logger); err != nil {
 		logger.Fatal("Failed to connect to MongoDB", zap.Error(err))
 	}
-	defer mongoClient.Disconnect(context.Background())
+	defer mongo.Client().Disconnect(context.Background())
 
-	db := mongoClient.Database(config.Database.Database)
+	db := mongo.DB()
 
 	// Initialize repositories
 	mediaRepo := repository.NewMongoMediaRepository(db)
@@ -67,12 +68,24 @@ func main() {
 		logger,
 	)
 
+	userDataUC := usecase.NewUserDataUseCase(mediaRepo, storage, logger)
+
 	// Initialize handlers
-	handler := presenter.NewHTTPHandler(mediaUC)
+	handler := presenter.NewHTTPHandler(mediaUC, userDataUC)
 
 	// Initialize server
 	server := infrastructure.NewEchoServer(config)
-	server.SetupRoutes(handler)
+	// revocations are written by auth-service; cache briefly to spare mongo
+	revocations := auth.NewCachedRevocationChecker(
+		auth.NewMongoRevocationStore(mongo.Client().Database(config.Auth.RevocationDB).Collection("revocations")),
+		time.Duration(config.Auth.RevocationCacheSec)*time.Second,
+	)
+	apiKeys := auth.NewMongoAPIKeyStore(mongo.Client().Database(config.Auth.RevocationDB).Collection("api_keys"))
+	policy, err := rbac.LoadPolicy(config.Auth.RBACPolicy)
+	if err != nil {
+		logger.Fatal("Failed to load rbac policy", zap.Error(err))
+	}
+	server.SetupRoutes(handler, revocations, apiKeys, policy)
 
 	// Start server in goroutine
 	go func() {
@@ -94,7 +107,7 @@ func main() {
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
-	if err := server.Shutdown(); err != nil {
+	if err := server.Shutdown(ctx); err != nil {
 		logger.Error("Failed to shutdown server", zap.Error(err))
 	}
 
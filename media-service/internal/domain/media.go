@@ -1,6 +1,9 @@
 package domain
 
-import "time"
+import (
+	"context"
+	"time"
+)
 
 type MediaType string
 
@@ -44,4 +47,7 @@ type MediaRepository interface {
 	Update(ctx context.Context, media *Media) error
 	Delete(ctx context.Context, id string) error
 	DeleteByUploader(ctx context.Context, uploaderID, id string) error
+	// ReassignUploader moves every upload of one user to another and drops
+	// the upload metadata, which identifies the original uploader.
+	ReassignUploader(ctx context.Context, from, to string) error
 }
@@ -14,6 +14,8 @@ type Config struct {
 	Storage  StorageConfig  `yaml:"storage"`
 	Media    MediaConfig    `yaml:"media"`
 	Log      LogConfig      `yaml:"log"`
+	Auth     AuthConfig     `yaml:"auth"`
+	Internal InternalConfig `yaml:"internal"`
 }
 
 type ServerConfig struct {
@@ -38,6 +40,22 @@ type MediaConfig struct {
 	ThumbnailSize int      `yaml:"thumbnail_size"`
 }
 
+type AuthConfig struct {
+	// auth-service's published signing keys
+	JWKSURL        string `yaml:"jwks_url"`
+	JWKSRefreshMin int    `yaml:"jwks_refresh_min"`
+	// database of auth-service holding the "revocations" and "api_keys" collections
+	RevocationDB       string `yaml:"revocation_db"`
+	RevocationCacheSec int    `yaml:"revocation_cache_sec"`
+	// role -> permission policy file shared with auth and blog
+	RBACPolicy string `yaml:"rbac_policy"`
+}
+
+type InternalConfig struct {
+	// shared with auth-service for the /internal user-data routes
+	Secret string `yaml:"secret"`
+}
+
 type LogConfig struct {
 	Level string `yaml:"level"`
 	File  string `yaml:"file"`
@@ -87,6 +105,15 @@ func overrideFromEnv(config *Config) {
 	if allowedTypes := os.Getenv("MEDIA_ALLOWED_TYPES"); allowedTypes != "" {
 		config.Media.AllowedTypes = strings.Split(allowedTypes, ",")
 	}
+	if jwksURL := os.Getenv("AUTH_JWKS_URL"); jwksURL != "" {
+		config.Auth.JWKSURL = jwksURL
+	}
+	if policy := os.Getenv("AUTH_RBAC_POLICY"); policy != "" {
+		config.Auth.RBACPolicy = policy
+	}
+	if secret := os.Getenv("INTERNAL_SECRET"); secret != "" {
+		config.Internal.Secret = secret
+	}
 	if level := os.Getenv("LOG_LEVEL"); level != "" {
 		config.Log.Level = level
 	}
@@ -1,11 +1,17 @@
 package infrastructure
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/HatefBarari/microblog-media/internal/presenter"
-	"github.com/HatefBarari/microblog-media/internal/usecase"
 	"github.com/HatefBarari/microblog-shared/pkg/auth"
+	"github.com/HatefBarari/microblog-shared/pkg/rbac"
+	"github.com/HatefBarari/microblog-shared/pkg/userdata"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
 )
@@ -19,7 +25,12 @@ func NewEchoServer(config *Config) *EchoServer {
 	e := echo.New()
 	
 	// Middleware
-	e.Use(middleware.Logger())
+	// the access log also names the user and, for impersonated tokens, the
+	// admin really acting
+	e.Use(middleware.LoggerWithConfig(middleware.LoggerConfig{
+		Format:        strings.TrimSuffix(middleware.DefaultLoggerConfig.Format, "}\n") + "${custom}}\n",
+		CustomTagFunc: logPrincipal,
+	}))
 	e.Use(middleware.Recover())
 	e.Use(middleware.CORS())
 	
@@ -35,7 +46,26 @@ func NewEchoServer(config *Config) *EchoServer {
 	}
 }
 
-func (s *EchoServer) SetupRoutes(handler *presenter.HTTPHandler) {
+// logPrincipal writes the user_id and actor_id fields of the access log.
+func logPrincipal(c echo.Context, buf *bytes.Buffer) (int, error) {
+	uid, _ := c.Get("userID").(string)
+	if uid == "" {
+		return 0, nil
+	}
+	fields := map[string]string{"user_id": uid}
+	if actor := auth.ActorID(c); actor != "" {
+		fields["actor_id"] = actor
+	}
+	b, err := json.Marshal(fields)
+	if err != nil {
+		return 0, err
+	}
+	// splice the object's members into the enclosing log line
+	b[0] = ','
+	return buf.Write(b[:len(b)-1])
+}
+
+func (s *EchoServer) SetupRoutes(handler *presenter.HTTPHandler, revocations auth.RevocationChecker, apiKeys auth.APIKeyResolver, policy *rbac.Policy) {
 	// Health check
 	s.server.GET("/health", func(c echo.Context) error {
 		return c.JSON(http.StatusOK, map[string]string{"status": "ok"})
@@ -43,16 +73,23 @@ func (s *EchoServer) SetupRoutes(handler *presenter.HTTPHandler) {
 
 	// API routes with authentication
 	api := s.server.Group("/api/v1")
-	api.Use(auth.JWTMiddleware("access_secret")) // You'll need to pass the secret from config
+	verifier := auth.NewJWKSVerifier(s.config.Auth.JWKSURL, time.Duration(s.config.Auth.JWKSRefreshMin)*time.Minute)
+	api.Use(auth.Middleware(verifier, auth.WithRevocationChecker(revocations), auth.WithAPIKeys(apiKeys), auth.WithPolicy(policy)))
 	
 	// Media routes
-	api.POST("/media/upload", handler.Upload)
-	api.GET("/media", handler.List)
-	api.GET("/media/:id", handler.GetByID)
-	api.DELETE("/media/:id", handler.Delete)
+	api.POST("/media/upload", handler.Upload, auth.Require(rbac.MediaUpload), auth.RequireScope(auth.ScopeMediaUpload))
+	api.GET("/media", handler.List, auth.Require(rbac.MediaRead), auth.RequireScope(auth.ScopeMediaRead))
+	api.GET("/media/:id", handler.GetByID, auth.Require(rbac.MediaRead), auth.RequireScope(auth.ScopeMediaRead))
+	api.DELETE("/media/:id", handler.Delete, auth.RequireScope(auth.ScopeMediaUpload))
 	
 	// Serve media files
 	s.server.GET("/media/:filename", handler.Serve)
+
+	// Internal routes for auth-service's data export and account deletion
+	internal := s.server.Group("/internal", userdata.Middleware(s.config.Internal.Secret))
+	internal.GET("/users/:id/export", handler.ExportUserData)
+	internal.GET("/users/:id/media/:mediaID", handler.ExportUserFile)
+	internal.DELETE("/users/:id", handler.DeleteUserData)
 }
 
 func (s *EchoServer) Start() error {
@@ -60,6 +97,6 @@ func (s *EchoServer) Start() error {
 	return s.server.Start(addr)
 }
 
-func (s *EchoServer) Shutdown() error {
-	return s.server.Close()
+func (s *EchoServer) Shutdown(ctx context.Context) error {
+	return s.server.Shutdown(ctx)
 }
@@ -10,23 +10,20 @@ import (
 )
 
 type HTTPHandler struct {
-	mediaUC *usecase.MediaUseCase
+	mediaUC    *usecase.MediaUseCase
+	userDataUC *usecase.UserDataUseCase
 }
 
-func NewHTTPHandler(mediaUC *usecase.MediaUseCase) *HTTPHandler {
+func NewHTTPHandler(mediaUC *usecase.MediaUseCase, userDataUC *usecase.UserDataUseCase) *HTTPHandler {
 	return &HTTPHandler{
-		mediaUC: mediaUC,
+		mediaUC:    mediaUC,
+		userDataUC: userDataUC,
 	}
 }
 
 // Upload media file
+// Permission is checked by the route (rbac.MediaUpload)
 func (h *HTTPHandler) Upload(c echo.Context) error {
-	// Check user role - only admin, manager, or author can upload
-	role := c.Get("role").(string)
-	if role != "admin" && role != "manager" && role != "author" {
-		return c.JSON(http.StatusForbidden, httputil.NewError(403, "insufficient permissions"))
-	}
-
 	userID := c.Get("userID").(string)
 	
 	// Get uploaded file
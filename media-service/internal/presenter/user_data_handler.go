@@ -0,0 +1,44 @@
+package presenter
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/HatefBarari/microblog-media/internal/usecase"
+	"github.com/HatefBarari/microblog-shared/pkg/httputil"
+	"github.com/HatefBarari/microblog-shared/pkg/userdata"
+	"github.com/labstack/echo/v4"
+)
+
+// ExportUserData lists the user's uploads for auth-service's data export
+func (h *HTTPHandler) ExportUserData(c echo.Context) error {
+	export, err := h.userDataUC.Export(c.Request().Context(), c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, httputil.NewError(500, err.Error()))
+	}
+	return c.JSON(http.StatusOK, httputil.OK(export))
+}
+
+// ExportUserFile streams one uploaded file into the export
+func (h *HTTPHandler) ExportUserFile(c echo.Context) error {
+	media, data, err := h.userDataUC.ReadFile(c.Request().Context(), c.Param("id"), c.Param("mediaID"))
+	if errors.Is(err, usecase.ErrMediaNotFound) {
+		return c.JSON(http.StatusNotFound, httputil.NewError(404, err.Error()))
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, httputil.NewError(500, err.Error()))
+	}
+	return c.Blob(http.StatusOK, media.MimeType, data)
+}
+
+// DeleteUserData removes or anonymizes the user's uploads
+func (h *HTTPHandler) DeleteUserData(c echo.Context) error {
+	mode := userdata.Mode(c.QueryParam("mode"))
+	if !mode.Valid() {
+		return c.JSON(http.StatusBadRequest, httputil.NewError(400, "mode must be anonymize or remove"))
+	}
+	if err := h.userDataUC.Delete(c.Request().Context(), c.Param("id"), mode); err != nil {
+		return c.JSON(http.StatusInternalServerError, httputil.NewError(500, err.Error()))
+	}
+	return c.NoContent(http.StatusNoContent)
+}
@@ -144,3 +144,14 @@ func (r *MongoMediaRepository) DeleteByUploader(ctx context.Context, uploaderID,
 
 	return nil
 }
+
+func (r *MongoMediaRepository) ReassignUploader(ctx context.Context, from, to string) error {
+	_, err := r.collection.UpdateMany(ctx,
+		bson.M{"uploader_id": from},
+		bson.M{
+			"$set":   bson.M{"uploader_id": to, "updated_at": time.Now()},
+			"$unset": bson.M{"metadata": ""},
+		},
+	)
+	return err
+}
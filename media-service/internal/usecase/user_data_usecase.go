@@ -0,0 +1,126 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"path/filepath"
+
+	"github.com/HatefBarari/microblog-media/internal/domain"
+	"github.com/HatefBarari/microblog-shared/pkg/userdata"
+	"go.uber.org/zap"
+)
+
+const exportPageSize = 100
+
+var ErrMediaNotFound = errors.New("media not found")
+
+// UserDataUseCase serves auth-service's personal-data export and account
+// deletion for the files a user uploaded.
+type UserDataUseCase struct {
+	repo    domain.MediaRepository
+	storage MediaStorage
+	log     *zap.Logger
+}
+
+func NewUserDataUseCase(repo domain.MediaRepository, storage MediaStorage, log *zap.Logger) *UserDataUseCase {
+	return &UserDataUseCase{repo: repo, storage: storage, log: log}
+}
+
+// Export lists the user's uploads and points at each file's content.
+func (uc *UserDataUseCase) Export(ctx context.Context, userID string) (*userdata.Export, error) {
+	media, err := uc.listAll(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	e := &userdata.Export{Files: make([]userdata.ExportFile, 0, len(media))}
+	list := make([]*MediaResponse, len(media))
+	for i, m := range media {
+		list[i] = &MediaResponse{
+			ID:           m.ID,
+			UploaderID:   m.UploaderID,
+			Filename:     m.Filename,
+			OriginalName: m.OriginalName,
+			MimeType:     m.MimeType,
+			Size:         m.Size,
+			Type:         string(m.Type),
+			Status:       string(m.Status),
+			URL:          m.URL,
+			ThumbnailURL: m.ThumbnailURL,
+			Metadata:     m.Metadata,
+			CreatedAt:    m.CreatedAt,
+			UpdatedAt:    m.UpdatedAt,
+		}
+		e.Files = append(e.Files, userdata.ExportFile{
+			Name: "files/" + m.ID + "_" + filepath.Base(m.OriginalName),
+			Path: "/internal/users/" + url.PathEscape(userID) + "/media/" + url.PathEscape(m.ID),
+		})
+	}
+	if err := e.AddDocument("media", list); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// ReadFile returns the content of one of the user's uploads.
+func (uc *UserDataUseCase) ReadFile(ctx context.Context, userID, mediaID string) (*domain.Media, []byte, error) {
+	m, err := uc.repo.GetByID(ctx, mediaID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if m == nil || m.UploaderID != userID {
+		return nil, nil, ErrMediaNotFound
+	}
+	data, err := uc.storage.Get(ctx, m.Filename)
+	if err != nil {
+		return nil, nil, err
+	}
+	return m, data, nil
+}
+
+// Delete removes the user's files, or with ModeAnonymize keeps them (they
+// may be used by anonymized articles) without any trace of the uploader.
+// Every step is safe to repeat.
+func (uc *UserDataUseCase) Delete(ctx context.Context, userID string, mode userdata.Mode) error {
+	if userID == userdata.DeletedUserID {
+		return fmt.Errorf("cannot delete %q", userID)
+	}
+	switch mode {
+	case userdata.ModeAnonymize:
+		if err := uc.repo.ReassignUploader(ctx, userID, userdata.DeletedUserID); err != nil {
+			return err
+		}
+	case userdata.ModeRemove:
+		media, err := uc.listAll(ctx, userID)
+		if err != nil {
+			return err
+		}
+		for _, m := range media {
+			if err := uc.storage.Delete(ctx, m.Filename); err != nil {
+				return err
+			}
+			if err := uc.repo.Delete(ctx, m.ID); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("unknown deletion mode %q", mode)
+	}
+	uc.log.Info("user data deleted", zap.String("user_id", userID), zap.String("mode", string(mode)))
+	return nil
+}
+
+func (uc *UserDataUseCase) listAll(ctx context.Context, userID string) ([]*domain.Media, error) {
+	var all []*domain.Media
+	for offset := 0; ; offset += exportPageSize {
+		page, total, err := uc.repo.ListByUploader(ctx, userID, exportPageSize, offset)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, page...)
+		if len(page) < exportPageSize || offset+len(page) >= total {
+			return all, nil
+		}
+	}
+}
@@ -2,249 +2,187 @@ package tests
 
 import (
 	"bytes"
-	"context"
 	"errors"
 	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
+	"net/textproto"
 	"testing"
 
 	"github.com/HatefBarari/microblog-media/internal/domain"
 	"github.com/HatefBarari/microblog-media/internal/presenter"
 	"github.com/HatefBarari/microblog-media/internal/usecase"
+	"github.com/HatefBarari/microblog-shared/pkg/auth"
+	"github.com/HatefBarari/microblog-shared/pkg/rbac"
 	"github.com/labstack/echo/v4"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"go.uber.org/zap"
 )
 
-// Mock use case
-type MockMediaUseCase struct {
-	mock.Mock
-}
-
-func (m *MockMediaUseCase) Upload(ctx context.Context, uploaderID string, file *multipart.FileHeader) (*usecase.UploadResponse, error) {
-	args := m.Called(ctx, uploaderID, file)
-	if args.Get(0) == nil {
-		return nil, args.Error(1)
-	}
-	return args.Get(0).(*usecase.UploadResponse), args.Error(1)
-}
-
-func (m *MockMediaUseCase) GetByID(ctx context.Context, id string) (*usecase.MediaResponse, error) {
-	args := m.Called(ctx, id)
-	if args.Get(0) == nil {
-		return nil, args.Error(1)
-	}
-	return args.Get(0).(*usecase.MediaResponse), args.Error(1)
+// The handler takes a concrete use case, so these tests drive it through a
+// real MediaUseCase backed by a mock repository and storage.
+func setupTestHandler() (*presenter.HTTPHandler, *MockMediaRepository, *MockMediaStorage) {
+	logger, _ := zap.NewDevelopment()
+	mockRepo := new(MockMediaRepository)
+	mockStorage := new(MockMediaStorage)
+	uc := usecase.NewMediaUseCase(
+		mockRepo,
+		mockStorage,
+		&usecase.Config{
+			MaxFileSize:   10 * 1024 * 1024, // 10MB
+			AllowedTypes:  []string{"image/"},
+			StoragePath:   "./uploads",
+			BaseURL:       "http://localhost:8083",
+			ThumbnailSize: 300,
+		},
+		logger,
+	)
+	handler := presenter.NewHTTPHandler(uc, usecase.NewUserDataUseCase(mockRepo, mockStorage, logger))
+	return handler, mockRepo, mockStorage
 }
 
-func (m *MockMediaUseCase) List(ctx context.Context, filter usecase.ListFilter) (*usecase.ListResponse, error) {
-	args := m.Called(ctx, filter)
-	if args.Get(0) == nil {
-		return nil, args.Error(1)
+// withRole puts the permissions the default policy grants role on the
+// context, as the JWT middleware would.
+func withRole(role string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			c.Set("userID", "user123")
+			c.Set("role", role)
+			c.Set("permissions", rbac.DefaultPolicy().Permissions(role))
+			return next(c)
+		}
 	}
-	return args.Get(0).(*usecase.ListResponse), args.Error(1)
-}
-
-func (m *MockMediaUseCase) Delete(ctx context.Context, uploaderID, id string) error {
-	args := m.Called(ctx, uploaderID, id)
-	return args.Error(0)
-}
-
-func setupTestHandler() (*presenter.HTTPHandler, *MockMediaUseCase) {
-	mockUC := new(MockMediaUseCase)
-	handler := presenter.NewHTTPHandler(mockUC)
-	return handler, mockUC
 }
 
 func TestHTTPHandler_Upload(t *testing.T) {
-	handler, mockUC := setupTestHandler()
-	
 	tests := []struct {
 		name           string
-		userID         string
 		role           string
-		file           *multipart.FileHeader
-		mockSetup      func(*MockMediaUseCase)
+		contentType    string
+		withFile       bool
+		mockSetup      func(*MockMediaRepository, *MockMediaStorage)
 		expectedStatus int
-		expectedError  string
 	}{
 		{
-			name:   "successful upload by admin",
-			userID: "user123",
-			role:   "admin",
-			file: &multipart.FileHeader{
-				Filename: "test.jpg",
-				Size:     1024,
-				Header:   map[string][]string{"Content-Type": {"image/jpeg"}},
-			},
-			mockSetup: func(mockUC *MockMediaUseCase) {
-				mockUC.On("Upload", mock.Anything, "user123", mock.AnythingOfType("*multipart.FileHeader")).
-					Return(&usecase.UploadResponse{
-						Media: &usecase.MediaResponse{
-							ID:           "media123",
-							UploaderID:   "user123",
-							Filename:     "test.jpg",
-							OriginalName: "test.jpg",
-							Type:         string(domain.MediaTypeImage),
-						},
-						URL: "http://localhost:8083/uploads/test.jpg",
-					}, nil)
+			name:        "successful upload by admin",
+			role:        "admin",
+			contentType: "image/jpeg",
+			withFile:    true,
+			mockSetup: func(mockRepo *MockMediaRepository, mockStorage *MockMediaStorage) {
+				mockStorage.On("Save", mock.Anything, mock.AnythingOfType("string"), []byte("fake image data")).
+					Return("http://localhost:8083/uploads/test.jpg", nil)
+				mockStorage.On("GenerateThumbnail", mock.Anything, mock.AnythingOfType("string"), 300).
+					Return("http://localhost:8083/uploads/thumb_test.jpg", nil)
+				mockRepo.On("Create", mock.Anything, mock.MatchedBy(func(media *domain.Media) bool {
+					return media.UploaderID == "user123" &&
+						media.OriginalName == "test.jpg" &&
+						media.Type == domain.MediaTypeImage
+				})).Return(nil)
 			},
 			expectedStatus: http.StatusCreated,
 		},
 		{
-			name:   "successful upload by author",
-			userID: "user123",
-			role:   "author",
-			file: &multipart.FileHeader{
-				Filename: "test.jpg",
-				Size:     1024,
-				Header:   map[string][]string{"Content-Type": {"image/jpeg"}},
-			},
-			mockSetup: func(mockUC *MockMediaUseCase) {
-				mockUC.On("Upload", mock.Anything, "user123", mock.AnythingOfType("*multipart.FileHeader")).
-					Return(&usecase.UploadResponse{
-						Media: &usecase.MediaResponse{
-							ID:           "media123",
-							UploaderID:   "user123",
-							Filename:     "test.jpg",
-							OriginalName: "test.jpg",
-							Type:         string(domain.MediaTypeImage),
-						},
-						URL: "http://localhost:8083/uploads/test.jpg",
-					}, nil)
+			name:        "successful upload by manager",
+			role:        "manager",
+			contentType: "image/jpeg",
+			withFile:    true,
+			mockSetup: func(mockRepo *MockMediaRepository, mockStorage *MockMediaStorage) {
+				mockStorage.On("Save", mock.Anything, mock.AnythingOfType("string"), mock.Anything).
+					Return("http://localhost:8083/uploads/test.jpg", nil)
+				mockStorage.On("GenerateThumbnail", mock.Anything, mock.AnythingOfType("string"), 300).
+					Return("http://localhost:8083/uploads/thumb_test.jpg", nil)
+				mockRepo.On("Create", mock.Anything, mock.Anything).Return(nil)
 			},
 			expectedStatus: http.StatusCreated,
 		},
 		{
-			name:   "forbidden - user role",
-			userID: "user123",
-			role:   "user",
-			file: &multipart.FileHeader{
-				Filename: "test.jpg",
-				Size:     1024,
-				Header:   map[string][]string{"Content-Type": {"image/jpeg"}},
-			},
-			mockSetup:      func(mockUC *MockMediaUseCase) {},
+			name:           "forbidden - user role",
+			role:           "user",
+			contentType:    "image/jpeg",
+			withFile:       true,
+			mockSetup:      func(mockRepo *MockMediaRepository, mockStorage *MockMediaStorage) {},
 			expectedStatus: http.StatusForbidden,
-			expectedError:  "insufficient permissions",
 		},
 		{
-			name:   "forbidden - guest role",
-			userID: "user123",
-			role:   "guest",
-			file: &multipart.FileHeader{
-				Filename: "test.jpg",
-				Size:     1024,
-				Header:   map[string][]string{"Content-Type": {"image/jpeg"}},
-			},
-			mockSetup:      func(mockUC *MockMediaUseCase) {},
+			name:           "forbidden - guest role",
+			role:           "guest",
+			contentType:    "image/jpeg",
+			withFile:       true,
+			mockSetup:      func(mockRepo *MockMediaRepository, mockStorage *MockMediaStorage) {},
 			expectedStatus: http.StatusForbidden,
-			expectedError:  "insufficient permissions",
 		},
 		{
-			name:   "no file uploaded",
-			userID: "user123",
-			role:   "admin",
-			file:   nil,
-			mockSetup: func(mockUC *MockMediaUseCase) {
+			name:     "no file uploaded",
+			role:     "admin",
+			withFile: false,
+			mockSetup: func(mockRepo *MockMediaRepository, mockStorage *MockMediaStorage) {
 				// No repository calls expected
 			},
 			expectedStatus: http.StatusBadRequest,
-			expectedError:  "no file uploaded",
 		},
 		{
-			name:   "use case error",
-			userID: "user123",
-			role:   "admin",
-			file: &multipart.FileHeader{
-				Filename: "test.jpg",
-				Size:     1024,
-				Header:   map[string][]string{"Content-Type": {"image/jpeg"}},
-			},
-			mockSetup: func(mockUC *MockMediaUseCase) {
-				mockUC.On("Upload", mock.Anything, "user123", mock.AnythingOfType("*multipart.FileHeader")).
-					Return(nil, errors.New("file too large"))
-			},
+			name:           "use case error",
+			role:           "admin",
+			contentType:    "application/x-msdownload",
+			withFile:       true,
+			mockSetup:      func(mockRepo *MockMediaRepository, mockStorage *MockMediaStorage) {},
 			expectedStatus: http.StatusBadRequest,
-			expectedError:  "file too large",
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			// Reset mocks
-			mockUC.ExpectedCalls = nil
-			tt.mockSetup(mockUC)
+			handler, mockRepo, mockStorage := setupTestHandler()
+			tt.mockSetup(mockRepo, mockStorage)
 
 			// Setup request
-			req := httptest.NewRequest(http.MethodPost, "/api/v1/media/upload", nil)
-			rec := httptest.NewRecorder()
-
-			// Setup echo context
-			e := echo.New()
-			c := e.NewContext(req, rec)
-			c.Set("userID", tt.userID)
-			c.Set("role", tt.role)
-
-			// Add file to form if provided
-			if tt.file != nil {
-				// Create a simple form with file
-				body := &bytes.Buffer{}
-				writer := multipart.NewWriter(body)
-				part, _ := writer.CreateFormFile("file", tt.file.Filename)
+			body := &bytes.Buffer{}
+			writer := multipart.NewWriter(body)
+			if tt.withFile {
+				h := make(textproto.MIMEHeader)
+				h.Set("Content-Disposition", `form-data; name="file"; filename="test.jpg"`)
+				h.Set("Content-Type", tt.contentType)
+				part, _ := writer.CreatePart(h)
 				part.Write([]byte("fake image data"))
-				writer.Close()
-				
-				req = httptest.NewRequest(http.MethodPost, "/api/v1/media/upload", body)
-				req.Header.Set("Content-Type", writer.FormDataContentType())
-				rec = httptest.NewRecorder()
-				
-				c = e.NewContext(req, rec)
-				c.Set("userID", tt.userID)
-				c.Set("role", tt.role)
 			}
+			writer.Close()
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/media/upload", body)
+			req.Header.Set("Content-Type", writer.FormDataContentType())
+			rec := httptest.NewRecorder()
 
-			// Execute
-			err := handler.Upload(c)
+			// Route as the server does, behind the upload permission
+			e := echo.New()
+			e.POST("/api/v1/media/upload", handler.Upload, withRole(tt.role), auth.Require(rbac.MediaUpload))
+			e.ServeHTTP(rec, req)
 
 			// Assertions
-			if tt.expectedError != "" {
-				assert.Error(t, err)
-				assert.Contains(t, err.Error(), tt.expectedError)
-			} else {
-				assert.NoError(t, err)
-				assert.Equal(t, tt.expectedStatus, rec.Code)
-			}
+			assert.Equal(t, tt.expectedStatus, rec.Code)
 
-			mockUC.AssertExpectations(t)
+			mockRepo.AssertExpectations(t)
+			mockStorage.AssertExpectations(t)
 		})
 	}
 }
 
 func TestHTTPHandler_GetByID(t *testing.T) {
-	handler, mockUC := setupTestHandler()
-	
 	tests := []struct {
 		name           string
 		mediaID        string
-		mockSetup      func(*MockMediaUseCase)
+		mockSetup      func(*MockMediaRepository)
 		expectedStatus int
-		expectedError  string
 	}{
 		{
 			name:    "successful get by ID",
 			mediaID: "media123",
-			mockSetup: func(mockUC *MockMediaUseCase) {
-				mockUC.On("GetByID", mock.Anything, "media123").
-					Return(&usecase.MediaResponse{
+			mockSetup: func(mockRepo *MockMediaRepository) {
+				mockRepo.On("GetByID", mock.Anything, "media123").
+					Return(&domain.Media{
 						ID:           "media123",
 						UploaderID:   "user123",
 						Filename:     "test.jpg",
 						OriginalName: "test.jpg",
-						Type:         string(domain.MediaTypeImage),
+						Type:         domain.MediaTypeImage,
 					}, nil)
 			},
 			expectedStatus: http.StatusOK,
@@ -252,30 +190,26 @@ func TestHTTPHandler_GetByID(t *testing.T) {
 		{
 			name:    "media not found",
 			mediaID: "nonexistent",
-			mockSetup: func(mockUC *MockMediaUseCase) {
-				mockUC.On("GetByID", mock.Anything, "nonexistent").
-					Return(nil, errors.New("media not found"))
+			mockSetup: func(mockRepo *MockMediaRepository) {
+				mockRepo.On("GetByID", mock.Anything, "nonexistent").Return(nil, nil)
 			},
 			expectedStatus: http.StatusNotFound,
-			expectedError:  "media not found",
 		},
 		{
 			name:    "use case error",
 			mediaID: "media123",
-			mockSetup: func(mockUC *MockMediaUseCase) {
-				mockUC.On("GetByID", mock.Anything, "media123").
+			mockSetup: func(mockRepo *MockMediaRepository) {
+				mockRepo.On("GetByID", mock.Anything, "media123").
 					Return(nil, errors.New("database error"))
 			},
 			expectedStatus: http.StatusInternalServerError,
-			expectedError:  "database error",
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			// Reset mocks
-			mockUC.ExpectedCalls = nil
-			tt.mockSetup(mockUC)
+			handler, mockRepo, _ := setupTestHandler()
+			tt.mockSetup(mockRepo)
 
 			// Setup request
 			req := httptest.NewRequest(http.MethodGet, "/api/v1/media/"+tt.mediaID, nil)
@@ -291,29 +225,21 @@ func TestHTTPHandler_GetByID(t *testing.T) {
 			err := handler.GetByID(c)
 
 			// Assertions
-			if tt.expectedError != "" {
-				assert.Error(t, err)
-				assert.Contains(t, err.Error(), tt.expectedError)
-			} else {
-				assert.NoError(t, err)
-				assert.Equal(t, tt.expectedStatus, rec.Code)
-			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedStatus, rec.Code)
 
-			mockUC.AssertExpectations(t)
+			mockRepo.AssertExpectations(t)
 		})
 	}
 }
 
 func TestHTTPHandler_List(t *testing.T) {
-	handler, mockUC := setupTestHandler()
-	
 	tests := []struct {
 		name           string
 		userID         string
 		queryParams    map[string]string
-		mockSetup      func(*MockMediaUseCase)
+		mockSetup      func(*MockMediaRepository)
 		expectedStatus int
-		expectedError  string
 	}{
 		{
 			name:   "successful list",
@@ -322,32 +248,12 @@ func TestHTTPHandler_List(t *testing.T) {
 				"page":      "1",
 				"page_size": "10",
 			},
-			mockSetup: func(mockUC *MockMediaUseCase) {
-				mockUC.On("List", mock.Anything, mock.MatchedBy(func(filter usecase.ListFilter) bool {
-					return filter.UploaderID == "user123" &&
-						filter.Page == 1 &&
-						filter.PageSize == 10
-				})).Return(&usecase.ListResponse{
-					Media: []*usecase.MediaResponse{
-						{
-							ID:           "media1",
-							UploaderID:   "user123",
-							Filename:     "test1.jpg",
-							OriginalName: "test1.jpg",
-							Type:         string(domain.MediaTypeImage),
-						},
-						{
-							ID:           "media2",
-							UploaderID:   "user123",
-							Filename:     "test2.jpg",
-							OriginalName: "test2.jpg",
-							Type:         string(domain.MediaTypeImage),
-						},
-					},
-					Total: 2,
-					Page:  1,
-					Size:  2,
-				}, nil)
+			mockSetup: func(mockRepo *MockMediaRepository) {
+				mockRepo.On("ListByUploader", mock.Anything, "user123", 10, 0).
+					Return([]*domain.Media{
+						{ID: "media1", UploaderID: "user123", Filename: "test1.jpg", Type: domain.MediaTypeImage},
+						{ID: "media2", UploaderID: "user123", Filename: "test2.jpg", Type: domain.MediaTypeImage},
+					}, 2, nil)
 			},
 			expectedStatus: http.StatusOK,
 		},
@@ -355,17 +261,12 @@ func TestHTTPHandler_List(t *testing.T) {
 			name:   "empty list",
 			userID: "user123",
 			queryParams: map[string]string{
-				"page":      "1",
+				"page":      "2",
 				"page_size": "10",
 			},
-			mockSetup: func(mockUC *MockMediaUseCase) {
-				mockUC.On("List", mock.Anything, mock.AnythingOfType("usecase.ListFilter")).
-					Return(&usecase.ListResponse{
-						Media: []*usecase.MediaResponse{},
-						Total: 0,
-						Page:  1,
-						Size:  0,
-					}, nil)
+			mockSetup: func(mockRepo *MockMediaRepository) {
+				mockRepo.On("ListByUploader", mock.Anything, "user123", 10, 10).
+					Return([]*domain.Media{}, 0, nil)
 			},
 			expectedStatus: http.StatusOK,
 		},
@@ -376,20 +277,18 @@ func TestHTTPHandler_List(t *testing.T) {
 				"page":      "1",
 				"page_size": "10",
 			},
-			mockSetup: func(mockUC *MockMediaUseCase) {
-				mockUC.On("List", mock.Anything, mock.AnythingOfType("usecase.ListFilter")).
-					Return(nil, errors.New("database error"))
+			mockSetup: func(mockRepo *MockMediaRepository) {
+				mockRepo.On("ListByUploader", mock.Anything, "user123", 10, 0).
+					Return([]*domain.Media{}, 0, errors.New("database error"))
 			},
 			expectedStatus: http.StatusInternalServerError,
-			expectedError:  "database error",
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			// Reset mocks
-			mockUC.ExpectedCalls = nil
-			tt.mockSetup(mockUC)
+			handler, mockRepo, _ := setupTestHandler()
+			tt.mockSetup(mockRepo)
 
 			// Setup request with query parameters
 			req := httptest.NewRequest(http.MethodGet, "/api/v1/media", nil)
@@ -409,36 +308,32 @@ func TestHTTPHandler_List(t *testing.T) {
 			err := handler.List(c)
 
 			// Assertions
-			if tt.expectedError != "" {
-				assert.Error(t, err)
-				assert.Contains(t, err.Error(), tt.expectedError)
-			} else {
-				assert.NoError(t, err)
-				assert.Equal(t, tt.expectedStatus, rec.Code)
-			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedStatus, rec.Code)
 
-			mockUC.AssertExpectations(t)
+			mockRepo.AssertExpectations(t)
 		})
 	}
 }
 
 func TestHTTPHandler_Delete(t *testing.T) {
-	handler, mockUC := setupTestHandler()
-	
+	owned := &domain.Media{ID: "media123", UploaderID: "user123", Filename: "test.jpg"}
+
 	tests := []struct {
 		name           string
 		userID         string
 		mediaID        string
-		mockSetup      func(*MockMediaUseCase)
+		mockSetup      func(*MockMediaRepository, *MockMediaStorage)
 		expectedStatus int
-		expectedError  string
 	}{
 		{
 			name:    "successful delete",
 			userID:  "user123",
 			mediaID: "media123",
-			mockSetup: func(mockUC *MockMediaUseCase) {
-				mockUC.On("Delete", mock.Anything, "user123", "media123").Return(nil)
+			mockSetup: func(mockRepo *MockMediaRepository, mockStorage *MockMediaStorage) {
+				mockRepo.On("GetByID", mock.Anything, "media123").Return(owned, nil)
+				mockStorage.On("Delete", mock.Anything, "test.jpg").Return(nil)
+				mockRepo.On("DeleteByUploader", mock.Anything, "user123", "media123").Return(nil)
 			},
 			expectedStatus: http.StatusNoContent,
 		},
@@ -446,42 +341,38 @@ func TestHTTPHandler_Delete(t *testing.T) {
 			name:    "media not found",
 			userID:  "user123",
 			mediaID: "nonexistent",
-			mockSetup: func(mockUC *MockMediaUseCase) {
-				mockUC.On("Delete", mock.Anything, "user123", "nonexistent").
-					Return(errors.New("media not found"))
+			mockSetup: func(mockRepo *MockMediaRepository, mockStorage *MockMediaStorage) {
+				mockRepo.On("GetByID", mock.Anything, "nonexistent").Return(nil, nil)
 			},
 			expectedStatus: http.StatusNotFound,
-			expectedError:  "media not found",
 		},
 		{
 			name:    "forbidden",
 			userID:  "user456",
 			mediaID: "media123",
-			mockSetup: func(mockUC *MockMediaUseCase) {
-				mockUC.On("Delete", mock.Anything, "user456", "media123").
-					Return(errors.New("forbidden"))
+			mockSetup: func(mockRepo *MockMediaRepository, mockStorage *MockMediaStorage) {
+				mockRepo.On("GetByID", mock.Anything, "media123").Return(owned, nil)
 			},
 			expectedStatus: http.StatusForbidden,
-			expectedError:  "forbidden",
 		},
 		{
 			name:    "use case error",
 			userID:  "user123",
 			mediaID: "media123",
-			mockSetup: func(mockUC *MockMediaUseCase) {
-				mockUC.On("Delete", mock.Anything, "user123", "media123").
+			mockSetup: func(mockRepo *MockMediaRepository, mockStorage *MockMediaStorage) {
+				mockRepo.On("GetByID", mock.Anything, "media123").Return(owned, nil)
+				mockStorage.On("Delete", mock.Anything, "test.jpg").Return(nil)
+				mockRepo.On("DeleteByUploader", mock.Anything, "user123", "media123").
 					Return(errors.New("database error"))
 			},
 			expectedStatus: http.StatusBadRequest,
-			expectedError:  "database error",
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			// Reset mocks
-			mockUC.ExpectedCalls = nil
-			tt.mockSetup(mockUC)
+			handler, mockRepo, mockStorage := setupTestHandler()
+			tt.mockSetup(mockRepo, mockStorage)
 
 			// Setup request
 			req := httptest.NewRequest(http.MethodDelete, "/api/v1/media/"+tt.mediaID, nil)
@@ -498,15 +389,11 @@ func TestHTTPHandler_Delete(t *testing.T) {
 			err := handler.Delete(c)
 
 			// Assertions
-			if tt.expectedError != "" {
-				assert.Error(t, err)
-				assert.Contains(t, err.Error(), tt.expectedError)
-			} else {
-				assert.NoError(t, err)
-				assert.Equal(t, tt.expectedStatus, rec.Code)
-			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedStatus, rec.Code)
 
-			mockUC.AssertExpectations(t)
+			mockRepo.AssertExpectations(t)
+			mockStorage.AssertExpectations(t)
 		})
 	}
 }
@@ -1,9 +1,11 @@
 package tests
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"mime/multipart"
+	"net/textproto"
 	"testing"
 	"time"
 
@@ -11,6 +13,7 @@ import (
 	"github.com/HatefBarari/microblog-media/internal/usecase"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
 )
 
@@ -60,6 +63,11 @@ func (m *MockMediaRepository) DeleteByUploader(ctx context.Context, uploaderID,
 	return args.Error(0)
 }
 
+func (m *MockMediaRepository) ReassignUploader(ctx context.Context, from, to string) error {
+	args := m.Called(ctx, from, to)
+	return args.Error(0)
+}
+
 type MockMediaStorage struct {
 	mock.Mock
 }
@@ -84,6 +92,28 @@ func (m *MockMediaStorage) GenerateThumbnail(ctx context.Context, filename strin
 	return args.String(0), args.Error(1)
 }
 
+// newFileHeader builds a file header the way a parsed multipart form does,
+// so Upload can open it. size overrides the reported size for the limit check.
+func newFileHeader(t *testing.T, filename, contentType string, size int64) *multipart.FileHeader {
+	t.Helper()
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	h := make(textproto.MIMEHeader)
+	h.Set("Content-Disposition", `form-data; name="file"; filename="`+filename+`"`)
+	h.Set("Content-Type", contentType)
+	part, err := writer.CreatePart(h)
+	require.NoError(t, err)
+	_, err = part.Write([]byte("fake file data"))
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+
+	form, err := multipart.NewReader(body, writer.Boundary()).ReadForm(1024)
+	require.NoError(t, err)
+	file := form.File["file"][0]
+	file.Size = size
+	return file
+}
+
 func TestMediaUseCase_Upload(t *testing.T) {
 	logger, _ := zap.NewDevelopment()
 	
@@ -98,11 +128,7 @@ func TestMediaUseCase_Upload(t *testing.T) {
 		{
 			name:       "successful image upload",
 			uploaderID: "user123",
-			file: &multipart.FileHeader{
-				Filename: "test.jpg",
-				Size:     1024,
-				Header:   map[string][]string{"Content-Type": {"image/jpeg"}},
-			},
+			file:       newFileHeader(t, "test.jpg", "image/jpeg", 1024),
 			mockSetup: func(mockRepo *MockMediaRepository, mockStorage *MockMediaStorage) {
 				mockStorage.On("Save", mock.Anything, mock.AnythingOfType("string"), mock.AnythingOfType("[]uint8")).
 					Return("http://localhost:8083/uploads/test.jpg", nil)
@@ -126,11 +152,7 @@ func TestMediaUseCase_Upload(t *testing.T) {
 		{
 			name:       "file too large",
 			uploaderID: "user123",
-			file: &multipart.FileHeader{
-				Filename: "large.jpg",
-				Size:     20 * 1024 * 1024, // 20MB
-				Header:   map[string][]string{"Content-Type": {"image/jpeg"}},
-			},
+			file:       newFileHeader(t, "large.jpg", "image/jpeg", 20*1024*1024), // 20MB
 			mockSetup: func(mockRepo *MockMediaRepository, mockStorage *MockMediaStorage) {
 				// No repository calls expected for invalid input
 			},
@@ -139,11 +161,7 @@ func TestMediaUseCase_Upload(t *testing.T) {
 		{
 			name:       "unsupported file type",
 			uploaderID: "user123",
-			file: &multipart.FileHeader{
-				Filename: "test.txt",
-				Size:     1024,
-				Header:   map[string][]string{"Content-Type": {"text/plain"}},
-			},
+			file:       newFileHeader(t, "test.txt", "text/plain", 1024),
 			mockSetup: func(mockRepo *MockMediaRepository, mockStorage *MockMediaStorage) {
 				// No repository calls expected for invalid input
 			},
@@ -152,11 +170,7 @@ func TestMediaUseCase_Upload(t *testing.T) {
 		{
 			name:       "storage error",
 			uploaderID: "user123",
-			file: &multipart.FileHeader{
-				Filename: "test.jpg",
-				Size:     1024,
-				Header:   map[string][]string{"Content-Type": {"image/jpeg"}},
-			},
+			file:       newFileHeader(t, "test.jpg", "image/jpeg", 1024),
 			mockSetup: func(mockRepo *MockMediaRepository, mockStorage *MockMediaStorage) {
 				mockStorage.On("Save", mock.Anything, mock.AnythingOfType("string"), mock.AnythingOfType("[]uint8")).
 					Return("", errors.New("storage error"))
@@ -166,11 +180,7 @@ func TestMediaUseCase_Upload(t *testing.T) {
 		{
 			name:       "database error",
 			uploaderID: "user123",
-			file: &multipart.FileHeader{
-				Filename: "test.jpg",
-				Size:     1024,
-				Header:   map[string][]string{"Content-Type": {"image/jpeg"}},
-			},
+			file:       newFileHeader(t, "test.jpg", "image/jpeg", 1024),
 			mockSetup: func(mockRepo *MockMediaRepository, mockStorage *MockMediaStorage) {
 				mockStorage.On("Save", mock.Anything, mock.AnythingOfType("string"), mock.AnythingOfType("[]uint8")).
 					Return("http://localhost:8083/uploads/test.jpg", nil)
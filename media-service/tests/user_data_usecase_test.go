@@ -0,0 +1,184 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/HatefBarari/microblog-media/internal/domain"
+	"github.com/HatefBarari/microblog-media/internal/usecase"
+	"github.com/HatefBarari/microblog-shared/pkg/userdata"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// mediaStore backs the repository and storage mocks so the tests can check
+// what is left after a deletion rather than which calls were made.
+type mediaStore struct {
+	media []*domain.Media
+	files map[string][]byte
+}
+
+func newMediaStore() *mediaStore {
+	return &mediaStore{
+		media: []*domain.Media{
+			{ID: "m1", UploaderID: "user123", Filename: "f1.jpg", OriginalName: "holiday.jpg"},
+			{ID: "m2", UploaderID: "user123", Filename: "f2.png", OriginalName: "../../etc/avatar.png"},
+			{ID: "m3", UploaderID: "other", Filename: "f3.jpg", OriginalName: "cat.jpg"},
+		},
+		files: map[string][]byte{
+			"f1.jpg": []byte("one"),
+			"f2.png": []byte("two"),
+			"f3.jpg": []byte("three"),
+		},
+	}
+}
+
+func (s *mediaStore) useCase() *usecase.UserDataUseCase {
+	repo := new(MockMediaRepository)
+	// the mocks assert concrete return types, so answers from the store are
+	// filled in by Run, which testify calls before reading them
+	list := repo.On("ListByUploader", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	list.Run(func(args mock.Arguments) {
+		page, total := s.page(args.String(1), args.Int(2), args.Int(3))
+		list.ReturnArguments = mock.Arguments{page, total, nil}
+	})
+	get := repo.On("GetByID", mock.Anything, mock.Anything)
+	get.Run(func(args mock.Arguments) {
+		get.ReturnArguments = mock.Arguments{nil, nil}
+		for _, m := range s.media {
+			if m.ID == args.String(1) {
+				get.ReturnArguments = mock.Arguments{m, nil}
+			}
+		}
+	})
+	repo.On("ReassignUploader", mock.Anything, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		for _, m := range s.media {
+			if m.UploaderID == args.String(1) {
+				m.UploaderID = args.String(2)
+			}
+		}
+	}).Return(nil)
+	repo.On("Delete", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		kept := s.media[:0]
+		for _, m := range s.media {
+			if m.ID != args.String(1) {
+				kept = append(kept, m)
+			}
+		}
+		s.media = kept
+	}).Return(nil)
+
+	storage := new(MockMediaStorage)
+	read := storage.On("Get", mock.Anything, mock.Anything)
+	read.Run(func(args mock.Arguments) {
+		read.ReturnArguments = mock.Arguments{s.files[args.String(1)], nil}
+	})
+	storage.On("Delete", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		delete(s.files, args.String(1))
+	}).Return(nil)
+
+	logger, _ := zap.NewDevelopment()
+	return usecase.NewUserDataUseCase(repo, storage, logger)
+}
+
+func (s *mediaStore) page(uploaderID string, limit, offset int) ([]*domain.Media, int) {
+	var all []*domain.Media
+	for _, m := range s.media {
+		if m.UploaderID == uploaderID {
+			all = append(all, m)
+		}
+	}
+	if offset >= len(all) {
+		return []*domain.Media{}, len(all)
+	}
+	end := offset + limit
+	if end > len(all) {
+		end = len(all)
+	}
+	return append([]*domain.Media(nil), all[offset:end]...), len(all)
+}
+
+func (s *mediaStore) uploaders() map[string]string {
+	out := map[string]string{}
+	for _, m := range s.media {
+		out[m.ID] = m.UploaderID
+	}
+	return out
+}
+
+func TestUserDataUseCase_Export(t *testing.T) {
+	store := newMediaStore()
+	uc := store.useCase()
+
+	e, err := uc.Export(context.Background(), "user123")
+	require.NoError(t, err)
+
+	// the original name is reduced to its base so it can't escape the archive
+	assert.Equal(t, []userdata.ExportFile{
+		{Name: "files/m1_holiday.jpg", Path: "/internal/users/user123/media/m1"},
+		{Name: "files/m2_avatar.png", Path: "/internal/users/user123/media/m2"},
+	}, e.Files)
+
+	var list []usecase.MediaResponse
+	require.NoError(t, json.Unmarshal(e.Documents["media"], &list))
+	require.Len(t, list, 2)
+	assert.Equal(t, "m1", list[0].ID)
+	assert.Equal(t, "m2", list[1].ID)
+}
+
+func TestUserDataUseCase_ReadFile(t *testing.T) {
+	store := newMediaStore()
+	uc := store.useCase()
+
+	m, data, err := uc.ReadFile(context.Background(), "user123", "m1")
+	require.NoError(t, err)
+	assert.Equal(t, "m1", m.ID)
+	assert.Equal(t, []byte("one"), data)
+
+	// another user's upload looks the same as a missing one
+	_, _, err = uc.ReadFile(context.Background(), "user123", "m3")
+	assert.ErrorIs(t, err, usecase.ErrMediaNotFound)
+
+	_, _, err = uc.ReadFile(context.Background(), "user123", "missing")
+	assert.ErrorIs(t, err, usecase.ErrMediaNotFound)
+}
+
+func TestUserDataUseCase_DeleteAnonymize(t *testing.T) {
+	store := newMediaStore()
+	uc := store.useCase()
+
+	require.NoError(t, uc.Delete(context.Background(), "user123", userdata.ModeAnonymize))
+
+	// files stay, under the placeholder uploader
+	assert.Equal(t, map[string]string{
+		"m1": userdata.DeletedUserID,
+		"m2": userdata.DeletedUserID,
+		"m3": "other",
+	}, store.uploaders())
+	assert.Len(t, store.files, 3)
+}
+
+func TestUserDataUseCase_DeleteRemove(t *testing.T) {
+	store := newMediaStore()
+	uc := store.useCase()
+
+	require.NoError(t, uc.Delete(context.Background(), "user123", userdata.ModeRemove))
+
+	assert.Equal(t, map[string]string{"m3": "other"}, store.uploaders())
+	assert.Equal(t, map[string][]byte{"f3.jpg": []byte("three")}, store.files)
+}
+
+func TestUserDataUseCase_DeleteRejects(t *testing.T) {
+	store := newMediaStore()
+	uc := store.useCase()
+	before := store.uploaders()
+
+	assert.Error(t, uc.Delete(context.Background(), userdata.DeletedUserID, userdata.ModeRemove))
+	assert.Error(t, uc.Delete(context.Background(), "user123", userdata.Mode("archive")))
+
+	assert.Equal(t, before, store.uploaders())
+	assert.Len(t, store.files, 3)
+}
@@ -0,0 +1,41 @@
+package auth
+
+import (
+	"net/http"
+
+	"github.com/HatefBarari/microblog-shared/pkg/httputil"
+	"github.com/labstack/echo/v4"
+)
+
+// Actor is the act claim (RFC 8693): the admin really using a token that
+// was issued to impersonate another user.
+type Actor struct {
+	Subject string `json:"sub"`
+}
+
+// Impersonated reports whether the token is used by someone other than its
+// user.
+func (c *Claims) Impersonated() bool {
+	return c.Actor != nil && c.Actor.Subject != ""
+}
+
+// ActorID returns the admin impersonating the request's user, or "" when
+// users act for themselves. Log it next to userID so records show who
+// really acted.
+func ActorID(c echo.Context) string {
+	id, _ := c.Get("actorID").(string)
+	return id
+}
+
+// DenyImpersonation refuses impersonated tokens, for changes only the user
+// may make, such as their password, email or 2FA.
+func DenyImpersonation() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if ActorID(c) != "" {
+				return c.JSON(http.StatusForbidden, httputil.NewError(403, "not allowed while impersonating"))
+			}
+			return next(c)
+		}
+	}
+}
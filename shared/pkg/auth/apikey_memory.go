@@ -0,0 +1,94 @@
+package auth
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MemoryAPIKeyStore keeps API keys in process memory. It is meant for
+// tests and single-instance deployments.
+type MemoryAPIKeyStore struct {
+	mu   sync.Mutex
+	keys map[string]*APIKey
+}
+
+func NewMemoryAPIKeyStore() *MemoryAPIKeyStore {
+	return &MemoryAPIKeyStore{keys: map[string]*APIKey{}}
+}
+
+func (s *MemoryAPIKeyStore) CreateAPIKey(ctx context.Context, k *APIKey) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cp := *k
+	s.keys[k.ID] = &cp
+	return nil
+}
+
+func (s *MemoryAPIKeyStore) ListAPIKeys(ctx context.Context, userID string) ([]*APIKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	list := []*APIKey{}
+	for _, k := range s.keys {
+		if k.UserID == userID {
+			cp := *k
+			list = append(list, &cp)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].CreatedAt.After(list[j].CreatedAt) })
+	return list, nil
+}
+
+func (s *MemoryAPIKeyStore) RevokeAPIKey(ctx context.Context, userID, id string, now time.Time) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	k, ok := s.keys[id]
+	if !ok || k.UserID != userID || k.RevokedAt != nil {
+		return false, nil
+	}
+	k.RevokedAt = &now
+	return true, nil
+}
+
+func (s *MemoryAPIKeyStore) RevokeUserAPIKeys(ctx context.Context, userID string, now time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := 0
+	for _, k := range s.keys {
+		if k.UserID == userID && k.RevokedAt == nil {
+			k.RevokedAt = &now
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (s *MemoryAPIKeyStore) SetUserAPIKeyRole(ctx context.Context, userID, role string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, k := range s.keys {
+		if k.UserID == userID {
+			k.Role = role
+		}
+	}
+	return nil
+}
+
+func (s *MemoryAPIKeyStore) ResolveAPIKey(ctx context.Context, token string) (*Claims, error) {
+	hash := HashAPIKey(token)
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, k := range s.keys {
+		if k.Hash != hash {
+			continue
+		}
+		if !k.Active(now) {
+			return nil, ErrInvalidAPIKey
+		}
+		k.LastUsedAt = &now
+		return k.Claims(), nil
+	}
+	return nil, ErrInvalidAPIKey
+}
@@ -0,0 +1,89 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoAPIKeyStore keeps API keys in a collection that the auth service
+// writes and other services read. It expects these indexes:
+//
+//	{ "hash": 1 }, unique
+//	{ "user_id": 1 }
+type MongoAPIKeyStore struct {
+	coll *mongo.Collection
+}
+
+func NewMongoAPIKeyStore(coll *mongo.Collection) *MongoAPIKeyStore {
+	return &MongoAPIKeyStore{coll: coll}
+}
+
+func (s *MongoAPIKeyStore) CreateAPIKey(ctx context.Context, k *APIKey) error {
+	_, err := s.coll.InsertOne(ctx, k)
+	return err
+}
+
+func (s *MongoAPIKeyStore) ListAPIKeys(ctx context.Context, userID string) ([]*APIKey, error) {
+	cursor, err := s.coll.Find(ctx, bson.M{"user_id": userID},
+		options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+	list := []*APIKey{}
+	if err := cursor.All(ctx, &list); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (s *MongoAPIKeyStore) RevokeAPIKey(ctx context.Context, userID, id string, now time.Time) (bool, error) {
+	res, err := s.coll.UpdateOne(ctx,
+		bson.M{"_id": id, "user_id": userID, "revoked_at": nil},
+		bson.M{"$set": bson.M{"revoked_at": now}},
+	)
+	if err != nil {
+		return false, err
+	}
+	return res.ModifiedCount == 1, nil
+}
+
+func (s *MongoAPIKeyStore) RevokeUserAPIKeys(ctx context.Context, userID string, now time.Time) (int, error) {
+	res, err := s.coll.UpdateMany(ctx,
+		bson.M{"user_id": userID, "revoked_at": nil},
+		bson.M{"$set": bson.M{"revoked_at": now}},
+	)
+	if err != nil {
+		return 0, err
+	}
+	return int(res.ModifiedCount), nil
+}
+
+func (s *MongoAPIKeyStore) SetUserAPIKeyRole(ctx context.Context, userID, role string) error {
+	_, err := s.coll.UpdateMany(ctx, bson.M{"user_id": userID}, bson.M{"$set": bson.M{"role": role}})
+	return err
+}
+
+func (s *MongoAPIKeyStore) ResolveAPIKey(ctx context.Context, token string) (*Claims, error) {
+	var k APIKey
+	err := s.coll.FindOne(ctx, bson.M{"hash": HashAPIKey(token)}).Decode(&k)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrInvalidAPIKey
+		}
+		return nil, err
+	}
+	now := time.Now()
+	if !k.Active(now) {
+		return nil, ErrInvalidAPIKey
+	}
+	if k.LastUsedAt == nil || now.Sub(*k.LastUsedAt) > lastUsedGranularity {
+		_, _ = s.coll.UpdateOne(ctx, bson.M{"_id": k.ID}, bson.M{"$set": bson.M{"last_used_at": now}})
+	}
+	return k.Claims(), nil
+}
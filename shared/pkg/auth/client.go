@@ -0,0 +1,39 @@
+package auth
+
+import "github.com/labstack/echo/v4"
+
+// RoleService is the role of tokens issued to OAuth2 clients. Such tokens
+// carry the client ID in sub and no uid.
+const RoleService = "service"
+
+// IsClient reports whether the token was issued to a client rather than a
+// user.
+func (c *Claims) IsClient() bool {
+	return c.UserID == "" && c.Subject != ""
+}
+
+// principal is the user, or for client tokens the client, that user
+// revocations apply to.
+func (c *Claims) principal() string {
+	if c.IsClient() {
+		return c.Subject
+	}
+	return c.UserID
+}
+
+// principals are everyone whose user revocations apply to the token: its
+// principal and, when impersonated, the admin using it, so an admin who is
+// signed out or demoted can't keep acting as someone else.
+func (c *Claims) principals() []string {
+	if c.Impersonated() {
+		return []string{c.principal(), c.Actor.Subject}
+	}
+	return []string{c.principal()}
+}
+
+// ClientID returns the client the request's token was issued to, or "" for
+// user tokens.
+func ClientID(c echo.Context) string {
+	id, _ := c.Get("clientID").(string)
+	return id
+}
@@ -0,0 +1,155 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ClientCredentialsConfig identifies a service to the auth service's
+// token endpoint.
+type ClientCredentialsConfig struct {
+	TokenURL     string
+	ClientID     string
+	ClientSecret string
+	// Scopes to ask for; empty gets every scope the client is registered
+	// with.
+	Scopes []string
+}
+
+// tokens are refreshed this long before they expire, so one is never sent
+// just as it runs out
+const tokenRefreshMargin = 30 * time.Second
+
+// ClientCredentials fetches access tokens with the OAuth2
+// client_credentials grant and caches them until shortly before expiry.
+type ClientCredentials struct {
+	cfg    ClientCredentialsConfig
+	client *http.Client
+
+	mu     sync.Mutex
+	token  string
+	expiry time.Time
+}
+
+func NewClientCredentials(cfg ClientCredentialsConfig) *ClientCredentials {
+	return &ClientCredentials{
+		cfg:    cfg,
+		client: &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+// Token returns a cached token, fetching a new one when needed.
+func (cc *ClientCredentials) Token(ctx context.Context) (string, error) {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+	if cc.token != "" && time.Now().Add(tokenRefreshMargin).Before(cc.expiry) {
+		return cc.token, nil
+	}
+	token, expiry, err := cc.fetch(ctx)
+	if err != nil {
+		return "", err
+	}
+	cc.token, cc.expiry = token, expiry
+	return token, nil
+}
+
+// Invalidate drops the cached token, e.g. after it was refused.
+func (cc *ClientCredentials) Invalidate(token string) {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+	if cc.token == token {
+		cc.token = ""
+	}
+}
+
+// Client returns an HTTP client that sends the token on every request.
+func (cc *ClientCredentials) Client() *http.Client {
+	return &http.Client{Transport: cc.Transport(nil), Timeout: 10 * time.Second}
+}
+
+// Transport wraps base (nil means http.DefaultTransport) so requests carry
+// the token. A request refused with 401 is retried once with a fresh token
+// when its body can be replayed.
+func (cc *ClientCredentials) Transport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &clientCredentialsTransport{cc: cc, base: base}
+}
+
+func (cc *ClientCredentials) fetch(ctx context.Context) (string, time.Time, error) {
+	form := url.Values{"grant_type": {"client_credentials"}}
+	if len(cc.cfg.Scopes) > 0 {
+		form.Set("scope", strings.Join(cc.cfg.Scopes, " "))
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, cc.cfg.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(url.QueryEscape(cc.cfg.ClientID), url.QueryEscape(cc.cfg.ClientSecret))
+	resp, err := cc.client.Do(req)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	defer resp.Body.Close()
+	var body struct {
+		AccessToken      string `json:"access_token"`
+		ExpiresIn        int    `json:"expires_in"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", time.Time{}, fmt.Errorf("token endpoint: status %d: %w", resp.StatusCode, err)
+	}
+	if resp.StatusCode != http.StatusOK || body.AccessToken == "" {
+		return "", time.Time{}, fmt.Errorf("token endpoint: status %d: %s %s", resp.StatusCode, body.Error, body.ErrorDescription)
+	}
+	return body.AccessToken, time.Now().Add(time.Duration(body.ExpiresIn) * time.Second), nil
+}
+
+type clientCredentialsTransport struct {
+	cc   *ClientCredentials
+	base http.RoundTripper
+}
+
+func (t *clientCredentialsTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	token, err := t.cc.Token(req.Context())
+	if err != nil {
+		return nil, err
+	}
+	resp, err := t.send(req, token)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+	// the token may have been revoked or signed with a retired key
+	t.cc.Invalidate(token)
+	if req.Body != nil && req.GetBody == nil {
+		return resp, nil
+	}
+	fresh, err := t.cc.Token(req.Context())
+	if err != nil || fresh == token {
+		return resp, nil
+	}
+	retry := req.Clone(req.Context())
+	if req.GetBody != nil {
+		if retry.Body, err = req.GetBody(); err != nil {
+			return resp, nil
+		}
+	}
+	resp.Body.Close()
+	return t.send(retry, fresh)
+}
+
+func (t *clientCredentialsTransport) send(req *http.Request, token string) (*http.Response, error) {
+	// a RoundTripper must not modify the caller's request
+	r := req.Clone(req.Context())
+	r.Header.Set("Authorization", "Bearer "+token)
+	return t.base.RoundTrip(r)
+}
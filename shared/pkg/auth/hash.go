@@ -1,12 +1,179 @@
 package auth
 
-import "golang.org/x/crypto/bcrypt"
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	AlgorithmArgon2id = "argon2id"
+	AlgorithmBcrypt   = "bcrypt"
+)
+
+// PasswordHasher creates and checks password hashes. Argon2id hashes use
+// the PHC string format ($argon2id$v=19$m=...,t=...,p=...$salt$hash);
+// bcrypt hashes keep their own $2a$ format. Either kind is accepted by
+// Verify whatever the hasher is configured to produce.
+type PasswordHasher interface {
+	Hash(password string) (string, error)
+	// Verify reports whether password matches hash, and whether hash was
+	// made with another algorithm or other parameters and should be
+	// replaced by a fresh Hash.
+	Verify(hash, password string) (ok, rehash bool)
+}
+
+// Argon2Params are the argon2id cost parameters. Memory is in KiB.
+type Argon2Params struct {
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+// DefaultArgon2Params follow the OWASP minimum for argon2id.
+var DefaultArgon2Params = Argon2Params{
+	Memory:      19 * 1024,
+	Iterations:  2,
+	Parallelism: 1,
+	SaltLength:  16,
+	KeyLength:   32,
+}
+
+// HasherConfig selects the algorithm new hashes are made with. Zero values
+// take the defaults: argon2id with DefaultArgon2Params, bcrypt at
+// bcrypt.DefaultCost.
+type HasherConfig struct {
+	Algorithm  string
+	Argon2     Argon2Params
+	BcryptCost int
+}
+
+type Hasher struct {
+	cfg HasherConfig
+}
+
+// DefaultHasher backs HashPassword and CheckPassword.
+var DefaultHasher PasswordHasher = mustHasher(HasherConfig{})
+
+func NewHasher(cfg HasherConfig) (*Hasher, error) {
+	if cfg.Algorithm == "" {
+		cfg.Algorithm = AlgorithmArgon2id
+	}
+	d := DefaultArgon2Params
+	if cfg.Argon2.Memory == 0 {
+		cfg.Argon2.Memory = d.Memory
+	}
+	if cfg.Argon2.Iterations == 0 {
+		cfg.Argon2.Iterations = d.Iterations
+	}
+	if cfg.Argon2.Parallelism == 0 {
+		cfg.Argon2.Parallelism = d.Parallelism
+	}
+	if cfg.Argon2.SaltLength == 0 {
+		cfg.Argon2.SaltLength = d.SaltLength
+	}
+	if cfg.Argon2.KeyLength == 0 {
+		cfg.Argon2.KeyLength = d.KeyLength
+	}
+	if cfg.BcryptCost == 0 {
+		cfg.BcryptCost = bcrypt.DefaultCost
+	}
+	switch cfg.Algorithm {
+	case AlgorithmArgon2id, AlgorithmBcrypt:
+	default:
+		return nil, fmt.Errorf("unsupported password hash algorithm %q", cfg.Algorithm)
+	}
+	if cfg.BcryptCost < bcrypt.MinCost || cfg.BcryptCost > bcrypt.MaxCost {
+		return nil, fmt.Errorf("bcrypt cost %d out of range", cfg.BcryptCost)
+	}
+	return &Hasher{cfg: cfg}, nil
+}
+
+func mustHasher(cfg HasherConfig) *Hasher {
+	h, err := NewHasher(cfg)
+	if err != nil {
+		panic(err)
+	}
+	return h
+}
+
+func (h *Hasher) Hash(password string) (string, error) {
+	if h.cfg.Algorithm == AlgorithmBcrypt {
+		bytes, err := bcrypt.GenerateFromPassword([]byte(password), h.cfg.BcryptCost)
+		return string(bytes), err
+	}
+	p := h.cfg.Argon2
+	salt := make([]byte, p.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key := argon2.IDKey([]byte(password), salt, p.Iterations, p.Memory, p.Parallelism, p.KeyLength)
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, p.Memory, p.Iterations, p.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+func (h *Hasher) Verify(hash, password string) (ok, rehash bool) {
+	if strings.HasPrefix(hash, "$argon2id$") {
+		p, salt, key, err := parseArgon2id(hash)
+		if err != nil {
+			return false, false
+		}
+		got := argon2.IDKey([]byte(password), salt, p.Iterations, p.Memory, p.Parallelism, p.KeyLength)
+		if subtle.ConstantTimeCompare(got, key) != 1 {
+			return false, false
+		}
+		return true, h.cfg.Algorithm != AlgorithmArgon2id || p != h.cfg.Argon2
+	}
+	if bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) != nil {
+		return false, false
+	}
+	cost, err := bcrypt.Cost([]byte(hash))
+	return true, err != nil || h.cfg.Algorithm != AlgorithmBcrypt || cost != h.cfg.BcryptCost
+}
+
+var errMalformedHash = errors.New("malformed argon2id hash")
+
+func parseArgon2id(hash string) (Argon2Params, []byte, []byte, error) {
+	var p Argon2Params
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 {
+		return p, nil, nil, errMalformedHash
+	}
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return p, nil, nil, errMalformedHash
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &p.Memory, &p.Iterations, &p.Parallelism); err != nil {
+		return p, nil, nil, errMalformedHash
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return p, nil, nil, errMalformedHash
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return p, nil, nil, errMalformedHash
+	}
+	p.SaltLength, p.KeyLength = uint32(len(salt)), uint32(len(key))
+	return p, salt, key, nil
+}
 
 func HashPassword(password string) (string, error) {
-	bytes, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
-	return string(bytes), err
+	return DefaultHasher.Hash(password)
 }
 
 func CheckPassword(hash, password string) bool {
-	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
-}
\ No newline at end of file
+	ok, _ := DefaultHasher.Verify(hash, password)
+	return ok
+}
@@ -0,0 +1,175 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// JWK is a public key in RFC 7517 form. Only RSA and Ed25519 (OKP) keys are
+// supported.
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use,omitempty"`
+	Alg string `json:"alg,omitempty"`
+	// RSA
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+	// OKP
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+func NewJWK(kid string, pub crypto.PublicKey) (JWK, error) {
+	enc := base64.RawURLEncoding
+	switch k := pub.(type) {
+	case *rsa.PublicKey:
+		return JWK{
+			Kty: "RSA", Kid: kid, Use: "sig", Alg: "RS256",
+			N: enc.EncodeToString(k.N.Bytes()),
+			E: enc.EncodeToString(big.NewInt(int64(k.E)).Bytes()),
+		}, nil
+	case ed25519.PublicKey:
+		return JWK{
+			Kty: "OKP", Kid: kid, Use: "sig", Alg: "EdDSA",
+			Crv: "Ed25519", X: enc.EncodeToString(k),
+		}, nil
+	}
+	return JWK{}, fmt.Errorf("unsupported key type %T", pub)
+}
+
+func (j JWK) PublicKey() (crypto.PublicKey, error) {
+	dec := base64.RawURLEncoding
+	switch j.Kty {
+	case "RSA":
+		n, err := dec.DecodeString(j.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := dec.DecodeString(j.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "OKP":
+		if j.Crv != "Ed25519" {
+			return nil, fmt.Errorf("unsupported curve %q", j.Crv)
+		}
+		x, err := dec.DecodeString(j.X)
+		if err != nil {
+			return nil, err
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid Ed25519 key size")
+		}
+		return ed25519.PublicKey(x), nil
+	}
+	return nil, fmt.Errorf("unsupported key type %q", j.Kty)
+}
+
+// JWKSVerifier verifies tokens against keys published by the issuer. Keys
+// are cached for the refresh interval; a token with an unknown kid triggers
+// an early refetch, at most once every minRefetch. Fetches run outside the
+// lock and only one is in flight at a time, so a slow issuer doesn't stall
+// checks of tokens whose key is already cached.
+type JWKSVerifier struct {
+	url     string
+	refresh time.Duration
+	client  *http.Client
+
+	mu          sync.Mutex
+	keys        map[string]crypto.PublicKey
+	fetchedAt   time.Time
+	lastAttempt time.Time
+	// inflight is closed when the running fetch finishes
+	inflight chan struct{}
+}
+
+const minRefetch = 10 * time.Second
+
+func NewJWKSVerifier(url string, refresh time.Duration) *JWKSVerifier {
+	return &JWKSVerifier{
+		url:     url,
+		refresh: refresh,
+		client:  &http.Client{Timeout: 5 * time.Second},
+		keys:    map[string]crypto.PublicKey{},
+	}
+}
+
+func (v *JWKSVerifier) Verify(tokenString string) (*Claims, error) {
+	return parseWithKeys(tokenString, v.key)
+}
+
+func (v *JWKSVerifier) key(kid string) (crypto.PublicKey, bool) {
+	v.mu.Lock()
+	now := time.Now()
+	key, ok := v.keys[kid]
+	stale := now.Sub(v.fetchedAt) > v.refresh
+	wait := v.inflight
+	if (!ok || stale) && wait == nil && now.Sub(v.lastAttempt) >= minRefetch {
+		v.lastAttempt = now
+		wait = make(chan struct{})
+		v.inflight = wait
+		go v.refetch(wait)
+	}
+	v.mu.Unlock()
+	// a stale key keeps working while it is refreshed; a missing one has
+	// to wait for the fetch
+	if ok || wait == nil {
+		return key, ok
+	}
+	<-wait
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	key, ok = v.keys[kid]
+	return key, ok
+}
+
+// refetch replaces the cached keys and closes done. On failure the old keys
+// stay.
+func (v *JWKSVerifier) refetch(done chan struct{}) {
+	keys, err := v.fetch()
+	v.mu.Lock()
+	if err == nil {
+		v.keys = keys
+		v.fetchedAt = time.Now()
+	}
+	v.inflight = nil
+	v.mu.Unlock()
+	close(done)
+}
+
+func (v *JWKSVerifier) fetch() (map[string]crypto.PublicKey, error) {
+	resp, err := v.client.Get(v.url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("jwks: unexpected status %d", resp.StatusCode)
+	}
+	var set JWKS
+	if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return nil, err
+	}
+	keys := map[string]crypto.PublicKey{}
+	for _, j := range set.Keys {
+		if pub, err := j.PublicKey(); err == nil {
+			keys[j.Kid] = pub
+		}
+	}
+	return keys, nil
+}
@@ -1,6 +1,8 @@
 package auth
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
 	"time"
 
@@ -8,36 +10,69 @@ import (
 )
 
 type Claims struct {
-	UserID string `json:"uid"`
-	Role   string `json:"role"`
+	UserID    string `json:"uid"`
+	Role      string `json:"role"`
+	SessionID string `json:"sid,omitempty"`
+	// Scopes restrict what the token may do. Empty means the full access
+	// of the user's role, as for a normal login.
+	Scopes []string `json:"scp,omitempty"`
+	// set in OpenID Connect ID tokens
+	Nonce    string           `json:"nonce,omitempty"`
+	AuthTime *jwt.NumericDate `json:"auth_time,omitempty"`
+	// Actor is set when an admin impersonates UserID.
+	Actor *Actor `json:"act,omitempty"`
 	jwt.RegisteredClaims
 }
 
+// NewTokenID returns a random identifier suitable for the jti claim.
+func NewTokenID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
+
 func GenerateTokens(uid, role, accessSecret, refreshSecret string, accMin, refHour int) (acc, ref string, err error) {
+	return GenerateSessionTokens(uid, role, "", "", accessSecret, refreshSecret, accMin, refHour)
+}
+
+// GenerateSessionTokens binds both tokens to session sid and stamps the
+// refresh token with refreshJTI so the issuer can rotate it.
+func GenerateSessionTokens(uid, role, sid, refreshJTI, accessSecret, refreshSecret string, accMin, refHour int) (acc, ref string, err error) {
+	return SignSessionTokens(HMAC(accessSecret), HMAC(refreshSecret), uid, role, sid, refreshJTI, accMin, refHour)
+}
+
+// SignSessionTokens is GenerateSessionTokens with explicit signers, so the
+// access token can be signed with an asymmetric KeySet while the refresh
+// token, which only the issuer reads back, keeps an HMAC secret.
+func SignSessionTokens(access, refresh Signer, uid, role, sid, refreshJTI string, accMin, refHour int) (acc, ref string, err error) {
 	now := time.Now()
-	accClaims := Claims{
-		UserID: uid,
-		Role:   role,
+	accClaims := &Claims{
+		UserID:    uid,
+		Role:      role,
+		SessionID: sid,
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        NewTokenID(),
 			ExpiresAt: jwt.NewNumericDate(now.Add(time.Duration(accMin) * time.Minute)),
 			IssuedAt:  jwt.NewNumericDate(now),
 		},
 	}
-	accToken := jwt.NewWithClaims(jwt.SigningMethodHS256, accClaims)
-	acc, err = accToken.SignedString([]byte(accessSecret))
+	acc, err = access.Sign(accClaims)
 	if err != nil {
 		return "", "", err
 	}
-	refClaims := Claims{
-		UserID: uid,
-		Role:   role,
+	refClaims := &Claims{
+		UserID:    uid,
+		Role:      role,
+		SessionID: sid,
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        refreshJTI,
 			ExpiresAt: jwt.NewNumericDate(now.Add(time.Duration(refHour) * time.Hour)),
 			IssuedAt:  jwt.NewNumericDate(now),
 		},
 	}
-	refToken := jwt.NewWithClaims(jwt.SigningMethodHS256, refClaims)
-	ref, err = refToken.SignedString([]byte(refreshSecret))
+	ref, err = refresh.Sign(refClaims)
 	return acc, ref, err
 }
 
@@ -55,4 +90,4 @@ func ValidateToken(tokenString, secret string) (*Claims, error) {
 		return claims, nil
 	}
 	return nil, fmt.Errorf("invalid token")
-}
\ No newline at end of file
+}
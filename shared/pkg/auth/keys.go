@@ -0,0 +1,181 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Signer signs access tokens.
+type Signer interface {
+	Sign(claims *Claims) (string, error)
+}
+
+// Verifier parses a token and checks its signature and expiry.
+type Verifier interface {
+	Verify(tokenString string) (*Claims, error)
+}
+
+// HMACKey signs and verifies HS256 tokens with a shared secret.
+type HMACKey []byte
+
+func HMAC(secret string) HMACKey { return HMACKey(secret) }
+
+func (k HMACKey) Sign(claims *Claims) (string, error) {
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(k))
+}
+
+func (k HMACKey) Verify(tokenString string) (*Claims, error) {
+	return ValidateToken(tokenString, string(k))
+}
+
+// SigningKey is an asymmetric private key identified by the kid header.
+type SigningKey struct {
+	ID     string
+	Method jwt.SigningMethod
+	Key    crypto.Signer
+}
+
+// NewSigningKey picks the JWT algorithm from the key type: RS256 for RSA,
+// EdDSA for Ed25519.
+func NewSigningKey(kid string, key crypto.Signer) (SigningKey, error) {
+	switch key.(type) {
+	case *rsa.PrivateKey:
+		return SigningKey{ID: kid, Method: jwt.SigningMethodRS256, Key: key}, nil
+	case ed25519.PrivateKey:
+		return SigningKey{ID: kid, Method: jwt.SigningMethodEdDSA, Key: key}, nil
+	}
+	return SigningKey{}, fmt.Errorf("unsupported key type %T", key)
+}
+
+// GenerateSigningKey creates a fresh key for alg "RS256" or "EdDSA".
+func GenerateSigningKey(kid, alg string) (SigningKey, error) {
+	var key crypto.Signer
+	var err error
+	switch alg {
+	case "RS256":
+		key, err = rsa.GenerateKey(rand.Reader, 2048)
+	case "EdDSA", "":
+		_, key, err = ed25519.GenerateKey(rand.Reader)
+	default:
+		return SigningKey{}, fmt.Errorf("unsupported algorithm %q", alg)
+	}
+	if err != nil {
+		return SigningKey{}, err
+	}
+	return NewSigningKey(kid, key)
+}
+
+// ParseSigningKeyPEM reads a PKCS#8 (RSA or Ed25519) or PKCS#1 (RSA) key.
+func ParseSigningKeyPEM(kid string, data []byte) (SigningKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return SigningKey{}, errors.New("no PEM block found")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return NewSigningKey(kid, key)
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return SigningKey{}, err
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return SigningKey{}, fmt.Errorf("unsupported key type %T", key)
+	}
+	return NewSigningKey(kid, signer)
+}
+
+// KeySet signs with one active key and verifies with any of its keys, so a
+// new key can be rolled out while tokens signed by the old one are still
+// in circulation.
+type KeySet struct {
+	active string
+	keys   map[string]SigningKey
+	order  []string
+}
+
+func NewKeySet(activeKID string, keys ...SigningKey) (*KeySet, error) {
+	ks := &KeySet{active: activeKID, keys: map[string]SigningKey{}}
+	for _, k := range keys {
+		if _, dup := ks.keys[k.ID]; dup {
+			return nil, fmt.Errorf("duplicate kid %q", k.ID)
+		}
+		ks.keys[k.ID] = k
+		ks.order = append(ks.order, k.ID)
+	}
+	if _, ok := ks.keys[activeKID]; !ok {
+		return nil, fmt.Errorf("active kid %q not in key set", activeKID)
+	}
+	return ks, nil
+}
+
+func (ks *KeySet) Sign(claims *Claims) (string, error) {
+	k := ks.keys[ks.active]
+	t := jwt.NewWithClaims(k.Method, claims)
+	t.Header["kid"] = k.ID
+	return t.SignedString(k.Key)
+}
+
+func (ks *KeySet) Verify(tokenString string) (*Claims, error) {
+	return parseWithKeys(tokenString, func(kid string) (crypto.PublicKey, bool) {
+		k, ok := ks.keys[kid]
+		if !ok {
+			return nil, false
+		}
+		return k.Key.Public(), true
+	})
+}
+
+// JWKS returns the public half of every key for publishing.
+func (ks *KeySet) JWKS() JWKS {
+	set := JWKS{Keys: []JWK{}}
+	for _, kid := range ks.order {
+		k := ks.keys[kid]
+		jwk, err := NewJWK(k.ID, k.Key.Public())
+		if err != nil {
+			continue
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	return set
+}
+
+// parseWithKeys verifies an RS256 or EdDSA token using the key its kid
+// header names, rejecting algorithms that don't match the key type.
+func parseWithKeys(tokenString string, lookup func(kid string) (crypto.PublicKey, bool)) (*Claims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		key, ok := lookup(kid)
+		if !ok {
+			return nil, fmt.Errorf("unknown signing key %q", kid)
+		}
+		switch key.(type) {
+		case *rsa.PublicKey:
+			if t.Method != jwt.SigningMethodRS256 {
+				return nil, fmt.Errorf("unexpected signing method: %v", t.Header["alg"])
+			}
+		case ed25519.PublicKey:
+			if t.Method != jwt.SigningMethodEdDSA {
+				return nil, fmt.Errorf("unexpected signing method: %v", t.Header["alg"])
+			}
+		default:
+			return nil, fmt.Errorf("unsupported key type %T", key)
+		}
+		return key, nil
+	}, jwt.WithValidMethods([]string{"RS256", "EdDSA"}))
+	if err != nil {
+		return nil, err
+	}
+	if claims, ok := token.Claims.(*Claims); ok && token.Valid {
+		return claims, nil
+	}
+	return nil, fmt.Errorf("invalid token")
+}
@@ -1,14 +1,52 @@
 package auth
 
 import (
+	"errors"
 	"net/http"
 	"strings"
 
 	"github.com/HatefBarari/microblog-shared/pkg/httputil"
+	"github.com/HatefBarari/microblog-shared/pkg/rbac"
 	"github.com/labstack/echo/v4"
 )
 
-func Middleware(accessSecret string) echo.MiddlewareFunc {
+type MiddlewareOption func(*middlewareConfig)
+
+type middlewareConfig struct {
+	revocations RevocationChecker
+	apiKeys     APIKeyResolver
+	policy      *rbac.Policy
+	audience    string
+}
+
+// WithRevocationChecker makes the middleware refuse tokens the checker
+// reports as revoked.
+func WithRevocationChecker(chk RevocationChecker) MiddlewareOption {
+	return func(cfg *middlewareConfig) { cfg.revocations = chk }
+}
+
+// WithAPIKeys also accepts personal access tokens (APIKeyPrefix) in the
+// Authorization header, resolved through r. They are not subject to the
+// revocation checker: ending sessions leaves API keys working.
+func WithAPIKeys(r APIKeyResolver) MiddlewareOption {
+	return func(cfg *middlewareConfig) { cfg.apiKeys = r }
+}
+
+// WithAudience accepts only tokens issued for aud, such as the access tokens
+// of OpenID Connect clients at /userinfo. Without it tokens that name any
+// audience are refused, so an ID token or a token meant for one endpoint
+// cannot be used as a general access token.
+func WithAudience(aud string) MiddlewareOption {
+	return func(cfg *middlewareConfig) { cfg.audience = aud }
+}
+
+// Middleware authenticates bearer tokens with verifier: a KeySet inside the
+// issuer, a JWKSVerifier in downstream services, or HMAC for shared secrets.
+func Middleware(verifier Verifier, opts ...MiddlewareOption) echo.MiddlewareFunc {
+	cfg := &middlewareConfig{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
 			authHeader := c.Request().Header.Get("Authorization")
@@ -19,13 +57,65 @@ func Middleware(accessSecret string) echo.MiddlewareFunc {
 			if len(bearer) != 2 || bearer[0] != "Bearer" {
 				return c.JSON(http.StatusUnauthorized, httputil.NewError(401, "invalid token format"))
 			}
-			claims, err := ValidateToken(bearer[1], accessSecret)
-			if err != nil {
-				return c.JSON(http.StatusUnauthorized, httputil.NewError(401, "invalid or expired token"))
+			var claims *Claims
+			if cfg.apiKeys != nil && IsAPIKey(bearer[1]) {
+				resolved, err := cfg.apiKeys.ResolveAPIKey(c.Request().Context(), bearer[1])
+				if errors.Is(err, ErrInvalidAPIKey) {
+					return c.JSON(http.StatusUnauthorized, httputil.NewError(401, err.Error()))
+				}
+				if err != nil {
+					c.Logger().Errorf("resolve api key: %v", err)
+					return c.JSON(http.StatusServiceUnavailable, httputil.NewError(503, "unable to verify token"))
+				}
+				claims = resolved
+			} else {
+				verified, err := verifier.Verify(bearer[1])
+				if err != nil {
+					return c.JSON(http.StatusUnauthorized, httputil.NewError(401, "invalid or expired token"))
+				}
+				claims = verified
+			}
+			if !audienceAllowed(claims, cfg.audience) {
+				return c.JSON(http.StatusUnauthorized, httputil.NewError(401, "token is not valid for this service"))
+			}
+			// API keys are read live from their store, which already
+			// reflects revocation; the checker covers issued JWTs
+			if cfg.revocations != nil && !IsAPIKey(bearer[1]) {
+				revoked, err := cfg.revocations.IsRevoked(c.Request().Context(), claims)
+				if err != nil {
+					c.Logger().Errorf("revocation check: %v", err)
+					return c.JSON(http.StatusServiceUnavailable, httputil.NewError(503, "unable to verify token"))
+				}
+				if revoked {
+					return c.JSON(http.StatusUnauthorized, httputil.NewError(401, "token has been revoked"))
+				}
 			}
 			c.Set("userID", claims.UserID)
 			c.Set("role", claims.Role)
+			c.Set("sessionID", claims.SessionID)
+			c.Set("scopes", claims.Scopes)
+			if claims.IsClient() {
+				c.Set("clientID", claims.Subject)
+			}
+			if claims.Impersonated() {
+				c.Set("actorID", claims.Actor.Subject)
+			}
+			if cfg.policy != nil {
+				c.Set("permissions", cfg.policy.Permissions(claims.Role))
+			}
 			return next(c)
 		}
 	}
-}
\ No newline at end of file
+}
+
+func audienceAllowed(claims *Claims, aud string) bool {
+	if aud == "" {
+		return len(claims.Audience) == 0
+	}
+	for _, a := range claims.Audience {
+		if a == aud {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,46 @@
+package auth
+
+import (
+	"net/http"
+
+	"github.com/HatefBarari/microblog-shared/pkg/httputil"
+	"github.com/HatefBarari/microblog-shared/pkg/rbac"
+	"github.com/labstack/echo/v4"
+)
+
+// WithPolicy resolves the token's role to permissions through p and puts
+// them on the context for Require and Permissions.
+func WithPolicy(p *rbac.Policy) MiddlewareOption {
+	return func(cfg *middlewareConfig) { cfg.policy = p }
+}
+
+// Permissions returns the permissions of the authenticated user's role.
+func Permissions(c echo.Context) []string {
+	perms, _ := c.Get("permissions").([]string)
+	return perms
+}
+
+// HasPermission reports whether the authenticated user's role grants perm.
+func HasPermission(c echo.Context, perm string) bool {
+	for _, p := range Permissions(c) {
+		if p == perm {
+			return true
+		}
+	}
+	return false
+}
+
+// Require refuses requests whose role lacks any of perms. It must run after
+// a Middleware configured WithPolicy.
+func Require(perms ...string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			for _, p := range perms {
+				if !HasPermission(c, p) {
+					return c.JSON(http.StatusForbidden, httputil.NewError(403, "missing permission "+p))
+				}
+			}
+			return next(c)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package auth
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// RevocationChecker reports whether an otherwise valid access token must be
+// refused, e.g. because its session was logged out or its user was banned.
+type RevocationChecker interface {
+	IsRevoked(ctx context.Context, claims *Claims) (bool, error)
+}
+
+// RevocationStore is a RevocationChecker that can also record revocations.
+// The issuing service writes to it; other services only read.
+type RevocationStore interface {
+	RevocationChecker
+	// RevokeToken denies every token whose jti or sid equals id. The entry
+	// can be forgotten after until, once those tokens have expired anyway.
+	RevokeToken(ctx context.Context, id string, until time.Time) error
+	// RevokeUser denies the user's tokens issued before the given time. For
+	// client tokens userID is the client ID.
+	// iat only has whole-second precision, so tokens issued in the same
+	// second as the revocation are denied too; a refresh racing a
+	// logout-all must not survive it.
+	RevokeUser(ctx context.Context, userID string, before, until time.Time) error
+}
+
+// issuedBy reports whether claims were issued no later than the second t
+// falls in, the precision of the iat claim.
+func issuedBy(claims *Claims, t time.Time) bool {
+	if claims.IssuedAt == nil {
+		return true
+	}
+	return !claims.IssuedAt.Time.After(t.Truncate(time.Second))
+}
+
+type cachedRevocationChecker struct {
+	next RevocationChecker
+	ttl  time.Duration
+
+	mu      sync.Mutex
+	entries map[string]cachedRevocation
+}
+
+type cachedRevocation struct {
+	revoked bool
+	expires time.Time
+}
+
+// NewCachedRevocationChecker remembers each token's answer for ttl so that
+// downstream services don't hit the store on every request. A revocation
+// therefore takes up to ttl to be seen.
+func NewCachedRevocationChecker(next RevocationChecker, ttl time.Duration) RevocationChecker {
+	return &cachedRevocationChecker{next: next, ttl: ttl, entries: map[string]cachedRevocation{}}
+}
+
+func (c *cachedRevocationChecker) IsRevoked(ctx context.Context, claims *Claims) (bool, error) {
+	key := claims.ID
+	if key == "" && claims.IssuedAt != nil {
+		key = claims.principal() + "@" + claims.IssuedAt.Time.String()
+	}
+	if key == "" {
+		return c.next.IsRevoked(ctx, claims)
+	}
+	now := time.Now()
+	c.mu.Lock()
+	e, ok := c.entries[key]
+	c.mu.Unlock()
+	if ok && now.Before(e.expires) {
+		return e.revoked, nil
+	}
+
+	revoked, err := c.next.IsRevoked(ctx, claims)
+	if err != nil {
+		return false, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.entries) > 10000 {
+		for k, v := range c.entries {
+			if !now.Before(v.expires) {
+				delete(c.entries, k)
+			}
+		}
+	}
+	c.entries[key] = cachedRevocation{revoked: revoked, expires: now.Add(c.ttl)}
+	return revoked, nil
+}
@@ -0,0 +1,72 @@
+package auth
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryRevocationStore keeps revocations in process memory. It is meant for
+// tests and single-instance deployments.
+type MemoryRevocationStore struct {
+	mu     sync.RWMutex
+	tokens map[string]time.Time
+	users  map[string]memoryUserRevocation
+}
+
+type memoryUserRevocation struct {
+	before time.Time
+	until  time.Time
+}
+
+func NewMemoryRevocationStore() *MemoryRevocationStore {
+	return &MemoryRevocationStore{
+		tokens: map[string]time.Time{},
+		users:  map[string]memoryUserRevocation{},
+	}
+}
+
+func (s *MemoryRevocationStore) RevokeToken(ctx context.Context, id string, until time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if cur, ok := s.tokens[id]; !ok || until.After(cur) {
+		s.tokens[id] = until
+	}
+	return nil
+}
+
+func (s *MemoryRevocationStore) RevokeUser(ctx context.Context, userID string, before, until time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cur, ok := s.users[userID]
+	if ok && cur.before.After(before) {
+		before = cur.before
+	}
+	if ok && cur.until.After(until) {
+		until = cur.until
+	}
+	s.users[userID] = memoryUserRevocation{before: before, until: until}
+	return nil
+}
+
+func (s *MemoryRevocationStore) IsRevoked(ctx context.Context, claims *Claims) (bool, error) {
+	now := time.Now()
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, id := range []string{claims.ID, claims.SessionID} {
+		if id == "" {
+			continue
+		}
+		if until, ok := s.tokens[id]; ok && now.Before(until) {
+			return true, nil
+		}
+	}
+	for _, p := range claims.principals() {
+		if u, ok := s.users[p]; ok && now.Before(u.until) && issuedBy(claims, u.before) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+var _ RevocationStore = (*MemoryRevocationStore)(nil)
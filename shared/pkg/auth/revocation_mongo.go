@@ -0,0 +1,82 @@
+package auth
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoRevocationStore keeps revocations in a collection shared by all
+// services. Documents are keyed "token:<jti or sid>" or "user:<uid>" and
+// carry an expires_at field for a TTL index:
+//
+//	{ "expires_at": 1 }, expireAfterSeconds: 0
+type MongoRevocationStore struct {
+	coll *mongo.Collection
+}
+
+type mongoRevocation struct {
+	ID        string    `bson:"_id"`
+	Before    time.Time `bson:"before,omitempty"`
+	ExpiresAt time.Time `bson:"expires_at"`
+}
+
+func NewMongoRevocationStore(coll *mongo.Collection) *MongoRevocationStore {
+	return &MongoRevocationStore{coll: coll}
+}
+
+func (s *MongoRevocationStore) RevokeToken(ctx context.Context, id string, until time.Time) error {
+	_, err := s.coll.UpdateOne(ctx,
+		bson.M{"_id": "token:" + id},
+		bson.M{"$max": bson.M{"expires_at": until}},
+		options.Update().SetUpsert(true),
+	)
+	return err
+}
+
+func (s *MongoRevocationStore) RevokeUser(ctx context.Context, userID string, before, until time.Time) error {
+	_, err := s.coll.UpdateOne(ctx,
+		bson.M{"_id": "user:" + userID},
+		bson.M{"$max": bson.M{"before": before, "expires_at": until}},
+		options.Update().SetUpsert(true),
+	)
+	return err
+}
+
+func (s *MongoRevocationStore) IsRevoked(ctx context.Context, claims *Claims) (bool, error) {
+	var ids []string
+	for _, p := range claims.principals() {
+		ids = append(ids, "user:"+p)
+	}
+	if claims.ID != "" {
+		ids = append(ids, "token:"+claims.ID)
+	}
+	if claims.SessionID != "" {
+		ids = append(ids, "token:"+claims.SessionID)
+	}
+	now := time.Now()
+	cursor, err := s.coll.Find(ctx, bson.M{
+		"_id":        bson.M{"$in": ids},
+		"expires_at": bson.M{"$gt": now},
+	})
+	if err != nil {
+		return false, err
+	}
+	defer cursor.Close(ctx)
+	var docs []mongoRevocation
+	if err := cursor.All(ctx, &docs); err != nil {
+		return false, err
+	}
+	for _, d := range docs {
+		if !strings.HasPrefix(d.ID, "user:") || issuedBy(claims, d.Before) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+var _ RevocationStore = (*MongoRevocationStore)(nil)
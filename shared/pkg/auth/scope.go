@@ -0,0 +1,46 @@
+package auth
+
+import (
+	"net/http"
+
+	"github.com/HatefBarari/microblog-shared/pkg/httputil"
+	"github.com/labstack/echo/v4"
+)
+
+// Scopes returns the scopes of the authenticated token. Nil means the token
+// is not scope-restricted.
+func Scopes(c echo.Context) []string {
+	scopes, _ := c.Get("scopes").([]string)
+	return scopes
+}
+
+// HasScope reports whether the request's token may act within scope.
+// Tokens without scopes, such as login sessions, may do anything their
+// role allows.
+func HasScope(c echo.Context, scope string) bool {
+	scopes := Scopes(c)
+	if len(scopes) == 0 {
+		return true
+	}
+	for _, s := range scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// RequireScope refuses scope-restricted tokens that lack any of scopes.
+// It must run after Middleware.
+func RequireScope(scopes ...string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			for _, s := range scopes {
+				if !HasScope(c, s) {
+					return c.JSON(http.StatusForbidden, httputil.NewError(403, "token lacks scope "+s))
+				}
+			}
+			return next(c)
+		}
+	}
+}
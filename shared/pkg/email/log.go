@@ -0,0 +1,24 @@
+package email
+
+import "go.uber.org/zap"
+
+// LogTransport logs messages instead of sending them.
+type LogTransport struct {
+	log *zap.Logger
+}
+
+func NewLogTransport(log *zap.Logger) *LogTransport {
+	if log == nil {
+		log = zap.NewNop()
+	}
+	return &LogTransport{log: log}
+}
+
+func (t *LogTransport) Deliver(from string, m *Message) error {
+	t.log.Info("email",
+		zap.String("from", from),
+		zap.String("to", m.To),
+		zap.String("subject", m.Subject),
+		zap.String("text", m.Text))
+	return nil
+}
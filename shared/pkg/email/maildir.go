@@ -0,0 +1,51 @@
+package email
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// MaildirTransport writes each message into a maildir instead of sending
+// it, for local development. Mail clients such as mutt open it directly.
+type MaildirTransport struct {
+	dir  string
+	host string
+	seq  atomic.Uint64
+}
+
+func NewMaildirTransport(dir string) (*MaildirTransport, error) {
+	if dir == "" {
+		return nil, errors.New("file email transport needs a directory")
+	}
+	for _, sub := range []string{"tmp", "new", "cur"} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0o700); err != nil {
+			return nil, err
+		}
+	}
+	host, err := os.Hostname()
+	if err != nil {
+		host = "localhost"
+	}
+	// '/' and ':' would break the file name
+	host = strings.NewReplacer("/", `\057`, ":", `\072`).Replace(host)
+	return &MaildirTransport{dir: dir, host: host}, nil
+}
+
+func (t *MaildirTransport) Deliver(from string, m *Message) error {
+	msg, err := m.Encode(from)
+	if err != nil {
+		return err
+	}
+	// written to tmp first so readers never see a partial message
+	name := fmt.Sprintf("%d.P%dQ%d.%s", time.Now().Unix(), os.Getpid(), t.seq.Add(1), t.host)
+	tmp := filepath.Join(t.dir, "tmp", name)
+	if err := os.WriteFile(tmp, msg, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filepath.Join(t.dir, "new", name))
+}
@@ -0,0 +1,51 @@
+package email
+
+import "sync"
+
+// Delivery is one message a Recorder received.
+type Delivery struct {
+	From    string
+	Message Message
+}
+
+// Recorder keeps messages in memory, for tests.
+type Recorder struct {
+	mu         sync.Mutex
+	deliveries []Delivery
+	err        error
+}
+
+func NewRecorder() *Recorder {
+	return &Recorder{}
+}
+
+func (r *Recorder) Deliver(from string, m *Message) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err != nil {
+		return r.err
+	}
+	r.deliveries = append(r.deliveries, Delivery{From: from, Message: *m})
+	return nil
+}
+
+// Deliveries returns what was recorded so far, oldest first.
+func (r *Recorder) Deliveries() []Delivery {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Delivery(nil), r.deliveries...)
+}
+
+// Fail makes later deliveries return err, until called with nil.
+func (r *Recorder) Fail(err error) {
+	r.mu.Lock()
+	r.err = err
+	r.mu.Unlock()
+}
+
+// Reset forgets the recorded messages.
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	r.deliveries = nil
+	r.mu.Unlock()
+}
@@ -1,28 +1,67 @@
 package email
 
 import (
-	"fmt"
-	"net/smtp"
+	"io"
+	"time"
+
+	"go.uber.org/zap"
 )
 
 type Config struct {
-	Host string
-	Port string
-	User string
-	Pass string
+	// Transport is "smtp" (the default), "file" or "log".
+	Transport string
+	Host      string
+	Port      string
+	User      string
+	Pass      string
+	// TLS is one of the TLS* modes; empty uses STARTTLS when offered.
+	TLS string
+	// PoolSize is how many idle SMTP connections are kept for reuse.
+	PoolSize int
+	// Timeout bounds connecting and each message; zero means 30 seconds.
+	Timeout time.Duration
+	// Dir is the maildir the file transport writes to.
+	Dir string
+	// From is the sender address; empty uses User.
+	From string
 }
 
 type Sender struct {
-	cfg Config
+	transport Transport
+	from      string
+}
+
+// NewSender sends through the transport cfg selects.
+func NewSender(cfg Config, log *zap.Logger) (*Sender, error) {
+	t, err := NewTransport(cfg, log)
+	if err != nil {
+		return nil, err
+	}
+	from := cfg.From
+	if from == "" {
+		from = cfg.User
+	}
+	return NewSenderWithTransport(t, from), nil
 }
 
-func NewSender(cfg Config) *Sender {
-	return &Sender{cfg: cfg}
+func NewSenderWithTransport(t Transport, from string) *Sender {
+	return &Sender{transport: t, from: from}
 }
 
+// Send sends a plain-text message.
 func (s *Sender) Send(to, subject, body string) error {
-	msg := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: %s\r\n\r\n%s", s.cfg.User, to, subject, body)
-	addr := s.cfg.Host + ":" + s.cfg.Port
-	auth := smtp.PlainAuth("", s.cfg.User, s.cfg.Pass, s.cfg.Host)
-	return smtp.SendMail(addr, auth, s.cfg.User, []string{to}, []byte(msg))
-}
\ No newline at end of file
+	return s.SendMessage(&Message{To: to, Subject: subject, Text: body})
+}
+
+// SendMessage sends m, as multipart/alternative when it has an HTML body.
+func (s *Sender) SendMessage(m *Message) error {
+	return s.transport.Deliver(s.from, m)
+}
+
+// Close releases the transport's connections, if it keeps any.
+func (s *Sender) Close() error {
+	if c, ok := s.transport.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
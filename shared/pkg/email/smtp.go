@@ -0,0 +1,201 @@
+package email
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"sync"
+	"time"
+)
+
+// TLS modes of the SMTP transport.
+const (
+	// TLSOpportunistic upgrades with STARTTLS when the server offers it.
+	TLSOpportunistic = ""
+	TLSStartTLS      = "starttls"
+	// TLSImplicit speaks TLS from the start, usually on port 465.
+	TLSImplicit = "tls"
+	TLSNone     = "none"
+)
+
+const (
+	defaultSMTPTimeout = 30 * time.Second
+	// pooled connections idle for longer are dropped rather than reused;
+	// servers tend to close them after a few minutes anyway
+	smtpIdleTimeout = time.Minute
+)
+
+// SMTPTransport sends through an SMTP server, keeping up to PoolSize idle
+// connections open for the next messages.
+type SMTPTransport struct {
+	cfg    Config
+	addr   string
+	tls    *tls.Config
+	mu     sync.Mutex
+	idle   []*smtpConn
+	closed bool
+}
+
+type smtpConn struct {
+	conn   net.Conn
+	client *smtp.Client
+	used   time.Time
+}
+
+func NewSMTPTransport(cfg Config) (*SMTPTransport, error) {
+	switch cfg.TLS {
+	case TLSOpportunistic, TLSStartTLS, TLSImplicit, TLSNone:
+	default:
+		return nil, fmt.Errorf("unknown smtp tls mode %q", cfg.TLS)
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = defaultSMTPTimeout
+	}
+	return &SMTPTransport{
+		cfg:  cfg,
+		addr: net.JoinHostPort(cfg.Host, cfg.Port),
+		tls:  &tls.Config{ServerName: cfg.Host},
+	}, nil
+}
+
+func (t *SMTPTransport) Deliver(from string, m *Message) error {
+	msg, err := m.Encode(from)
+	if err != nil {
+		return err
+	}
+	// Encode has already checked both addresses
+	fromAddr, _ := mail.ParseAddress(from)
+	toAddr, _ := mail.ParseAddress(m.To)
+
+	c, err := t.get()
+	if err != nil {
+		return err
+	}
+	if err := t.send(c, fromAddr.Address, toAddr.Address, msg); err != nil {
+		c.client.Close()
+		return err
+	}
+	t.put(c)
+	return nil
+}
+
+// Close quits the pooled connections. Later messages still go out, each on
+// its own connection.
+func (t *SMTPTransport) Close() error {
+	t.mu.Lock()
+	idle := t.idle
+	t.idle = nil
+	t.closed = true
+	t.mu.Unlock()
+	for _, c := range idle {
+		_ = c.client.Quit()
+	}
+	return nil
+}
+
+func (t *SMTPTransport) send(c *smtpConn, from, to string, msg []byte) error {
+	if err := c.conn.SetDeadline(time.Now().Add(t.cfg.Timeout)); err != nil {
+		return err
+	}
+	if err := c.client.Mail(from); err != nil {
+		return err
+	}
+	if err := c.client.Rcpt(to); err != nil {
+		return err
+	}
+	w, err := c.client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	return w.Close()
+}
+
+// get returns a pooled connection that still answers, or a new one.
+func (t *SMTPTransport) get() (*smtpConn, error) {
+	for {
+		t.mu.Lock()
+		var c *smtpConn
+		if n := len(t.idle); n > 0 {
+			c = t.idle[n-1]
+			t.idle = t.idle[:n-1]
+		}
+		t.mu.Unlock()
+		if c == nil {
+			return t.dial()
+		}
+		if time.Since(c.used) < smtpIdleTimeout &&
+			c.conn.SetDeadline(time.Now().Add(t.cfg.Timeout)) == nil &&
+			c.client.Noop() == nil {
+			return c, nil
+		}
+		c.client.Close()
+	}
+}
+
+func (t *SMTPTransport) put(c *smtpConn) {
+	c.used = time.Now()
+	t.mu.Lock()
+	if !t.closed && len(t.idle) < t.cfg.PoolSize {
+		t.idle = append(t.idle, c)
+		t.mu.Unlock()
+		return
+	}
+	t.mu.Unlock()
+	_ = c.client.Quit()
+}
+
+func (t *SMTPTransport) dial() (*smtpConn, error) {
+	dialer := &net.Dialer{Timeout: t.cfg.Timeout}
+	var conn net.Conn
+	var err error
+	if t.cfg.TLS == TLSImplicit {
+		conn, err = tls.DialWithDialer(dialer, "tcp", t.addr, t.tls)
+	} else {
+		conn, err = dialer.Dial("tcp", t.addr)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := conn.SetDeadline(time.Now().Add(t.cfg.Timeout)); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	client, err := smtp.NewClient(conn, t.cfg.Host)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if err := t.hello(client); err != nil {
+		client.Close()
+		return nil, err
+	}
+	return &smtpConn{conn: conn, client: client}, nil
+}
+
+// hello secures and authenticates a new connection.
+func (t *SMTPTransport) hello(c *smtp.Client) error {
+	if t.cfg.TLS == TLSOpportunistic || t.cfg.TLS == TLSStartTLS {
+		ok, _ := c.Extension("STARTTLS")
+		switch {
+		case ok:
+			if err := c.StartTLS(t.tls); err != nil {
+				return err
+			}
+		case t.cfg.TLS == TLSStartTLS:
+			return errors.New("smtp server does not offer STARTTLS")
+		}
+	}
+	if t.cfg.User != "" {
+		// PlainAuth refuses to send the password unencrypted, except to localhost
+		if err := c.Auth(smtp.PlainAuth("", t.cfg.User, t.cfg.Pass, t.cfg.Host)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,180 @@
+package email
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+	texttemplate "text/template"
+)
+
+// DefaultLocale is used when a template has no variant for the requested
+// locale.
+const DefaultLocale = "fa"
+
+//go:embed templates
+var builtin embed.FS
+
+// Templates renders named emails in several locales. Each locale directory
+// holds, per template name:
+//
+//	<name>.subject.txt   subject line (text/template)
+//	<name>.txt           plain-text body (text/template)
+//	<name>.html          HTML body (html/template), optional
+//	layout.html          wraps HTML bodies that define "content"
+type Templates struct {
+	locales map[string]map[string]*template
+}
+
+type template struct {
+	subject *texttemplate.Template
+	text    *texttemplate.Template
+	html    *htmltemplate.Template
+}
+
+var ErrTemplateNotFound = errors.New("email template not found")
+
+// LoadTemplates parses the built-in templates, replacing any file that also
+// exists under overrideDir (same <locale>/<file> layout). Locales present
+// only in overrideDir are added.
+func LoadTemplates(overrideDir string) (*Templates, error) {
+	files := map[string]map[string]string{}
+	if err := collect(files, builtin, "templates"); err != nil {
+		return nil, err
+	}
+	if overrideDir != "" {
+		if err := collect(files, os.DirFS(overrideDir), "."); err != nil {
+			return nil, fmt.Errorf("email templates in %s: %w", overrideDir, err)
+		}
+	}
+	t := &Templates{locales: map[string]map[string]*template{}}
+	for locale, byFile := range files {
+		t.locales[locale] = map[string]*template{}
+		for file := range byFile {
+			if !strings.HasSuffix(file, ".subject.txt") {
+				continue
+			}
+			name := strings.TrimSuffix(file, ".subject.txt")
+			tpl, err := parse(name, byFile)
+			if err != nil {
+				return nil, fmt.Errorf("email template %s/%s: %w", locale, name, err)
+			}
+			t.locales[locale][name] = tpl
+		}
+	}
+	return t, nil
+}
+
+var defaultTemplates = func() *Templates {
+	t, err := LoadTemplates("")
+	if err != nil {
+		panic(err)
+	}
+	return t
+}()
+
+// DefaultTemplates returns the built-in templates.
+func DefaultTemplates() *Templates {
+	return defaultTemplates
+}
+
+// Render fills the named template for locale, falling back to
+// DefaultLocale. "en-US" selects "en".
+func (t *Templates) Render(name, locale string, data interface{}) (*Message, error) {
+	tpl := t.lookup(name, locale)
+	if tpl == nil {
+		return nil, fmt.Errorf("%w: %s", ErrTemplateNotFound, name)
+	}
+	var subject, text, html bytes.Buffer
+	if err := tpl.subject.Execute(&subject, data); err != nil {
+		return nil, err
+	}
+	if err := tpl.text.Execute(&text, data); err != nil {
+		return nil, err
+	}
+	if tpl.html != nil {
+		if err := tpl.html.Execute(&html, data); err != nil {
+			return nil, err
+		}
+	}
+	return &Message{
+		Subject: strings.TrimSpace(subject.String()),
+		Text:    text.String(),
+		HTML:    html.String(),
+	}, nil
+}
+
+func (t *Templates) lookup(name, locale string) *template {
+	locale = strings.ToLower(locale)
+	if i := strings.IndexAny(locale, "-_"); i > 0 {
+		locale = locale[:i]
+	}
+	if tpl := t.locales[locale][name]; tpl != nil {
+		return tpl
+	}
+	return t.locales[DefaultLocale][name]
+}
+
+// collect reads <locale>/<file> entries under root into files.
+func collect(files map[string]map[string]string, fsys fs.FS, root string) error {
+	locales, err := fs.ReadDir(fsys, root)
+	if err != nil {
+		return err
+	}
+	for _, l := range locales {
+		if !l.IsDir() {
+			continue
+		}
+		entries, err := fs.ReadDir(fsys, path.Join(root, l.Name()))
+		if err != nil {
+			return err
+		}
+		for _, e := range entries {
+			if e.IsDir() {
+				continue
+			}
+			data, err := fs.ReadFile(fsys, path.Join(root, l.Name(), e.Name()))
+			if err != nil {
+				return err
+			}
+			if files[l.Name()] == nil {
+				files[l.Name()] = map[string]string{}
+			}
+			files[l.Name()][e.Name()] = string(data)
+		}
+	}
+	return nil
+}
+
+func parse(name string, byFile map[string]string) (*template, error) {
+	text, ok := byFile[name+".txt"]
+	if !ok {
+		return nil, errors.New("missing " + name + ".txt")
+	}
+	tpl := &template{}
+	var err error
+	if tpl.subject, err = texttemplate.New(name + ".subject.txt").Option("missingkey=error").Parse(byFile[name+".subject.txt"]); err != nil {
+		return nil, err
+	}
+	if tpl.text, err = texttemplate.New(name + ".txt").Option("missingkey=error").Parse(text); err != nil {
+		return nil, err
+	}
+	if html, ok := byFile[name+".html"]; ok {
+		// the body defines "content" for the locale's layout, if it has one
+		tpl.html = htmltemplate.New(name + ".html").Option("missingkey=error")
+		if layout, ok := byFile["layout.html"]; ok {
+			if _, err = tpl.html.Parse(layout); err != nil {
+				return nil, err
+			}
+		}
+		if _, err = tpl.html.Parse(html); err != nil {
+			return nil, err
+		}
+	}
+	return tpl, nil
+}
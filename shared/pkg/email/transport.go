@@ -0,0 +1,33 @@
+package email
+
+import (
+	"fmt"
+
+	"go.uber.org/zap"
+)
+
+// Transport hands a message over for delivery. from is used both as the
+// envelope sender and in the From header.
+type Transport interface {
+	Deliver(from string, m *Message) error
+}
+
+const (
+	TransportSMTP = "smtp"
+	TransportFile = "file"
+	TransportLog  = "log"
+)
+
+// NewTransport builds the transport cfg.Transport names; empty means SMTP.
+// log is only used by the log transport.
+func NewTransport(cfg Config, log *zap.Logger) (Transport, error) {
+	switch cfg.Transport {
+	case "", TransportSMTP:
+		return NewSMTPTransport(cfg)
+	case TransportFile:
+		return NewMaildirTransport(cfg.Dir)
+	case TransportLog:
+		return NewLogTransport(log), nil
+	}
+	return nil, fmt.Errorf("unknown email transport %q", cfg.Transport)
+}
@@ -0,0 +1,127 @@
+// Package rbac maps roles to the permissions every service checks, so role
+// policy is defined once instead of in each handler.
+package rbac
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Permissions checked across services.
+const (
+	ArticleWrite    = "article.write"
+	ArticlePublish  = "article.publish"
+	CommentWrite    = "comment.write"
+	CommentModerate = "comment.moderate"
+	RatingWrite     = "rating.write"
+	CategoryManage  = "category.manage"
+	MediaRead       = "media.read"
+	MediaUpload     = "media.upload"
+	UserManage      = "user.manage"
+	UserInvite      = "user.invite"
+
+	// All grants every permission, including ones added later.
+	All = "*"
+)
+
+var KnownPermissions = []string{
+	ArticleWrite, ArticlePublish,
+	CommentWrite, CommentModerate,
+	RatingWrite,
+	CategoryManage,
+	MediaRead, MediaUpload,
+	UserManage, UserInvite,
+}
+
+// Policy is an immutable role to permission mapping. Unknown roles have no
+// permissions.
+type Policy struct {
+	roles map[string]map[string]bool
+}
+
+func NewPolicy(roles map[string][]string) (*Policy, error) {
+	p := &Policy{roles: make(map[string]map[string]bool, len(roles))}
+	for role, perms := range roles {
+		set := make(map[string]bool, len(perms))
+		for _, perm := range perms {
+			if perm != All && !known(perm) {
+				return nil, fmt.Errorf("rbac: role %q: unknown permission %q", role, perm)
+			}
+			set[perm] = true
+		}
+		p.roles[role] = set
+	}
+	return p, nil
+}
+
+// DefaultPolicy is used when no policy file is configured.
+func DefaultPolicy() *Policy {
+	p, err := NewPolicy(map[string][]string{
+		"guest":   {},
+		"user":    {ArticleWrite, CommentWrite, RatingWrite, MediaRead},
+		"manager": {ArticleWrite, ArticlePublish, CommentWrite, CommentModerate, RatingWrite, CategoryManage, MediaRead, MediaUpload, UserInvite},
+		"admin":   {All},
+		// OAuth2 clients; their scopes narrow this further
+		"service": {MediaRead},
+	})
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+// LoadPolicy reads a YAML file of the form
+//
+//	roles:
+//	  user: [article.write, comment.write]
+//	  admin: ["*"]
+//
+// An empty path returns DefaultPolicy.
+func LoadPolicy(path string) (*Policy, error) {
+	if path == "" {
+		return DefaultPolicy(), nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var file struct {
+		Roles map[string][]string `yaml:"roles"`
+	}
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("rbac: %s: %w", path, err)
+	}
+	return NewPolicy(file.Roles)
+}
+
+// Can reports whether role holds perm.
+func (p *Policy) Can(role, perm string) bool {
+	set := p.roles[role]
+	return set[All] || set[perm]
+}
+
+// Permissions lists the permissions of role, sorted, with All expanded.
+func (p *Policy) Permissions(role string) []string {
+	set := p.roles[role]
+	if set[All] {
+		return append([]string(nil), KnownPermissions...)
+	}
+	perms := make([]string, 0, len(set))
+	for perm := range set {
+		perms = append(perms, perm)
+	}
+	sort.Strings(perms)
+	return perms
+}
+
+func known(perm string) bool {
+	for _, k := range KnownPermissions {
+		if k == perm {
+			return true
+		}
+	}
+	return false
+}
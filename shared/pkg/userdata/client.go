@@ -0,0 +1,85 @@
+package userdata
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Client calls one service's internal user-data endpoints.
+type Client struct {
+	name    string
+	baseURL string
+	secret  string
+	client  *http.Client
+}
+
+func NewClient(name, baseURL, secret string) *Client {
+	return &Client{
+		name:    name,
+		baseURL: baseURL,
+		secret:  secret,
+		client:  &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// Name identifies the service; it prefixes its entries in an export.
+func (c *Client) Name() string { return c.name }
+
+func (c *Client) Export(ctx context.Context, userID string) (*Export, error) {
+	resp, err := c.do(ctx, http.MethodGet, "/internal/users/"+url.PathEscape(userID)+"/export")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	var body struct {
+		Data *Export `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("%s export: %w", c.name, err)
+	}
+	if body.Data == nil {
+		return nil, fmt.Errorf("%s export: empty response", c.name)
+	}
+	return body.Data, nil
+}
+
+// Fetch opens an ExportFile path. The caller closes the body.
+func (c *Client) Fetch(ctx context.Context, path string) (io.ReadCloser, error) {
+	resp, err := c.do(ctx, http.MethodGet, path)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+func (c *Client) Delete(ctx context.Context, userID string, mode Mode) error {
+	resp, err := c.do(ctx, http.MethodDelete, "/internal/users/"+url.PathEscape(userID)+"?mode="+url.QueryEscape(string(mode)))
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// do sends the request and returns the response only for 2xx statuses.
+func (c *Client) do(ctx context.Context, method, path string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set(HeaderInternalToken, c.secret)
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", c.name, err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		resp.Body.Close()
+		return nil, fmt.Errorf("%s %s %s: unexpected status %d", c.name, method, path, resp.StatusCode)
+	}
+	return resp, nil
+}
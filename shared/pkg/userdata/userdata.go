@@ -0,0 +1,81 @@
+// Package userdata is the internal protocol auth-service uses to export and
+// delete a user's data held by other services.
+//
+// Every service holding user data serves, behind Middleware:
+//
+//	GET    /internal/users/:id/export        -> 200 {"data": Export}
+//	GET    <ExportFile.Path>                 -> 200 raw file content
+//	DELETE /internal/users/:id?mode=<Mode>   -> 204
+//
+// Requests carry the shared secret in the X-Internal-Token header. DELETE
+// must be idempotent: auth-service retries it until every service succeeds.
+package userdata
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+
+	"github.com/HatefBarari/microblog-shared/pkg/httputil"
+	"github.com/labstack/echo/v4"
+)
+
+const HeaderInternalToken = "X-Internal-Token"
+
+// DeletedUserID replaces the author of content kept by ModeAnonymize.
+const DeletedUserID = "deleted"
+
+// Mode says what happens to content the user authored.
+type Mode string
+
+const (
+	// ModeAnonymize keeps published content under DeletedUserID.
+	ModeAnonymize Mode = "anonymize"
+	// ModeRemove deletes it.
+	ModeRemove Mode = "remove"
+)
+
+func (m Mode) Valid() bool {
+	return m == ModeAnonymize || m == ModeRemove
+}
+
+// Export is one service's share of a personal-data export.
+type Export struct {
+	// Documents are written to the archive as <service>/<name>.json.
+	Documents map[string]json.RawMessage `json:"documents"`
+	// Files are fetched from the same service and written as
+	// <service>/<name>.
+	Files []ExportFile `json:"files,omitempty"`
+}
+
+type ExportFile struct {
+	Name string `json:"name"`
+	Path string `json:"path"`
+}
+
+// AddDocument marshals v as the named document.
+func (e *Export) AddDocument(name string, v interface{}) error {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if e.Documents == nil {
+		e.Documents = map[string]json.RawMessage{}
+	}
+	e.Documents[name] = raw
+	return nil
+}
+
+// Middleware admits only requests carrying secret. An empty secret refuses
+// everything, so a missing setting never opens the internal routes.
+func Middleware(secret string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			got := c.Request().Header.Get(HeaderInternalToken)
+			if secret == "" || subtle.ConstantTimeCompare([]byte(got), []byte(secret)) != 1 {
+				return c.JSON(http.StatusUnauthorized, httputil.NewError(401, "invalid internal token"))
+			}
+			return next(c)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/HatefBarari/microblog-shared/pkg/auth"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImpersonatedTokens(t *testing.T) {
+	key := auth.HMAC("access")
+	now := time.Now()
+	token, err := key.Sign(&auth.Claims{
+		UserID: "user123",
+		Role:   "user",
+		Actor:  &auth.Actor{Subject: "admin1"},
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        "jti1",
+			IssuedAt:  jwt.NewNumericDate(now.Add(-time.Minute)),
+			ExpiresAt: jwt.NewNumericDate(now.Add(time.Hour)),
+		},
+	})
+	require.NoError(t, err)
+	store := auth.NewMemoryRevocationStore()
+
+	e := echo.New()
+	g := e.Group("", auth.Middleware(key, auth.WithRevocationChecker(store)))
+	g.GET("/whoami", func(c echo.Context) error {
+		return c.String(http.StatusOK, c.Get("userID").(string)+" by "+auth.ActorID(c))
+	})
+	g.POST("/password", func(c echo.Context) error { return c.NoContent(http.StatusNoContent) }, auth.DenyImpersonation())
+	call := func(method, path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := call(http.MethodGet, "/whoami")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "user123 by admin1", rec.Body.String())
+	assert.Equal(t, http.StatusForbidden, call(http.MethodPost, "/password").Code)
+
+	// signing the admin out ends their impersonation too
+	require.NoError(t, store.RevokeUser(context.Background(), "admin1", now, now.Add(time.Hour)))
+	assert.Equal(t, http.StatusUnauthorized, call(http.MethodGet, "/whoami").Code)
+}
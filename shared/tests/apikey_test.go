@@ -0,0 +1,128 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/HatefBarari/microblog-shared/pkg/auth"
+	"github.com/HatefBarari/microblog-shared/pkg/rbac"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryAPIKeyStore(t *testing.T) {
+	ctx := context.Background()
+	store := auth.NewMemoryAPIKeyStore()
+	now := time.Now()
+
+	token, hash, err := auth.NewAPIKeyToken()
+	require.NoError(t, err)
+	assert.True(t, auth.IsAPIKey(token))
+	require.NoError(t, store.CreateAPIKey(ctx, &auth.APIKey{
+		ID: "key1", UserID: "user123", Role: "user", Hash: hash,
+		Scopes: []string{auth.ScopeArticlesWrite}, CreatedAt: now,
+	}))
+
+	claims, err := store.ResolveAPIKey(ctx, token)
+	require.NoError(t, err)
+	assert.Equal(t, "user123", claims.UserID)
+	assert.Equal(t, "key1", claims.ID)
+	assert.Equal(t, []string{auth.ScopeArticlesWrite}, claims.Scopes)
+
+	keys, err := store.ListAPIKeys(ctx, "user123")
+	require.NoError(t, err)
+	require.Len(t, keys, 1)
+	assert.NotNil(t, keys[0].LastUsedAt)
+
+	ok, err := store.RevokeAPIKey(ctx, "someone-else", "key1", now)
+	require.NoError(t, err)
+	assert.False(t, ok)
+	ok, err = store.RevokeAPIKey(ctx, "user123", "key1", now)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	_, err = store.ResolveAPIKey(ctx, token)
+	assert.ErrorIs(t, err, auth.ErrInvalidAPIKey)
+
+	_, err = store.ResolveAPIKey(ctx, auth.APIKeyPrefix+"unknown")
+	assert.ErrorIs(t, err, auth.ErrInvalidAPIKey)
+}
+
+func TestMiddlewareAcceptsAPIKeys(t *testing.T) {
+	ctx := context.Background()
+	store := auth.NewMemoryAPIKeyStore()
+	revocations := auth.NewMemoryRevocationStore()
+	token, hash, err := auth.NewAPIKeyToken()
+	require.NoError(t, err)
+	require.NoError(t, store.CreateAPIKey(ctx, &auth.APIKey{
+		ID: "key1", UserID: "user123", Role: "user", Hash: hash,
+		Scopes: []string{auth.ScopeMediaUpload}, CreatedAt: time.Now().Add(-time.Hour),
+	}))
+	session, _, err := auth.GenerateSessionTokens("user123", "user", "sess1", "jti1", "access", "refresh", 15, 1)
+	require.NoError(t, err)
+
+	e := echo.New()
+	mw := auth.Middleware(auth.HMAC("access"), auth.WithAPIKeys(store), auth.WithRevocationChecker(revocations))
+	ok := func(c echo.Context) error { return c.NoContent(http.StatusOK) }
+	e.POST("/upload", ok, mw, auth.RequireScope(auth.ScopeMediaUpload))
+	e.POST("/articles", ok, mw, auth.RequireScope(auth.ScopeArticlesWrite))
+	call := func(path, token string) int {
+		req := httptest.NewRequest(http.MethodPost, path, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	assert.Equal(t, http.StatusOK, call("/upload", token))
+	assert.Equal(t, http.StatusForbidden, call("/articles", token))
+	// login sessions are not scope-restricted
+	assert.Equal(t, http.StatusOK, call("/articles", session))
+	assert.Equal(t, http.StatusUnauthorized, call("/upload", auth.APIKeyPrefix+"bogus"))
+
+	// ending the user's sessions leaves keys alone; revoking them doesn't
+	require.NoError(t, revocations.RevokeUser(ctx, "user123", time.Now().Add(time.Second), time.Now().Add(time.Hour)))
+	assert.Equal(t, http.StatusUnauthorized, call("/articles", session))
+	assert.Equal(t, http.StatusOK, call("/upload", token))
+	n, err := store.RevokeUserAPIKeys(ctx, "user123", time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Equal(t, http.StatusUnauthorized, call("/upload", token))
+}
+
+func TestCommentModerationNeedsScope(t *testing.T) {
+	ctx := context.Background()
+	store := auth.NewMemoryAPIKeyStore()
+	upload, hash, err := auth.NewAPIKeyToken()
+	require.NoError(t, err)
+	require.NoError(t, store.CreateAPIKey(ctx, &auth.APIKey{
+		ID: "key1", UserID: "mgr1", Role: "manager", Hash: hash,
+		Scopes: []string{auth.ScopeMediaUpload}, CreatedAt: time.Now(),
+	}))
+	moderate, hash, err := auth.NewAPIKeyToken()
+	require.NoError(t, err)
+	require.NoError(t, store.CreateAPIKey(ctx, &auth.APIKey{
+		ID: "key2", UserID: "mgr1", Role: "manager", Hash: hash,
+		Scopes: []string{auth.ScopeCommentsModerate}, CreatedAt: time.Now(),
+	}))
+
+	// the chain blog-service puts on PUT /comments/:id/status
+	e := echo.New()
+	mw := auth.Middleware(auth.HMAC("access"), auth.WithAPIKeys(store), auth.WithPolicy(rbac.DefaultPolicy()))
+	e.PUT("/comments/:id/status", func(c echo.Context) error { return c.NoContent(http.StatusOK) },
+		mw, auth.Require(rbac.CommentModerate), auth.RequireScope(auth.ScopeCommentsModerate))
+	call := func(token string) int {
+		req := httptest.NewRequest(http.MethodPut, "/comments/c1/status", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	// the manager role allows moderation, but this key wasn't granted it
+	assert.Equal(t, http.StatusForbidden, call(upload))
+	assert.Equal(t, http.StatusOK, call(moderate))
+}
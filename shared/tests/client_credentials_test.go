@@ -0,0 +1,113 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/HatefBarari/microblog-shared/pkg/auth"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientCredentials_CachesAndRetries(t *testing.T) {
+	var issued atomic.Int32
+	tokenSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, secret, ok := r.BasicAuth()
+		if !ok || id != "mbc_blog" || secret != "s3cret" || r.FormValue("grant_type") != "client_credentials" {
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid_client"})
+			return
+		}
+		n := issued.Add(1)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"access_token": fmt.Sprintf("token-%d", n),
+			"token_type":   "Bearer",
+			"expires_in":   900,
+			"scope":        r.FormValue("scope"),
+		})
+	}))
+	defer tokenSrv.Close()
+
+	// the API refuses the first token, as if it had been revoked
+	var seen []string
+	apiSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = append(seen, r.Header.Get("Authorization"))
+		if r.Header.Get("Authorization") == "Bearer token-1" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer apiSrv.Close()
+
+	cc := auth.NewClientCredentials(auth.ClientCredentialsConfig{
+		TokenURL:     tokenSrv.URL,
+		ClientID:     "mbc_blog",
+		ClientSecret: "s3cret",
+		Scopes:       []string{auth.ScopeMediaRead},
+	})
+	client := cc.Client()
+
+	resp, err := client.Post(apiSrv.URL, "application/json", strings.NewReader(`{}`))
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, []string{"Bearer token-1", "Bearer token-2"}, seen)
+
+	// the fresh token is cached
+	resp, err = client.Get(apiSrv.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, int32(2), issued.Load())
+	assert.Equal(t, "Bearer token-2", seen[2])
+
+	bad := auth.NewClientCredentials(auth.ClientCredentialsConfig{TokenURL: tokenSrv.URL, ClientID: "mbc_blog", ClientSecret: "wrong"})
+	_, err = bad.Token(context.Background())
+	assert.ErrorContains(t, err, "invalid_client")
+}
+
+func TestClientTokens(t *testing.T) {
+	key, err := auth.GenerateSigningKey("k1", "EdDSA")
+	require.NoError(t, err)
+	keys, err := auth.NewKeySet("k1", key)
+	require.NoError(t, err)
+
+	claims := &auth.Claims{Role: auth.RoleService, Scopes: []string{auth.ScopeMediaRead}}
+	claims.Subject = "mbc_blog"
+	claims.IssuedAt = jwt.NewNumericDate(time.Now())
+	claims.ExpiresAt = jwt.NewNumericDate(time.Now().Add(time.Minute))
+	assert.True(t, claims.IsClient())
+	assert.False(t, (&auth.Claims{UserID: "u1"}).IsClient())
+	token, err := keys.Sign(claims)
+	require.NoError(t, err)
+
+	// a client token sets the client ID and no user ID, and the client can
+	// be revoked like a user
+	store := auth.NewMemoryRevocationStore()
+	e := echo.New()
+	e.GET("/whoami", func(c echo.Context) error {
+		return c.String(http.StatusOK, auth.ClientID(c)+"|"+c.Get("userID").(string))
+	}, auth.Middleware(keys, auth.WithRevocationChecker(store)))
+	call := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/whoami", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+	rec := call()
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "mbc_blog|", rec.Body.String())
+
+	require.NoError(t, store.RevokeUser(context.Background(), "mbc_blog", time.Now().Add(time.Minute), time.Now().Add(time.Hour)))
+	assert.Equal(t, http.StatusUnauthorized, call().Code)
+}
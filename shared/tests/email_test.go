@@ -0,0 +1,102 @@
+package tests
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/HatefBarari/microblog-shared/pkg/email"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTemplates_Locales(t *testing.T) {
+	tpls := email.DefaultTemplates()
+	data := map[string]interface{}{"Link": "https://example.com/verify?token=a&b=<c>", "Hours": 24}
+
+	fa, err := tpls.Render("verify_email", "fa", data)
+	require.NoError(t, err)
+	assert.Equal(t, "تایید حساب کاربری - Microblog", fa.Subject)
+	assert.Contains(t, fa.Text, "https://example.com/verify?token=a&b=<c>")
+	assert.Contains(t, fa.HTML, `dir="rtl"`)
+	// HTML bodies are escaped
+	assert.Contains(t, fa.HTML, "token=a&amp;b=%3cc%3e")
+
+	en, err := tpls.Render("verify_email", "en-US", data)
+	require.NoError(t, err)
+	assert.Equal(t, "Verify your account - Microblog", en.Subject)
+
+	// unknown locales fall back to Persian
+	de, err := tpls.Render("verify_email", "de", data)
+	require.NoError(t, err)
+	assert.Equal(t, fa.Subject, de.Subject)
+
+	_, err = tpls.Render("no_such_template", "fa", data)
+	assert.ErrorIs(t, err, email.ErrTemplateNotFound)
+	_, err = tpls.Render("verify_email", "fa", map[string]interface{}{})
+	assert.Error(t, err)
+}
+
+func TestTemplates_Override(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "en"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "en", "verify_email.subject.txt"), []byte("Welcome aboard"), 0o644))
+
+	tpls, err := email.LoadTemplates(dir)
+	require.NoError(t, err)
+	msg, err := tpls.Render("verify_email", "en", map[string]interface{}{"Link": "x", "Hours": 1})
+	require.NoError(t, err)
+	assert.Equal(t, "Welcome aboard", msg.Subject)
+	// files that aren't overridden keep the built-in version
+	assert.Contains(t, msg.Text, "Follow the link below")
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "en", "verify_email.txt"), []byte("{{.Link"), 0o644))
+	_, err = email.LoadTemplates(dir)
+	assert.Error(t, err)
+}
+
+func TestMessage_Encode(t *testing.T) {
+	msg := &email.Message{To: "user@example.com", Subject: "بازیابی رمز عبور", Text: "متن ساده", HTML: "<p>متن</p>"}
+	raw, err := msg.Encode("noreply@microblog.local")
+	require.NoError(t, err)
+
+	parsed, err := mail.ReadMessage(bytes.NewReader(raw))
+	require.NoError(t, err)
+	subject, err := new(mime.WordDecoder).DecodeHeader(parsed.Header.Get("Subject"))
+	require.NoError(t, err)
+	assert.Equal(t, "بازیابی رمز عبور", subject)
+	assert.True(t, isASCII(parsed.Header.Get("Subject")))
+
+	mediaType, params, err := mime.ParseMediaType(parsed.Header.Get("Content-Type"))
+	require.NoError(t, err)
+	assert.Equal(t, "multipart/alternative", mediaType)
+	mr := multipart.NewReader(parsed.Body, params["boundary"])
+	var types, bodies []string
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		body, err := io.ReadAll(part)
+		require.NoError(t, err)
+		types = append(types, part.Header.Get("Content-Type"))
+		bodies = append(bodies, string(body))
+	}
+	assert.Equal(t, []string{"text/plain; charset=UTF-8", "text/html; charset=UTF-8"}, types)
+	assert.Equal(t, []string{"متن ساده", "<p>متن</p>"}, bodies)
+
+	plain, err := (&email.Message{To: "user@example.com", Subject: "hi", Text: "hello"}).Encode("noreply@microblog.local")
+	require.NoError(t, err)
+	assert.Contains(t, string(plain), "Content-Type: text/plain; charset=UTF-8")
+}
+
+func isASCII(s string) bool {
+	return strings.IndexFunc(s, func(r rune) bool { return r > 127 }) < 0
+}
@@ -0,0 +1,157 @@
+package tests
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/HatefBarari/microblog-shared/pkg/email"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSMTP is a minimal SMTP server that accepts every message.
+type fakeSMTP struct {
+	ln       net.Listener
+	mu       sync.Mutex
+	conns    int
+	messages []string
+}
+
+func startFakeSMTP(t *testing.T) *fakeSMTP {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	s := &fakeSMTP{ln: ln}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			s.mu.Lock()
+			s.conns++
+			s.mu.Unlock()
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+func (s *fakeSMTP) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+	reply("220 fake ESMTP")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		cmd := strings.ToUpper(strings.Fields(line + " x")[0])
+		switch cmd {
+		case "EHLO":
+			reply("250-fake")
+			reply("250 8BITMIME")
+		case "DATA":
+			reply("354 go ahead")
+			var body strings.Builder
+			for {
+				l, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if l == ".\r\n" {
+					break
+				}
+				body.WriteString(l)
+			}
+			s.mu.Lock()
+			s.messages = append(s.messages, body.String())
+			s.mu.Unlock()
+			reply("250 queued")
+		case "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("250 ok")
+		}
+	}
+}
+
+func (s *fakeSMTP) stats() (int, []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.conns, append([]string(nil), s.messages...)
+}
+
+func TestSMTPTransport_ReusesConnections(t *testing.T) {
+	srv := startFakeSMTP(t)
+	host, port, _ := net.SplitHostPort(srv.ln.Addr().String())
+	sender, err := email.NewSender(email.Config{Host: host, Port: port, PoolSize: 1, From: "Microblog <noreply@microblog.local>"}, nil)
+	require.NoError(t, err)
+
+	for _, to := range []string{"a@example.com", "b@example.com"} {
+		require.NoError(t, sender.Send(to, "سلام", "متن"))
+	}
+	conns, messages := srv.stats()
+	assert.Equal(t, 1, conns)
+	require.Len(t, messages, 2)
+	parsed, err := mail.ReadMessage(strings.NewReader(messages[1]))
+	require.NoError(t, err)
+	assert.Equal(t, "<b@example.com>", parsed.Header.Get("To"))
+	require.NoError(t, sender.Close())
+
+	// STARTTLS can be required, and this server doesn't offer it
+	strict, err := email.NewSender(email.Config{Host: host, Port: port, TLS: email.TLSStartTLS, From: "noreply@microblog.local"}, nil)
+	require.NoError(t, err)
+	assert.ErrorContains(t, strict.Send("a@example.com", "hi", "text"), "STARTTLS")
+
+	_, err = email.NewSender(email.Config{Host: host, Port: port, TLS: "ssl"}, nil)
+	assert.Error(t, err)
+}
+
+func TestMaildirTransport(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "mail")
+	sender, err := email.NewSender(email.Config{Transport: email.TransportFile, Dir: dir, From: "noreply@microblog.local"}, nil)
+	require.NoError(t, err)
+	require.NoError(t, sender.Send("user@example.com", "hi", "hello"))
+
+	entries, err := os.ReadDir(filepath.Join(dir, "new"))
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	raw, err := os.ReadFile(filepath.Join(dir, "new", entries[0].Name()))
+	require.NoError(t, err)
+	parsed, err := mail.ReadMessage(strings.NewReader(string(raw)))
+	require.NoError(t, err)
+	assert.Equal(t, "<user@example.com>", parsed.Header.Get("To"))
+	tmp, err := os.ReadDir(filepath.Join(dir, "tmp"))
+	require.NoError(t, err)
+	assert.Empty(t, tmp)
+
+	_, err = email.NewSender(email.Config{Transport: email.TransportFile}, nil)
+	assert.Error(t, err)
+}
+
+func TestRecorder(t *testing.T) {
+	rec := email.NewRecorder()
+	sender := email.NewSenderWithTransport(rec, "noreply@microblog.local")
+	require.NoError(t, sender.SendMessage(&email.Message{To: "user@example.com", Subject: "hi", Text: "text", HTML: "<p>html</p>"}))
+
+	got := rec.Deliveries()
+	require.Len(t, got, 1)
+	assert.Equal(t, "noreply@microblog.local", got[0].From)
+	assert.Equal(t, "<p>html</p>", got[0].Message.HTML)
+
+	rec.Fail(errors.New("smtp down"))
+	assert.Error(t, sender.Send("user@example.com", "hi", "text"))
+	rec.Fail(nil)
+	rec.Reset()
+	assert.Empty(t, rec.Deliveries())
+}
@@ -0,0 +1,64 @@
+package tests
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/HatefBarari/microblog-shared/pkg/auth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var argon2idPHC = regexp.MustCompile(`^\$argon2id\$v=19\$m=\d+,t=\d+,p=\d+\$[A-Za-z0-9+/]+\$[A-Za-z0-9+/]+$`)
+
+func TestHasher_Argon2id(t *testing.T) {
+	h, err := auth.NewHasher(auth.HasherConfig{})
+	require.NoError(t, err)
+	hash, err := h.Hash("correct horse")
+	require.NoError(t, err)
+	assert.Regexp(t, argon2idPHC, hash)
+
+	ok, rehash := h.Verify(hash, "correct horse")
+	assert.True(t, ok)
+	assert.False(t, rehash)
+	ok, _ = h.Verify(hash, "wrong horse")
+	assert.False(t, ok)
+	ok, _ = h.Verify(hash[:len(hash)-4], "correct horse")
+	assert.False(t, ok)
+
+	// stronger parameters mark older hashes for an upgrade
+	params := auth.DefaultArgon2Params
+	params.Iterations++
+	stronger, err := auth.NewHasher(auth.HasherConfig{Argon2: params})
+	require.NoError(t, err)
+	ok, rehash = stronger.Verify(hash, "correct horse")
+	assert.True(t, ok)
+	assert.True(t, rehash)
+}
+
+func TestHasher_Bcrypt(t *testing.T) {
+	h, err := auth.NewHasher(auth.HasherConfig{Algorithm: auth.AlgorithmBcrypt, BcryptCost: 4})
+	require.NoError(t, err)
+	hash, err := h.Hash("correct horse")
+	require.NoError(t, err)
+	ok, rehash := h.Verify(hash, "correct horse")
+	assert.True(t, ok)
+	assert.False(t, rehash)
+
+	// existing bcrypt hashes keep working and are moved to argon2id
+	argon, err := auth.NewHasher(auth.HasherConfig{Algorithm: auth.AlgorithmArgon2id})
+	require.NoError(t, err)
+	ok, rehash = argon.Verify(hash, "correct horse")
+	assert.True(t, ok)
+	assert.True(t, rehash)
+	ok, _ = argon.Verify(hash, "wrong horse")
+	assert.False(t, ok)
+	assert.True(t, auth.CheckPassword(hash, "correct horse"))
+}
+
+func TestNewHasher_RejectsUnknownAlgorithm(t *testing.T) {
+	_, err := auth.NewHasher(auth.HasherConfig{Algorithm: "md5"})
+	assert.Error(t, err)
+	_, err = auth.NewHasher(auth.HasherConfig{Algorithm: auth.AlgorithmBcrypt, BcryptCost: 40})
+	assert.Error(t, err)
+}
@@ -0,0 +1,192 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/HatefBarari/microblog-shared/pkg/auth"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func accessClaims() *auth.Claims {
+	now := time.Now()
+	return &auth.Claims{
+		UserID: "user123",
+		Role:   "user",
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(now.Add(time.Minute)),
+			IssuedAt:  jwt.NewNumericDate(now),
+		},
+	}
+}
+
+func TestKeySet_Rotation(t *testing.T) {
+	oldKey, err := auth.GenerateSigningKey("old", "RS256")
+	require.NoError(t, err)
+	newKey, err := auth.GenerateSigningKey("new", "EdDSA")
+	require.NoError(t, err)
+
+	before, err := auth.NewKeySet("old", oldKey)
+	require.NoError(t, err)
+	issued, err := before.Sign(accessClaims())
+	require.NoError(t, err)
+
+	// after rotation the old key still verifies but no longer signs
+	after, err := auth.NewKeySet("new", oldKey, newKey)
+	require.NoError(t, err)
+	claims, err := after.Verify(issued)
+	require.NoError(t, err)
+	assert.Equal(t, "user123", claims.UserID)
+
+	fresh, err := after.Sign(accessClaims())
+	require.NoError(t, err)
+	parsed, _, err := jwt.NewParser().ParseUnverified(fresh, &auth.Claims{})
+	require.NoError(t, err)
+	assert.Equal(t, "new", parsed.Header["kid"])
+	assert.Equal(t, "EdDSA", parsed.Header["alg"])
+
+	_, err = auth.NewKeySet("missing", oldKey)
+	assert.Error(t, err)
+}
+
+func TestKeySet_RejectsHMACTokens(t *testing.T) {
+	key, err := auth.GenerateSigningKey("k1", "EdDSA")
+	require.NoError(t, err)
+	ks, err := auth.NewKeySet("k1", key)
+	require.NoError(t, err)
+
+	tok := jwt.NewWithClaims(jwt.SigningMethodHS256, accessClaims())
+	tok.Header["kid"] = "k1"
+	signed, err := tok.SignedString([]byte("secret"))
+	require.NoError(t, err)
+
+	_, err = ks.Verify(signed)
+	assert.Error(t, err)
+}
+
+func TestJWKSVerifier(t *testing.T) {
+	k1, err := auth.GenerateSigningKey("k1", "RS256")
+	require.NoError(t, err)
+	k2, err := auth.GenerateSigningKey("k2", "EdDSA")
+	require.NoError(t, err)
+
+	published, err := auth.NewKeySet("k1", k1)
+	require.NoError(t, err)
+	var fetches int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&fetches, 1)
+		_ = json.NewEncoder(w).Encode(published.JWKS())
+	}))
+	defer srv.Close()
+
+	v := auth.NewJWKSVerifier(srv.URL, time.Hour)
+
+	signed, err := published.Sign(accessClaims())
+	require.NoError(t, err)
+	claims, err := v.Verify(signed)
+	require.NoError(t, err)
+	assert.Equal(t, "user123", claims.UserID)
+
+	_, err = v.Verify(signed)
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, atomic.LoadInt32(&fetches), "keys are cached")
+
+	// a key the verifier has never seen is rejected, and the refetch it
+	// triggers is throttled
+	other, err := auth.NewKeySet("k2", k2)
+	require.NoError(t, err)
+	unknown, err := other.Sign(accessClaims())
+	require.NoError(t, err)
+	_, err = v.Verify(unknown)
+	assert.Error(t, err)
+	assert.EqualValues(t, 1, atomic.LoadInt32(&fetches))
+}
+
+func TestJWKSVerifier_SingleFetch(t *testing.T) {
+	k1, err := auth.GenerateSigningKey("k1", "EdDSA")
+	require.NoError(t, err)
+	published, err := auth.NewKeySet("k1", k1)
+	require.NoError(t, err)
+	var fetches int32
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&fetches, 1)
+		<-release
+		_ = json.NewEncoder(w).Encode(published.JWKS())
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	v := auth.NewJWKSVerifier(srv.URL, time.Hour)
+	signed, err := published.Sign(accessClaims())
+	require.NoError(t, err)
+
+	// concurrent checks share one slow fetch instead of queueing behind it
+	var wg sync.WaitGroup
+	errs := make([]error, 8)
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, errs[i] = v.Verify(signed)
+		}(i)
+	}
+	require.Eventually(t, func() bool { return atomic.LoadInt32(&fetches) == 1 }, time.Second, 10*time.Millisecond)
+	release <- struct{}{}
+	wg.Wait()
+	for _, err := range errs {
+		assert.NoError(t, err)
+	}
+	assert.EqualValues(t, 1, atomic.LoadInt32(&fetches))
+}
+
+func TestJWK_RoundTrip(t *testing.T) {
+	for _, alg := range []string{"RS256", "EdDSA"} {
+		key, err := auth.GenerateSigningKey("kid", alg)
+		require.NoError(t, err)
+		jwk, err := auth.NewJWK("kid", key.Key.Public())
+		require.NoError(t, err)
+		assert.Equal(t, alg, jwk.Alg)
+		pub, err := jwk.PublicKey()
+		require.NoError(t, err)
+		assert.Equal(t, key.Key.Public(), pub)
+	}
+}
+
+func TestMiddlewareAudience(t *testing.T) {
+	key, err := auth.GenerateSigningKey("k1", "EdDSA")
+	require.NoError(t, err)
+	keys, err := auth.NewKeySet("k1", key)
+	require.NoError(t, err)
+	general, err := keys.Sign(accessClaims())
+	require.NoError(t, err)
+	scoped := accessClaims()
+	scoped.Audience = jwt.ClaimStrings{"https://auth.example.com/userinfo"}
+	userinfo, err := keys.Sign(scoped)
+	require.NoError(t, err)
+
+	call := func(token string, opts ...auth.MiddlewareOption) int {
+		e := echo.New()
+		e.GET("/", func(c echo.Context) error { return c.NoContent(http.StatusOK) }, auth.Middleware(keys, opts...))
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	// tokens for an audience only work where that audience is expected
+	assert.Equal(t, http.StatusOK, call(general))
+	assert.Equal(t, http.StatusUnauthorized, call(userinfo))
+	withAud := auth.WithAudience("https://auth.example.com/userinfo")
+	assert.Equal(t, http.StatusOK, call(userinfo, withAud))
+	assert.Equal(t, http.StatusUnauthorized, call(general, withAud))
+}
@@ -0,0 +1,64 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/HatefBarari/microblog-shared/pkg/auth"
+	"github.com/HatefBarari/microblog-shared/pkg/rbac"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRBACPolicy(t *testing.T) {
+	p := rbac.DefaultPolicy()
+	assert.True(t, p.Can("manager", rbac.CommentModerate))
+	assert.False(t, p.Can("user", rbac.CommentModerate))
+	assert.False(t, p.Can("author", rbac.MediaUpload))
+	assert.True(t, p.Can("admin", rbac.UserManage))
+	assert.Equal(t, rbac.KnownPermissions, p.Permissions("admin"))
+	assert.Empty(t, p.Permissions("guest"))
+
+	// the deployed policy file matches the built-in default
+	loaded, err := rbac.LoadPolicy("../../deployments/rbac.yaml")
+	require.NoError(t, err)
+	for _, role := range []string{"guest", "user", "manager", "admin", "service"} {
+		assert.Equal(t, p.Permissions(role), loaded.Permissions(role), role)
+	}
+
+	path := filepath.Join(t.TempDir(), "rbac.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("roles:\n  user: [article.delete]\n"), 0o600))
+	_, err = rbac.LoadPolicy(path)
+	assert.Error(t, err)
+}
+
+func TestRequirePermission(t *testing.T) {
+	key, err := auth.GenerateSigningKey("k1", "EdDSA")
+	require.NoError(t, err)
+	keys, err := auth.NewKeySet("k1", key)
+	require.NoError(t, err)
+
+	e := echo.New()
+	mid := auth.Middleware(keys, auth.WithPolicy(rbac.DefaultPolicy()))
+	e.POST("/categories", func(c echo.Context) error {
+		return c.JSON(http.StatusOK, auth.Permissions(c))
+	}, mid, auth.Require(rbac.CategoryManage))
+
+	call := func(role string) int {
+		token, err := keys.Sign(&auth.Claims{UserID: "u1", Role: role})
+		require.NoError(t, err)
+		req := httptest.NewRequest(http.MethodPost, "/categories", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec.Code
+	}
+	assert.Equal(t, http.StatusOK, call("manager"))
+	assert.Equal(t, http.StatusOK, call("admin"))
+	assert.Equal(t, http.StatusForbidden, call("user"))
+	assert.Equal(t, http.StatusForbidden, call("author"))
+}
@@ -0,0 +1,109 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/HatefBarari/microblog-shared/pkg/auth"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func claimsIssuedAt(t time.Time) *auth.Claims {
+	return &auth.Claims{
+		UserID:    "user123",
+		SessionID: "sess1",
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:       "jti1",
+			IssuedAt: jwt.NewNumericDate(t),
+		},
+	}
+}
+
+func TestMemoryRevocationStore(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+
+	store := auth.NewMemoryRevocationStore()
+	revoked, err := store.IsRevoked(ctx, claimsIssuedAt(now))
+	require.NoError(t, err)
+	assert.False(t, revoked)
+
+	require.NoError(t, store.RevokeToken(ctx, "sess1", now.Add(time.Hour)))
+	revoked, _ = store.IsRevoked(ctx, claimsIssuedAt(now))
+	assert.True(t, revoked, "revoked session")
+
+	store = auth.NewMemoryRevocationStore()
+	require.NoError(t, store.RevokeToken(ctx, "jti1", now.Add(-time.Second)))
+	revoked, _ = store.IsRevoked(ctx, claimsIssuedAt(now))
+	assert.False(t, revoked, "expired entry is ignored")
+
+	store = auth.NewMemoryRevocationStore()
+	require.NoError(t, store.RevokeUser(ctx, "user123", now, now.Add(time.Hour)))
+	revoked, _ = store.IsRevoked(ctx, claimsIssuedAt(now.Add(-time.Minute)))
+	assert.True(t, revoked, "token issued before the user was revoked")
+	revoked, _ = store.IsRevoked(ctx, claimsIssuedAt(now.Add(2*time.Second)))
+	assert.False(t, revoked, "token issued afterwards")
+}
+
+func TestRevokeUserCoversSameSecond(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now().Truncate(time.Second).Add(300 * time.Millisecond)
+
+	store := auth.NewMemoryRevocationStore()
+	require.NoError(t, store.RevokeUser(ctx, "user123", now, now.Add(time.Hour)))
+	// a refresh racing the revocation lands in the same iat second
+	revoked, _ := store.IsRevoked(ctx, claimsIssuedAt(now.Add(500*time.Millisecond)))
+	assert.True(t, revoked, "token issued in the same second")
+	revoked, _ = store.IsRevoked(ctx, claimsIssuedAt(now.Add(time.Second)))
+	assert.False(t, revoked, "token issued in the next second")
+}
+
+type countingChecker struct {
+	calls   int
+	revoked bool
+}
+
+func (c *countingChecker) IsRevoked(ctx context.Context, claims *auth.Claims) (bool, error) {
+	c.calls++
+	return c.revoked, nil
+}
+
+func TestCachedRevocationChecker(t *testing.T) {
+	inner := &countingChecker{}
+	chk := auth.NewCachedRevocationChecker(inner, time.Minute)
+	claims := claimsIssuedAt(time.Now())
+
+	for i := 0; i < 3; i++ {
+		revoked, err := chk.IsRevoked(context.Background(), claims)
+		require.NoError(t, err)
+		assert.False(t, revoked)
+	}
+	assert.Equal(t, 1, inner.calls)
+}
+
+func TestMiddlewareRejectsRevokedTokens(t *testing.T) {
+	acc, _, err := auth.GenerateSessionTokens("user123", "user", "sess1", "jti1", "access", "refresh", 15, 1)
+	require.NoError(t, err)
+	store := auth.NewMemoryRevocationStore()
+
+	e := echo.New()
+	e.GET("/", func(c echo.Context) error { return c.NoContent(http.StatusOK) },
+		auth.Middleware(auth.HMAC("access"), auth.WithRevocationChecker(store)))
+	call := func() int {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Authorization", "Bearer "+acc)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	assert.Equal(t, http.StatusOK, call())
+	require.NoError(t, store.RevokeToken(context.Background(), "sess1", time.Now().Add(time.Hour)))
+	assert.Equal(t, http.StatusUnauthorized, call())
+}
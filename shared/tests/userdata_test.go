@@ -0,0 +1,73 @@
+package tests
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/HatefBarari/microblog-shared/pkg/httputil"
+	"github.com/HatefBarari/microblog-shared/pkg/userdata"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newUserDataServer(t *testing.T, secret string, deleted *[]string) *httptest.Server {
+	e := echo.New()
+	internal := e.Group("/internal", userdata.Middleware(secret))
+	internal.GET("/users/:id/export", func(c echo.Context) error {
+		export := &userdata.Export{Files: []userdata.ExportFile{{Name: "files/a.txt", Path: "/internal/users/" + c.Param("id") + "/files/a"}}}
+		require.NoError(t, export.AddDocument("articles", []string{"a1"}))
+		return c.JSON(http.StatusOK, httputil.OK(export))
+	})
+	internal.GET("/users/:id/files/a", func(c echo.Context) error {
+		return c.String(http.StatusOK, "file content")
+	})
+	internal.DELETE("/users/:id", func(c echo.Context) error {
+		*deleted = append(*deleted, c.Param("id")+":"+c.QueryParam("mode"))
+		return c.NoContent(http.StatusNoContent)
+	})
+	srv := httptest.NewServer(e)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestUserDataClient(t *testing.T) {
+	ctx := context.Background()
+	var deleted []string
+	srv := newUserDataServer(t, "s3cret", &deleted)
+	client := userdata.NewClient("blog", srv.URL, "s3cret")
+
+	export, err := client.Export(ctx, "user123")
+	require.NoError(t, err)
+	assert.JSONEq(t, `["a1"]`, string(export.Documents["articles"]))
+	require.Len(t, export.Files, 1)
+
+	body, err := client.Fetch(ctx, export.Files[0].Path)
+	require.NoError(t, err)
+	data, err := io.ReadAll(body)
+	body.Close()
+	require.NoError(t, err)
+	assert.Equal(t, "file content", string(data))
+
+	require.NoError(t, client.Delete(ctx, "user123", userdata.ModeAnonymize))
+	assert.Equal(t, []string{"user123:anonymize"}, deleted)
+}
+
+func TestUserDataMiddleware(t *testing.T) {
+	ctx := context.Background()
+	var deleted []string
+	srv := newUserDataServer(t, "s3cret", &deleted)
+
+	_, err := userdata.NewClient("blog", srv.URL, "wrong").Export(ctx, "user123")
+	assert.Error(t, err)
+	assert.Error(t, userdata.NewClient("blog", srv.URL, "").Delete(ctx, "user123", userdata.ModeRemove))
+	assert.Empty(t, deleted)
+
+	// an unset secret must not open the routes to requests without a token
+	open := newUserDataServer(t, "", &deleted)
+	assert.Error(t, userdata.NewClient("blog", open.URL, "").Delete(ctx, "user123", userdata.ModeRemove))
+	assert.Empty(t, deleted)
+}